@@ -24,6 +24,7 @@ type provider struct {
 	name      string
 	keys      map[string]struct{}
 	expiresAt map[string]time.Time
+	tenants   map[string]string
 	now       func() time.Time
 }
 
@@ -40,7 +41,8 @@ func newProvider(cfg *sdkconfig.AccessProvider, _ *sdkconfig.SDKConfig) (sdkacce
 		keys[key] = struct{}{}
 	}
 	expiresAt := parseExpiryMap(cfg)
-	return &provider{name: name, keys: keys, expiresAt: expiresAt, now: time.Now}, nil
+	tenants := parseTenantMap(cfg)
+	return &provider{name: name, keys: keys, expiresAt: expiresAt, tenants: tenants, now: time.Now}, nil
 }
 
 func parseExpiryMap(cfg *sdkconfig.AccessProvider) map[string]time.Time {
@@ -93,6 +95,33 @@ func parseExpiryMap(cfg *sdkconfig.AccessProvider) map[string]time.Time {
 	return out
 }
 
+// parseTenantMap reads the key-to-tenant mapping of tenant client API keys.
+func parseTenantMap(cfg *sdkconfig.AccessProvider) map[string]string {
+	if cfg == nil || len(cfg.Config) == 0 {
+		return nil
+	}
+	out := map[string]string{}
+	switch v := cfg.Config["tenant-keys"].(type) {
+	case map[string]string:
+		for key, tenant := range v {
+			if key = strings.TrimSpace(key); key != "" && tenant != "" {
+				out[key] = tenant
+			}
+		}
+	case map[string]any:
+		for key, tenantAny := range v {
+			tenant := strings.TrimSpace(toString(tenantAny))
+			if key = strings.TrimSpace(key); key != "" && tenant != "" {
+				out[key] = tenant
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 func toString(v any) string {
 	switch t := v.(type) {
 	case string:
@@ -160,12 +189,16 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 					}
 				}
 			}
+			metadata := map[string]string{
+				"source": candidate.source,
+			}
+			if tenant := p.tenants[candidate.value]; tenant != "" {
+				metadata["tenant"] = tenant
+			}
 			return &sdkaccess.Result{
 				Provider:  p.Identifier(),
 				Principal: candidate.value,
-				Metadata: map[string]string{
-					"source": candidate.source,
-				},
+				Metadata:  metadata,
 			}, nil
 		}
 	}
@@ -206,10 +206,22 @@ func collectProviderEntries(cfg *config.Config) []*sdkConfig.AccessProvider {
 }
 
 func inlineAPIKeyProvider(cfg *config.Config) *sdkConfig.AccessProvider {
-	if cfg == nil || len(cfg.APIKeys) == 0 {
+	if cfg == nil {
+		return nil
+	}
+	tenantKeys := cfg.TenantAPIKeys()
+	keys := cfg.APIKeys
+	if len(tenantKeys) > 0 {
+		keys = make([]string, 0, len(cfg.APIKeys)+len(tenantKeys))
+		keys = append(keys, cfg.APIKeys...)
+		for key := range tenantKeys {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
 		return nil
 	}
-	provider := sdkConfig.MakeInlineAPIKeyProvider(cfg.APIKeys)
+	provider := sdkConfig.MakeInlineAPIKeyProvider(keys)
 	if provider == nil {
 		return nil
 	}
@@ -220,6 +232,13 @@ func inlineAPIKeyProvider(cfg *config.Config) *sdkConfig.AccessProvider {
 		// Pass expiry mapping to the access provider so it can enforce expiration at auth time.
 		provider.Config["api-key-expiry"] = cfg.APIKeyExpiry
 	}
+	if len(tenantKeys) > 0 {
+		if provider.Config == nil {
+			provider.Config = make(map[string]any, 1)
+		}
+		// Tenant keys resolve to their tenant so requests only reach that tenant's auths.
+		provider.Config["tenant-keys"] = tenantKeys
+	}
 	return provider
 }
 
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// drainState tracks active requests and whether the server has stopped accepting new ones.
+type drainState struct {
+	mu        sync.Mutex
+	draining  bool
+	active    int
+	waiters   []chan struct{}
+	requested chan struct{}
+	once      sync.Once
+}
+
+func newDrainState() *drainState {
+	return &drainState{requested: make(chan struct{})}
+}
+
+// enter registers a new request. It returns false when the server is draining.
+func (d *drainState) enter() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+func (d *drainState) leave() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.active > 0 {
+		return
+	}
+	for _, ch := range d.waiters {
+		close(ch)
+	}
+	d.waiters = nil
+}
+
+// start stops accepting requests and returns a channel closed once none are active.
+func (d *drainState) start() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draining = true
+	ch := make(chan struct{})
+	if d.active == 0 {
+		close(ch)
+		return ch
+	}
+	d.waiters = append(d.waiters, ch)
+	return ch
+}
+
+// drainMiddleware answers new requests with 503 and a Retry-After header while the server
+// drains, and counts the requests it lets through. Management routes stay reachable so the
+// drain can be observed. Websocket upgrades (AI Studio relay, executor workers) are refused
+// while draining but not counted: they stay open until shutdown and would hold every drain
+// to its timeout, while the requests relayed over them are counted on their own routes.
+func (s *Server) drainMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/v0/management") {
+			c.Next()
+			return
+		}
+		if !s.drain.enter() {
+			retryAfter := config.DefaultDrainRetryAfter
+			if s.cfg != nil {
+				retryAfter = s.cfg.Drain.RetryAfterSeconds()
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.Header("Connection", "close")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+			return
+		}
+		if websocket.IsWebSocketUpgrade(c.Request) {
+			s.drain.leave()
+			c.Next()
+			return
+		}
+		defer s.drain.leave()
+		c.Next()
+	}
+}
+
+// Drain stops accepting new requests and waits until active requests, including streams,
+// have finished or ctx is done. It returns ctx.Err() when requests were still active.
+func (s *Server) Drain(ctx context.Context) error {
+	idle := s.drain.start()
+	if active := s.ActiveRequests(); active > 0 {
+		log.Infof("draining API server: waiting for %d active request(s)", active)
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RequestDrain asks the host to drain and shut down the server. It is idempotent.
+func (s *Server) RequestDrain() {
+	s.drain.once.Do(func() {
+		close(s.drain.requested)
+	})
+}
+
+// DrainRequested is closed once a drain was requested through the management API.
+func (s *Server) DrainRequested() <-chan struct{} {
+	return s.drain.requested
+}
+
+// Draining reports whether the server has stopped accepting new requests.
+func (s *Server) Draining() bool {
+	s.drain.mu.Lock()
+	defer s.drain.mu.Unlock()
+	return s.drain.draining
+}
+
+// ActiveRequests reports how many API requests are being served.
+func (s *Server) ActiveRequests() int {
+	s.drain.mu.Lock()
+	defer s.drain.mu.Unlock()
+	return s.drain.active
+}
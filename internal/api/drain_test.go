@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gin "github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestDrainRejectsNewRequestsAndWaitsForActive(t *testing.T) {
+	server := newTestServer(t)
+	server.cfg.Drain.RetryAfter = 7
+	release := make(chan struct{})
+	server.engine.GET("/test-slow", func(c *gin.Context) {
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	slow := make(chan int, 1)
+	go func() {
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test-slow", nil))
+		slow <- rr.Code
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for server.ActiveRequests() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("slow request never became active")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- server.Drain(context.Background()) }()
+	for !server.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") != "7" {
+		t.Fatalf("new request during drain: status %d, Retry-After %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain() returned %v before the active request finished", err)
+	default:
+	}
+
+	close(release)
+	if code := <-slow; code != http.StatusOK {
+		t.Fatalf("active request status = %d, want 200", code)
+	}
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+}
+
+func TestDrainTimesOut(t *testing.T) {
+	server := newTestServer(t)
+	if !server.drain.enter() {
+		t.Fatal("enter() = false before draining")
+	}
+	defer server.drain.leave()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := server.Drain(ctx); err == nil {
+		t.Fatal("Drain() should report the deadline with an active request")
+	}
+}
+
+func TestDrainIgnoresOpenWebsockets(t *testing.T) {
+	server := newTestServer(t)
+	upgrader := websocket.Upgrader{}
+	server.engine.GET("/test-ws", func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	httpServer := httptest.NewServer(server.engine)
+	defer httpServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/test-ws", nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = server.Drain(ctx); err != nil {
+		t.Fatalf("Drain() with an open websocket = %v, want nil", err)
+	}
+	if _, _, err = websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/test-ws", nil); err == nil {
+		t.Fatal("websocket upgrade accepted while draining")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/antigravity"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/claude"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
 	geminiAuth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/gemini"
 	iflowauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/iflow"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/qwen"
@@ -285,14 +286,33 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "handler not initialized"})
 		return
 	}
+	tenant, scoped, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
 	if h.authManager == nil {
-		h.listAuthFilesFromDisk(c)
+		h.listAuthFilesFromDisk(c, h.authDirFor(tenant))
+		return
+	}
+	filter, errFilter := h.authTagFilter(c)
+	if errFilter != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errFilter.Error()})
 		return
 	}
 	auths := h.authManager.List()
 	files := make([]gin.H, 0, len(auths))
 	for _, auth := range auths {
+		if !authVisible(auth, tenant, scoped) {
+			continue
+		}
+		if filter != nil && !filter.Matches(auth.Tags) {
+			continue
+		}
 		if entry := h.buildAuthFileEntry(auth); entry != nil {
+			if name, _ := entry["name"].(string); scoped && name != "" {
+				entry["name"] = filepath.Base(name)
+			}
 			files = append(files, entry)
 		}
 	}
@@ -312,6 +332,11 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "name or id is required"})
 		return
 	}
+	tenant, scoped, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
 
 	candidateIDs := make([]string, 0, 4)
 	seenIDs := make(map[string]struct{}, 8)
@@ -327,15 +352,17 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 		candidateIDs = append(candidateIDs, trimmed)
 	}
 
-	// Prefer explicit id/name from query first.
-	addCandidate(authIDQuery)
-	addCandidate(name)
+	// Prefer explicit id/name from query first. Scoped requests only resolve visible auths.
+	if !scoped {
+		addCandidate(authIDQuery)
+		addCandidate(name)
+	}
 
 	// Also scan auth manager to resolve legacy/new IDs and filename aliases.
 	if h.authManager != nil {
 		auths := h.authManager.List()
 		for _, auth := range auths {
-			if auth == nil {
+			if auth == nil || !authVisible(auth, tenant, scoped) {
 				continue
 			}
 			matchByName := false
@@ -390,8 +417,8 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 }
 
 // List auth files from disk when the auth manager is unavailable.
-func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
-	entries, err := os.ReadDir(h.cfg.AuthDir)
+func (h *Handler) listAuthFilesFromDisk(c *gin.Context, authDir string) {
+	entries, err := os.ReadDir(authDir)
 	if err != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read auth dir: %v", err)})
 		return
@@ -409,7 +436,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 			fileData := gin.H{"name": name, "size": info.Size(), "modtime": info.ModTime()}
 
 			// Read file to get type field
-			full := filepath.Join(h.cfg.AuthDir, name)
+			full := filepath.Join(authDir, name)
 			if data, errRead := os.ReadFile(full); errRead == nil {
 				typeValue := gjson.GetBytes(data, "type").String()
 				emailValue := gjson.GetBytes(data, "email").String()
@@ -450,6 +477,8 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 		"status":         auth.Status,
 		"status_message": auth.StatusMessage,
 		"disabled":       auth.Disabled,
+		"draining":       auth.Draining,
+		"in_flight":      h.authManager.InFlight(auth.ID),
 		"unavailable":    auth.Unavailable,
 		"runtime_only":   runtimeOnly,
 		"source":         "memory",
@@ -471,6 +500,13 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if email := authEmail(auth); email != "" {
 		entry["email"] = email
 	}
+	if len(auth.Tags) > 0 {
+		entry["tags"] = auth.Tags
+	}
+	if auth.Schedule != nil {
+		entry["schedule"] = auth.Schedule
+		addScheduleStatus(entry, auth, time.Now())
+	}
 	if accountType, account := auth.AccountInfo(); accountType != "" || account != "" {
 		if accountType != "" {
 			entry["account_type"] = accountType
@@ -596,7 +632,12 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "name must end with .json"})
 		return
 	}
-	full := filepath.Join(h.cfg.AuthDir, name)
+	tenant, _, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
+	full := filepath.Join(h.authDirFor(tenant), name)
 	data, err := os.ReadFile(full)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -617,13 +658,23 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 		return
 	}
 	ctx := c.Request.Context()
+	tenant, _, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
+	authDir := h.authDirFor(tenant)
+	if errMkdir := os.MkdirAll(authDir, 0o700); errMkdir != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to create auth dir: %v", errMkdir)})
+		return
+	}
 	if file, err := c.FormFile("file"); err == nil && file != nil {
 		name := filepath.Base(file.Filename)
 		if !strings.HasSuffix(strings.ToLower(name), ".json") {
 			c.JSON(400, gin.H{"error": "file must be .json"})
 			return
 		}
-		dst := filepath.Join(h.cfg.AuthDir, name)
+		dst := filepath.Join(authDir, name)
 		if !filepath.IsAbs(dst) {
 			if abs, errAbs := filepath.Abs(dst); errAbs == nil {
 				dst = abs
@@ -659,7 +710,7 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "failed to read body"})
 		return
 	}
-	dst := filepath.Join(h.cfg.AuthDir, filepath.Base(name))
+	dst := filepath.Join(authDir, filepath.Base(name))
 	if !filepath.IsAbs(dst) {
 		if abs, errAbs := filepath.Abs(dst); errAbs == nil {
 			dst = abs
@@ -683,8 +734,14 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 		return
 	}
 	ctx := c.Request.Context()
+	tenant, _, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
+	authDir := h.authDirFor(tenant)
 	if all := c.Query("all"); all == "true" || all == "1" || all == "*" {
-		entries, err := os.ReadDir(h.cfg.AuthDir)
+		entries, err := os.ReadDir(authDir)
 		if err != nil {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read auth dir: %v", err)})
 			return
@@ -698,7 +755,7 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 			if !strings.HasSuffix(strings.ToLower(name), ".json") {
 				continue
 			}
-			full := filepath.Join(h.cfg.AuthDir, name)
+			full := filepath.Join(authDir, name)
 			if !filepath.IsAbs(full) {
 				if abs, errAbs := filepath.Abs(full); errAbs == nil {
 					full = abs
@@ -721,7 +778,7 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid name"})
 		return
 	}
-	full := filepath.Join(h.cfg.AuthDir, filepath.Base(name))
+	full := filepath.Join(authDir, filepath.Base(name))
 	if !filepath.IsAbs(full) {
 		if abs, errAbs := filepath.Abs(full); errAbs == nil {
 			full = abs
@@ -825,6 +882,7 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
+	coreauth.SetTenantFromPath(auth, authID)
 	if hasLastRefresh {
 		auth.LastRefreshedAt = lastRefresh
 	}
@@ -842,7 +900,9 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	return err
 }
 
-// PatchAuthFileStatus toggles the disabled state of an auth file
+// PatchAuthFileStatus toggles the disabled state of an auth file. With "drain": true a disable
+// first drains the auth: it stops being selected at once and is disabled after its in-flight
+// requests finish.
 func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 	if h.authManager == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
@@ -852,6 +912,7 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 	var req struct {
 		Name     string `json:"name"`
 		Disabled *bool  `json:"disabled"`
+		Drain    bool   `json:"drain"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
@@ -870,42 +931,284 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	// Find auth by name or ID
-	var targetAuth *coreauth.Auth
-	if auth, ok := h.authManager.GetByID(name); ok {
-		targetAuth = auth
+	targetAuth := h.findAuth(c, name)
+	if targetAuth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+
+	if *req.Disabled && req.Drain && !targetAuth.Disabled {
+		idle, err := h.authManager.Drain(targetAuth.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to drain auth: %v", err)})
+			return
+		}
+		id := targetAuth.ID
+		go func() {
+			<-idle
+			h.disableDrainedAuth(id)
+		}()
+		c.JSON(http.StatusAccepted, gin.H{"status": "draining", "draining": true, "in_flight": h.authManager.InFlight(id)})
+		return
+	}
+	if !*req.Disabled {
+		h.authManager.CancelDrain(targetAuth.ID)
+		targetAuth.Draining = false
+	}
+
+	if err := h.setAuthDisabled(ctx, targetAuth, *req.Disabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update auth: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "disabled": *req.Disabled})
+}
+
+// GetAuthFileDrain reports the drain state of an auth. With "wait" (seconds, at most 300) it
+// blocks until the auth has no request in flight, so callers can use it as a completion signal.
+func (h *Handler) GetAuthFileDrain(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	targetAuth := h.findAuth(c, name)
+	if targetAuth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	if raw := strings.TrimSpace(c.Query("wait")); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid wait"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(min(seconds, 300))*time.Second)
+		_ = h.authManager.WaitDrained(ctx, targetAuth.ID)
+		cancel()
+		if current, ok := h.authManager.GetByID(targetAuth.ID); ok {
+			targetAuth = current
+		}
+	}
+	inFlight := h.authManager.InFlight(targetAuth.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"id":        targetAuth.ID,
+		"draining":  targetAuth.Draining,
+		"disabled":  targetAuth.Disabled,
+		"in_flight": inFlight,
+		"drained":   (targetAuth.Draining || targetAuth.Disabled) && inFlight == 0,
+	})
+}
+
+// findAuth resolves an auth visible to the request by ID or file name.
+func (h *Handler) findAuth(c *gin.Context, name string) *coreauth.Auth {
+	tenant, scoped, err := h.tenantScope(c)
+	if err != nil {
+		return nil
+	}
+	if auth, ok := h.authManager.GetByID(name); ok && authVisible(auth, tenant, scoped) {
+		return auth
+	}
+	for _, auth := range h.authManager.List() {
+		if !authVisible(auth, tenant, scoped) {
+			continue
+		}
+		// Scoped listings name tenant files without their tenants/<name>/ prefix.
+		if auth.FileName == name || (scoped && tenant != "" && authFileNameFor(tenant, name) == auth.ID) {
+			return auth
+		}
+	}
+	return nil
+}
+
+func (h *Handler) setAuthDisabled(ctx context.Context, auth *coreauth.Auth, disabled bool) error {
+	auth.Disabled = disabled
+	if disabled {
+		auth.Status = coreauth.StatusDisabled
+		auth.StatusMessage = "disabled via management API"
 	} else {
-		auths := h.authManager.List()
-		for _, auth := range auths {
-			if auth.FileName == name {
-				targetAuth = auth
-				break
-			}
+		auth.Status = coreauth.StatusActive
+		auth.StatusMessage = ""
+	}
+	auth.UpdatedAt = time.Now()
+	_, err := h.authManager.Update(ctx, auth)
+	return err
+}
+
+// disableDrainedAuth completes a drain unless it was cancelled in the meantime.
+func (h *Handler) disableDrainedAuth(id string) {
+	auth, ok := h.authManager.GetByID(id)
+	if !ok || !auth.Draining || auth.Disabled {
+		return
+	}
+	if err := h.setAuthDisabled(context.Background(), auth, true); err != nil {
+		log.Errorf("failed to disable drained auth %s: %v", id, err)
+		return
+	}
+	log.Infof("auth %s drained and disabled", id)
+}
+
+// authTagFilter builds the tag selector of a listing from the "tag" query parameters
+// (e.g. tag=team=research&tag=tier=max) and an optional "pool" name. It returns nil when
+// the listing is not filtered.
+func (h *Handler) authTagFilter(c *gin.Context) (coreauth.TagSelector, error) {
+	terms := make([]string, 0, 4)
+	for _, raw := range c.QueryArray("tag") {
+		if raw = strings.TrimSpace(raw); raw != "" {
+			terms = append(terms, raw)
+		}
+	}
+	if pool := strings.TrimSpace(c.Query("pool")); pool != "" {
+		raw, ok := h.cfg.AuthPools[pool]
+		if !ok {
+			return nil, fmt.Errorf("unknown auth pool %q", pool)
 		}
+		terms = append(terms, raw)
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	return coreauth.ParseTagSelector(strings.Join(terms, ","))
+}
+
+// PatchAuthFileTags sets, merges or removes the tags of an auth file. Tags are stored in the
+// file's "tags" field so they survive re-logins that keep the file name.
+func (h *Handler) PatchAuthFileTags(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+
+	var req struct {
+		Name    string            `json:"name"`
+		Tags    map[string]string `json:"tags"`
+		Remove  []string          `json:"remove"`
+		Replace bool              `json:"replace"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
 	}
 
+	targetAuth := h.findAuth(c, name)
 	if targetAuth == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
 		return
 	}
+	if targetAuth.Metadata == nil || strings.HasPrefix(authAttribute(targetAuth, "source"), "config:") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tags of config credentials are set in their config entry"})
+		return
+	}
 
-	// Update disabled state
-	targetAuth.Disabled = *req.Disabled
-	if *req.Disabled {
-		targetAuth.Status = coreauth.StatusDisabled
-		targetAuth.StatusMessage = "disabled via management API"
+	tags := make(map[string]string, len(targetAuth.Tags)+len(req.Tags))
+	if !req.Replace {
+		for key, value := range targetAuth.Tags {
+			tags[key] = value
+		}
+	}
+	for key, value := range req.Tags {
+		tags[key] = value
+	}
+	tags = coreauth.NormalizeTags(tags)
+	for _, key := range req.Remove {
+		delete(tags, strings.ToLower(strings.TrimSpace(key)))
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+
+	targetAuth.Tags = tags
+	if tags == nil {
+		delete(targetAuth.Metadata, "tags")
 	} else {
-		targetAuth.Status = coreauth.StatusActive
-		targetAuth.StatusMessage = ""
+		targetAuth.Metadata["tags"] = coreauth.TagsToMetadata(tags)
 	}
 	targetAuth.UpdatedAt = time.Now()
+	if _, err := h.authManager.Update(c.Request.Context(), targetAuth); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update auth: %v", err)})
+		return
+	}
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "tags": tags})
+}
+
+// PatchAuthFileSchedule sets or clears (null or no windows) the time-window schedule of an
+// auth file. Outside its windows the auth is skipped like a cooled-down one.
+func (h *Handler) PatchAuthFileSchedule(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
 
-	if _, err := h.authManager.Update(ctx, targetAuth); err != nil {
+	var req struct {
+		Name     string             `json:"name"`
+		Schedule *coreauth.Schedule `json:"schedule"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	schedule := coreauth.NormalizeSchedule(req.Schedule)
+	if _, err := config.CompileSchedule(schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetAuth := h.findAuth(c, name)
+	if targetAuth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	if targetAuth.Metadata == nil || strings.HasPrefix(authAttribute(targetAuth, "source"), "config:") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "schedules of config credentials are set in their config entry"})
+		return
+	}
+
+	targetAuth.Schedule = schedule
+	if schedule == nil {
+		delete(targetAuth.Metadata, "schedule")
+	} else {
+		targetAuth.Metadata["schedule"] = coreauth.ScheduleToMetadata(schedule)
+	}
+	targetAuth.UpdatedAt = time.Now()
+	if _, err := h.authManager.Update(c.Request.Context(), targetAuth); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update auth: %v", err)})
 		return
 	}
+	resp := gin.H{"status": "ok", "schedule": schedule}
+	addScheduleStatus(resp, targetAuth, time.Now())
+	c.JSON(http.StatusOK, resp)
+}
 
-	c.JSON(http.StatusOK, gin.H{"status": "ok", "disabled": *req.Disabled})
+// addScheduleStatus reports whether the auth's schedule currently allows it.
+func addScheduleStatus(entry gin.H, auth *coreauth.Auth, now time.Time) {
+	if auth.Schedule == nil {
+		return
+	}
+	available, next, err := coreauth.ScheduleAvailability(auth, now)
+	entry["schedule_open"] = available
+	if !next.IsZero() {
+		entry["schedule_next_open"] = next
+	}
+	if err != nil {
+		entry["schedule_error"] = err.Error()
+	}
 }
 
 func (h *Handler) disableAuth(ctx context.Context, id string) {
@@ -1748,6 +2051,81 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok", "url": authURL, "state": state})
 }
 
+// RequestCopilotToken starts the GitHub device flow for GitHub Copilot. The response carries
+// the verification URL and the user code to enter there; the credential is saved once the
+// user approves the device.
+func (h *Handler) RequestCopilotToken(c *gin.Context) {
+	ctx := context.Background()
+
+	fmt.Println("Initializing GitHub Copilot authentication...")
+
+	state := fmt.Sprintf("cop-%d", time.Now().UnixNano())
+	authSvc := copilotauth.NewCopilotAuth(h.cfg)
+
+	deviceFlow, err := authSvc.InitiateDeviceFlow(ctx)
+	if err != nil {
+		log.Errorf("Failed to start GitHub device flow: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate authorization url"})
+		return
+	}
+
+	RegisterOAuthSession(state, "copilot")
+
+	go func() {
+		fmt.Println("Waiting for authentication...")
+		githubToken, errPoll := authSvc.PollForToken(ctx, deviceFlow)
+		if errPoll != nil {
+			SetOAuthSessionError(state, "Authentication failed")
+			fmt.Printf("Authentication failed: %v\n", errPoll)
+			return
+		}
+
+		tokenData, errExchange := authSvc.ExchangeToken(ctx, githubToken)
+		if errExchange != nil {
+			log.Errorf("Failed to obtain Copilot token: %v", errExchange)
+			SetOAuthSessionError(state, "Failed to obtain Copilot token")
+			return
+		}
+
+		tokenStorage := authSvc.CreateTokenStorage(githubToken, tokenData)
+		login, email, errUser := authSvc.FetchUser(ctx, githubToken)
+		if errUser != nil {
+			log.Warnf("copilot: failed to fetch GitHub user: %v", errUser)
+		}
+		tokenStorage.Login = strings.TrimSpace(login)
+		tokenStorage.Email = strings.TrimSpace(email)
+		account := tokenStorage.Login
+		if account == "" {
+			account = fmt.Sprintf("%d", time.Now().UnixMilli())
+		}
+
+		metadata := map[string]any{"login": tokenStorage.Login}
+		if tokenStorage.Email != "" {
+			metadata["email"] = tokenStorage.Email
+		}
+		record := &coreauth.Auth{
+			ID:       fmt.Sprintf("copilot-%s.json", account),
+			Provider: "copilot",
+			FileName: fmt.Sprintf("copilot-%s.json", account),
+			Label:    tokenStorage.Login,
+			Storage:  tokenStorage,
+			Metadata: metadata,
+		}
+		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		if errSave != nil {
+			log.Errorf("Failed to save authentication tokens: %v", errSave)
+			SetOAuthSessionError(state, "Failed to save authentication tokens")
+			return
+		}
+
+		fmt.Printf("Authentication successful! Token saved to %s\n", savedPath)
+		fmt.Println("You can now use GitHub Copilot through this CLI")
+		CompleteOAuthSession(state)
+	}()
+
+	c.JSON(200, gin.H{"status": "ok", "url": deviceFlow.VerificationURI, "user_code": deviceFlow.UserCode, "state": state})
+}
+
 func (h *Handler) RequestIFlowToken(c *gin.Context) {
 	ctx := context.Background()
 
@@ -0,0 +1,590 @@
+package management
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// archivePassphraseHeader carries the passphrase of an encrypted auth archive.
+	archivePassphraseHeader = "X-Archive-Passphrase"
+	maxAuthArchiveSize      = 32 << 20
+	maxAuthArchiveEntry     = 1 << 20
+	maxAuthArchiveEntries   = 5000
+)
+
+// encryptedArchiveMagic prefixes archives encrypted with AES-256-GCM under a scrypt-derived
+// key: magic | salt(16) | nonce(12) | ciphertext.
+var encryptedArchiveMagic = []byte("CPAENC1\n")
+
+// Conflict policies of an auth archive import.
+const (
+	importConflictSkip      = "skip"
+	importConflictOverwrite = "overwrite"
+	importConflictRename    = "rename"
+)
+
+type archiveEntry struct {
+	name string
+	data []byte
+}
+
+// authImportResult reports what happened to one archive entry.
+type authImportResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Target   string `json:"target,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Account  string `json:"account,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ExportAuthFiles streams the stored auth files as a zip (default) or tar.gz archive. The
+// listing can be narrowed with "provider" and the tag/pool filters of ListAuthFiles; an
+// X-Archive-Passphrase header encrypts the archive. Only the auth files of the request's tenant
+// directory are exported.
+func (h *Handler) ExportAuthFiles(c *gin.Context) {
+	store := h.tokenStoreWithBaseDir()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store unavailable"})
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "zip")))
+	if format != "zip" && format != "tar" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be zip or tar"})
+		return
+	}
+	filter, errFilter := h.authTagFilter(c)
+	if errFilter != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errFilter.Error()})
+		return
+	}
+	tenant, _, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
+	providers := make(map[string]struct{})
+	for _, raw := range c.QueryArray("provider") {
+		for _, p := range strings.Split(raw, ",") {
+			if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+				providers[p] = struct{}{}
+			}
+		}
+	}
+
+	auths, err := store.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list auth files: %v", err)})
+		return
+	}
+	entries := make([]archiveEntry, 0, len(auths))
+	seen := make(map[string]struct{}, len(auths))
+	for _, auth := range auths {
+		if auth == nil || auth.Metadata == nil || isRuntimeOnlyAuth(auth) {
+			continue
+		}
+		if coreauth.AuthTenant(auth) != tenant {
+			continue
+		}
+		if len(providers) > 0 {
+			if _, ok := providers[strings.ToLower(auth.Provider)]; !ok {
+				continue
+			}
+		}
+		if filter != nil && !filter.Matches(coreauth.TagsFromMetadata(auth.Metadata)) {
+			continue
+		}
+		name := exportFileName(auth)
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		data, errMarshal := json.MarshalIndent(auth.Metadata, "", "  ")
+		if errMarshal != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to encode %s: %v", name, errMarshal)})
+			return
+		}
+		seen[name] = struct{}{}
+		entries = append(entries, archiveEntry{name: name, data: data})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	var archive []byte
+	if format == "tar" {
+		archive, err = writeTarGzArchive(entries)
+	} else {
+		archive, err = writeZipArchive(entries)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to build archive: %v", err)})
+		return
+	}
+	fileName := fmt.Sprintf("auth-files-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	contentType := "application/zip"
+	if format == "tar" {
+		fileName = strings.TrimSuffix(fileName, ".zip") + ".tar.gz"
+		contentType = "application/gzip"
+	}
+	if passphrase := c.GetHeader(archivePassphraseHeader); passphrase != "" {
+		if archive, err = encryptArchive(archive, passphrase); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to encrypt archive: %v", err)})
+			return
+		}
+		fileName += ".enc"
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.Header("X-Auth-Files-Count", fmt.Sprintf("%d", len(entries)))
+	c.Data(http.StatusOK, contentType, archive)
+}
+
+// ImportAuthFiles imports the auth files of a zip or tar(.gz) archive, encrypted or not,
+// uploaded as multipart "file" or as the raw body. Each file must be a JSON object with a
+// "type". Files matching an existing auth by account identity or by file name are resolved by
+// ?conflict=: skip (default) keeps the existing file, overwrite replaces it and rename imports
+// the file under a free name unless the account already exists. Files land in the request's
+// tenant directory and only conflict with auths of that directory.
+func (h *Handler) ImportAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	if h.tokenStoreWithBaseDir() == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store unavailable"})
+		return
+	}
+	policy := strings.ToLower(strings.TrimSpace(c.DefaultQuery("conflict", importConflictSkip)))
+	switch policy {
+	case importConflictSkip, importConflictOverwrite, importConflictRename:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "conflict must be skip, overwrite or rename"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAuthArchiveSize+1<<20)
+	var (
+		raw        []byte
+		err        error
+		passphrase = c.GetHeader(archivePassphraseHeader)
+	)
+	if fileHeader, errForm := c.FormFile("file"); errForm == nil && fileHeader != nil {
+		file, errOpen := fileHeader.Open()
+		if errOpen != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to read file: %v", errOpen)})
+			return
+		}
+		raw, err = io.ReadAll(io.LimitReader(file, maxAuthArchiveSize+1))
+		_ = file.Close()
+		if passphrase == "" {
+			passphrase = c.PostForm("passphrase")
+		}
+	} else {
+		raw, err = io.ReadAll(io.LimitReader(c.Request.Body, maxAuthArchiveSize+1))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to read archive: %v", err)})
+		return
+	}
+	if len(raw) > maxAuthArchiveSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "archive too large"})
+		return
+	}
+	if bytes.HasPrefix(raw, encryptedArchiveMagic) {
+		if passphrase == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "archive is encrypted; passphrase required"})
+			return
+		}
+		if raw, err = decryptArchive(raw, passphrase); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	entries, err := readAuthArchive(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tenant, _, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
+
+	results := h.importAuthEntries(c.Request.Context(), tenant, entries, policy)
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "ok",
+		"imported":    counts["imported"],
+		"overwritten": counts["overwritten"],
+		"renamed":     counts["renamed"],
+		"skipped":     counts["skipped"],
+		"invalid":     counts["invalid"],
+		"failed":      counts["failed"],
+		"files":       results,
+	})
+}
+
+func (h *Handler) importAuthEntries(ctx context.Context, tenant string, entries []archiveEntry, policy string) []authImportResult {
+	byName := make(map[string]string)
+	byAccount := make(map[string]string)
+	for _, auth := range h.authManager.List() {
+		if auth == nil || isRuntimeOnlyAuth(auth) || auth.Metadata == nil || coreauth.AuthTenant(auth) != tenant {
+			continue
+		}
+		name := exportFileName(auth)
+		byName[strings.ToLower(name)] = name
+		if key := accountIdentity(auth); key != "" {
+			byAccount[key] = name
+		}
+	}
+
+	results := make([]authImportResult, 0, len(entries))
+	for _, entry := range entries {
+		result := authImportResult{Name: entry.name}
+		metadata, provider, errValidate := validateImportedAuth(entry)
+		if errValidate != nil {
+			result.Status = "invalid"
+			result.Reason = errValidate.Error()
+			results = append(results, result)
+			continue
+		}
+		candidate := &coreauth.Auth{Provider: provider, Metadata: metadata}
+		key := accountIdentity(candidate)
+		_, result.Account = candidate.AccountInfo()
+		result.Provider = provider
+
+		target := entry.name
+		status := "imported"
+		existingByAccount, accountTaken := byAccount[key]
+		if key == "" {
+			accountTaken = false
+		}
+		existingByName, nameTaken := byName[strings.ToLower(entry.name)]
+		switch {
+		case !accountTaken && !nameTaken:
+		case policy == importConflictOverwrite:
+			status = "overwritten"
+			if accountTaken {
+				target = existingByAccount
+			} else {
+				target = existingByName
+			}
+		case policy == importConflictRename && !accountTaken:
+			status = "renamed"
+			target = freeAuthFileName(entry.name, byName)
+		default:
+			result.Status = "skipped"
+			if accountTaken {
+				result.Reason = fmt.Sprintf("account already stored in %s", existingByAccount)
+			} else {
+				result.Reason = fmt.Sprintf("file %s already exists", existingByName)
+			}
+			results = append(results, result)
+			continue
+		}
+
+		if errSave := h.saveImportedAuth(ctx, authFileNameFor(tenant, target), provider, metadata); errSave != nil {
+			result.Status = "failed"
+			result.Reason = errSave.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Status = status
+		if target != entry.name {
+			result.Target = target
+		}
+		byName[strings.ToLower(target)] = target
+		if key != "" {
+			byAccount[key] = target
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// saveImportedAuth writes the file through the token store, so every store backend receives
+// it, then registers it with the running manager.
+func (h *Handler) saveImportedAuth(ctx context.Context, name, provider string, metadata map[string]any) error {
+	disabled, _ := metadata["disabled"].(bool)
+	record := &coreauth.Auth{
+		ID:       name,
+		Provider: provider,
+		FileName: name,
+		Metadata: metadata,
+	}
+	savedPath, err := h.saveTokenRecord(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to save auth file: %w", err)
+	}
+	if savedPath == "" {
+		savedPath = filepath.Join(h.cfg.AuthDir, name)
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode auth file: %w", err)
+	}
+	if err = h.registerAuthFromFile(ctx, savedPath, data); err != nil {
+		return err
+	}
+	if disabled {
+		if auth, ok := h.authManager.GetByID(h.authIDForPath(savedPath)); ok {
+			return h.setAuthDisabled(ctx, auth, true)
+		}
+	}
+	return nil
+}
+
+func validateImportedAuth(entry archiveEntry) (map[string]any, string, error) {
+	if !strings.HasSuffix(strings.ToLower(entry.name), ".json") {
+		return nil, "", errors.New("not a .json file")
+	}
+	if len(entry.data) > maxAuthArchiveEntry {
+		return nil, "", errors.New("file too large")
+	}
+	metadata := make(map[string]any)
+	if err := json.Unmarshal(entry.data, &metadata); err != nil {
+		return nil, "", fmt.Errorf("invalid json: %v", err)
+	}
+	provider, _ := metadata["type"].(string)
+	provider = strings.TrimSpace(provider)
+	if provider == "" {
+		return nil, "", errors.New("missing \"type\"")
+	}
+	return metadata, provider, nil
+}
+
+// accountIdentity keys an auth by provider and account so the same login stored under two
+// file names is recognised.
+func accountIdentity(auth *coreauth.Auth) string {
+	_, account := auth.AccountInfo()
+	account = strings.ToLower(strings.TrimSpace(account))
+	if account == "" {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(auth.Provider)) + "|" + account
+}
+
+func exportFileName(auth *coreauth.Auth) string {
+	name := strings.TrimSpace(auth.FileName)
+	if name == "" {
+		name = auth.ID
+	}
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if !strings.HasSuffix(strings.ToLower(name), ".json") {
+		name += ".json"
+	}
+	return name
+}
+
+func freeAuthFileName(name string, taken map[string]string) string {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d.json", base, i)
+		if _, exists := taken[strings.ToLower(candidate)]; !exists {
+			return candidate
+		}
+	}
+}
+
+// readAuthArchive extracts the regular files of a zip, tar or tar.gz archive. Directory
+// components are dropped; hidden files and macOS resource forks are ignored.
+func readAuthArchive(raw []byte) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	var extracted int
+	add := func(name string, r io.Reader) error {
+		name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+		if name == "" || name == "." || name == "/" || strings.HasPrefix(name, ".") {
+			return nil
+		}
+		if len(entries) >= maxAuthArchiveEntries {
+			return fmt.Errorf("archive has more than %d files", maxAuthArchiveEntries)
+		}
+		data, err := io.ReadAll(io.LimitReader(r, maxAuthArchiveEntry+1))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		entries = append(entries, archiveEntry{name: name, data: data})
+		extracted += len(data)
+		return nil
+	}
+
+	switch {
+	case bytes.HasPrefix(raw, []byte("PK\x03\x04")), bytes.HasPrefix(raw, []byte("PK\x05\x06")):
+		zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zip archive: %w", err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+				continue
+			}
+			rc, errOpen := f.Open()
+			if errOpen != nil {
+				return nil, fmt.Errorf("failed to open %s: %w", f.Name, errOpen)
+			}
+			errAdd := add(f.Name, rc)
+			_ = rc.Close()
+			if errAdd != nil {
+				return nil, errAdd
+			}
+			if extracted > maxAuthArchiveSize {
+				return nil, fmt.Errorf("archive expands to more than %d bytes", maxAuthArchiveSize)
+			}
+		}
+		return entries, nil
+	case bytes.HasPrefix(raw, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip archive: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		err = readTarEntries(tar.NewReader(io.LimitReader(gz, maxAuthArchiveSize)), add)
+		return entries, err
+	case len(raw) > 262 && string(raw[257:262]) == "ustar":
+		err := readTarEntries(tar.NewReader(bytes.NewReader(raw)), add)
+		return entries, err
+	default:
+		return nil, errors.New("unsupported archive: expected zip, tar or tar.gz")
+	}
+}
+
+func readTarEntries(tr *tar.Reader, add func(string, io.Reader) error) error {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err = add(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func writeZipArchive(entries []archiveEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	now := time.Now()
+	for _, entry := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(entry.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeTarGzArchive(entries []archiveEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, entry := range entries {
+		hdr := &tar.Header{Name: entry.name, Mode: 0o600, Size: int64(len(entry.data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(entry.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func archiveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+func encryptArchive(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := archiveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(encryptedArchiveMagic)+len(salt)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, encryptedArchiveMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain, encryptedArchiveMagic), nil
+}
+
+func decryptArchive(data []byte, passphrase string) ([]byte, error) {
+	data = data[len(encryptedArchiveMagic):]
+	if len(data) < 16+12 {
+		return nil, errors.New("encrypted archive is truncated")
+	}
+	salt, rest := data[:16], data[16:]
+	key, err := archiveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, encryptedArchiveMagic)
+	if err != nil {
+		return nil, errors.New("failed to decrypt archive: wrong passphrase or corrupted data")
+	}
+	return plain, nil
+}
@@ -0,0 +1,176 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type authImportResponse struct {
+	Imported    int                `json:"imported"`
+	Overwritten int                `json:"overwritten"`
+	Renamed     int                `json:"renamed"`
+	Skipped     int                `json:"skipped"`
+	Invalid     int                `json:"invalid"`
+	Files       []authImportResult `json:"files"`
+}
+
+func newTransferHandler(t *testing.T, files map[string]string) (*Handler, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store := sdkAuth.NewFileTokenStore()
+	store.SetBaseDir(dir)
+	h := &Handler{
+		cfg:         &config.Config{AuthDir: dir},
+		authManager: coreauth.NewManager(store, nil, nil),
+		tokenStore:  store,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if err := h.registerAuthFromFile(context.Background(), path, []byte(content)); err != nil {
+			t.Fatalf("register %s: %v", name, err)
+		}
+	}
+	return h, dir
+}
+
+func transferRouter(h *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/export", h.ExportAuthFiles)
+	r.POST("/import", h.ImportAuthFiles)
+	return r
+}
+
+func importArchive(t *testing.T, r *gin.Engine, archive []byte, query, passphrase string) (int, authImportResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/import"+query, bytes.NewReader(archive))
+	if passphrase != "" {
+		req.Header.Set(archivePassphraseHeader, passphrase)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp authImportResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode import response: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestExportImportAuthFiles(t *testing.T) {
+	source, _ := newTransferHandler(t, map[string]string{
+		"claude-a.json": `{"type":"claude","email":"a@example.com","access_token":"new"}`,
+		"codex-b.json":  `{"type":"codex","email":"b@example.com","tags":{"team":"research"}}`,
+		"qwen-c.json":   `{"type":"qwen","email":"c@example.com"}`,
+	})
+	req := httptest.NewRequest(http.MethodGet, "/export?format=tar&provider=claude,codex", nil)
+	req.Header.Set(archivePassphraseHeader, "secret")
+	w := httptest.NewRecorder()
+	transferRouter(source).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Auth-Files-Count"); got != "2" {
+		t.Fatalf("exported %s files, want 2", got)
+	}
+	archive := w.Body.Bytes()
+
+	dest, destDir := newTransferHandler(t, map[string]string{
+		"claude-old.json": `{"type":"claude","email":"A@example.com","access_token":"old"}`,
+		"codex-b.json":    `{"type":"codex","email":"z@example.com"}`,
+	})
+	r := transferRouter(dest)
+
+	if code, _ := importArchive(t, r, archive, "", "wrong"); code != http.StatusBadRequest {
+		t.Fatalf("import with wrong passphrase status = %d, want 400", code)
+	}
+
+	code, resp := importArchive(t, r, archive, "", "secret")
+	if code != http.StatusOK || resp.Skipped != 2 {
+		t.Fatalf("skip import: status %d, %+v", code, resp)
+	}
+
+	// The account of claude-a.json exists, so only the name clash of codex-b.json is renamed.
+	code, resp = importArchive(t, r, archive, "?conflict=rename", "secret")
+	if code != http.StatusOK || resp.Renamed != 1 || resp.Skipped != 1 {
+		t.Fatalf("rename import: status %d, %+v", code, resp)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "codex-b-2.json")); err != nil {
+		t.Fatalf("codex-b-2.json not written: %v", err)
+	}
+	if _, ok := dest.authManager.GetByID("codex-b-2.json"); !ok {
+		t.Fatal("imported auth not registered")
+	}
+
+	code, resp = importArchive(t, r, archive, "?conflict=overwrite", "secret")
+	if code != http.StatusOK || resp.Overwritten != 2 {
+		t.Fatalf("overwrite import: status %d, %+v", code, resp)
+	}
+	data, err := os.ReadFile(filepath.Join(destDir, "claude-old.json"))
+	if err != nil {
+		t.Fatalf("read claude-old.json: %v", err)
+	}
+	var metadata map[string]any
+	_ = json.Unmarshal(data, &metadata)
+	if metadata["access_token"] != "new" {
+		t.Fatalf("account match was not overwritten in place: %s", data)
+	}
+	if _, err = os.Stat(filepath.Join(destDir, "claude-a.json")); !os.IsNotExist(err) {
+		t.Fatalf("duplicate account file created: %v", err)
+	}
+}
+
+func TestReadAuthArchiveValidatesEntries(t *testing.T) {
+	archive, err := writeZipArchive([]archiveEntry{
+		{name: "nested/ok.json", data: []byte(`{"type":"claude"}`)},
+		{name: "notes.txt", data: []byte("hello")},
+		{name: "broken.json", data: []byte("{")},
+		{name: "untyped.json", data: []byte(`{"email":"x"}`)},
+	})
+	if err != nil {
+		t.Fatalf("writeZipArchive() error = %v", err)
+	}
+	h, _ := newTransferHandler(t, nil)
+	code, resp := importArchive(t, transferRouter(h), archive, "", "")
+	if code != http.StatusOK || resp.Imported != 1 || resp.Invalid != 3 {
+		t.Fatalf("import: status %d, %+v", code, resp)
+	}
+	if resp.Files[0].Name != "ok.json" {
+		t.Fatalf("directory components kept: %+v", resp.Files[0])
+	}
+
+	if _, err = readAuthArchive([]byte("not an archive")); err == nil {
+		t.Fatal("readAuthArchive() accepted garbage")
+	}
+}
+
+func TestReadAuthArchiveRejectsOversizedZip(t *testing.T) {
+	entry := bytes.Repeat([]byte(" "), maxAuthArchiveEntry)
+	var entries []archiveEntry
+	for i := 0; i <= maxAuthArchiveSize/maxAuthArchiveEntry; i++ {
+		entries = append(entries, archiveEntry{name: fmt.Sprintf("auth-%d.json", i), data: entry})
+	}
+	archive, err := writeZipArchive(entries)
+	if err != nil {
+		t.Fatalf("writeZipArchive() error = %v", err)
+	}
+	if _, err = readAuthArchive(archive); err == nil {
+		t.Fatal("readAuthArchive() accepted an archive expanding past the size limit")
+	}
+}
@@ -0,0 +1,193 @@
+package management
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// The /v0/client endpoints below are authenticated with the client API key itself and only
+// ever expose data of that key and the keys it was rotated from.
+
+const (
+	defaultClientRequestLimit = 50
+	defaultKeyRotationGrace   = 24 * time.Hour
+	maxKeyRotationGrace       = 30 * 24 * time.Hour
+)
+
+func clientKey(c *gin.Context) string {
+	return c.GetString("apiKey")
+}
+
+// GetClientUsage returns the usage of the calling key over time, with its budget and expiry.
+// ?days=N limits the window to the last N days.
+func (h *Handler) GetClientUsage(c *gin.Context) {
+	key := clientKey(c)
+	var since time.Time
+	if raw := strings.TrimSpace(c.Query("days")); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+	}
+	resp := gin.H{
+		"usage_statistics_enabled": h.cfg.UsageStatisticsEnabled,
+		"usage":                    h.usageStats.KeyUsage(key, since),
+	}
+	if budget, ok := h.cfg.APIKeyBudgets[key]; ok {
+		resp["budget"] = h.usageStats.CheckBudget(key, budget, time.Now())
+	}
+	if expiry, ok := h.cfg.APIKeyExpiry[key]; ok {
+		resp["expires_at"] = expiry
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetClientRequests lists the most recent requests of the calling key from the request log.
+func (h *Handler) GetClientRequests(c *gin.Context) {
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	if limit == 0 {
+		limit = defaultClientRequestLimit
+	}
+	keys := h.usageStats.LinkedAPIKeys(clientKey(c))
+	requests := make([]usage.RequestLogEntry, 0, limit)
+	for _, entry := range usage.SnapshotRequestLogs(0) {
+		if len(requests) == limit {
+			break
+		}
+		if entry.APIKey == "" || !slices.Contains(keys, entry.APIKey) {
+			continue
+		}
+		entry.APIKey = util.HideAPIKey(entry.APIKey)
+		requests = append(requests, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// RotateClientKey issues a replacement for the calling key. The new key inherits the settings
+// of the old one, which keeps working for a grace period (grace_seconds, default one day).
+// Spend of both keys counts against the budget, and a replaced key cannot be rotated again.
+func (h *Handler) RotateClientKey(c *gin.Context) {
+	var body struct {
+		GraceSeconds *int64 `json:"grace_seconds"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	grace := defaultKeyRotationGrace
+	if body.GraceSeconds != nil {
+		grace = time.Duration(*body.GraceSeconds) * time.Second
+		if grace < 0 || grace > maxKeyRotationGrace {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("grace_seconds must be between 0 and %d", int64(maxKeyRotationGrace/time.Second))})
+			return
+		}
+	}
+	oldKey := clientKey(c)
+	newKey, err := generateClientKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate key: %v", err)})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err = h.cfg.RotateAPIKey(oldKey, newKey, time.Now().Add(grace)); err != nil {
+		if errors.Is(err, config.ErrAPIKeyRotated) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, config.ErrAPIKeyNotConfigured) {
+			c.JSON(http.StatusConflict, gin.H{"error": "api key cannot be rotated by the client"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err = config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
+		return
+	}
+	h.usageStats.LinkAPIKeys(oldKey, newKey)
+	c.JSON(http.StatusOK, gin.H{
+		"api_key":                 newKey,
+		"previous_key_expires_at": h.cfg.APIKeyExpiry[oldKey],
+	})
+}
+
+// generateClientKey returns a random client API key.
+func generateClientKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "sk-" + hex.EncodeToString(buf), nil
+}
+
+// GetClientModels lists the models served by the accounts the calling key may use.
+func (h *Handler) GetClientModels(c *gin.Context) {
+	result := make([]gin.H, 0, 32)
+	if h.authManager == nil {
+		c.JSON(http.StatusOK, gin.H{"models": result})
+		return
+	}
+	allowed, restricted := h.authManager.AllowedAuthIDsForClientKey(clientKey(c))
+	tenant := c.GetString("tenant")
+	reg := registry.GetGlobalRegistry()
+	seen := make(map[string]struct{}, 32)
+	for _, auth := range h.authManager.List() {
+		if auth == nil || auth.Disabled || coreauth.AuthTenant(auth) != tenant {
+			continue
+		}
+		if _, ok := allowed[auth.ID]; restricted && !ok {
+			continue
+		}
+		for _, m := range reg.GetModelsForClient(auth.ID) {
+			modelID := strings.TrimSpace(m.ID)
+			if modelID == "" {
+				continue
+			}
+			key := strings.ToLower(modelID)
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			seen[key] = struct{}{}
+			entry := gin.H{"id": modelID}
+			if m.DisplayName != "" {
+				entry["display_name"] = m.DisplayName
+			}
+			if m.Type != "" {
+				entry["type"] = m.Type
+			}
+			if m.OwnedBy != "" {
+				entry["owned_by"] = m.OwnedBy
+			}
+			result = append(result, entry)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i]["id"].(string) < result[j]["id"].(string) })
+	c.JSON(http.StatusOK, gin.H{"models": result})
+}
@@ -0,0 +1,133 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+func newClientPortalRouter(h *Handler, key string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	client := r.Group("/v0/client", func(c *gin.Context) { c.Set("apiKey", key) })
+	client.GET("/requests", h.GetClientRequests)
+	client.POST("/key/rotate", h.RotateClientKey)
+	return r
+}
+
+func TestRotateClientKeyCarriesSettingsAndExpiresOldKey(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("api-keys:\n  - old-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		APIKeyAuth:    map[string][]string{"old-key": {"auth-a"}},
+		APIKeyBudgets: map[string]config.APIKeyBudget{"old-key": {Limit: 5, Unit: config.BudgetUnitCost}},
+	}
+	cfg.APIKeys = []string{"old-key"}
+	cfg.Guardrails.Rules = []config.GuardrailRule{{Name: "pii", APIKeys: []string{"old-key"}}}
+	h := &Handler{cfg: cfg, configFilePath: configPath, usageStats: usage.NewRequestStatistics()}
+	r := newClientPortalRouter(h, "old-key")
+
+	before := time.Now()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v0/client/key/rotate", strings.NewReader(`{"grace_seconds":3600}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("rotate status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		APIKey    string `json:"api_key"`
+		ExpiresAt string `json:"previous_key_expires_at"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	newKey := resp.APIKey
+	if !strings.HasPrefix(newKey, "sk-") || !slices.Contains(cfg.APIKeys, newKey) {
+		t.Fatalf("new key %q not added to api-keys %v", newKey, cfg.APIKeys)
+	}
+	expiry, err := time.Parse(time.RFC3339, resp.ExpiresAt)
+	if err != nil || expiry.Before(before.Add(time.Hour-time.Second)) || expiry.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("old key expiry = %q, want about an hour from now", resp.ExpiresAt)
+	}
+	if _, ok := cfg.APIKeyExpiry[newKey]; ok {
+		t.Fatal("new key should not expire when the old key had no expiry")
+	}
+	if !slices.Equal(cfg.APIKeyAuth[newKey], []string{"auth-a"}) || cfg.APIKeyBudgets[newKey].Limit != 5 {
+		t.Fatalf("settings not carried over: auth %v budget %+v", cfg.APIKeyAuth[newKey], cfg.APIKeyBudgets[newKey])
+	}
+	if cfg.APIKeyRotations["old-key"] != newKey {
+		t.Fatalf("api-key-rotations = %v", cfg.APIKeyRotations)
+	}
+	if !slices.Contains(cfg.Guardrails.Rules[0].APIKeys, newKey) {
+		t.Fatalf("guardrail keys = %v", cfg.Guardrails.Rules[0].APIKeys)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(saved), newKey) || !strings.Contains(string(saved), "api-key-rotations") {
+		t.Fatalf("config file not updated: %s", saved)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v0/client/key/rotate", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second rotation of old key status = %d, want 409", w.Code)
+	}
+
+	// After a restart the rotation is only known from the saved configuration.
+	reloaded, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = &Handler{cfg: reloaded, configFilePath: configPath, usageStats: usage.NewRequestStatistics()}
+	w = httptest.NewRecorder()
+	newClientPortalRouter(h, "old-key").ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v0/client/key/rotate", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("rotation of old key after reload status = %d, want 409", w.Code)
+	}
+}
+
+func TestGetClientRequestsOnlyListsOwnKeys(t *testing.T) {
+	stats := usage.NewRequestStatistics()
+	stats.LinkAPIKeys("portal-old", "portal-new")
+	h := &Handler{cfg: &config.Config{}, usageStats: stats}
+	now := time.Now()
+	for i, key := range []string{"portal-old", "portal-other", "portal-new"} {
+		id := "client-portal-test-" + key
+		usage.StartRequestLog(id, http.MethodPost, "/v1/chat/completions", now.Add(time.Duration(i)*time.Second))
+		usage.UpdateRequestLog(id, usage.RequestLogUpdate{APIKey: key, Model: "gpt-5"})
+		usage.FinishRequestLog(id, http.StatusOK, "", time.Time{})
+	}
+
+	w := httptest.NewRecorder()
+	newClientPortalRouter(h, "portal-new").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v0/client/requests", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Requests []usage.RequestLogEntry `json:"requests"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, entry := range resp.Requests {
+		if strings.Contains(entry.APIKey, "portal-") {
+			t.Fatalf("api key not masked: %q", entry.APIKey)
+		}
+		ids = append(ids, entry.ID)
+	}
+	want := []string{"client-portal-test-portal-new", "client-portal-test-portal-old"}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("requests = %v, want %v", ids, want)
+	}
+}
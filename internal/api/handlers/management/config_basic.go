@@ -289,6 +289,8 @@ func normalizeRoutingStrategy(strategy string) (string, bool) {
 		return "fill-first", true
 	case "session", "sess":
 		return "session", true
+	case "weighted":
+		return "weighted", true
 	default:
 		return "", false
 	}
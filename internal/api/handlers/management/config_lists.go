@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 // Generic helpers for list[string]
@@ -181,6 +182,60 @@ func (h *Handler) PutAPIKeyExpiry(c *gin.Context) {
 	h.persist(c)
 }
 
+// auth-pools
+func (h *Handler) GetAuthPools(c *gin.Context) {
+	pools := h.cfg.AuthPools
+	if pools == nil {
+		pools = map[string]string{}
+	}
+	c.JSON(200, gin.H{"auth-pools": pools})
+}
+
+func (h *Handler) PutAuthPools(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var pools map[string]string
+	if err = json.Unmarshal(data, &pools); err != nil {
+		var obj struct {
+			Pools map[string]string `json:"auth-pools"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		pools = obj.Pools
+	}
+	clean := config.NormalizeAuthPools(pools)
+	for name, selector := range clean {
+		if _, errSelector := coreauth.ParseTagSelector(selector); errSelector != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("pool %s: %v", name, errSelector)})
+			return
+		}
+	}
+	h.cfg.AuthPools = clean
+	h.persist(c)
+}
+
+func (h *Handler) DeleteAuthPool(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(400, gin.H{"error": "missing name"})
+		return
+	}
+	if _, ok := h.cfg.AuthPools[name]; !ok {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+	delete(h.cfg.AuthPools, name)
+	if len(h.cfg.AuthPools) == 0 {
+		h.cfg.AuthPools = nil
+	}
+	h.persist(c)
+}
+
 // gemini-api-key: []GeminiKey
 func (h *Handler) GetGeminiKeys(c *gin.Context) {
 	c.JSON(200, gin.H{"gemini-api-key": h.cfg.GeminiKey})
@@ -210,6 +265,8 @@ func (h *Handler) PatchGeminiKey(c *gin.Context) {
 	type geminiKeyPatch struct {
 		APIKey         *string            `json:"api-key"`
 		Prefix         *string            `json:"prefix"`
+		Tags           *map[string]string `json:"tags"`
+		Schedule       *config.Schedule   `json:"schedule"`
 		BaseURL        *string            `json:"base-url"`
 		ProxyURL       *string            `json:"proxy-url"`
 		Headers        *map[string]string `json:"headers"`
@@ -258,6 +315,17 @@ func (h *Handler) PatchGeminiKey(c *gin.Context) {
 	if body.Value.Prefix != nil {
 		entry.Prefix = strings.TrimSpace(*body.Value.Prefix)
 	}
+	if body.Value.Tags != nil {
+		entry.Tags = *body.Value.Tags
+	}
+	if body.Value.Schedule != nil {
+		schedule := config.NormalizeSchedule(body.Value.Schedule)
+		if _, errSchedule := config.CompileSchedule(schedule); errSchedule != nil {
+			c.JSON(400, gin.H{"error": errSchedule.Error()})
+			return
+		}
+		entry.Schedule = schedule
+	}
 	if body.Value.BaseURL != nil {
 		entry.BaseURL = strings.TrimSpace(*body.Value.BaseURL)
 	}
@@ -336,6 +404,8 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 	type claudeKeyPatch struct {
 		APIKey         *string               `json:"api-key"`
 		Prefix         *string               `json:"prefix"`
+		Tags           *map[string]string    `json:"tags"`
+		Schedule       *config.Schedule      `json:"schedule"`
 		BaseURL        *string               `json:"base-url"`
 		ProxyURL       *string               `json:"proxy-url"`
 		Models         *[]config.ClaudeModel `json:"models"`
@@ -376,6 +446,17 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 	if body.Value.Prefix != nil {
 		entry.Prefix = strings.TrimSpace(*body.Value.Prefix)
 	}
+	if body.Value.Tags != nil {
+		entry.Tags = *body.Value.Tags
+	}
+	if body.Value.Schedule != nil {
+		schedule := config.NormalizeSchedule(body.Value.Schedule)
+		if _, errSchedule := config.CompileSchedule(schedule); errSchedule != nil {
+			c.JSON(400, gin.H{"error": errSchedule.Error()})
+			return
+		}
+		entry.Schedule = schedule
+	}
 	if body.Value.BaseURL != nil {
 		entry.BaseURL = strings.TrimSpace(*body.Value.BaseURL)
 	}
@@ -423,6 +504,164 @@ func (h *Handler) DeleteClaudeKey(c *gin.Context) {
 	c.JSON(400, gin.H{"error": "missing api-key or index"})
 }
 
+// bedrock-api-key: []BedrockKey
+func (h *Handler) GetBedrockKeys(c *gin.Context) {
+	c.JSON(200, gin.H{"bedrock-api-key": h.cfg.BedrockKey})
+}
+func (h *Handler) PutBedrockKeys(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.BedrockKey
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.BedrockKey `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	for i := range arr {
+		normalizeBedrockKey(&arr[i])
+	}
+	h.cfg.BedrockKey = arr
+	h.cfg.SanitizeBedrockKeys()
+	h.persist(c)
+}
+func (h *Handler) PatchBedrockKey(c *gin.Context) {
+	type bedrockKeyPatch struct {
+		Region                  *string                `json:"region"`
+		AccessKeyID             *string                `json:"access-key-id"`
+		SecretAccessKey         *string                `json:"secret-access-key"`
+		SessionToken            *string                `json:"session-token"`
+		Profile                 *string                `json:"profile"`
+		Priority                *int                   `json:"priority"`
+		Prefix                  *string                `json:"prefix"`
+		Tags                    *map[string]string     `json:"tags"`
+		Schedule                *config.Schedule       `json:"schedule"`
+		BaseURL                 *string                `json:"base-url"`
+		ProxyURL                *string                `json:"proxy-url"`
+		DisableInferenceProfile *bool                  `json:"disable-inference-profile"`
+		Models                  *[]config.BedrockModel `json:"models"`
+		Headers                 *map[string]string     `json:"headers"`
+		ExcludedModels          *[]string              `json:"excluded-models"`
+	}
+	var body struct {
+		Index *int             `json:"index"`
+		Match *string          `json:"match"`
+		Value *bedrockKeyPatch `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Value == nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.BedrockKey) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Match != nil {
+		match := strings.TrimSpace(*body.Match)
+		for i := range h.cfg.BedrockKey {
+			if match != "" && h.cfg.BedrockKey[i].GetAPIKey() == match {
+				targetIndex = i
+				break
+			}
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+
+	entry := h.cfg.BedrockKey[targetIndex]
+	if body.Value.Region != nil {
+		entry.Region = strings.TrimSpace(*body.Value.Region)
+	}
+	if body.Value.AccessKeyID != nil {
+		entry.AccessKeyID = strings.TrimSpace(*body.Value.AccessKeyID)
+	}
+	if body.Value.SecretAccessKey != nil {
+		entry.SecretAccessKey = strings.TrimSpace(*body.Value.SecretAccessKey)
+	}
+	if body.Value.SessionToken != nil {
+		entry.SessionToken = strings.TrimSpace(*body.Value.SessionToken)
+	}
+	if body.Value.Profile != nil {
+		entry.Profile = strings.TrimSpace(*body.Value.Profile)
+	}
+	if body.Value.Priority != nil {
+		entry.Priority = *body.Value.Priority
+	}
+	if body.Value.Prefix != nil {
+		entry.Prefix = strings.TrimSpace(*body.Value.Prefix)
+	}
+	if body.Value.Tags != nil {
+		entry.Tags = *body.Value.Tags
+	}
+	if body.Value.Schedule != nil {
+		schedule := config.NormalizeSchedule(body.Value.Schedule)
+		if _, errSchedule := config.CompileSchedule(schedule); errSchedule != nil {
+			c.JSON(400, gin.H{"error": errSchedule.Error()})
+			return
+		}
+		entry.Schedule = schedule
+	}
+	if body.Value.BaseURL != nil {
+		entry.BaseURL = strings.TrimSpace(*body.Value.BaseURL)
+	}
+	if body.Value.ProxyURL != nil {
+		entry.ProxyURL = strings.TrimSpace(*body.Value.ProxyURL)
+	}
+	if body.Value.DisableInferenceProfile != nil {
+		entry.DisableInferenceProfile = *body.Value.DisableInferenceProfile
+	}
+	if body.Value.Models != nil {
+		entry.Models = append([]config.BedrockModel(nil), (*body.Value.Models)...)
+	}
+	if body.Value.Headers != nil {
+		entry.Headers = config.NormalizeHeaders(*body.Value.Headers)
+	}
+	if body.Value.ExcludedModels != nil {
+		entry.ExcludedModels = config.NormalizeExcludedModels(*body.Value.ExcludedModels)
+	}
+	normalizeBedrockKey(&entry)
+	h.cfg.BedrockKey[targetIndex] = entry
+	h.cfg.SanitizeBedrockKeys()
+	h.persist(c)
+}
+
+// DeleteBedrockKey removes an entry by index or by identity: the access key ID,
+// or "profile:<name>" for profile-backed entries.
+func (h *Handler) DeleteBedrockKey(c *gin.Context) {
+	if val := strings.TrimSpace(c.Query("api-key")); val != "" {
+		out := make([]config.BedrockKey, 0, len(h.cfg.BedrockKey))
+		for _, v := range h.cfg.BedrockKey {
+			if v.GetAPIKey() != val {
+				out = append(out, v)
+			}
+		}
+		h.cfg.BedrockKey = out
+		h.cfg.SanitizeBedrockKeys()
+		h.persist(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, err := fmt.Sscanf(idxStr, "%d", &idx)
+		if err == nil && idx >= 0 && idx < len(h.cfg.BedrockKey) {
+			h.cfg.BedrockKey = append(h.cfg.BedrockKey[:idx], h.cfg.BedrockKey[idx+1:]...)
+			h.cfg.SanitizeBedrockKeys()
+			h.persist(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing api-key or index"})
+}
+
 // openai-compatibility: []OpenAICompatibility
 func (h *Handler) GetOpenAICompat(c *gin.Context) {
 	c.JSON(200, gin.H{"openai-compatibility": normalizedOpenAICompatibilityEntries(h.cfg.OpenAICompatibility)})
@@ -459,10 +698,14 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 	type openAICompatPatch struct {
 		Name          *string                             `json:"name"`
 		Prefix        *string                             `json:"prefix"`
+		Tags          *map[string]string                  `json:"tags"`
+		Schedule      *config.Schedule                    `json:"schedule"`
 		BaseURL       *string                             `json:"base-url"`
 		APIKeyEntries *[]config.OpenAICompatibilityAPIKey `json:"api-key-entries"`
 		Models        *[]config.OpenAICompatibilityModel  `json:"models"`
 		Headers       *map[string]string                  `json:"headers"`
+		// Azure replaces the Azure OpenAI settings; an explicit null disables Azure mode.
+		Azure json.RawMessage `json:"azure"`
 	}
 	var body struct {
 		Name  *string            `json:"name"`
@@ -498,6 +741,17 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 	if body.Value.Prefix != nil {
 		entry.Prefix = strings.TrimSpace(*body.Value.Prefix)
 	}
+	if body.Value.Tags != nil {
+		entry.Tags = *body.Value.Tags
+	}
+	if body.Value.Schedule != nil {
+		schedule := config.NormalizeSchedule(body.Value.Schedule)
+		if _, errSchedule := config.CompileSchedule(schedule); errSchedule != nil {
+			c.JSON(400, gin.H{"error": errSchedule.Error()})
+			return
+		}
+		entry.Schedule = schedule
+	}
 	if body.Value.BaseURL != nil {
 		trimmed := strings.TrimSpace(*body.Value.BaseURL)
 		if trimmed == "" {
@@ -517,6 +771,14 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 	if body.Value.Headers != nil {
 		entry.Headers = config.NormalizeHeaders(*body.Value.Headers)
 	}
+	if len(body.Value.Azure) > 0 {
+		var azure *config.AzureOpenAIConfig
+		if err := json.Unmarshal(body.Value.Azure, &azure); err != nil {
+			c.JSON(400, gin.H{"error": "invalid azure"})
+			return
+		}
+		entry.Azure = azure
+	}
 	normalizeOpenAICompatibilityEntry(&entry)
 	h.cfg.OpenAICompatibility[targetIndex] = entry
 	h.cfg.SanitizeOpenAICompatibility()
@@ -581,6 +843,8 @@ func (h *Handler) PatchVertexCompatKey(c *gin.Context) {
 	type vertexCompatPatch struct {
 		APIKey   *string                     `json:"api-key"`
 		Prefix   *string                     `json:"prefix"`
+		Tags     *map[string]string          `json:"tags"`
+		Schedule *config.Schedule            `json:"schedule"`
 		BaseURL  *string                     `json:"base-url"`
 		ProxyURL *string                     `json:"proxy-url"`
 		Headers  *map[string]string          `json:"headers"`
@@ -629,6 +893,17 @@ func (h *Handler) PatchVertexCompatKey(c *gin.Context) {
 	if body.Value.Prefix != nil {
 		entry.Prefix = strings.TrimSpace(*body.Value.Prefix)
 	}
+	if body.Value.Tags != nil {
+		entry.Tags = *body.Value.Tags
+	}
+	if body.Value.Schedule != nil {
+		schedule := config.NormalizeSchedule(body.Value.Schedule)
+		if _, errSchedule := config.CompileSchedule(schedule); errSchedule != nil {
+			c.JSON(400, gin.H{"error": errSchedule.Error()})
+			return
+		}
+		entry.Schedule = schedule
+	}
 	if body.Value.BaseURL != nil {
 		trimmed := strings.TrimSpace(*body.Value.BaseURL)
 		if trimmed == "" {
@@ -862,6 +1137,120 @@ func (h *Handler) DeleteOAuthModelAlias(c *gin.Context) {
 	h.persist(c)
 }
 
+// prompt-profiles: []PromptProfile
+func (h *Handler) GetPromptProfiles(c *gin.Context) {
+	c.JSON(200, gin.H{"prompt-profiles": h.cfg.PromptProfiles})
+}
+func (h *Handler) PutPromptProfiles(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.PromptProfile
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.PromptProfile `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.cfg.PromptProfiles = arr
+	h.cfg.SanitizePromptProfiles()
+	h.persist(c)
+}
+func (h *Handler) PatchPromptProfile(c *gin.Context) {
+	type promptProfilePatch struct {
+		Name      *string            `json:"name"`
+		APIKeys   *[]string          `json:"api-keys"`
+		Models    *[]string          `json:"models"`
+		Label     *string            `json:"label"`
+		System    *string            `json:"system"`
+		Position  *string            `json:"position"`
+		Templates *map[string]string `json:"templates"`
+	}
+	var body struct {
+		Index *int                `json:"index"`
+		Match *string             `json:"match"`
+		Value *promptProfilePatch `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Value == nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.PromptProfiles) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Match != nil {
+		match := strings.TrimSpace(*body.Match)
+		for i := range h.cfg.PromptProfiles {
+			if strings.EqualFold(h.cfg.PromptProfiles[i].Name, match) {
+				targetIndex = i
+				break
+			}
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+
+	entry := h.cfg.PromptProfiles[targetIndex]
+	if body.Value.Name != nil {
+		entry.Name = *body.Value.Name
+	}
+	if body.Value.APIKeys != nil {
+		entry.APIKeys = append([]string(nil), (*body.Value.APIKeys)...)
+	}
+	if body.Value.Models != nil {
+		entry.Models = append([]string(nil), (*body.Value.Models)...)
+	}
+	if body.Value.Label != nil {
+		entry.Label = *body.Value.Label
+	}
+	if body.Value.System != nil {
+		entry.System = *body.Value.System
+	}
+	if body.Value.Position != nil {
+		entry.Position = *body.Value.Position
+	}
+	if body.Value.Templates != nil {
+		entry.Templates = *body.Value.Templates
+	}
+	config.NormalizePromptProfile(&entry)
+	h.cfg.PromptProfiles[targetIndex] = entry
+	h.cfg.SanitizePromptProfiles()
+	h.persist(c)
+}
+
+func (h *Handler) DeletePromptProfile(c *gin.Context) {
+	if val := strings.TrimSpace(c.Query("name")); val != "" {
+		out := make([]config.PromptProfile, 0, len(h.cfg.PromptProfiles))
+		for _, v := range h.cfg.PromptProfiles {
+			if !strings.EqualFold(v.Name, val) {
+				out = append(out, v)
+			}
+		}
+		h.cfg.PromptProfiles = out
+		h.persist(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, err := fmt.Sscanf(idxStr, "%d", &idx)
+		if err == nil && idx >= 0 && idx < len(h.cfg.PromptProfiles) {
+			h.cfg.PromptProfiles = append(h.cfg.PromptProfiles[:idx], h.cfg.PromptProfiles[idx+1:]...)
+			h.persist(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing name or index"})
+}
+
 // codex-api-key: []CodexKey
 func (h *Handler) GetCodexKeys(c *gin.Context) {
 	c.JSON(200, gin.H{"codex-api-key": h.cfg.CodexKey})
@@ -901,6 +1290,8 @@ func (h *Handler) PatchCodexKey(c *gin.Context) {
 	type codexKeyPatch struct {
 		APIKey         *string              `json:"api-key"`
 		Prefix         *string              `json:"prefix"`
+		Tags           *map[string]string   `json:"tags"`
+		Schedule       *config.Schedule     `json:"schedule"`
 		BaseURL        *string              `json:"base-url"`
 		ProxyURL       *string              `json:"proxy-url"`
 		Models         *[]config.CodexModel `json:"models"`
@@ -941,6 +1332,17 @@ func (h *Handler) PatchCodexKey(c *gin.Context) {
 	if body.Value.Prefix != nil {
 		entry.Prefix = strings.TrimSpace(*body.Value.Prefix)
 	}
+	if body.Value.Tags != nil {
+		entry.Tags = *body.Value.Tags
+	}
+	if body.Value.Schedule != nil {
+		schedule := config.NormalizeSchedule(body.Value.Schedule)
+		if _, errSchedule := config.CompileSchedule(schedule); errSchedule != nil {
+			c.JSON(400, gin.H{"error": errSchedule.Error()})
+			return
+		}
+		entry.Schedule = schedule
+	}
 	if body.Value.BaseURL != nil {
 		trimmed := strings.TrimSpace(*body.Value.BaseURL)
 		if trimmed == "" {
@@ -1053,6 +1455,35 @@ func normalizeClaudeKey(entry *config.ClaudeKey) {
 	entry.Models = normalized
 }
 
+func normalizeBedrockKey(entry *config.BedrockKey) {
+	if entry == nil {
+		return
+	}
+	entry.Region = strings.ToLower(strings.TrimSpace(entry.Region))
+	entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+	entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+	entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+	entry.Profile = strings.TrimSpace(entry.Profile)
+	entry.BaseURL = strings.TrimSpace(entry.BaseURL)
+	entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+	entry.Headers = config.NormalizeHeaders(entry.Headers)
+	entry.ExcludedModels = config.NormalizeExcludedModels(entry.ExcludedModels)
+	if len(entry.Models) == 0 {
+		return
+	}
+	normalized := make([]config.BedrockModel, 0, len(entry.Models))
+	for i := range entry.Models {
+		model := entry.Models[i]
+		model.Name = strings.TrimSpace(model.Name)
+		model.Alias = strings.TrimSpace(model.Alias)
+		if model.Name == "" {
+			continue
+		}
+		normalized = append(normalized, model)
+	}
+	entry.Models = normalized
+}
+
 func normalizeCodexKey(entry *config.CodexKey) {
 	if entry == nil {
 		return
@@ -0,0 +1,41 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DrainController is implemented by the API server to drain before shutting down.
+type DrainController interface {
+	RequestDrain()
+	Draining() bool
+	ActiveRequests() int
+}
+
+// SetDrainController wires the server drain used by the /drain endpoints.
+func (h *Handler) SetDrainController(drain DrainController) { h.drain = drain }
+
+// GetDrain reports whether the server is draining and how many requests are still active.
+func (h *Handler) GetDrain(c *gin.Context) {
+	if h.drain == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "drain unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draining": h.drain.Draining(), "active_requests": h.drain.ActiveRequests()})
+}
+
+// PostDrain starts a server-wide drain: new requests get 503 with Retry-After, active
+// requests and streams finish up to drain.timeout, then the server shuts down.
+func (h *Handler) PostDrain(c *gin.Context) {
+	if h.drain == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "drain unavailable"})
+		return
+	}
+	h.drain.RequestDrain()
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":          "draining",
+		"active_requests": h.drain.ActiveRequests(),
+		"timeout":         int(h.cfg.Drain.TimeoutDuration().Seconds()),
+	})
+}
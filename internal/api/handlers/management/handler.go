@@ -47,6 +47,7 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	drain               DrainController
 }
 
 // NewHandler creates a new management handler instance.
@@ -196,7 +197,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				h.attemptsMu.Unlock()
 			}
 		}
-		if secretHash == "" && envSecret == "" {
+		if secretHash == "" && envSecret == "" && !cfg.HasTenantManagementKeys() {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
 			return
 		}
@@ -245,12 +246,16 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			return
 		}
 
+		tenant := ""
 		if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
-			if !localClient {
-				fail()
+			var okTenant bool
+			if tenant, okTenant = cfg.TenantForManagementKey(provided); !okTenant {
+				if !localClient {
+					fail()
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
+				return
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
-			return
 		}
 
 		if !localClient {
@@ -262,6 +267,13 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			h.attemptsMu.Unlock()
 		}
 
+		if tenant != "" {
+			if !tenantRouteAllowed(c) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not available to tenant management keys"})
+				return
+			}
+			c.Set(managementTenantKey, tenant)
+		}
 		c.Next()
 	}
 }
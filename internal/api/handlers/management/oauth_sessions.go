@@ -231,6 +231,8 @@ func NormalizeOAuthProvider(provider string) (string, error) {
 		return "antigravity", nil
 	case "qwen":
 		return "qwen", nil
+	case "copilot", "github-copilot":
+		return "copilot", nil
 	default:
 		return "", errUnsupportedOAuthFlow
 	}
@@ -0,0 +1,115 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+)
+
+type payloadRuleTestRequest struct {
+	// Rules to test; when omitted the configured payload rules are used.
+	Rules *config.PayloadConfig `json:"rules"`
+	// Model is the upstream model name the rules are matched against.
+	Model string `json:"model"`
+	// Protocol is the upstream payload format (e.g. "openai", "claude", "gemini", "codex").
+	Protocol string `json:"protocol"`
+	// Payload is the sample translated request body.
+	Payload json.RawMessage `json:"payload"`
+	// APIKey, SourceFormat, Stream and Headers describe the simulated client request.
+	APIKey       string            `json:"api-key"`
+	SourceFormat string            `json:"source-format"`
+	Stream       bool              `json:"stream"`
+	Headers      map[string]string `json:"headers"`
+	// Tenant selects the tenant whose configured rules are used when Rules is omitted.
+	Tenant string `json:"tenant"`
+}
+
+// TestPayloadRules applies payload rules to a sample payload without sending anything upstream.
+//
+// Endpoint:
+//
+//	POST /v0/management/payload/test
+//
+// The response holds the resulting payload, whether it changed, and the matched rules as
+// "section[index]" labels.
+func (h *Handler) TestPayloadRules(c *gin.Context) {
+	var body payloadRuleTestRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	model := strings.TrimSpace(body.Model)
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+	if len(body.Payload) == 0 || !json.Valid(body.Payload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payload must be a JSON document"})
+		return
+	}
+
+	cfg := &config.Config{}
+	if body.Rules != nil {
+		cfg.Payload = *body.Rules
+		if err := validatePayloadRuleConditions(cfg.Payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cfg.SanitizePayloadRules()
+	} else if h.cfg != nil {
+		cfg.Payload = h.cfg.PayloadRulesFor(strings.TrimSpace(body.Tenant))
+	}
+
+	headers := make(http.Header, len(body.Headers))
+	for name, value := range body.Headers {
+		headers.Set(name, value)
+	}
+	info := executor.PayloadRequestInfo{
+		APIKey:       strings.TrimSpace(body.APIKey),
+		SourceFormat: strings.TrimSpace(body.SourceFormat),
+		Stream:       body.Stream,
+		Headers:      headers,
+	}
+	out, matched := executor.ApplyPayloadRules(cfg, model, strings.TrimSpace(body.Protocol), body.Payload, info)
+	if matched == nil {
+		matched = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"payload": json.RawMessage(out),
+		"changed": string(out) != string(body.Payload),
+		"matched": matched,
+	})
+}
+
+// validatePayloadRuleConditions reports the first rule whose condition expression is invalid.
+func validatePayloadRuleConditions(rules config.PayloadConfig) error {
+	sections := []struct {
+		name  string
+		rules []config.PayloadRule
+	}{
+		{"default", rules.Default},
+		{"default-raw", rules.DefaultRaw},
+		{"override", rules.Override},
+		{"override-raw", rules.OverrideRaw},
+		{"append", rules.Append},
+		{"prepend", rules.Prepend},
+	}
+	for _, section := range sections {
+		for i, rule := range section.rules {
+			if err := config.ValidatePayloadCondition(rule.When); err != nil {
+				return fmt.Errorf("%s[%d]: %w", section.name, i, err)
+			}
+		}
+	}
+	for i, rule := range rules.Filter {
+		if err := config.ValidatePayloadCondition(rule.When); err != nil {
+			return fmt.Errorf("filter[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// GetPricing returns the configured price overrides.
+func (h *Handler) GetPricing(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"pricing": h.cfg.Pricing})
+}
+
+// PutPricing replaces the price configuration. The body is the pricing object, optionally
+// wrapped as {"pricing": {...}}.
+func (h *Handler) PutPricing(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	var obj struct {
+		Pricing *config.PricingConfig `json:"pricing"`
+	}
+	var body config.PricingConfig
+	if err = json.Unmarshal(data, &obj); err == nil && obj.Pricing != nil {
+		body = *obj.Pricing
+	} else if err = json.Unmarshal(data, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	body.File = strings.TrimSpace(body.File)
+	body.Models = config.NormalizeModelPrices(body.Models)
+	if body.File != "" {
+		if _, errFile := pricing.ReadFile(body.File); errFile != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errFile.Error()})
+			return
+		}
+	}
+	h.cfg.Pricing = body
+	h.persist(c)
+}
+
+// GetModelPrice resolves the price applied to a model, e.g.
+// GET /pricing/lookup?model=gpt-5&provider=codex.
+func (h *Handler) GetModelPrice(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing model"})
+		return
+	}
+	provider := strings.TrimSpace(c.Query("provider"))
+	price, ok := pricing.Current().Lookup(provider, model)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no price for model"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"model": model, "provider": provider, "price": price})
+}
+
+// api-key-budgets
+func (h *Handler) GetAPIKeyBudgets(c *gin.Context) {
+	budgets := h.cfg.APIKeyBudgets
+	if budgets == nil {
+		budgets = map[string]config.APIKeyBudget{}
+	}
+	status := make(map[string]usage.BudgetStatus, len(budgets))
+	if h.usageStats != nil {
+		now := time.Now()
+		for key, budget := range budgets {
+			status[key] = h.usageStats.CheckBudget(key, budget, now)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"api-key-budgets": budgets, "status": status})
+}
+
+func (h *Handler) PutAPIKeyBudgets(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	var obj struct {
+		Budgets map[string]config.APIKeyBudget `json:"api-key-budgets"`
+	}
+	var budgets map[string]config.APIKeyBudget
+	if err = json.Unmarshal(data, &obj); err == nil && obj.Budgets != nil {
+		budgets = obj.Budgets
+	} else if err = json.Unmarshal(data, &budgets); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	h.cfg.APIKeyBudgets = config.NormalizeAPIKeyBudgets(budgets)
+	h.persist(c)
+}
+
+func (h *Handler) DeleteAPIKeyBudget(c *gin.Context) {
+	key := strings.TrimSpace(c.Query("key"))
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing key"})
+		return
+	}
+	if _, ok := h.cfg.APIKeyBudgets[key]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		return
+	}
+	delete(h.cfg.APIKeyBudgets, key)
+	if len(h.cfg.APIKeyBudgets) == 0 {
+		h.cfg.APIKeyBudgets = nil
+	}
+	h.persist(c)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -169,6 +170,9 @@ func (h *Handler) DeleteReverseProxy(c *gin.Context) {
 	if h.cfg.ProxyRouting.Qwen == proxyID {
 		h.cfg.ProxyRouting.Qwen = ""
 	}
+	if h.cfg.ProxyRouting.Copilot == proxyID {
+		h.cfg.ProxyRouting.Copilot = ""
+	}
 	if h.cfg.ProxyRouting.IFlow == proxyID {
 		h.cfg.ProxyRouting.IFlow = ""
 	}
@@ -439,9 +443,12 @@ func (h *Handler) sanitizeProxyRoutingAuthLocked() (map[string]string, bool) {
 			changed = true
 			continue
 		}
-		if _, exists := known[trimKey]; !exists {
-			changed = true
-			continue
+		// Pool references do not name a single auth and stay until removed explicitly.
+		if _, isPool := coreauth.PoolNameFromRef(trimKey); !isPool {
+			if _, exists := known[trimKey]; !exists {
+				changed = true
+				continue
+			}
 		}
 		clean[trimKey] = trimValue
 	}
@@ -0,0 +1,64 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// sessionSelector returns the active session selector, or nil when another strategy is in use.
+func (h *Handler) sessionSelector() *coreauth.SessionSelector {
+	if h == nil || h.authManager == nil {
+		return nil
+	}
+	selector, _ := h.authManager.GetSelector().(*coreauth.SessionSelector)
+	return selector
+}
+
+// ListRoutingSessions returns active sticky session bindings, including bindings created by
+// other replicas when session persistence is enabled. Optional provider and auth_id query
+// parameters narrow the result.
+func (h *Handler) ListRoutingSessions(c *gin.Context) {
+	selector := h.sessionSelector()
+	if selector == nil {
+		c.JSON(http.StatusOK, gin.H{"sessions": []coreauth.SessionBindingInfo{}, "total": 0})
+		return
+	}
+	provider := strings.TrimSpace(c.Query("provider"))
+	authID := strings.TrimSpace(c.Query("auth_id"))
+	sessions := make([]coreauth.SessionBindingInfo, 0)
+	for _, info := range selector.Sessions(c.Request.Context()) {
+		if provider != "" && !strings.EqualFold(info.Provider, provider) {
+			continue
+		}
+		if authID != "" && info.AuthID != authID {
+			continue
+		}
+		sessions = append(sessions, info)
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "total": len(sessions)})
+}
+
+// DeleteRoutingSessions evicts sticky session bindings selected by session_id (optionally
+// scoped by provider), by auth_id, or all bindings when all=true.
+func (h *Handler) DeleteRoutingSessions(c *gin.Context) {
+	filter := coreauth.SessionFilter{
+		Provider:  strings.TrimSpace(c.Query("provider")),
+		SessionID: strings.TrimSpace(c.Query("session_id")),
+		AuthID:    strings.TrimSpace(c.Query("auth_id")),
+	}
+	all := strings.EqualFold(strings.TrimSpace(c.Query("all")), "true")
+	if filter.SessionID == "" && filter.AuthID == "" && filter.Provider == "" && !all {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session_id, auth_id, provider or all=true is required"})
+		return
+	}
+	selector := h.sessionSelector()
+	if selector == nil {
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "evicted": 0})
+		return
+	}
+	evicted := selector.EvictSessions(c.Request.Context(), filter)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "evicted": evicted})
+}
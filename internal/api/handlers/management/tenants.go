@@ -0,0 +1,71 @@
+package management
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// managementTenantKey holds the tenant of a request authenticated with a tenant management key.
+const managementTenantKey = "managementTenant"
+
+// tenantRoutes are the management routes open to tenant management keys. Their handlers only
+// touch the tenant's own auth files and usage partition.
+var tenantRoutes = map[string]struct{}{
+	"GET /v0/management/auth-files":            {},
+	"GET /v0/management/auth-files/models":     {},
+	"GET /v0/management/auth-files/download":   {},
+	"GET /v0/management/auth-files/export":     {},
+	"GET /v0/management/auth-files/drain":      {},
+	"POST /v0/management/auth-files":           {},
+	"POST /v0/management/auth-files/import":    {},
+	"DELETE /v0/management/auth-files":         {},
+	"PATCH /v0/management/auth-files/status":   {},
+	"PATCH /v0/management/auth-files/tags":     {},
+	"PATCH /v0/management/auth-files/schedule": {},
+	"GET /v0/management/usage":                 {},
+}
+
+func tenantRouteAllowed(c *gin.Context) bool {
+	_, ok := tenantRoutes[c.Request.Method+" "+c.FullPath()]
+	return ok
+}
+
+// tenantScope returns the tenant a request operates on. Tenant management keys are bound to
+// their tenant; the global key may pick one with ?tenant=. scoped is false for global requests
+// without a tenant, which see every auth but write to the root of the auth directory.
+func (h *Handler) tenantScope(c *gin.Context) (tenant string, scoped bool, err error) {
+	if tenant = c.GetString(managementTenantKey); tenant != "" {
+		return tenant, true, nil
+	}
+	tenant = c.Query("tenant")
+	if tenant == "" {
+		return "", false, nil
+	}
+	if h.cfg.Tenant(tenant) == nil {
+		return "", false, fmt.Errorf("unknown tenant %q", tenant)
+	}
+	return tenant, true, nil
+}
+
+// authDirFor returns the directory holding the auth files of tenant.
+func (h *Handler) authDirFor(tenant string) string {
+	return config.TenantAuthDir(h.cfg.AuthDir, tenant)
+}
+
+// authFileNameFor returns the store file name of an auth file of tenant, relative to the auth
+// directory.
+func authFileNameFor(tenant, name string) string {
+	if tenant == "" {
+		return name
+	}
+	return filepath.Join(config.TenantsDirName, tenant, name)
+}
+
+// authVisible reports whether a request scoped to tenant may see auth.
+func authVisible(auth *coreauth.Auth, tenant string, scoped bool) bool {
+	return !scoped || coreauth.AuthTenant(auth) == tenant
+}
@@ -0,0 +1,108 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestTenantManagementKeyScopesAuthFiles(t *testing.T) {
+	dir := t.TempDir()
+	hash, err := bcrypt.GenerateFromPassword([]byte("admin-key"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{AuthDir: dir, Tenants: []config.TenantConfig{{Name: "team-a", ManagementKey: "team-a-key"}}}
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.SecretKey = string(hash)
+	store := sdkAuth.NewFileTokenStore()
+	store.SetBaseDir(dir)
+	h := &Handler{
+		cfg:            cfg,
+		authManager:    coreauth.NewManager(store, nil, nil),
+		tokenStore:     store,
+		failedAttempts: make(map[string]*attemptInfo),
+	}
+	for _, rel := range []string{"shared.json", "tenants/team-a/mine.json"} {
+		path := filepath.Join(dir, rel)
+		content := []byte(`{"type":"claude","email":"` + filepath.Base(rel) + `"}`)
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err = h.registerAuthFromFile(context.Background(), path, content); err != nil {
+			t.Fatalf("register %s: %v", rel, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	mgmt := r.Group("/v0/management", h.Middleware())
+	mgmt.GET("/auth-files", h.ListAuthFiles)
+	mgmt.POST("/auth-files", h.UploadAuthFile)
+	mgmt.DELETE("/auth-files", h.DeleteAuthFile)
+	mgmt.GET("/config", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+
+	do := func(method, target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	listNames := func(key string) []string {
+		w := do(http.MethodGet, "/v0/management/auth-files", key, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("list status = %d, body %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Files []struct {
+				Name string `json:"name"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(resp.Files))
+		for _, f := range resp.Files {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	if names := listNames("team-a-key"); len(names) != 1 || names[0] != "mine.json" {
+		t.Fatalf("tenant listing = %v, want [mine.json]", names)
+	}
+	if names := listNames("admin-key"); len(names) != 2 {
+		t.Fatalf("admin listing = %v, want both files", names)
+	}
+	if w := do(http.MethodGet, "/v0/management/config", "team-a-key", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("tenant config status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := do(http.MethodDelete, "/v0/management/auth-files?name=shared.json", "team-a-key", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("tenant delete of a global file status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "shared.json")); err != nil {
+		t.Fatalf("global file removed by tenant: %v", err)
+	}
+	if w := do(http.MethodPost, "/v0/management/auth-files?name=new.json", "team-a-key", `{"type":"codex"}`); w.Code != http.StatusOK {
+		t.Fatalf("tenant upload status = %d, body %s", w.Code, w.Body.String())
+	}
+	auth, ok := h.authManager.GetByID(filepath.Join("tenants", "team-a", "new.json"))
+	if !ok || coreauth.AuthTenant(auth) != "team-a" {
+		t.Fatalf("uploaded auth = %+v, want it owned by team-a", auth)
+	}
+}
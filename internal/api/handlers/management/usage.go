@@ -20,11 +20,21 @@ type usageImportPayload struct {
 	Usage   usage.StatisticsSnapshot `json:"usage"`
 }
 
-// GetUsageStatistics returns the in-memory request statistics snapshot.
+// GetUsageStatistics returns the in-memory request statistics snapshot, or the partition of
+// the tenant for tenant-scoped requests.
 func (h *Handler) GetUsageStatistics(c *gin.Context) {
+	tenant, scoped, errTenant := h.tenantScope(c)
+	if errTenant != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTenant.Error()})
+		return
+	}
 	var snapshot usage.StatisticsSnapshot
 	if h != nil && h.usageStats != nil {
-		snapshot = h.usageStats.Snapshot()
+		if scoped {
+			snapshot = h.usageStats.Tenant(tenant).Snapshot()
+		} else {
+			snapshot = h.usageStats.Snapshot()
+		}
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"usage":           snapshot,
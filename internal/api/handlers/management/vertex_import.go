@@ -71,6 +71,9 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 		location = "us-central1"
 	}
 
+	claudeModels := strings.EqualFold(strings.TrimSpace(c.PostForm("claude-models")), "true")
+	claudeLocation := strings.TrimSpace(c.PostForm("claude-location"))
+
 	fileName := fmt.Sprintf("vertex-%s.json", sanitizeVertexFilePart(projectID))
 	label := labelForVertex(projectID, email)
 	storage := &vertex.VertexCredentialStorage{
@@ -78,6 +81,8 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 		ProjectID:      projectID,
 		Email:          email,
 		Location:       location,
+		ClaudeModels:   claudeModels,
+		ClaudeLocation: claudeLocation,
 		Type:           "vertex",
 	}
 	metadata := map[string]any{
@@ -88,6 +93,12 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 		"type":            "vertex",
 		"label":           label,
 	}
+	if claudeModels {
+		metadata["claude_models"] = true
+	}
+	if claudeLocation != "" {
+		metadata["claude_location"] = claudeLocation
+	}
 	record := &coreauth.Auth{
 		ID:       fileName,
 		Provider: "vertex",
@@ -113,6 +124,7 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 		"project_id": projectID,
 		"email":      email,
 		"location":   location,
+		"claude":     claudeModels,
 	})
 }
 
@@ -397,15 +397,35 @@ func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
 }
 
 func (w *ResponseWriterWrapper) extractAPIRequest(c *gin.Context) []byte {
-	apiRequest, isExist := c.Get("API_REQUEST")
+	var data []byte
+	if apiRequest, isExist := c.Get("API_REQUEST"); isExist {
+		data, _ = apiRequest.([]byte)
+	}
+	return appendGuardrailDecisions(c, data)
+}
+
+// appendGuardrailDecisions adds the guardrail decisions recorded by the handlers after the
+// upstream request section.
+func appendGuardrailDecisions(c *gin.Context, apiRequest []byte) []byte {
+	value, isExist := c.Get("API_GUARDRAILS")
 	if !isExist {
-		return nil
+		return apiRequest
 	}
-	data, ok := apiRequest.([]byte)
-	if !ok || len(data) == 0 {
-		return nil
+	decisions, ok := value.([]byte)
+	if !ok || len(decisions) == 0 {
+		return apiRequest
 	}
-	return data
+	combined := make([]byte, 0, len(apiRequest)+len(decisions)+24)
+	combined = append(combined, apiRequest...)
+	if len(combined) > 0 {
+		if combined[len(combined)-1] != '\n' {
+			combined = append(combined, '\n')
+		}
+		combined = append(combined, '\n')
+	}
+	combined = append(combined, "=== GUARDRAILS ===\n"...)
+	combined = append(combined, decisions...)
+	return combined
 }
 
 func (w *ResponseWriterWrapper) extractAPIResponse(c *gin.Context) []byte {
@@ -9,10 +9,12 @@ import (
 	"crypto/subtle"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,12 +30,14 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/ollama"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -156,6 +160,11 @@ type Server struct {
 	// management handler
 	mgmt *managementHandlers.Handler
 
+	// batchHandlers and claudeBatchHandlers run batch jobs in the background and must be
+	// stopped on shutdown.
+	batchHandlers       *openai.OpenAIBatchAPIHandler
+	claudeBatchHandlers *claude.ClaudeBatchAPIHandler
+
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
@@ -174,6 +183,9 @@ type Server struct {
 	keepAliveOnTimeout func()
 	keepAliveHeartbeat chan struct{}
 	keepAliveStop      chan struct{}
+
+	// drain tracks active requests for graceful shutdown.
+	drain *drainState
 }
 
 // NewServer creates and initializes a new API server instance.
@@ -250,7 +262,12 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		currentPath:         wd,
 		envManagementSecret: envManagementSecret,
 		wsRoutes:            make(map[string]struct{}),
+		drain:               newDrainState(),
 	}
+	s.handlers.ClientAPIKeys = s.clientAPIKeys
+	s.handlers.ReplayMiddleware = []gin.HandlerFunc{AuthMiddleware(accessManager), s.budgetMiddleware()}
+	// Registered before any route so every handler is counted while draining.
+	engine.Use(s.drainMiddleware())
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
@@ -259,6 +276,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
 	managementasset.SetCurrentConfig(cfg)
+	linkRotatedAPIKeys(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	misc.SetCodexInstructionsEnabled(cfg.CodexInstructionsEnabled)
 	// Initialize management handler
@@ -268,6 +286,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	logDir := logging.ResolveLogDirectory(cfg)
 	s.mgmt.SetLogDirectory(logDir)
+	s.mgmt.SetDrainController(s)
 	s.localPassword = optionState.localPassword
 
 	// Setup routes
@@ -291,7 +310,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 
 	// Register management routes when configuration or environment secrets are available.
-	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || envManagementSecret
+	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || cfg.HasTenantManagementKeys() || envManagementSecret
 	s.managementRoutesEnabled.Store(hasManagementSecret)
 	if hasManagementSecret {
 		s.registerManagementRoutes()
@@ -319,34 +338,76 @@ func (s *Server) setupRoutes() {
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiImagesHandlers := openai.NewOpenAIImagesAPIHandler(s.handlers)
+	openaiAudioHandlers := openai.NewOpenAIAudioAPIHandler(s.handlers)
+	ollamaHandlers := ollama.NewOllamaAPIHandler(s.handlers)
+	s.batchHandlers = openai.NewOpenAIBatchAPIHandler(s.handlers, openaiHandlers, openaiResponsesHandlers)
+	s.batchHandlers.Start(context.Background())
+	s.claudeBatchHandlers = claude.NewClaudeBatchAPIHandler(s.handlers, claudeCodeHandlers)
+	s.claudeBatchHandlers.Start(context.Background())
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(AuthMiddleware(s.accessManager), s.budgetMiddleware())
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/batches", s.claudeBatchHandlers.CreateBatch)
+		v1.GET("/messages/batches", s.claudeBatchHandlers.ListBatches)
+		v1.GET("/messages/batches/:batch_id", s.claudeBatchHandlers.RetrieveBatch)
+		v1.DELETE("/messages/batches/:batch_id", s.claudeBatchHandlers.DeleteBatch)
+		v1.POST("/messages/batches/:batch_id/cancel", s.claudeBatchHandlers.CancelBatch)
+		v1.GET("/messages/batches/:batch_id/results", s.claudeBatchHandlers.BatchResults)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.POST("/images/generations", openaiImagesHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiImagesHandlers.ImageEdits)
+		v1.POST("/audio/transcriptions", openaiAudioHandlers.Transcriptions)
+		v1.POST("/audio/translations", openaiAudioHandlers.Translations)
+		v1.POST("/audio/speech", openaiAudioHandlers.Speech)
+		v1.POST("/files", s.batchHandlers.UploadFile)
+		v1.GET("/files", s.batchHandlers.ListFiles)
+		v1.GET("/files/:file_id", s.batchHandlers.RetrieveFile)
+		v1.DELETE("/files/:file_id", s.batchHandlers.DeleteFile)
+		v1.GET("/files/:file_id/content", s.batchHandlers.FileContent)
+		v1.POST("/batches", s.batchHandlers.CreateBatch)
+		v1.GET("/batches", s.batchHandlers.ListBatches)
+		v1.GET("/batches/:batch_id", s.batchHandlers.RetrieveBatch)
+		v1.POST("/batches/:batch_id/cancel", s.batchHandlers.CancelBatch)
 	}
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(AuthMiddleware(s.accessManager), s.budgetMiddleware())
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
 		v1beta.GET("/models/*action", geminiHandlers.GeminiGetHandler)
 	}
 
+	// Ollama compatible API routes
+	ollamaAPI := s.engine.Group("/api")
+	ollamaAPI.Use(AuthMiddleware(s.accessManager), s.budgetMiddleware())
+	{
+		ollamaAPI.GET("/version", ollamaHandlers.Version)
+		ollamaAPI.GET("/tags", ollamaHandlers.Tags)
+		ollamaAPI.POST("/show", ollamaHandlers.Show)
+		ollamaAPI.POST("/chat", ollamaHandlers.Chat)
+		ollamaAPI.POST("/generate", ollamaHandlers.Generate)
+	}
+
 	// Client-scoped endpoints (API-key auth; no management key required).
 	v0client := s.engine.Group("/v0/client")
 	v0client.Use(AuthMiddleware(s.accessManager))
 	{
 		v0client.GET("/usage/auth-files", s.GetClientAuthFileUsage)
+		v0client.GET("/usage", s.mgmt.GetClientUsage)
+		v0client.GET("/requests", s.mgmt.GetClientRequests)
+		v0client.GET("/models", s.mgmt.GetClientModels)
+		v0client.POST("/key/rotate", s.mgmt.RotateClientKey)
 	}
 
 	// Root endpoint
@@ -475,6 +536,31 @@ func (s *Server) AttachWebsocketRoute(path string, handler http.Handler) {
 	s.engine.GET(trimmed, conditionalAuth, finalHandler)
 }
 
+// AttachExternalExecutorRoute registers the websocket endpoint external executor workers
+// connect to. Workers authenticate with their own secret keys, so client API key
+// authentication is not applied.
+func (s *Server) AttachExternalExecutorRoute(path string, handler http.Handler) {
+	if s == nil || s.engine == nil || handler == nil {
+		return
+	}
+	trimmed := strings.TrimSpace(path)
+	if !strings.HasPrefix(trimmed, "/") {
+		trimmed = "/" + trimmed
+	}
+	s.wsRouteMu.Lock()
+	if _, exists := s.wsRoutes[trimmed]; exists {
+		s.wsRouteMu.Unlock()
+		return
+	}
+	s.wsRoutes[trimmed] = struct{}{}
+	s.wsRouteMu.Unlock()
+
+	s.engine.GET(trimmed, func(c *gin.Context) {
+		handler.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	})
+}
+
 func (s *Server) registerManagementRoutes() {
 	if s == nil || s.engine == nil || s.mgmt == nil {
 		return
@@ -544,6 +630,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/proxy-routing-auth", s.mgmt.UpdateProxyRoutingAuth)
 
 		mgmt.POST("/api-call", s.mgmt.APICall)
+		mgmt.POST("/payload/test", s.mgmt.TestPayloadRules)
 
 		mgmt.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
 		mgmt.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
@@ -564,6 +651,21 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/api-key-expiry", s.mgmt.PutAPIKeyExpiry)
 		mgmt.PATCH("/api-key-expiry", s.mgmt.PutAPIKeyExpiry)
 
+		mgmt.GET("/api-key-budgets", s.mgmt.GetAPIKeyBudgets)
+		mgmt.PUT("/api-key-budgets", s.mgmt.PutAPIKeyBudgets)
+		mgmt.PATCH("/api-key-budgets", s.mgmt.PutAPIKeyBudgets)
+		mgmt.DELETE("/api-key-budgets", s.mgmt.DeleteAPIKeyBudget)
+
+		mgmt.GET("/pricing", s.mgmt.GetPricing)
+		mgmt.PUT("/pricing", s.mgmt.PutPricing)
+		mgmt.PATCH("/pricing", s.mgmt.PutPricing)
+		mgmt.GET("/pricing/lookup", s.mgmt.GetModelPrice)
+
+		mgmt.GET("/auth-pools", s.mgmt.GetAuthPools)
+		mgmt.PUT("/auth-pools", s.mgmt.PutAuthPools)
+		mgmt.PATCH("/auth-pools", s.mgmt.PutAuthPools)
+		mgmt.DELETE("/auth-pools", s.mgmt.DeleteAuthPool)
+
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
 		mgmt.PATCH("/gemini-api-key", s.mgmt.PatchGeminiKey)
@@ -623,12 +725,19 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/routing/session", s.mgmt.GetRoutingSession)
 		mgmt.PUT("/routing/session", s.mgmt.PutRoutingSession)
 		mgmt.PATCH("/routing/session", s.mgmt.PutRoutingSession)
+		mgmt.GET("/routing/sessions", s.mgmt.ListRoutingSessions)
+		mgmt.DELETE("/routing/sessions", s.mgmt.DeleteRoutingSessions)
 
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
 		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
 		mgmt.PATCH("/claude-api-key", s.mgmt.PatchClaudeKey)
 		mgmt.DELETE("/claude-api-key", s.mgmt.DeleteClaudeKey)
 
+		mgmt.GET("/bedrock-api-key", s.mgmt.GetBedrockKeys)
+		mgmt.PUT("/bedrock-api-key", s.mgmt.PutBedrockKeys)
+		mgmt.PATCH("/bedrock-api-key", s.mgmt.PatchBedrockKey)
+		mgmt.DELETE("/bedrock-api-key", s.mgmt.DeleteBedrockKey)
+
 		mgmt.GET("/codex-api-key", s.mgmt.GetCodexKeys)
 		mgmt.PUT("/codex-api-key", s.mgmt.PutCodexKeys)
 		mgmt.PATCH("/codex-api-key", s.mgmt.PatchCodexKey)
@@ -654,13 +763,22 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/oauth-model-alias", s.mgmt.PatchOAuthModelAlias)
 		mgmt.DELETE("/oauth-model-alias", s.mgmt.DeleteOAuthModelAlias)
 
+		mgmt.GET("/prompt-profiles", s.mgmt.GetPromptProfiles)
+		mgmt.PUT("/prompt-profiles", s.mgmt.PutPromptProfiles)
+		mgmt.PATCH("/prompt-profiles", s.mgmt.PatchPromptProfile)
+		mgmt.DELETE("/prompt-profiles", s.mgmt.DeletePromptProfile)
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/model-definitions/:channel", s.mgmt.GetStaticModelDefinitions)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
+		mgmt.GET("/auth-files/export", s.mgmt.ExportAuthFiles)
+		mgmt.POST("/auth-files/import", s.mgmt.ImportAuthFiles)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
+		mgmt.GET("/auth-files/drain", s.mgmt.GetAuthFileDrain)
+		mgmt.PATCH("/auth-files/tags", s.mgmt.PatchAuthFileTags)
+		mgmt.PATCH("/auth-files/schedule", s.mgmt.PatchAuthFileSchedule)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
@@ -668,10 +786,72 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/gemini-cli-auth-url", s.mgmt.RequestGeminiCLIToken)
 		mgmt.GET("/antigravity-auth-url", s.mgmt.RequestAntigravityToken)
 		mgmt.GET("/qwen-auth-url", s.mgmt.RequestQwenToken)
+		mgmt.GET("/copilot-auth-url", s.mgmt.RequestCopilotToken)
 		mgmt.GET("/iflow-auth-url", s.mgmt.RequestIFlowToken)
 		mgmt.POST("/iflow-auth-url", s.mgmt.RequestIFlowCookieToken)
 		mgmt.POST("/oauth-callback", s.mgmt.PostOAuthCallback)
 		mgmt.GET("/get-auth-status", s.mgmt.GetAuthStatus)
+
+		mgmt.GET("/drain", s.mgmt.GetDrain)
+		mgmt.POST("/drain", s.mgmt.PostDrain)
+	}
+}
+
+// clientAPIKeys returns the configured client API keys, top-level and per tenant.
+func (s *Server) clientAPIKeys() []string {
+	cfg := s.cfg
+	if cfg == nil {
+		return nil
+	}
+	keys := append([]string(nil), cfg.APIKeys...)
+	for key := range cfg.TenantAPIKeys() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// linkRotatedAPIKeys links every key of api-key-rotations to its replacement, so usage views
+// and budgets keep counting both across restarts.
+func linkRotatedAPIKeys(cfg *config.Config) {
+	stats := usage.GetRequestStatistics()
+	for oldKey, newKey := range cfg.APIKeyRotations {
+		stats.LinkAPIKeys(oldKey, newKey)
+	}
+}
+
+// budgetMiddleware rejects requests from client API keys that have used up their
+// api-key-budgets allowance. Read-only requests such as model listings are always allowed.
+func (s *Server) budgetMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := s.cfg
+		if cfg == nil || len(cfg.APIKeyBudgets) == 0 || c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+		apiKey, _ := c.Get("apiKey")
+		clientKey, _ := apiKey.(string)
+		budget, ok := cfg.APIKeyBudgets[clientKey]
+		if !ok {
+			c.Next()
+			return
+		}
+		now := time.Now()
+		status := usage.GetRequestStatistics().CheckBudget(clientKey, budget, now)
+		if status.Exceeded {
+			if !status.PeriodEnd.IsZero() {
+				retryAfter := int(math.Ceil(status.PeriodEnd.Sub(now).Seconds()))
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+			}
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: "API key budget exceeded",
+					Type:    "rate_limit_error",
+					Code:    "budget_exceeded",
+				},
+			})
+			return
+		}
+		c.Next()
 	}
 }
 
@@ -864,6 +1044,18 @@ func (s *Server) Stop(ctx context.Context) error {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
+	// Checkpoint running batches so they resume after restart.
+	if s.batchHandlers != nil {
+		if err := s.batchHandlers.Stop(ctx); err != nil {
+			log.Warnf("failed to stop batch jobs: %v", err)
+		}
+	}
+	if s.claudeBatchHandlers != nil {
+		if err := s.claudeBatchHandlers.Stop(ctx); err != nil {
+			log.Warnf("failed to stop message batch jobs: %v", err)
+		}
+	}
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -933,6 +1125,14 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
 
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.Pricing, cfg.Pricing) {
+		pricing.Configure(cfg.Pricing)
+	}
+
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.APIKeyRotations, cfg.APIKeyRotations) {
+		linkRotatedAPIKeys(cfg)
+	}
+
 	if s.requestLogger != nil && (oldCfg == nil || oldCfg.ErrorLogsMaxFiles != cfg.ErrorLogsMaxFiles) {
 		if setter, ok := s.requestLogger.(interface{ SetErrorLogsMaxFiles(int) }); ok {
 			setter.SetErrorLogsMaxFiles(cfg.ErrorLogsMaxFiles)
@@ -958,9 +1158,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	prevSecretEmpty := true
 	if oldCfg != nil {
-		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == ""
+		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == "" && !oldCfg.HasTenantManagementKeys()
 	}
-	newSecretEmpty := cfg.RemoteManagement.SecretKey == ""
+	newSecretEmpty := cfg.RemoteManagement.SecretKey == "" && !cfg.HasTenantManagementKeys()
 	if s.envManagementSecret {
 		s.registerManagementRoutes()
 		if s.managementRoutesEnabled.CompareAndSwap(false, true) {
@@ -1077,6 +1277,9 @@ func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 				if len(result.Metadata) > 0 {
 					c.Set("accessMetadata", result.Metadata)
 				}
+				if tenant := result.Metadata["tenant"]; tenant != "" {
+					c.Set("tenant", tenant)
+				}
 			}
 			c.Next()
 			return
@@ -1190,18 +1393,18 @@ func (s *Server) GetClientAuthFileUsage(c *gin.Context) {
 	// Get auth files from auth manager
 	if s.authManager != nil {
 		allAuths := s.authManager.List()
-		allowedSet := make(map[string]struct{})
-		for _, a := range allowedAuths {
-			allowedSet[a] = struct{}{}
-		}
+		// Resolve references (including auth pools) through the manager.
+		allowedSet, _ := s.authManager.AllowedAuthIDsForClientKey(clientKey)
 
+		tenant := c.GetString("tenant")
 		for _, a := range allAuths {
+			// Clients only see the auths of their own tenant.
+			if auth.AuthTenant(a) != tenant {
+				continue
+			}
 			// If restricted, only include allowed auths
 			if restricted {
-				_, byID := allowedSet[a.ID]
-				_, byIndex := allowedSet[a.Index]
-				_, byFile := allowedSet[a.FileName]
-				if !byID && !byIndex && !byFile {
+				if _, ok := allowedSet[a.ID]; !ok {
 					continue
 				}
 			}
@@ -1,16 +1,21 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	gin "github.com/gin-gonic/gin"
+	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
 	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
@@ -109,3 +114,48 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestReplayedRequestsAreAuthenticatedAndBudgeted(t *testing.T) {
+	configaccess.Register()
+	server := newTestServer(t)
+	calls := 0
+	endpoint := func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"api_key": c.GetString("apiKey")})
+	}
+	var header http.Header
+	replay := func(apiKey string) (int, string) {
+		status, h, body := handlers.ReplayRequest(context.Background(), server.handlers.ReplayMiddleware, endpoint, apiKey, "", http.MethodPost, "/v1/chat/completions", []byte(`{}`))
+		header = h
+		return status, string(body)
+	}
+
+	if status, body := replay("test-key"); status != http.StatusOK || !strings.Contains(body, "test-key") {
+		t.Fatalf("replay as configured key: status %d, body %s", status, body)
+	}
+	if status, _ := replay("unknown-key"); status != http.StatusUnauthorized {
+		t.Fatalf("replay as unknown key: status %d, want 401", status)
+	}
+	server.cfg.APIKeyBudgets = map[string]proxyconfig.APIKeyBudget{"test-key": {Limit: 0}}
+	if status, body := replay("test-key"); status != http.StatusTooManyRequests || !strings.Contains(body, `"code":"budget_exceeded"`) {
+		t.Fatalf("replay over budget: status %d, body %s", status, body)
+	}
+	if header.Get("Retry-After") == "" {
+		t.Fatal("replay over budget: missing Retry-After")
+	}
+	if calls != 1 {
+		t.Fatalf("endpoint calls = %d, want 1", calls)
+	}
+}
+
+func TestConfiguredKeyRotationsLinkUsage(t *testing.T) {
+	server := newTestServer(t)
+	cfg := *server.cfg
+	cfg.APIKeyRotations = map[string]string{"rotated-old-key": "rotated-new-key"}
+	server.UpdateClients(&cfg)
+
+	linked := usage.GetRequestStatistics().LinkedAPIKeys("rotated-new-key")
+	if !slices.Contains(linked, "rotated-old-key") {
+		t.Fatalf("linked keys = %v", linked)
+	}
+}
@@ -0,0 +1,331 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+const (
+	// GitHubDeviceCodeEndpoint is the URL for initiating the GitHub device authorization flow.
+	GitHubDeviceCodeEndpoint = "https://github.com/login/device/code"
+	// GitHubAccessTokenEndpoint is the URL polled for the GitHub OAuth access token.
+	GitHubAccessTokenEndpoint = "https://github.com/login/oauth/access_token"
+	// CopilotTokenEndpoint exchanges a GitHub OAuth token for a Copilot API token.
+	CopilotTokenEndpoint = "https://api.github.com/copilot_internal/v2/token"
+	// GitHubUserEndpoint returns the profile of the authenticated GitHub user.
+	GitHubUserEndpoint = "https://api.github.com/user"
+	// DefaultAPIEndpoint is the Copilot API base URL used when the token response names none.
+	DefaultAPIEndpoint = "https://api.githubcopilot.com"
+	// CopilotClientID is the OAuth client identifier of the Copilot editor integration.
+	CopilotClientID = "Iv1.b507a08c87ecfe98"
+	// CopilotOAuthScope defines the GitHub permissions requested by the application.
+	CopilotOAuthScope = "read:user"
+	// CopilotGrantType specifies the grant type for the device code flow.
+	CopilotGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// EditorVersion is the editor identification Copilot requires on every request.
+	EditorVersion = "vscode/1.99.3"
+	// EditorPluginVersion is the plugin identification Copilot requires on every request.
+	EditorPluginVersion = "copilot-chat/0.26.7"
+	// UserAgent is the user agent of the Copilot chat plugin.
+	UserAgent = "GitHubCopilotChat/0.26.7"
+	// IntegrationID identifies the Copilot integration issuing chat requests.
+	IntegrationID = "vscode-chat"
+	// APIVersion is the GitHub API version sent with Copilot requests.
+	APIVersion = "2025-04-01"
+)
+
+// Endpoints holds the URLs used by CopilotAuth. Zero fields use the public GitHub endpoints.
+type Endpoints struct {
+	DeviceCode   string
+	AccessToken  string
+	CopilotToken string
+	User         string
+}
+
+// DeviceFlow represents the response from the GitHub device authorization endpoint.
+type DeviceFlow struct {
+	// DeviceCode is the code that the client uses to poll for an access token.
+	DeviceCode string `json:"device_code"`
+	// UserCode is the code that the user enters at the verification URI.
+	UserCode string `json:"user_code"`
+	// VerificationURI is the URL where the user enters the user code.
+	VerificationURI string `json:"verification_uri"`
+	// ExpiresIn is the time in seconds until the device_code and user_code expire.
+	ExpiresIn int `json:"expires_in"`
+	// Interval is the minimum time in seconds that the client should wait between polling requests.
+	Interval int `json:"interval"`
+}
+
+// CopilotTokenData is a Copilot API token obtained from a GitHub OAuth token.
+type CopilotTokenData struct {
+	// Token is the Copilot API bearer token.
+	Token string
+	// APIEndpoint is the Copilot API base URL assigned to the account.
+	APIEndpoint string
+	// Expire is the RFC 3339 expiry of Token.
+	Expire string
+	// RefreshIn is the number of seconds after which Copilot suggests minting a new token.
+	RefreshIn int
+}
+
+// copilotTokenResponse is the payload returned by the Copilot token endpoint.
+type copilotTokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+	RefreshIn int    `json:"refresh_in"`
+	Endpoints struct {
+		API string `json:"api"`
+	} `json:"endpoints"`
+}
+
+// CopilotAuth manages the GitHub device flow and Copilot token exchange.
+type CopilotAuth struct {
+	httpClient *http.Client
+	endpoints  Endpoints
+}
+
+// NewCopilotAuth creates a new CopilotAuth instance with a proxy-configured HTTP client.
+func NewCopilotAuth(cfg *config.Config) *CopilotAuth {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if cfg != nil {
+		client = util.SetProxy(&cfg.SDKConfig, client)
+	}
+	return NewCopilotAuthWithEndpoints(client, Endpoints{})
+}
+
+// NewCopilotAuthWithEndpoints creates a CopilotAuth that talks to the given endpoints, which
+// lets tests and GitHub Enterprise deployments substitute their own servers.
+func NewCopilotAuthWithEndpoints(client *http.Client, endpoints Endpoints) *CopilotAuth {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if endpoints.DeviceCode == "" {
+		endpoints.DeviceCode = GitHubDeviceCodeEndpoint
+	}
+	if endpoints.AccessToken == "" {
+		endpoints.AccessToken = GitHubAccessTokenEndpoint
+	}
+	if endpoints.CopilotToken == "" {
+		endpoints.CopilotToken = CopilotTokenEndpoint
+	}
+	if endpoints.User == "" {
+		endpoints.User = GitHubUserEndpoint
+	}
+	return &CopilotAuth{httpClient: client, endpoints: endpoints}
+}
+
+// InitiateDeviceFlow starts the GitHub device authorization flow.
+func (ca *CopilotAuth) InitiateDeviceFlow(ctx context.Context) (*DeviceFlow, error) {
+	data := url.Values{}
+	data.Set("client_id", CopilotClientID)
+	data.Set("scope", CopilotOAuthScope)
+
+	body, status, err := ca.postForm(ctx, ca.endpoints.DeviceCode, data)
+	if err != nil {
+		return nil, fmt.Errorf("device authorization request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("device authorization failed: %d. Response: %s", status, string(body))
+	}
+
+	var result DeviceFlow
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse device flow response: %w", err)
+	}
+	if result.DeviceCode == "" {
+		return nil, fmt.Errorf("device authorization failed: device_code not found in response")
+	}
+	if result.Interval <= 0 {
+		result.Interval = 5
+	}
+	if result.ExpiresIn <= 0 {
+		result.ExpiresIn = 900
+	}
+	return &result, nil
+}
+
+// PollForToken polls GitHub until the user approves the device flow and returns the GitHub
+// OAuth access token.
+func (ca *CopilotAuth) PollForToken(ctx context.Context, flow *DeviceFlow) (string, error) {
+	if flow == nil {
+		return "", fmt.Errorf("device flow is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	interval := time.Duration(flow.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(flow.ExpiresIn) * time.Second)
+
+	data := url.Values{}
+	data.Set("client_id", CopilotClientID)
+	data.Set("device_code", flow.DeviceCode)
+	data.Set("grant_type", CopilotGrantType)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		body, _, err := ca.postForm(ctx, ca.endpoints.AccessToken, data)
+		if err != nil {
+			continue
+		}
+		// GitHub reports pending and failed polls with status 200 and an "error" field.
+		var result struct {
+			AccessToken      string `json:"access_token"`
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+			Interval         int    `json:"interval"`
+		}
+		if err = json.Unmarshal(body, &result); err != nil {
+			return "", fmt.Errorf("failed to parse token response: %w", err)
+		}
+		switch result.Error {
+		case "":
+			if result.AccessToken == "" {
+				return "", fmt.Errorf("device token poll failed: access_token not found in response")
+			}
+			return result.AccessToken, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			if result.Interval > 0 {
+				interval = time.Duration(result.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+			continue
+		case "expired_token":
+			return "", fmt.Errorf("device code expired. Please restart the authentication process")
+		case "access_denied":
+			return "", fmt.Errorf("authorization denied by user. Please restart the authentication process")
+		default:
+			return "", fmt.Errorf("device token poll failed: %s - %s", result.Error, result.ErrorDescription)
+		}
+	}
+	return "", fmt.Errorf("authentication timeout. Please restart the authentication process")
+}
+
+// ExchangeToken mints a Copilot API token from a GitHub OAuth token.
+func (ca *CopilotAuth) ExchangeToken(ctx context.Context, githubToken string) (*CopilotTokenData, error) {
+	githubToken = strings.TrimSpace(githubToken)
+	if githubToken == "" {
+		return nil, fmt.Errorf("copilot token exchange: github token is empty")
+	}
+	body, status, err := ca.getJSON(ctx, ca.endpoints.CopilotToken, githubToken)
+	if err != nil {
+		return nil, fmt.Errorf("copilot token exchange request failed: %w", err)
+	}
+	switch {
+	case status == http.StatusUnauthorized:
+		return nil, fmt.Errorf("copilot token exchange failed: github token is invalid or revoked")
+	case status == http.StatusForbidden || status == http.StatusNotFound:
+		return nil, fmt.Errorf("copilot token exchange failed: account has no active Copilot subscription (%d)", status)
+	case status != http.StatusOK:
+		return nil, fmt.Errorf("copilot token exchange failed: %d. Response: %s", status, string(body))
+	}
+
+	var result copilotTokenResponse
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse copilot token response: %w", err)
+	}
+	if result.Token == "" {
+		return nil, fmt.Errorf("copilot token exchange failed: token not found in response")
+	}
+	expire := time.Now().Add(25 * time.Minute)
+	if result.ExpiresAt > 0 {
+		expire = time.Unix(result.ExpiresAt, 0)
+	}
+	apiEndpoint := strings.TrimSuffix(strings.TrimSpace(result.Endpoints.API), "/")
+	if apiEndpoint == "" {
+		apiEndpoint = DefaultAPIEndpoint
+	}
+	return &CopilotTokenData{
+		Token:       result.Token,
+		APIEndpoint: apiEndpoint,
+		Expire:      expire.Format(time.RFC3339),
+		RefreshIn:   result.RefreshIn,
+	}, nil
+}
+
+// FetchUser returns the login and public email of the GitHub user owning githubToken.
+func (ca *CopilotAuth) FetchUser(ctx context.Context, githubToken string) (login, email string, err error) {
+	body, status, err := ca.getJSON(ctx, ca.endpoints.User, githubToken)
+	if err != nil {
+		return "", "", fmt.Errorf("github user request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", "", fmt.Errorf("github user request failed: %d. Response: %s", status, string(body))
+	}
+	var user struct {
+		Login string `json:"login"`
+		Email string `json:"email"`
+	}
+	if err = json.Unmarshal(body, &user); err != nil {
+		return "", "", fmt.Errorf("failed to parse github user response: %w", err)
+	}
+	return user.Login, user.Email, nil
+}
+
+// CreateTokenStorage builds a CopilotTokenStorage from a GitHub token and its Copilot token.
+func (ca *CopilotAuth) CreateTokenStorage(githubToken string, tokenData *CopilotTokenData) *CopilotTokenStorage {
+	storage := &CopilotTokenStorage{GitHubToken: githubToken}
+	if tokenData != nil {
+		storage.AccessToken = tokenData.Token
+		storage.APIEndpoint = tokenData.APIEndpoint
+		storage.Expire = tokenData.Expire
+	}
+	storage.LastRefresh = time.Now().Format(time.RFC3339)
+	return storage
+}
+
+func (ca *CopilotAuth) postForm(ctx context.Context, endpoint string, data url.Values) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	return ca.do(req)
+}
+
+func (ca *CopilotAuth) getJSON(ctx context.Context, endpoint, githubToken string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "token "+githubToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Editor-Version", EditorVersion)
+	req.Header.Set("Editor-Plugin-Version", EditorPluginVersion)
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("X-Github-Api-Version", APIVersion)
+	return ca.do(req)
+}
+
+func (ca *CopilotAuth) do(req *http.Request) ([]byte, int, error) {
+	resp, err := ca.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
@@ -0,0 +1,113 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, pendingPolls int) *httptest.Server {
+	t.Helper()
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_id") != CopilotClientID {
+			t.Errorf("client_id = %q", r.FormValue("client_id"))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"device_code":      "dev-code",
+			"user_code":        "ABCD-1234",
+			"verification_uri": "https://github.com/login/device",
+			"expires_in":       60,
+			"interval":         1,
+		})
+	})
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("device_code") != "dev-code" || r.FormValue("grant_type") != CopilotGrantType {
+			t.Errorf("unexpected poll form: %v", r.Form)
+		}
+		polls++
+		if polls <= pendingPolls {
+			_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"gho_test","token_type":"bearer"}`))
+	})
+	mux.HandleFunc("/copilot_internal/v2/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token gho_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token":      "copilot-token",
+			"expires_at": time.Now().Add(30 * time.Minute).Unix(),
+			"refresh_in": 1500,
+			"endpoints":  map[string]string{"api": "https://api.individual.githubcopilot.com/"},
+		})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"login":"octocat","email":"octocat@example.com"}`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestAuth(server *httptest.Server) *CopilotAuth {
+	return NewCopilotAuthWithEndpoints(server.Client(), Endpoints{
+		DeviceCode:   server.URL + "/login/device/code",
+		AccessToken:  server.URL + "/login/oauth/access_token",
+		CopilotToken: server.URL + "/copilot_internal/v2/token",
+		User:         server.URL + "/user",
+	})
+}
+
+func TestDeviceFlowAndTokenExchange(t *testing.T) {
+	server := newTestServer(t, 1)
+	defer server.Close()
+	svc := newTestAuth(server)
+	ctx := context.Background()
+
+	flow, err := svc.InitiateDeviceFlow(ctx)
+	if err != nil {
+		t.Fatalf("InitiateDeviceFlow() error = %v", err)
+	}
+	if flow.UserCode != "ABCD-1234" || flow.Interval != 1 {
+		t.Fatalf("unexpected device flow: %+v", flow)
+	}
+
+	githubToken, err := svc.PollForToken(ctx, flow)
+	if err != nil {
+		t.Fatalf("PollForToken() error = %v", err)
+	}
+	if githubToken != "gho_test" {
+		t.Fatalf("github token = %q", githubToken)
+	}
+
+	token, err := svc.ExchangeToken(ctx, githubToken)
+	if err != nil {
+		t.Fatalf("ExchangeToken() error = %v", err)
+	}
+	if token.Token != "copilot-token" || token.APIEndpoint != "https://api.individual.githubcopilot.com" {
+		t.Fatalf("unexpected token data: %+v", token)
+	}
+	expire, err := time.Parse(time.RFC3339, token.Expire)
+	if err != nil || time.Until(expire) < 25*time.Minute {
+		t.Fatalf("unexpected expiry %q (%v)", token.Expire, err)
+	}
+
+	login, email, err := svc.FetchUser(ctx, githubToken)
+	if err != nil || login != "octocat" || email != "octocat@example.com" {
+		t.Fatalf("FetchUser() = %q, %q, %v", login, email, err)
+	}
+}
+
+func TestExchangeTokenRejectsInvalidGitHubToken(t *testing.T) {
+	server := newTestServer(t, 0)
+	defer server.Close()
+
+	if _, err := newTestAuth(server).ExchangeToken(context.Background(), "gho_revoked"); err == nil {
+		t.Fatal("expected error for revoked github token")
+	}
+}
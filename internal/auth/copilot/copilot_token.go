@@ -0,0 +1,65 @@
+// Package copilot provides authentication and token management functionality
+// for GitHub Copilot. It handles the GitHub device flow, the exchange of GitHub
+// tokens for short-lived Copilot API tokens, and the persistence of both.
+package copilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+)
+
+// CopilotTokenStorage stores the GitHub OAuth token and the Copilot API token derived from it.
+// The GitHub token is long-lived and is used to mint new Copilot tokens, which expire after
+// roughly thirty minutes.
+type CopilotTokenStorage struct {
+	// GitHubToken is the GitHub OAuth access token obtained through the device flow.
+	GitHubToken string `json:"github_token"`
+	// AccessToken is the short-lived Copilot API token used for chat requests.
+	AccessToken string `json:"access_token"`
+	// APIEndpoint is the Copilot API base URL assigned to the account.
+	APIEndpoint string `json:"api_endpoint"`
+	// Login is the GitHub user name associated with this token.
+	Login string `json:"login"`
+	// Email is the GitHub account email address, when visible.
+	Email string `json:"email,omitempty"`
+	// LastRefresh is the timestamp of the last Copilot token exchange.
+	LastRefresh string `json:"last_refresh"`
+	// Type indicates the authentication provider type, always "copilot" for this storage.
+	Type string `json:"type"`
+	// Expire is the timestamp when the current Copilot token expires.
+	Expire string `json:"expired"`
+}
+
+// SaveTokenToFile serializes the Copilot token storage to a JSON file.
+// This method creates the necessary directory structure and writes the token
+// data in JSON format to the specified file path for persistent storage.
+//
+// Parameters:
+//   - authFilePath: The full path where the token file should be saved
+//
+// Returns:
+//   - error: An error if the operation fails, nil otherwise
+func (ts *CopilotTokenStorage) SaveTokenToFile(authFilePath string) error {
+	misc.LogSavingCredentials(authFilePath)
+	ts.Type = "copilot"
+	if err := os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	f, err := os.Create(authFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to create token file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if err = json.NewEncoder(f).Encode(ts); err != nil {
+		return fmt.Errorf("failed to write token to file: %w", err)
+	}
+	return nil
+}
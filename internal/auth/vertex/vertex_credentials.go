@@ -28,6 +28,13 @@ type VertexCredentialStorage struct {
 	// Location optionally sets a default region (e.g., us-central1) for Vertex endpoints.
 	Location string `json:"location,omitempty"`
 
+	// ClaudeModels registers Anthropic Claude models for this credential, served through
+	// the Vertex publishers/anthropic endpoints. The project must have them enabled.
+	ClaudeModels bool `json:"claude_models,omitempty"`
+
+	// ClaudeLocation optionally overrides Location for Claude requests (e.g., us-east5 or global).
+	ClaudeLocation string `json:"claude_location,omitempty"`
+
 	// Type is the provider identifier stored alongside credentials. Always "vertex".
 	Type string `json:"type"`
 }
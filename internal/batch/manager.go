@@ -0,0 +1,539 @@
+package batch
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotFound is returned when a file or batch does not exist or belongs to another client.
+var ErrNotFound = errors.New("not found")
+
+// RequestError reports an invalid client request (HTTP 400).
+type RequestError struct {
+	Message string
+	Param   string
+}
+
+func (e *RequestError) Error() string { return e.Message }
+
+// Executor runs one batch request on behalf of the client API key apiKey, which belongs to
+// tenant ("" for none), and returns the endpoint response. It must honour ctx cancellation.
+type Executor func(ctx context.Context, apiKey, tenant string, req Request) Result
+
+// Availability reports how many credentials of tenant can serve model right now and, when
+// none can, the earliest time one of them recovers from cooldown.
+type Availability func(tenant, model string) (int, time.Time)
+
+// Options configures a Manager.
+type Options struct {
+	// Store persists files and job state; nil keeps everything in memory.
+	Store coreauth.StateStore
+	// Execute runs a single request line.
+	Execute Executor
+	// Endpoints lists the request URLs that batches may target (e.g. /v1/chat/completions).
+	Endpoints []string
+	// Available reports credential availability per model; nil disables per-auth limits.
+	Available Availability
+	// Settings returns the current execution limits (read on every scheduling decision).
+	Settings func() Settings
+	// Namespace prefixes the state store namespaces so several managers can share a store
+	// (defaults to "batch").
+	Namespace string
+	// OwnerKeys lists the client API keys that may own batches. Owners are persisted as key
+	// hashes, so jobs resumed after a restart look up the key to run as in this list.
+	OwnerKeys func() []string
+}
+
+// Manager owns batch files and jobs and runs jobs in the background.
+type Manager struct {
+	store     coreauth.StateStore
+	ns        namespaces
+	execute   Executor
+	endpoints map[string]struct{}
+	available Availability
+	settings  func() Settings
+	limiter   *limiter
+	ownerKeys func() []string
+
+	mu     sync.Mutex
+	owners map[string]string
+	jobs   map[string]*job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager creates a batch manager. Call Start to resume persisted jobs.
+func NewManager(opts Options) *Manager {
+	store := opts.Store
+	if store == nil {
+		store = coreauth.NewMemoryStateStore()
+	}
+	endpoints := make(map[string]struct{}, len(opts.Endpoints))
+	for _, endpoint := range opts.Endpoints {
+		endpoints[endpoint] = struct{}{}
+	}
+	settings := opts.Settings
+	if settings == nil {
+		settings = func() Settings { return Settings{} }
+	}
+	available := opts.Available
+	if available == nil {
+		available = func(string, string) (int, time.Time) { return 1, time.Time{} }
+	}
+	namespace := strings.TrimSpace(opts.Namespace)
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		store:     store,
+		ns:        newNamespaces(namespace),
+		execute:   opts.Execute,
+		endpoints: endpoints,
+		available: available,
+		settings:  func() Settings { return settings().normalized() },
+		limiter:   newLimiter(),
+		ownerKeys: opts.OwnerKeys,
+		owners:    make(map[string]string),
+		jobs:      make(map[string]*job),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Settings returns the effective execution limits.
+func (m *Manager) Settings() Settings {
+	return m.settings()
+}
+
+// Start resumes every persisted batch that has not reached a terminal status.
+func (m *Manager) Start(ctx context.Context) {
+	keys, err := m.store.ListState(ctx, m.ns.jobs)
+	if err != nil {
+		log.Warnf("batch: unable to list persisted jobs: %v", err)
+		return
+	}
+	for _, key := range keys {
+		rec, errLoad := m.loadJob(ctx, key)
+		if errLoad != nil {
+			log.Warnf("batch: unable to load job %s: %v", key, errLoad)
+			continue
+		}
+		if rec.Batch.Terminal() {
+			continue
+		}
+		log.Infof("batch: resuming %s (%s)", rec.Batch.ID, rec.Batch.Status)
+		m.launch(rec)
+	}
+}
+
+// Stop halts all running jobs after checkpointing their progress. Unfinished requests
+// are executed again when the manager is restarted.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.cancel()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CreateFile stores a file uploaded by owner, a client API key of tenant ("" for none).
+func (m *Manager) CreateFile(ctx context.Context, owner, tenant, filename, purpose string, data []byte) (*File, error) {
+	if purpose != PurposeBatch {
+		return nil, &RequestError{Message: fmt.Sprintf("purpose %q is not supported; only %q files can be uploaded", purpose, PurposeBatch), Param: "purpose"}
+	}
+	if limit := m.settings().MaxFileBytes; int64(len(data)) > limit {
+		return nil, &RequestError{Message: fmt.Sprintf("file exceeds the maximum size of %d bytes", limit), Param: "file"}
+	}
+	if len(data) == 0 {
+		return nil, &RequestError{Message: "file is empty", Param: "file"}
+	}
+	return m.saveFile(ctx, m.ownerID(owner), tenant, newID("file-"), filename, purpose, data)
+}
+
+func (m *Manager) saveFile(ctx context.Context, ownerID, tenant, id, filename, purpose string, data []byte) (*File, error) {
+	rec := fileRecord{
+		File: File{
+			ID:        id,
+			Object:    "file",
+			Bytes:     int64(len(data)),
+			CreatedAt: time.Now().Unix(),
+			Filename:  filename,
+			Purpose:   purpose,
+			Status:    "processed",
+		},
+		Owner:  ownerID,
+		Tenant: tenant,
+	}
+	if err := m.store.SaveState(ctx, m.ns.fileData, id, data); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.store.SaveState(ctx, m.ns.files, id, raw); err != nil {
+		return nil, err
+	}
+	file := rec.File
+	return &file, nil
+}
+
+// GetFile returns the metadata of a file owned by owner.
+func (m *Manager) GetFile(ctx context.Context, owner, id string) (*File, error) {
+	rec, err := m.loadFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Owner != m.ownerID(owner) {
+		return nil, ErrNotFound
+	}
+	return &rec.File, nil
+}
+
+// ListFiles returns the files owned by owner, newest first, optionally filtered by purpose.
+func (m *Manager) ListFiles(ctx context.Context, owner, purpose string) ([]File, error) {
+	keys, err := m.store.ListState(ctx, m.ns.files)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]File, 0, len(keys))
+	ownerID := m.ownerID(owner)
+	for _, key := range keys {
+		rec, errLoad := m.loadFile(ctx, key)
+		if errLoad != nil || rec.Owner != ownerID {
+			continue
+		}
+		if purpose != "" && rec.File.Purpose != purpose {
+			continue
+		}
+		files = append(files, rec.File)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].CreatedAt != files[j].CreatedAt {
+			return files[i].CreatedAt > files[j].CreatedAt
+		}
+		return files[i].ID > files[j].ID
+	})
+	return files, nil
+}
+
+// FileContent returns the raw content of a file owned by owner.
+func (m *Manager) FileContent(ctx context.Context, owner, id string) ([]byte, error) {
+	if _, err := m.GetFile(ctx, owner, id); err != nil {
+		return nil, err
+	}
+	data, err := m.store.LoadState(ctx, m.ns.fileData, id)
+	if errors.Is(err, coreauth.ErrStateNotFound) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// DeleteFile removes a file owned by owner.
+func (m *Manager) DeleteFile(ctx context.Context, owner, id string) error {
+	if _, err := m.GetFile(ctx, owner, id); err != nil {
+		return err
+	}
+	if err := m.store.DeleteState(ctx, m.ns.files, id); err != nil {
+		return err
+	}
+	return m.store.DeleteState(ctx, m.ns.fileData, id)
+}
+
+// CreateBatch validates the request and schedules a new batch job for owner, a client API key
+// of tenant ("" for none). Its requests only use credentials of that tenant.
+func (m *Manager) CreateBatch(ctx context.Context, owner, tenant, inputFileID, endpoint, completionWindow string, metadata map[string]string) (*Batch, error) {
+	if _, ok := m.endpoints[endpoint]; !ok {
+		supported := make([]string, 0, len(m.endpoints))
+		for key := range m.endpoints {
+			supported = append(supported, key)
+		}
+		sort.Strings(supported)
+		return nil, &RequestError{Message: fmt.Sprintf("endpoint %q is not supported; use one of %s", endpoint, strings.Join(supported, ", ")), Param: "endpoint"}
+	}
+	if strings.TrimSpace(completionWindow) == "" {
+		completionWindow = "24h"
+	}
+	window, err := time.ParseDuration(completionWindow)
+	if err != nil || window <= 0 {
+		return nil, &RequestError{Message: fmt.Sprintf("invalid completion_window %q", completionWindow), Param: "completion_window"}
+	}
+	input, err := m.GetFile(ctx, owner, inputFileID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, &RequestError{Message: fmt.Sprintf("input file %s not found", inputFileID), Param: "input_file_id"}
+		}
+		return nil, err
+	}
+	if input.Purpose != PurposeBatch {
+		return nil, &RequestError{Message: fmt.Sprintf("input file %s must have purpose %q", inputFileID, PurposeBatch), Param: "input_file_id"}
+	}
+
+	now := time.Now()
+	rec := &jobRecord{
+		Batch: Batch{
+			ID:               newID("batch_"),
+			Object:           "batch",
+			Endpoint:         endpoint,
+			InputFileID:      inputFileID,
+			CompletionWindow: completionWindow,
+			Status:           StatusValidating,
+			CreatedAt:        now.Unix(),
+			ExpiresAt:        now.Add(window).Unix(),
+			Metadata:         metadata,
+		},
+		Owner:      m.ownerID(owner),
+		Tenant:     tenant,
+		OutputFile: newID("file-"),
+		ErrorFile:  newID("file-"),
+	}
+	if err = m.saveJob(ctx, rec); err != nil {
+		return nil, err
+	}
+	batch := rec.Batch
+	m.launch(rec)
+	return &batch, nil
+}
+
+// GetBatch returns the current state of a batch owned by owner.
+func (m *Manager) GetBatch(ctx context.Context, owner, id string) (*Batch, error) {
+	if j := m.activeJob(id); j != nil {
+		snapshot := j.snapshot()
+		if snapshot.Owner != m.ownerID(owner) {
+			return nil, ErrNotFound
+		}
+		return &snapshot.Batch, nil
+	}
+	rec, err := m.loadJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Owner != m.ownerID(owner) {
+		return nil, ErrNotFound
+	}
+	return &rec.Batch, nil
+}
+
+// ListBatches returns the batches owned by owner, newest first.
+func (m *Manager) ListBatches(ctx context.Context, owner string) ([]Batch, error) {
+	keys, err := m.store.ListState(ctx, m.ns.jobs)
+	if err != nil {
+		return nil, err
+	}
+	batches := make([]Batch, 0, len(keys))
+	for _, key := range keys {
+		batch, errGet := m.GetBatch(ctx, owner, key)
+		if errGet != nil {
+			continue
+		}
+		batches = append(batches, *batch)
+	}
+	sort.SliceStable(batches, func(i, j int) bool {
+		if batches[i].CreatedAt != batches[j].CreatedAt {
+			return batches[i].CreatedAt > batches[j].CreatedAt
+		}
+		return batches[i].ID > batches[j].ID
+	})
+	return batches, nil
+}
+
+// CancelBatch requests cancellation. In-flight requests finish; pending ones are
+// reported in the error file with code batch_cancelled.
+func (m *Manager) CancelBatch(ctx context.Context, owner, id string) (*Batch, error) {
+	if j := m.activeJob(id); j != nil {
+		if j.snapshot().Owner != m.ownerID(owner) {
+			return nil, ErrNotFound
+		}
+		snapshot := j.requestCancel()
+		return &snapshot.Batch, nil
+	}
+	rec, err := m.loadJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Owner != m.ownerID(owner) {
+		return nil, ErrNotFound
+	}
+	if rec.Batch.Terminal() {
+		return nil, &RequestError{Message: fmt.Sprintf("batch %s is already %s and cannot be cancelled", id, rec.Batch.Status)}
+	}
+	// Not running in this process (e.g. owned by a stopped manager): cancel on resume.
+	now := time.Now().Unix()
+	rec.Batch.Status = StatusCancelling
+	rec.Batch.CancellingAt = &now
+	if err = m.saveJob(ctx, rec); err != nil {
+		return nil, err
+	}
+	return &rec.Batch, nil
+}
+
+// DeleteBatch removes a finished batch owned by owner together with its output and error files.
+func (m *Manager) DeleteBatch(ctx context.Context, owner, id string) error {
+	if m.activeJob(id) != nil {
+		return &RequestError{Message: fmt.Sprintf("batch %s is still running and cannot be deleted", id)}
+	}
+	rec, err := m.loadJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rec.Owner != m.ownerID(owner) {
+		return ErrNotFound
+	}
+	if !rec.Batch.Terminal() {
+		return &RequestError{Message: fmt.Sprintf("batch %s is %s and cannot be deleted", id, rec.Batch.Status)}
+	}
+	for _, fileID := range []string{rec.OutputFile, rec.ErrorFile} {
+		if errDelete := m.store.DeleteState(ctx, m.ns.files, fileID); errDelete != nil {
+			return errDelete
+		}
+		if errDelete := m.store.DeleteState(ctx, m.ns.fileData, fileID); errDelete != nil {
+			return errDelete
+		}
+	}
+	return m.store.DeleteState(ctx, m.ns.jobs, id)
+}
+
+func (m *Manager) launch(rec *jobRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx.Err() != nil {
+		return
+	}
+	if _, exists := m.jobs[rec.Batch.ID]; exists {
+		return
+	}
+	j := newJob(m, rec)
+	m.jobs[rec.Batch.ID] = j
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		j.run(m.ctx)
+		m.mu.Lock()
+		delete(m.jobs, rec.Batch.ID)
+		m.mu.Unlock()
+	}()
+}
+
+func (m *Manager) activeJob(id string) *job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs[id]
+}
+
+// ownerID returns the identifier stored for the client API key apiKey and remembers the key,
+// so jobs of that owner can run as it.
+func (m *Manager) ownerID(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	id := OwnerID(apiKey)
+	m.mu.Lock()
+	m.owners[id] = apiKey
+	m.mu.Unlock()
+	return id
+}
+
+// ownerKey resolves an owner identifier back to its client API key. It reports false when
+// the key is neither known from a call since startup nor listed by OwnerKeys, e.g. because
+// it was removed from the configuration.
+func (m *Manager) ownerKey(id string) (string, bool) {
+	if id == "" {
+		return "", true
+	}
+	m.mu.Lock()
+	key, ok := m.owners[id]
+	m.mu.Unlock()
+	if ok || m.ownerKeys == nil {
+		return key, ok
+	}
+	for _, candidate := range m.ownerKeys() {
+		if candidate != "" && OwnerID(candidate) == id {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func (m *Manager) loadFile(ctx context.Context, id string) (*fileRecord, error) {
+	if coreauth.ValidateStateKey(id) != nil {
+		return nil, ErrNotFound
+	}
+	raw, err := m.store.LoadState(ctx, m.ns.files, id)
+	if err != nil {
+		if errors.Is(err, coreauth.ErrStateNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var rec fileRecord
+	if err = json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("batch: decode file %s: %w", id, err)
+	}
+	return &rec, nil
+}
+
+func (m *Manager) loadJob(ctx context.Context, id string) (*jobRecord, error) {
+	if coreauth.ValidateStateKey(id) != nil {
+		return nil, ErrNotFound
+	}
+	raw, err := m.store.LoadState(ctx, m.ns.jobs, id)
+	if err != nil {
+		if errors.Is(err, coreauth.ErrStateNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var rec jobRecord
+	if err = json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("batch: decode job %s: %w", id, err)
+	}
+	return &rec, nil
+}
+
+func (m *Manager) saveJob(ctx context.Context, rec *jobRecord) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return m.store.SaveState(ctx, m.ns.jobs, rec.Batch.ID, raw)
+}
+
+// OwnerID returns the identifier persisted in place of the client API key apiKey, a SHA-256
+// hash, or "" when there is no key.
+func OwnerID(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newID returns prefix followed by 24 random hex characters.
+func newID(prefix string) string {
+	var buf [12]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return prefix + fmt.Sprintf("%024x", time.Now().UnixNano())
+	}
+	return prefix + hex.EncodeToString(buf[:])
+}
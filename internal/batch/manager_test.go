@@ -0,0 +1,332 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+const testInput = `{"custom_id":"ok-1","method":"POST","url":"/v1/chat/completions","body":{"model":"m","messages":[]}}
+{"custom_id":"bad-1","method":"POST","url":"/v1/chat/completions","body":{"model":"m","fail":true}}
+{"custom_id":"ok-2","method":"POST","url":"/v1/chat/completions","body":{"model":"m","stream":true}}
+`
+
+func waitForStatus(t *testing.T, m *Manager, owner, id string, statuses ...string) *Batch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		b, err := m.GetBatch(context.Background(), owner, id)
+		if err != nil {
+			t.Fatalf("GetBatch: %v", err)
+		}
+		for _, status := range statuses {
+			if b.Status == status {
+				return b
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not reach %v", id, statuses)
+	return nil
+}
+
+func TestManagerRunsBatchToCompletion(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts = map[string]int{}
+	)
+	m := NewManager(Options{
+		Store:     coreauth.NewDirStateStore(t.TempDir()),
+		Endpoints: []string{"/v1/chat/completions"},
+		Available: func(tenant, _ string) (int, time.Time) {
+			if tenant != "acme" {
+				t.Errorf("availability checked for tenant %q", tenant)
+			}
+			return 1, time.Time{}
+		},
+		Execute: func(_ context.Context, owner, tenant string, req Request) Result {
+			mu.Lock()
+			attempts[req.CustomID]++
+			n := attempts[req.CustomID]
+			mu.Unlock()
+			if owner != "key-a" || tenant != "acme" {
+				t.Errorf("owner = %q, tenant = %q", owner, tenant)
+			}
+			if gjson.GetBytes(req.Body, "stream").Exists() {
+				t.Errorf("stream flag was not stripped for %s", req.CustomID)
+			}
+			if gjson.GetBytes(req.Body, "fail").Bool() {
+				return Result{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":{"message":"bad"}}`)}
+			}
+			if req.CustomID == "ok-2" && n == 1 {
+				header := http.Header{}
+				header.Set("Retry-After", "0")
+				return Result{StatusCode: http.StatusTooManyRequests, Header: header}
+			}
+			return Result{StatusCode: http.StatusOK, Body: []byte(`{"id":"` + req.CustomID + `"}`)}
+		},
+	})
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	ctx := context.Background()
+	file, err := m.CreateFile(ctx, "key-a", "acme", "input.jsonl", PurposeBatch, []byte(testInput))
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	created, err := m.CreateBatch(ctx, "key-a", "acme", file.ID, "/v1/chat/completions", "", nil)
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if _, err = m.GetBatch(ctx, "key-b", created.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected other clients to get ErrNotFound, got %v", err)
+	}
+
+	done := waitForStatus(t, m, "key-a", created.ID, StatusCompleted)
+	if done.RequestCounts != (RequestCounts{Total: 3, Completed: 2, Failed: 1}) {
+		t.Fatalf("request counts = %+v", done.RequestCounts)
+	}
+	if done.OutputFileID == nil || done.ErrorFileID == nil {
+		t.Fatalf("missing output or error file: %+v", done)
+	}
+	output, err := m.FileContent(ctx, "key-a", *done.OutputFileID)
+	if err != nil {
+		t.Fatalf("output content: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("output lines = %d:\n%s", len(lines), output)
+	}
+	for _, line := range lines {
+		if gjson.Get(line, "response.status_code").Int() != http.StatusOK || gjson.Get(line, "response.body.id").String() != gjson.Get(line, "custom_id").String() {
+			t.Fatalf("unexpected output line %s", line)
+		}
+	}
+	errorsData, _ := m.FileContent(ctx, "key-a", *done.ErrorFileID)
+	if got := gjson.GetBytes(errorsData, "custom_id").String(); got != "bad-1" {
+		t.Fatalf("error line custom_id = %q", got)
+	}
+	if got := gjson.GetBytes(errorsData, "response.status_code").Int(); got != http.StatusBadRequest {
+		t.Fatalf("error line status = %d", got)
+	}
+}
+
+func TestManagerFailsInvalidInput(t *testing.T) {
+	m := NewManager(Options{
+		Endpoints: []string{"/v1/chat/completions"},
+		Execute: func(context.Context, string, string, Request) Result {
+			t.Error("invalid batch must not execute requests")
+			return Result{StatusCode: http.StatusOK}
+		},
+	})
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	ctx := context.Background()
+	input := `{"custom_id":"a","method":"POST","url":"/v1/responses","body":{"model":"m"}}
+not json
+{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"m"}}
+{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"m"}}
+`
+	file, err := m.CreateFile(ctx, "", "", "input.jsonl", PurposeBatch, []byte(input))
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	created, err := m.CreateBatch(ctx, "", "", file.ID, "/v1/chat/completions", "24h", nil)
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	failed := waitForStatus(t, m, "", created.ID, StatusFailed)
+	if failed.Errors == nil || len(failed.Errors.Data) != 3 {
+		t.Fatalf("errors = %+v", failed.Errors)
+	}
+	codes := []string{failed.Errors.Data[0].Code, failed.Errors.Data[1].Code, failed.Errors.Data[2].Code}
+	if strings.Join(codes, ",") != "mismatched_endpoint,invalid_json_line,duplicate_custom_id" {
+		t.Fatalf("error codes = %v", codes)
+	}
+
+	var reqErr *RequestError
+	if _, err = m.CreateBatch(ctx, "", "", file.ID, "/v1/embeddings", "", nil); !errors.As(err, &reqErr) {
+		t.Fatalf("expected RequestError for unsupported endpoint, got %v", err)
+	}
+}
+
+func TestManagerCancelAndResume(t *testing.T) {
+	store := coreauth.NewDirStateStore(t.TempDir())
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	blocking := Options{
+		Store:     store,
+		Endpoints: []string{"/v1/chat/completions"},
+		Settings:  func() Settings { return Settings{MaxConcurrency: 1, PerAuthConcurrency: 1} },
+		OwnerKeys: func() []string { return []string{"k"} },
+		Execute: func(ctx context.Context, _, _ string, req Request) Result {
+			started <- struct{}{}
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return Result{StatusCode: http.StatusServiceUnavailable}
+			}
+			return Result{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+		},
+	}
+
+	ctx := context.Background()
+	m := NewManager(blocking)
+	file, err := m.CreateFile(ctx, "k", "", "input.jsonl", PurposeBatch, []byte(strings.ReplaceAll(testInput, `"fail":true`, `"fail":false`)))
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	created, err := m.CreateBatch(ctx, "k", "", file.ID, "/v1/chat/completions", "", nil)
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	<-started
+	// Stopping the manager checkpoints the batch without finalizing it.
+	if err = m.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if rec, _ := m.loadJob(ctx, created.ID); rec == nil || rec.Batch.Terminal() {
+		t.Fatalf("stopped batch must remain resumable, got %+v", rec)
+	}
+
+	// A new manager resumes the batch; cancelling it reports pending lines.
+	resumed := NewManager(blocking)
+	defer func() { _ = resumed.Stop(context.Background()) }()
+	resumed.Start(ctx)
+	<-started
+	if _, err = resumed.CancelBatch(ctx, "k", created.ID); err != nil {
+		t.Fatalf("CancelBatch: %v", err)
+	}
+	close(release)
+	cancelled := waitForStatus(t, resumed, "k", created.ID, StatusCancelled)
+	if cancelled.RequestCounts.Completed != 1 || cancelled.RequestCounts.Failed != 2 {
+		t.Fatalf("request counts = %+v", cancelled.RequestCounts)
+	}
+	errorsData, _ := resumed.FileContent(ctx, "k", *cancelled.ErrorFileID)
+	if !strings.Contains(string(errorsData), "batch_cancelled") {
+		t.Fatalf("expected batch_cancelled errors, got %s", errorsData)
+	}
+	if _, err = resumed.CancelBatch(ctx, "k", created.ID); err == nil {
+		t.Fatal("expected error when cancelling a finished batch")
+	}
+}
+
+func TestManagerPersistsOwnerHashAndResolvesKeyOnResume(t *testing.T) {
+	store := coreauth.NewDirStateStore(t.TempDir())
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	opts := Options{
+		Store:     store,
+		Endpoints: []string{"/v1/chat/completions"},
+		Settings:  func() Settings { return Settings{MaxConcurrency: 1, PerAuthConcurrency: 1} },
+		Execute: func(ctx context.Context, apiKey, _ string, req Request) Result {
+			mu.Lock()
+			keys = append(keys, apiKey)
+			mu.Unlock()
+			started <- struct{}{}
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return Result{StatusCode: http.StatusServiceUnavailable}
+			}
+			return Result{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+		},
+	}
+
+	ctx := context.Background()
+	m := NewManager(opts)
+	file, err := m.CreateFile(ctx, "secret-key", "", "input.jsonl", PurposeBatch, []byte(strings.ReplaceAll(testInput, `"fail":true`, `"fail":false`)))
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	created, err := m.CreateBatch(ctx, "secret-key", "", file.ID, "/v1/chat/completions", "", nil)
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	<-started
+	if err = m.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	for _, ns := range []string{m.ns.files, m.ns.jobs} {
+		ids, _ := store.ListState(ctx, ns)
+		for _, id := range ids {
+			raw, _ := store.LoadState(ctx, ns, id)
+			if strings.Contains(string(raw), "secret-key") {
+				t.Fatalf("%s/%s stores the raw api key: %s", ns, id, raw)
+			}
+		}
+	}
+
+	// After a restart the key is only known from the configured owner keys.
+	opts.OwnerKeys = func() []string { return []string{"other-key", "secret-key"} }
+	resumed := NewManager(opts)
+	defer func() { _ = resumed.Stop(context.Background()) }()
+	resumed.Start(ctx)
+	close(release)
+	done := waitForStatus(t, resumed, "secret-key", created.ID, StatusCompleted)
+	if done.RequestCounts.Completed != 3 {
+		t.Fatalf("request counts = %+v", done.RequestCounts)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, key := range keys {
+		if key != "secret-key" {
+			t.Fatalf("request executed as %q", key)
+		}
+	}
+}
+
+func TestManagerFailsRequestsOfUnknownOwner(t *testing.T) {
+	store := coreauth.NewDirStateStore(t.TempDir())
+	ctx := context.Background()
+	creator := NewManager(Options{Store: store, Endpoints: []string{"/v1/chat/completions"}})
+	file, err := creator.CreateFile(ctx, "removed-key", "", "input.jsonl", PurposeBatch, []byte(testInput))
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	// Stop before creating the batch so it is only run by the manager below.
+	_ = creator.Stop(ctx)
+	created, err := creator.CreateBatch(ctx, "removed-key", "", file.ID, "/v1/chat/completions", "", nil)
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+
+	m := NewManager(Options{
+		Store:     store,
+		Endpoints: []string{"/v1/chat/completions"},
+		OwnerKeys: func() []string { return []string{"other-key"} },
+		Execute: func(context.Context, string, string, Request) Result {
+			t.Error("requests of an unknown owner must not execute")
+			return Result{StatusCode: http.StatusOK}
+		},
+	})
+	defer func() { _ = m.Stop(context.Background()) }()
+	m.Start(ctx)
+	rec := waitForJob(t, m, created.ID)
+	if rec.Batch.RequestCounts.Failed != 3 {
+		t.Fatalf("request counts = %+v", rec.Batch.RequestCounts)
+	}
+}
+
+func waitForJob(t *testing.T, m *Manager, id string) *jobRecord {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if rec, err := m.loadJob(context.Background(), id); err == nil && rec.Batch.Terminal() {
+			return rec
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not finish", id)
+	return nil
+}
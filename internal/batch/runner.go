@@ -0,0 +1,568 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxReportedLineErrors bounds the validation errors attached to a failed batch.
+const maxReportedLineErrors = 100
+
+// unknownOwnerResult answers requests of a batch whose client API key can no longer be found.
+var unknownOwnerResult = Result{
+	StatusCode: http.StatusUnauthorized,
+	Body:       []byte(`{"error":{"message":"The API key that created this batch is no longer configured.","type":"invalid_request_error"}}`),
+}
+
+// job drives one batch from validation to a terminal status.
+type job struct {
+	m *Manager
+
+	mu       sync.Mutex
+	rec      jobRecord
+	output   bytes.Buffer
+	errors   bytes.Buffer
+	done     map[string]struct{}
+	dirty    bool
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// pendingRequest is a request waiting for (re)execution.
+type pendingRequest struct {
+	req       Request
+	attempts  int
+	notBefore time.Time
+}
+
+func newJob(m *Manager, rec *jobRecord) *job {
+	return &job{m: m, rec: *rec, done: make(map[string]struct{})}
+}
+
+func (j *job) snapshot() jobRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.rec
+}
+
+func (j *job) requestCancel() jobRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.rec.Batch.Terminal() && j.rec.Batch.Status != StatusCancelling {
+		now := time.Now().Unix()
+		j.rec.Batch.Status = StatusCancelling
+		j.rec.Batch.CancellingAt = &now
+		j.dirty = true
+	}
+	j.canceled = true
+	if j.cancel != nil {
+		j.cancel()
+	}
+	return j.rec
+}
+
+func (j *job) run(parent context.Context) {
+	rec := j.snapshot()
+	requests, lineErrors, err := j.loadInput(parent, rec)
+	if err != nil {
+		if parent.Err() == nil {
+			j.fail([]LineError{{Code: "invalid_input_file", Message: err.Error()}})
+		}
+		return
+	}
+	if len(lineErrors) > 0 {
+		j.fail(lineErrors)
+		return
+	}
+	if errRestore := j.restoreProgress(parent, rec); errRestore != nil {
+		log.Warnf("batch %s: unable to restore progress: %v", rec.Batch.ID, errRestore)
+	}
+
+	j.mu.Lock()
+	if j.rec.Batch.Status == StatusValidating {
+		now := time.Now().Unix()
+		j.rec.Batch.Status = StatusInProgress
+		j.rec.Batch.InProgressAt = &now
+	}
+	if j.rec.Batch.Status == StatusCancelling {
+		j.canceled = true
+	}
+	j.rec.Batch.RequestCounts.Total = len(requests)
+	expiresAt := time.Unix(j.rec.Batch.ExpiresAt, 0)
+	dispatchCtx, cancel := context.WithDeadline(parent, expiresAt)
+	j.cancel = cancel
+	if j.canceled {
+		cancel()
+	}
+	j.dirty = true
+	j.mu.Unlock()
+	defer cancel()
+	j.checkpoint(parent)
+
+	stopCheckpoints := j.startCheckpoints(parent)
+	remaining := j.dispatch(parent, dispatchCtx, requests)
+	stopCheckpoints()
+
+	if parent.Err() != nil {
+		// Shutdown: keep what completed so far; the rest runs again after restart.
+		j.checkpoint(context.Background())
+		return
+	}
+	j.finalize(parent, remaining, dispatchCtx)
+}
+
+func (j *job) loadInput(ctx context.Context, rec jobRecord) ([]Request, []LineError, error) {
+	data, err := j.m.store.LoadState(ctx, j.m.ns.fileData, rec.Batch.InputFileID)
+	if err != nil {
+		if errors.Is(err, coreauth.ErrStateNotFound) {
+			return nil, nil, fmt.Errorf("input file %s not found", rec.Batch.InputFileID)
+		}
+		return nil, nil, err
+	}
+	requests, lineErrors := parseInput(data, rec.Batch.Endpoint)
+	return requests, lineErrors, nil
+}
+
+// restoreProgress reloads partial results written before a restart.
+func (j *job) restoreProgress(ctx context.Context, rec jobRecord) error {
+	var firstErr error
+	restore := func(id string, buf *bytes.Buffer, counter *int) {
+		data, err := j.m.store.LoadState(ctx, j.m.ns.fileData, id)
+		if err != nil {
+			if !errors.Is(err, coreauth.ErrStateNotFound) && firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			customID := gjson.GetBytes(line, "custom_id").String()
+			if customID == "" {
+				continue
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+			j.done[customID] = struct{}{}
+			*counter++
+		}
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.rec.Batch.RequestCounts.Completed = 0
+	j.rec.Batch.RequestCounts.Failed = 0
+	restore(rec.OutputFile, &j.output, &j.rec.Batch.RequestCounts.Completed)
+	restore(rec.ErrorFile, &j.errors, &j.rec.Batch.RequestCounts.Failed)
+	return firstErr
+}
+
+// dispatch executes every pending request and returns the ones that never ran
+// because the batch was cancelled, expired or the manager stopped.
+func (j *job) dispatch(parent, dispatchCtx context.Context, requests []Request) []Request {
+	queue := make([]*pendingRequest, 0, len(requests))
+	j.mu.Lock()
+	for _, req := range requests {
+		if _, finished := j.done[req.CustomID]; !finished {
+			queue = append(queue, &pendingRequest{req: req})
+		}
+	}
+	j.mu.Unlock()
+	apiKey, ownerKnown := j.m.ownerKey(j.rec.Owner)
+
+	var (
+		wg      sync.WaitGroup
+		retryMu sync.Mutex
+		retries []*pendingRequest
+	)
+	for len(queue) > 0 {
+		var remaining []*pendingRequest
+		for idx, item := range queue {
+			if wait := time.Until(item.notBefore); wait > 0 {
+				if !sleepContext(dispatchCtx, wait) {
+					remaining = queue[idx:]
+					break
+				}
+			}
+			release, err := j.m.limiter.acquire(dispatchCtx, j.rec.Tenant, item.req.Model, j.m.settings, j.m.available)
+			if err != nil {
+				remaining = queue[idx:]
+				break
+			}
+			wg.Add(1)
+			go func(item *pendingRequest) {
+				defer wg.Done()
+				defer release()
+				result := unknownOwnerResult
+				if ownerKnown {
+					result = j.m.execute(parent, apiKey, j.rec.Tenant, item.req)
+				}
+				if parent.Err() != nil {
+					return
+				}
+				if result.StatusCode == http.StatusTooManyRequests {
+					item.attempts++
+					item.notBefore = time.Now().Add(retryDelay(result.Header, item.attempts))
+					retryMu.Lock()
+					retries = append(retries, item)
+					retryMu.Unlock()
+					return
+				}
+				j.record(item.req, result)
+			}(item)
+		}
+		wg.Wait()
+
+		retryMu.Lock()
+		queue = append(remaining, retries...)
+		retries = nil
+		retryMu.Unlock()
+		if dispatchCtx.Err() != nil {
+			break
+		}
+	}
+
+	left := make([]Request, 0, len(queue))
+	for _, item := range queue {
+		left = append(left, item.req)
+	}
+	return left
+}
+
+func (j *job) record(req Request, result Result) {
+	line := outputLine(req.CustomID, result.StatusCode, result.Body)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if result.StatusCode >= 200 && result.StatusCode < 300 {
+		j.output.Write(line)
+		j.output.WriteByte('\n')
+		j.rec.Batch.RequestCounts.Completed++
+	} else {
+		j.errors.Write(line)
+		j.errors.WriteByte('\n')
+		j.rec.Batch.RequestCounts.Failed++
+	}
+	j.done[req.CustomID] = struct{}{}
+	j.dirty = true
+}
+
+func (j *job) finalize(ctx context.Context, remaining []Request, dispatchCtx context.Context) {
+	j.mu.Lock()
+	now := time.Now().Unix()
+	final := StatusCompleted
+	code, message := "", ""
+	switch {
+	case j.canceled:
+		final, code, message = StatusCancelled, "batch_cancelled", "This request was not executed because the batch was cancelled."
+	case len(remaining) > 0 || errors.Is(dispatchCtx.Err(), context.DeadlineExceeded):
+		final, code, message = StatusExpired, "batch_expired", "This request could not be executed before the completion window expired."
+	}
+	for _, req := range remaining {
+		j.errors.Write(errorLine(req.CustomID, code, message))
+		j.errors.WriteByte('\n')
+		j.rec.Batch.RequestCounts.Failed++
+	}
+	if final == StatusCompleted {
+		j.rec.Batch.Status = StatusFinalizing
+		j.rec.Batch.FinalizingAt = &now
+	}
+	output := append([]byte(nil), j.output.Bytes()...)
+	errorsData := append([]byte(nil), j.errors.Bytes()...)
+	rec := j.rec
+	j.mu.Unlock()
+
+	var outputID, errorID *string
+	if len(output) > 0 {
+		if file, err := j.m.saveFile(ctx, rec.Owner, rec.Tenant, rec.OutputFile, rec.Batch.ID+"_output.jsonl", PurposeBatchOutput, output); err != nil {
+			log.Errorf("batch %s: unable to store output file: %v", rec.Batch.ID, err)
+		} else {
+			outputID = &file.ID
+		}
+	}
+	if len(errorsData) > 0 {
+		if file, err := j.m.saveFile(ctx, rec.Owner, rec.Tenant, rec.ErrorFile, rec.Batch.ID+"_error.jsonl", PurposeBatchOutput, errorsData); err != nil {
+			log.Errorf("batch %s: unable to store error file: %v", rec.Batch.ID, err)
+		} else {
+			errorID = &file.ID
+		}
+	}
+
+	j.mu.Lock()
+	now = time.Now().Unix()
+	j.rec.Batch.OutputFileID = outputID
+	j.rec.Batch.ErrorFileID = errorID
+	j.rec.Batch.Status = final
+	switch final {
+	case StatusCompleted:
+		j.rec.Batch.CompletedAt = &now
+	case StatusCancelled:
+		j.rec.Batch.CancelledAt = &now
+	case StatusExpired:
+		j.rec.Batch.ExpiredAt = &now
+	}
+	counts := j.rec.Batch.RequestCounts
+	j.dirty = true
+	j.mu.Unlock()
+	j.checkpoint(ctx)
+	log.Infof("batch %s: %s (%d completed, %d failed)", rec.Batch.ID, final, counts.Completed, counts.Failed)
+}
+
+func (j *job) fail(lineErrors []LineError) {
+	j.mu.Lock()
+	now := time.Now().Unix()
+	j.rec.Batch.Status = StatusFailed
+	j.rec.Batch.FailedAt = &now
+	j.rec.Batch.Errors = &Errors{Object: "list", Data: lineErrors}
+	j.dirty = true
+	j.mu.Unlock()
+	j.checkpoint(context.Background())
+}
+
+func (j *job) startCheckpoints(ctx context.Context) func() {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(checkpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.checkpoint(ctx)
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		wg.Wait()
+	}
+}
+
+// checkpoint persists the job record and partial results when they changed.
+func (j *job) checkpoint(ctx context.Context) {
+	j.mu.Lock()
+	if !j.dirty {
+		j.mu.Unlock()
+		return
+	}
+	j.dirty = false
+	rec := j.rec
+	output := append([]byte(nil), j.output.Bytes()...)
+	errorsData := append([]byte(nil), j.errors.Bytes()...)
+	j.mu.Unlock()
+
+	if !rec.Batch.Terminal() {
+		if len(output) > 0 {
+			if err := j.m.store.SaveState(ctx, j.m.ns.fileData, rec.OutputFile, output); err != nil {
+				log.Warnf("batch %s: checkpoint output: %v", rec.Batch.ID, err)
+			}
+		}
+		if len(errorsData) > 0 {
+			if err := j.m.store.SaveState(ctx, j.m.ns.fileData, rec.ErrorFile, errorsData); err != nil {
+				log.Warnf("batch %s: checkpoint errors: %v", rec.Batch.ID, err)
+			}
+		}
+	}
+	if err := j.m.saveJob(ctx, &rec); err != nil {
+		log.Warnf("batch %s: checkpoint state: %v", rec.Batch.ID, err)
+		j.mu.Lock()
+		j.dirty = true
+		j.mu.Unlock()
+	}
+}
+
+// parseInput validates the JSONL input file against the batch endpoint.
+func parseInput(data []byte, endpoint string) ([]Request, []LineError) {
+	var (
+		requests   []Request
+		lineErrors []LineError
+	)
+	seen := make(map[string]struct{})
+	addError := func(line int, code, message, param string) {
+		if len(lineErrors) < maxReportedLineErrors {
+			lineErrors = append(lineErrors, LineError{Code: code, Message: message, Param: param, Line: line})
+		}
+	}
+	for idx, raw := range bytes.Split(data, []byte("\n")) {
+		lineNo := idx + 1
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		if !gjson.ValidBytes(raw) || !gjson.ParseBytes(raw).IsObject() {
+			addError(lineNo, "invalid_json_line", "This line is not parseable as valid JSON.", "")
+			continue
+		}
+		root := gjson.ParseBytes(raw)
+		customID := root.Get("custom_id").String()
+		if customID == "" {
+			addError(lineNo, "missing_required_parameter", "custom_id is required.", "custom_id")
+			continue
+		}
+		if _, duplicate := seen[customID]; duplicate {
+			addError(lineNo, "duplicate_custom_id", fmt.Sprintf("The custom_id %q is used more than once.", customID), "custom_id")
+			continue
+		}
+		seen[customID] = struct{}{}
+		method := strings.ToUpper(root.Get("method").String())
+		if method != http.MethodPost {
+			addError(lineNo, "invalid_method", "Only POST requests are supported.", "method")
+			continue
+		}
+		if url := root.Get("url").String(); url != endpoint {
+			addError(lineNo, "mismatched_endpoint", fmt.Sprintf("The url %q does not match the batch endpoint %q.", url, endpoint), "url")
+			continue
+		}
+		body := root.Get("body")
+		if !body.IsObject() {
+			addError(lineNo, "missing_required_parameter", "body must be a JSON object.", "body")
+			continue
+		}
+		model := body.Get("model").String()
+		if model == "" {
+			addError(lineNo, "missing_required_parameter", "body.model is required.", "body.model")
+			continue
+		}
+		payload := []byte(body.Raw)
+		if body.Get("stream").Exists() {
+			payload, _ = sjson.DeleteBytes(payload, "stream")
+		}
+		requests = append(requests, Request{CustomID: customID, Method: method, URL: endpoint, Body: payload, Model: model})
+	}
+	if len(requests) == 0 && len(lineErrors) == 0 {
+		addError(0, "empty_file", "The input file contains no requests.", "")
+	}
+	return requests, lineErrors
+}
+
+// outputLine renders one result in the OpenAI batch output format.
+func outputLine(customID string, status int, body []byte) []byte {
+	line := `{"id":"","custom_id":"","response":{"status_code":0,"request_id":"","body":null},"error":null}`
+	line, _ = sjson.Set(line, "id", newID("batch_req_"))
+	line, _ = sjson.Set(line, "custom_id", customID)
+	line, _ = sjson.Set(line, "response.status_code", status)
+	line, _ = sjson.Set(line, "response.request_id", newID("req_"))
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && gjson.ValidBytes(trimmed) {
+		line, _ = sjson.SetRaw(line, "response.body", string(trimmed))
+	} else if len(trimmed) > 0 {
+		line, _ = sjson.Set(line, "response.body", string(trimmed))
+	}
+	return []byte(line)
+}
+
+// errorLine renders a request that never produced a response.
+func errorLine(customID, code, message string) []byte {
+	line := `{"id":"","custom_id":"","response":null,"error":{"code":"","message":""}}`
+	line, _ = sjson.Set(line, "id", newID("batch_req_"))
+	line, _ = sjson.Set(line, "custom_id", customID)
+	line, _ = sjson.Set(line, "error.code", code)
+	line, _ = sjson.Set(line, "error.message", message)
+	return []byte(line)
+}
+
+// retryDelay honours Retry-After and otherwise backs off exponentially.
+func retryDelay(header http.Header, attempts int) time.Duration {
+	if header != nil {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(header.Get("Retry-After"))); err == nil && seconds > 0 {
+			return min(time.Duration(seconds)*time.Second, maxRetryBackoff)
+		}
+	}
+	delay := time.Second << min(attempts, 9)
+	return min(delay, maxRetryBackoff)
+}
+
+func sleepContext(ctx context.Context, wait time.Duration) bool {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// limiter bounds concurrent batch requests globally and per credential.
+type limiter struct {
+	mu       sync.Mutex
+	changed  chan struct{}
+	total    int
+	perModel map[modelKey]int
+}
+
+// modelKey identifies the credentials a request draws on: those of a tenant serving a model.
+type modelKey struct {
+	tenant string
+	model  string
+}
+
+func newLimiter() *limiter {
+	return &limiter{changed: make(chan struct{}), perModel: make(map[modelKey]int)}
+}
+
+// acquire blocks until a slot for model is free. Each credential of tenant that can currently
+// serve the model contributes PerAuthConcurrency slots; while every credential is cooling down
+// the request waits for the earliest recovery instead of failing.
+func (l *limiter) acquire(ctx context.Context, tenant, model string, settings func() Settings, available Availability) (func(), error) {
+	key := modelKey{tenant: tenant, model: model}
+	for {
+		s := settings()
+		count, recoverAt := available(tenant, model)
+		limit := count * s.PerAuthConcurrency
+		if count == 0 && recoverAt.IsZero() {
+			// No credential at all: let the request through so the error is reported.
+			limit = s.PerAuthConcurrency
+		}
+		l.mu.Lock()
+		if l.total < s.MaxConcurrency && l.perModel[key] < limit {
+			l.total++
+			l.perModel[key]++
+			l.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(func() { l.release(key) }) }, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		wait := time.Second
+		if count == 0 && !recoverAt.IsZero() {
+			wait = min(max(time.Until(recoverAt), 100*time.Millisecond), time.Minute)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+func (l *limiter) release(key modelKey) {
+	l.mu.Lock()
+	l.total--
+	l.perModel[key]--
+	if l.perModel[key] <= 0 {
+		delete(l.perModel, key)
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+	l.mu.Unlock()
+}
@@ -0,0 +1,179 @@
+// Package batch emulates the OpenAI Batch API on top of the proxy's auth manager.
+// Uploaded JSONL files are stored in the configured token store, batch jobs are
+// executed in the background with bounded concurrency, and results are written to
+// output and error JSONL files that clients download through the Files API.
+package batch
+
+import (
+	"net/http"
+	"time"
+)
+
+// Batch statuses as reported by the OpenAI Batch API.
+const (
+	StatusValidating = "validating"
+	StatusFailed     = "failed"
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusExpired    = "expired"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+// File purposes accepted or produced by the Files API.
+const (
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+)
+
+// defaultNamespace prefixes the state store namespaces of a Manager.
+const defaultNamespace = "batch"
+
+// namespaces names the state store namespaces used by one Manager.
+type namespaces struct {
+	files    string
+	fileData string
+	jobs     string
+}
+
+func newNamespaces(prefix string) namespaces {
+	return namespaces{files: prefix + "-files", fileData: prefix + "-file-data", jobs: prefix + "-jobs"}
+}
+
+// File mirrors the OpenAI file object.
+type File struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status"`
+}
+
+// fileRecord is the persisted form of a file's metadata.
+type fileRecord struct {
+	File File `json:"file"`
+	// Owner is the SHA-256 hash of the client API key that uploaded (or whose batch produced)
+	// the file; the key itself is never persisted.
+	Owner string `json:"owner,omitempty"`
+	// Tenant is the tenant of the owner, if any.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// RequestCounts tracks per-request progress of a batch.
+type RequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// LineError describes a validation error for one input line.
+type LineError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// Errors wraps validation errors in the OpenAI list shape.
+type Errors struct {
+	Object string      `json:"object"`
+	Data   []LineError `json:"data"`
+}
+
+// Batch mirrors the OpenAI batch object.
+type Batch struct {
+	ID               string            `json:"id"`
+	Object           string            `json:"object"`
+	Endpoint         string            `json:"endpoint"`
+	Errors           *Errors           `json:"errors"`
+	InputFileID      string            `json:"input_file_id"`
+	CompletionWindow string            `json:"completion_window"`
+	Status           string            `json:"status"`
+	OutputFileID     *string           `json:"output_file_id"`
+	ErrorFileID      *string           `json:"error_file_id"`
+	CreatedAt        int64             `json:"created_at"`
+	InProgressAt     *int64            `json:"in_progress_at"`
+	ExpiresAt        int64             `json:"expires_at"`
+	FinalizingAt     *int64            `json:"finalizing_at"`
+	CompletedAt      *int64            `json:"completed_at"`
+	FailedAt         *int64            `json:"failed_at"`
+	ExpiredAt        *int64            `json:"expired_at"`
+	CancellingAt     *int64            `json:"cancelling_at"`
+	CancelledAt      *int64            `json:"cancelled_at"`
+	RequestCounts    RequestCounts     `json:"request_counts"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+// Terminal reports whether the batch reached a final status.
+func (b *Batch) Terminal() bool {
+	switch b.Status {
+	case StatusCompleted, StatusFailed, StatusExpired, StatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// jobRecord is the persisted form of a batch, including server-side bookkeeping.
+type jobRecord struct {
+	Batch Batch `json:"batch"`
+	// Owner is the SHA-256 hash of the client API key that created the batch; requests run on
+	// behalf of that key.
+	Owner string `json:"owner,omitempty"`
+	// Tenant is the tenant of the owner, if any; requests only use credentials of that tenant.
+	Tenant string `json:"tenant,omitempty"`
+	// OutputFile and ErrorFile are reserved up front so partial results survive restarts.
+	OutputFile string `json:"output_file"`
+	ErrorFile  string `json:"error_file"`
+}
+
+// Request is one parsed line of a batch input file.
+type Request struct {
+	CustomID string
+	Method   string
+	URL      string
+	Body     []byte
+	Model    string
+}
+
+// Result is the outcome of executing a single batch request.
+type Result struct {
+	// StatusCode is the HTTP status returned by the endpoint handler.
+	StatusCode int
+	// Header carries response headers (Retry-After is honoured for 429 responses).
+	Header http.Header
+	// Body is the response body.
+	Body []byte
+}
+
+// Settings controls batch execution limits.
+type Settings struct {
+	MaxConcurrency     int
+	PerAuthConcurrency int
+	MaxFileBytes       int64
+}
+
+const (
+	defaultMaxConcurrency     = 8
+	defaultPerAuthConcurrency = 2
+	defaultMaxFileBytes       = 100 << 20
+	defaultCompletionWindow   = 24 * time.Hour
+	checkpointInterval        = 5 * time.Second
+	maxRetryBackoff           = 5 * time.Minute
+)
+
+func (s Settings) normalized() Settings {
+	if s.MaxConcurrency <= 0 {
+		s.MaxConcurrency = defaultMaxConcurrency
+	}
+	if s.PerAuthConcurrency <= 0 {
+		s.PerAuthConcurrency = defaultPerAuthConcurrency
+	}
+	if s.MaxFileBytes <= 0 {
+		s.MaxFileBytes = defaultMaxFileBytes
+	}
+	return s
+}
@@ -32,6 +32,12 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 
 	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+	// The first signal starts a graceful drain; restore the default handling so a second
+	// signal exits immediately instead of waiting for the drain deadline.
+	go func() {
+		<-ctxSignal.Done()
+		cancel()
+	}()
 
 	runCtx := ctxSignal
 	if localPassword != "" {
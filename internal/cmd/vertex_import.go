@@ -19,8 +19,9 @@ import (
 
 // DoVertexImport imports a Google Cloud service account key JSON and persists
 // it as a "vertex" provider credential. The file content is embedded in the auth
-// file to allow portable deployment across stores.
-func DoVertexImport(cfg *config.Config, keyPath string) {
+// file to allow portable deployment across stores. When claudeModels is set the
+// credential also serves Anthropic Claude models on Vertex.
+func DoVertexImport(cfg *config.Config, keyPath string, claudeModels bool) {
 	if cfg == nil {
 		cfg = &config.Config{}
 	}
@@ -69,6 +70,7 @@ func DoVertexImport(cfg *config.Config, keyPath string) {
 		ProjectID:      projectID,
 		Email:          email,
 		Location:       location,
+		ClaudeModels:   claudeModels,
 	}
 	metadata := map[string]any{
 		"service_account": sa,
@@ -78,6 +80,9 @@ func DoVertexImport(cfg *config.Config, keyPath string) {
 		"type":            "vertex",
 		"label":           labelForVertex(projectID, email),
 	}
+	if claudeModels {
+		metadata["claude_models"] = true
+	}
 	record := &coreauth.Auth{
 		ID:       fileName,
 		Provider: "vertex",
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrAPIKeyNotConfigured is returned when rotating a key that is not listed in api-keys or a
+// tenant's api-keys, e.g. one accepted by an external access provider.
+var ErrAPIKeyNotConfigured = errors.New("api key is not configured in this file")
+
+// ErrAPIKeyRotated is returned when rotating a key that was already replaced.
+var ErrAPIKeyRotated = errors.New("api key has already been rotated")
+
+// RotateAPIKey adds newKey next to oldKey and gives it every per-key setting of oldKey: account
+// restrictions, budget, expiry, and membership in guardrail, prompt profile, payload and Amp
+// upstream key lists. oldKey keeps working until oldExpiresAt, or its earlier configured expiry,
+// and is recorded in api-key-rotations as replaced by newKey.
+func (cfg *Config) RotateAPIKey(oldKey, newKey string, oldExpiresAt time.Time) error {
+	if cfg == nil || oldKey == "" || newKey == "" {
+		return ErrAPIKeyNotConfigured
+	}
+	if _, rotated := cfg.APIKeyRotations[oldKey]; rotated {
+		return ErrAPIKeyRotated
+	}
+	switch {
+	case slices.Contains(cfg.APIKeys, oldKey):
+		cfg.APIKeys = append(cfg.APIKeys, newKey)
+	default:
+		tenant := cfg.Tenant(cfg.TenantAPIKeys()[oldKey])
+		if tenant == nil {
+			return ErrAPIKeyNotConfigured
+		}
+		tenant.APIKeys = append(tenant.APIKeys, newKey)
+	}
+
+	if auths, ok := cfg.APIKeyAuth[oldKey]; ok {
+		cfg.APIKeyAuth[newKey] = append([]string(nil), auths...)
+	}
+	if budget, ok := cfg.APIKeyBudgets[oldKey]; ok {
+		cfg.APIKeyBudgets[newKey] = budget
+	}
+	oldExpiry := oldExpiresAt.UTC()
+	if raw, ok := cfg.APIKeyExpiry[oldKey]; ok {
+		cfg.APIKeyExpiry[newKey] = raw
+		if configured, err := time.Parse(time.RFC3339, raw); err == nil && configured.Before(oldExpiry) {
+			oldExpiry = configured
+		}
+	}
+	if cfg.APIKeyExpiry == nil {
+		cfg.APIKeyExpiry = make(map[string]string)
+	}
+	cfg.APIKeyExpiry[oldKey] = oldExpiry.Format(time.RFC3339)
+	if cfg.APIKeyRotations == nil {
+		cfg.APIKeyRotations = make(map[string]string)
+	}
+	cfg.APIKeyRotations[oldKey] = newKey
+
+	for i := range cfg.Guardrails.Rules {
+		cfg.Guardrails.Rules[i].APIKeys = withRotatedKey(cfg.Guardrails.Rules[i].APIKeys, oldKey, newKey)
+	}
+	for i := range cfg.PromptProfiles {
+		cfg.PromptProfiles[i].APIKeys = withRotatedKey(cfg.PromptProfiles[i].APIKeys, oldKey, newKey)
+	}
+	for i := range cfg.AmpCode.UpstreamAPIKeys {
+		cfg.AmpCode.UpstreamAPIKeys[i].APIKeys = withRotatedKey(cfg.AmpCode.UpstreamAPIKeys[i].APIKeys, oldKey, newKey)
+	}
+	rotatePayloadKeys(&cfg.Payload, oldKey, newKey)
+	for i := range cfg.Tenants {
+		rotatePayloadKeys(&cfg.Tenants[i].Payload, oldKey, newKey)
+	}
+	return nil
+}
+
+// SanitizeAPIKeyRotations trims api-key-rotations and drops entries without both keys.
+func (cfg *Config) SanitizeAPIKeyRotations() {
+	if cfg == nil || len(cfg.APIKeyRotations) == 0 {
+		return
+	}
+	out := make(map[string]string, len(cfg.APIKeyRotations))
+	for rawOld, rawNew := range cfg.APIKeyRotations {
+		oldKey, newKey := strings.TrimSpace(rawOld), strings.TrimSpace(rawNew)
+		if oldKey == "" || newKey == "" || oldKey == newKey {
+			continue
+		}
+		out[oldKey] = newKey
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	cfg.APIKeyRotations = out
+}
+
+func rotatePayloadKeys(p *PayloadConfig, oldKey, newKey string) {
+	for _, rules := range [][]PayloadRule{p.Default, p.DefaultRaw, p.Override, p.OverrideRaw, p.Append, p.Prepend} {
+		for i := range rules {
+			if rules[i].When != nil {
+				rules[i].When.APIKeys = withRotatedKey(rules[i].When.APIKeys, oldKey, newKey)
+			}
+		}
+	}
+	for i := range p.Filter {
+		if p.Filter[i].When != nil {
+			p.Filter[i].When.APIKeys = withRotatedKey(p.Filter[i].When.APIKeys, oldKey, newKey)
+		}
+	}
+}
+
+// withRotatedKey appends newKey to keys when they name oldKey.
+func withRotatedKey(keys []string, oldKey, newKey string) []string {
+	if slices.Contains(keys, oldKey) && !slices.Contains(keys, newKey) {
+		return append(keys, newKey)
+	}
+	return keys
+}
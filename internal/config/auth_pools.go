@@ -0,0 +1,33 @@
+package config
+
+import "strings"
+
+// SanitizeAuthPools trims pool names and selectors and drops incomplete entries.
+func (cfg *Config) SanitizeAuthPools() {
+	if cfg == nil {
+		return
+	}
+	cfg.AuthPools = NormalizeAuthPools(cfg.AuthPools)
+	cfg.Routing.PoolPriority = normalizeStringList(cfg.Routing.PoolPriority)
+}
+
+// NormalizeAuthPools returns a copy of pools with trimmed names and selectors.
+// Entries without a name or selector are dropped.
+func NormalizeAuthPools(pools map[string]string) map[string]string {
+	if len(pools) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(pools))
+	for name, selector := range pools {
+		name = strings.TrimSpace(name)
+		selector = strings.TrimSpace(selector)
+		if name == "" || selector == "" {
+			continue
+		}
+		out[name] = selector
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package config
+
+import "strings"
+
+// DefaultBedrockRegion is used when a Bedrock entry does not specify a region.
+const DefaultBedrockRegion = "us-east-1"
+
+// BedrockKey represents the configuration for an AWS Bedrock credential used to serve
+// Claude models through the Bedrock runtime InvokeModel APIs.
+//
+// Credentials are either a static access key pair (optionally with a session token)
+// or the name of a profile in the shared AWS credentials file.
+type BedrockKey struct {
+	// Region is the AWS region hosting the Bedrock runtime endpoint (e.g. "us-west-2").
+	Region string `yaml:"region" json:"region"`
+
+	// AccessKeyID is the AWS access key ID used for SigV4 signing.
+	AccessKeyID string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+
+	// SecretAccessKey is the AWS secret access key paired with AccessKeyID.
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+
+	// SessionToken is the optional session token for temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// Profile names a profile in the shared AWS credentials file. It is used when
+	// AccessKeyID is empty and is re-read on every request so rotated credentials apply.
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "aws/claude-sonnet-4").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Tags label the credential for auth-pools selectors (e.g., team: research).
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Tenant assigns the credential to a tenant; only that tenant's clients can use it.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// Schedule limits the credential to time windows (e.g., outside working hours).
+	Schedule *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
+	// BaseURL overrides the regional Bedrock runtime endpoint
+	// (https://bedrock-runtime.{region}.amazonaws.com), e.g. for VPC endpoints.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// DisableInferenceProfile sends built-in Claude models to the bare foundation model ID
+	// instead of the cross-region inference profile derived from Region.
+	DisableInferenceProfile bool `yaml:"disable-inference-profile,omitempty" json:"disable-inference-profile,omitempty"`
+
+	// Models defines Bedrock model IDs (or inference profile ARNs) and the client-facing
+	// aliases that route to them. When empty, the built-in Claude models are exposed.
+	Models []BedrockModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// GetAPIKey returns the identity of the credential: the access key ID, or the
+// profile reference when the entry relies on the shared credentials file.
+func (k BedrockKey) GetAPIKey() string {
+	if id := strings.TrimSpace(k.AccessKeyID); id != "" {
+		return id
+	}
+	if profile := strings.TrimSpace(k.Profile); profile != "" {
+		return "profile:" + profile
+	}
+	return ""
+}
+
+// GetBaseURL returns the effective Bedrock runtime endpoint for the entry.
+func (k BedrockKey) GetBaseURL() string {
+	if base := strings.TrimSpace(k.BaseURL); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	region := strings.TrimSpace(k.Region)
+	if region == "" {
+		region = DefaultBedrockRegion
+	}
+	return "https://bedrock-runtime." + region + ".amazonaws.com"
+}
+
+// BedrockModel maps a client-facing alias to a Bedrock model ID or inference profile.
+type BedrockModel struct {
+	// Name is the Bedrock model ID, inference profile ID, or ARN used upstream.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m BedrockModel) GetName() string  { return m.Name }
+func (m BedrockModel) GetAlias() string { return m.Alias }
+
+// SanitizeBedrockKeys normalizes Bedrock credentials, dropping entries without usable
+// credentials and duplicates of the same identity and endpoint.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.BedrockKey))
+	out := cfg.BedrockKey[:0]
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		entry.Region = strings.ToLower(strings.TrimSpace(entry.Region))
+		if entry.Region == "" {
+			entry.Region = DefaultBedrockRegion
+		}
+		entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+		entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+		entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+		entry.Profile = strings.TrimSpace(entry.Profile)
+		if entry.AccessKeyID != "" && entry.SecretAccessKey == "" {
+			continue
+		}
+		if entry.AccessKeyID == "" && entry.Profile == "" {
+			continue
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.BaseURL = strings.TrimSpace(entry.BaseURL)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]BedrockModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name == "" {
+				continue
+			}
+			models = append(models, model)
+		}
+		entry.Models = models
+
+		uniqueKey := entry.GetAPIKey() + "|" + entry.GetBaseURL()
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.BedrockKey = out
+}
@@ -14,6 +14,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/payloadexpr"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
@@ -96,6 +97,9 @@ type Config struct {
 	// ClaudeKey defines a list of Claude API key configurations as specified in the YAML configuration file.
 	ClaudeKey []ClaudeKey `yaml:"claude-api-key" json:"claude-api-key"`
 
+	// BedrockKey defines AWS Bedrock credentials used to serve Claude models via SigV4-signed requests.
+	BedrockKey []BedrockKey `yaml:"bedrock-api-key" json:"bedrock-api-key"`
+
 	// OpenAICompatibility defines OpenAI API compatibility configurations for external providers.
 	OpenAICompatibility []OpenAICompatibility `yaml:"openai-compatibility" json:"openai-compatibility"`
 
@@ -111,7 +115,7 @@ type Config struct {
 
 	// OAuthModelAlias defines global model name aliases for OAuth/file-backed auth channels.
 	// These aliases affect both model listing and model routing for supported channels:
-	// gemini-cli, vertex, aistudio, antigravity, claude, codex, qwen, iflow.
+	// gemini-cli, vertex, aistudio, antigravity, claude, codex, qwen, iflow, copilot.
 	//
 	// NOTE: This does not apply to existing per-credential model alias features under:
 	// gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.
@@ -120,6 +124,9 @@ type Config struct {
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
+	// TranslatorPlugins loads Starlark request/response transforms from a plugin directory.
+	TranslatorPlugins TranslatorPluginsConfig `yaml:"translator-plugins,omitempty" json:"translator-plugins,omitempty"`
+
 	// ReverseProxies defines reverse proxy endpoints for routing traffic.
 	ReverseProxies []ReverseProxy `yaml:"reverse-proxies,omitempty" json:"reverse-proxies,omitempty"`
 
@@ -133,11 +140,17 @@ type Config struct {
 	ProxyRouting ProxyRouting `yaml:"proxy-routing,omitempty" json:"proxy-routing,omitempty"`
 
 	// ProxyRoutingAuth defines which reverse proxy each auth account should use.
-	// Keys can be auth ID, auth index, or auth file name.
+	// Keys can be auth ID, auth index, auth file name, or "pool:<name>" for an auth pool.
 	ProxyRoutingAuth map[string]string `yaml:"proxy-routing-auth,omitempty" json:"proxy-routing-auth,omitempty"`
 
+	// AuthPools names groups of credentials selected by tags. Values are tag selectors such as
+	// "team=research,tier=max"; api-key-auth, proxy-routing-auth and routing.pool-priority
+	// reference a pool as "pool:<name>".
+	AuthPools map[string]string `yaml:"auth-pools,omitempty" json:"auth-pools,omitempty"`
+
 	// APIKeyAuth defines which auth accounts each client API key can access.
-	// Keys are client API keys (from top-level api-keys). Values can be auth ID, auth index, or auth file name.
+	// Keys are client API keys (from top-level api-keys). Values can be auth ID, auth index, auth file name,
+	// or "pool:<name>" for every credential of an auth pool.
 	// When a client key is not listed, it can access all accounts (default behavior).
 	APIKeyAuth map[string][]string `yaml:"api-key-auth,omitempty" json:"api-key-auth,omitempty"`
 
@@ -146,6 +159,27 @@ type Config struct {
 	// If a key is not listed, it never expires.
 	APIKeyExpiry map[string]string `yaml:"api-key-expiry,omitempty" json:"api-key-expiry,omitempty"`
 
+	// APIKeyRotations maps client API keys replaced through key rotation to their replacement.
+	// Usage views and budgets count the spend of both keys, and a replaced key cannot be rotated again.
+	APIKeyRotations map[string]string `yaml:"api-key-rotations,omitempty" json:"api-key-rotations,omitempty"`
+
+	// APIKeyBudgets limits the spend of client API keys, measured in cost (USD) or tokens.
+	// Keys are client API keys (from top-level api-keys). Keys that are not listed are unlimited.
+	APIKeyBudgets map[string]APIKeyBudget `yaml:"api-key-budgets,omitempty" json:"api-key-budgets,omitempty"`
+
+	// Pricing configures the model price table used to compute the cost of each request.
+	Pricing PricingConfig `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+
+	// Drain controls graceful draining before shutdown (SIGTERM or POST /v0/management/drain).
+	Drain DrainConfig `yaml:"drain,omitempty" json:"drain,omitempty"`
+
+	// ExternalExecutors accepts out-of-process workers that serve their own providers.
+	ExternalExecutors ExternalExecutorConfig `yaml:"external-executors,omitempty" json:"external-executors,omitempty"`
+
+	// Tenants isolates teams: each gets its own auth files, provider keys, client API keys,
+	// payload rules, usage partition and management key.
+	Tenants []TenantConfig `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
 }
 
@@ -190,15 +224,55 @@ type QuotaExceeded struct {
 	SwitchPreviewModel bool `yaml:"switch-preview-model" json:"switch-preview-model"`
 }
 
+// TranslatorPluginsConfig configures runtime-loaded translator plugins.
+type TranslatorPluginsConfig struct {
+	// Enabled turns plugin loading on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Dir is the directory scanned for *.star plugin files; it is watched for changes.
+	Dir string `yaml:"dir" json:"dir"`
+	// MaxSteps bounds the Starlark execution steps of a single hook call (0 uses the default).
+	MaxSteps uint64 `yaml:"max-steps,omitempty" json:"max-steps,omitempty"`
+}
+
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first", "session".
+	// Supported values: "round-robin" (default), "fill-first", "session", "weighted".
 	// When set to "session", the Session config below is used for session-aware routing.
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
 	// Session configures session-aware routing (sticky sessions + scoring).
 	// Only effective when Strategy is set to "session".
 	Session SessionRoutingConfig `yaml:"session,omitempty" json:"session,omitempty"`
+	// Weighted tunes the cost- and latency-aware "weighted" strategy.
+	Weighted WeightedRoutingConfig `yaml:"weighted,omitempty" json:"weighted,omitempty"`
+	// PoolPriority lists auth pools in order of preference. The selector only considers
+	// credentials of the first pool that has an available one; credentials outside every
+	// listed pool are used last.
+	PoolPriority []string `yaml:"pool-priority,omitempty" json:"pool-priority,omitempty"`
+}
+
+// WeightedRoutingConfig tunes the "weighted" strategy. Every available credential gets a score
+// from its operator weight, observed latency, price and quota health, and one is picked at
+// random in proportion to its score.
+type WeightedRoutingConfig struct {
+	// LatencyWeight, PriceWeight and QuotaWeight set how strongly each factor counts; 0 ignores
+	// the factor. When all three are zero they default to 1.
+	LatencyWeight float64 `yaml:"latency-weight,omitempty" json:"latency-weight,omitempty"`
+	PriceWeight   float64 `yaml:"price-weight,omitempty" json:"price-weight,omitempty"`
+	QuotaWeight   float64 `yaml:"quota-weight,omitempty" json:"quota-weight,omitempty"`
+	// LatencySmoothing is the weight (0-1] of a new sample in the latency averages. Default 0.2.
+	LatencySmoothing float64 `yaml:"latency-smoothing,omitempty" json:"latency-smoothing,omitempty"`
+	// SmallRequestTokens is the estimated prompt size up to which a request counts as small and
+	// prefers API-key credentials. Default 4000.
+	SmallRequestTokens int `yaml:"small-request-tokens,omitempty" json:"small-request-tokens,omitempty"`
+	// LongSessionRequests is the number of requests after which a session counts as a long agent
+	// session and prefers subscription OAuth accounts. Default 5.
+	LongSessionRequests int `yaml:"long-session-requests,omitempty" json:"long-session-requests,omitempty"`
+	// PreferenceBoost multiplies the score of the preferred credential kind. Default 4; 1 disables it.
+	PreferenceBoost float64 `yaml:"preference-boost,omitempty" json:"preference-boost,omitempty"`
+	// Weights are static operator weights keyed by auth ID, auth index, auth file name or
+	// "pool:<name>". Unlisted credentials weigh 1; 0 only uses the credential as a last resort.
+	Weights map[string]float64 `yaml:"weights,omitempty" json:"weights,omitempty"`
 }
 
 // SessionRoutingConfig configures session stickiness and scoring.
@@ -239,6 +313,12 @@ type SessionRoutingConfig struct {
 	// "exponential" (default): 1 - exp(-LoadWeight * loadCount / avgLoad) - ensures even distribution
 	// "linear": loadCount / (loadCount + 1) - legacy behavior
 	LoadBalanceMode string `yaml:"load-balance-mode,omitempty" json:"load-balance-mode,omitempty"`
+	// Persist stores session bindings and health statistics in the token store so sticky
+	// routing survives restarts and is shared between replicas using the same store. The git
+	// store keeps state in its local clone and refuses to start with this option.
+	Persist bool `yaml:"persist,omitempty" json:"persist,omitempty"`
+	// PersistIntervalSeconds controls how often changed state is written back (default 10).
+	PersistIntervalSeconds int `yaml:"persist-interval-seconds,omitempty" json:"persist-interval-seconds,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -318,14 +398,36 @@ type PayloadConfig struct {
 	Override []PayloadRule `yaml:"override" json:"override"`
 	// OverrideRaw defines rules that always set raw JSON values, overwriting any existing values.
 	OverrideRaw []PayloadRule `yaml:"override-raw" json:"override-raw"`
+	// Append defines rules that append values to JSON arrays (e.g. inject a tool or message).
+	Append []PayloadRule `yaml:"append,omitempty" json:"append,omitempty"`
+	// Prepend defines rules that insert values at the start of JSON arrays (e.g. a system message).
+	Prepend []PayloadRule `yaml:"prepend,omitempty" json:"prepend,omitempty"`
 	// Filter defines rules that remove parameters from the payload by JSON path.
 	Filter []PayloadFilterRule `yaml:"filter" json:"filter"`
 }
 
+// PayloadCondition narrows a payload rule beyond model and protocol. All set fields must match.
+type PayloadCondition struct {
+	// APIKeys limits the rule to requests authenticated with one of these client API keys.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+	// SourceFormats limits the rule to requests received in these client formats
+	// (e.g., "openai", "openai-response", "claude", "gemini").
+	SourceFormats []string `yaml:"source-formats,omitempty" json:"source-formats,omitempty"`
+	// Stream limits the rule to streaming (true) or non-streaming (false) requests.
+	Stream *bool `yaml:"stream,omitempty" json:"stream,omitempty"`
+	// Headers maps client request header names to value patterns ('*' wildcard);
+	// an empty pattern only requires the header to be present.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Expr is a boolean expression over the translated payload, e.g. "len(tools) > 20".
+	Expr string `yaml:"expr,omitempty" json:"expr,omitempty"`
+}
+
 // PayloadFilterRule describes a rule to remove specific JSON paths from matching model payloads.
 type PayloadFilterRule struct {
 	// Models lists model entries with name pattern and protocol constraint.
 	Models []PayloadModelRule `yaml:"models" json:"models"`
+	// When adds optional request conditions.
+	When *PayloadCondition `yaml:"when,omitempty" json:"when,omitempty"`
 	// Params lists JSON paths (gjson/sjson syntax) to remove from the payload.
 	Params []string `yaml:"params" json:"params"`
 }
@@ -334,8 +436,12 @@ type PayloadFilterRule struct {
 type PayloadRule struct {
 	// Models lists model entries with name pattern and protocol constraint.
 	Models []PayloadModelRule `yaml:"models" json:"models"`
+	// When adds optional request conditions.
+	When *PayloadCondition `yaml:"when,omitempty" json:"when,omitempty"`
 	// Params maps JSON paths (gjson/sjson syntax) to values written into the payload.
 	// For *-raw rules, values are treated as raw JSON fragments (strings are used as-is).
+	// For append/prepend rules, values are added to the array at the path; a list value
+	// adds each of its elements.
 	Params map[string]any `yaml:"params" json:"params"`
 }
 
@@ -379,6 +485,15 @@ type ClaudeKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tags label the credential for auth-pools selectors (e.g., team: research).
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Tenant assigns the credential to a tenant; only that tenant's clients can use it.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// Schedule limits the credential to time windows (e.g., outside working hours).
+	Schedule *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
 	// BaseURL is the base URL for the Claude API endpoint.
 	// If empty, the default Claude API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -395,6 +510,10 @@ type ClaudeKey struct {
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
 
+	// Batches forwards /v1/messages/batches to this key's upstream instead of running
+	// batches locally. Enable only for upstreams that implement the Message Batches API.
+	Batches bool `yaml:"batches,omitempty" json:"batches,omitempty"`
+
 	// Cloak configures request cloaking for non-Claude-Code clients.
 	Cloak *CloakConfig `yaml:"cloak,omitempty" json:"cloak,omitempty"`
 }
@@ -427,6 +546,15 @@ type CodexKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-5-codex").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tags label the credential for auth-pools selectors (e.g., team: research).
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Tenant assigns the credential to a tenant; only that tenant's clients can use it.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// Schedule limits the credential to time windows (e.g., outside working hours).
+	Schedule *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
 	// BaseURL is the base URL for the Codex API endpoint.
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -472,6 +600,15 @@ type GeminiKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gemini-3-pro-preview").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tags label the credential for auth-pools selectors (e.g., team: research).
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Tenant assigns the credential to a tenant; only that tenant's clients can use it.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// Schedule limits the credential to time windows (e.g., outside working hours).
+	Schedule *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
 	// BaseURL optionally overrides the Gemini API endpoint.
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
@@ -516,6 +653,15 @@ type OpenAICompatibility struct {
 	// Prefix optionally namespaces model aliases for this provider (e.g., "teamA/kimi-k2").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tags label the credential for auth-pools selectors (e.g., team: research).
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Tenant assigns the credential to a tenant; only that tenant's clients can use it.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// Schedule limits the credential to time windows (e.g., outside working hours).
+	Schedule *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
 	// BaseURL is the base URL for the external OpenAI-compatible API endpoint.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
@@ -527,8 +673,49 @@ type OpenAICompatibility struct {
 
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Azure switches the provider to Azure OpenAI request conventions when set.
+	Azure *AzureOpenAIConfig `yaml:"azure,omitempty" json:"azure,omitempty"`
+}
+
+// AzureOpenAIConfig configures an OpenAI-compatibility provider that targets Azure OpenAI.
+// BaseURL is the resource endpoint (e.g. "https://my-resource.openai.azure.com") and each
+// model Name is the deployment that serves the client-facing Alias.
+type AzureOpenAIConfig struct {
+	// APIVersion is sent as the api-version query parameter. The value "v1" selects the
+	// deployment-agnostic /openai/v1 API, which takes the deployment in the request body.
+	APIVersion string `yaml:"api-version" json:"api-version"`
+
+	// Responses forwards OpenAI Responses API requests to the Azure /responses endpoint
+	// unchanged instead of translating them to Chat Completions.
+	Responses bool `yaml:"responses,omitempty" json:"responses,omitempty"`
+
+	// Entra authenticates with a Microsoft Entra ID service principal instead of API keys.
+	Entra *AzureEntraConfig `yaml:"entra,omitempty" json:"entra,omitempty"`
+}
+
+// AzureEntraConfig holds Microsoft Entra ID client-credential settings.
+type AzureEntraConfig struct {
+	// TenantID is the directory (tenant) ID of the service principal.
+	TenantID string `yaml:"tenant-id" json:"tenant-id"`
+
+	// ClientID is the application (client) ID of the service principal.
+	ClientID string `yaml:"client-id" json:"client-id"`
+
+	// ClientSecret is the client secret of the service principal.
+	ClientSecret string `yaml:"client-secret" json:"client-secret"`
+
+	// Scope overrides the token scope; defaults to "https://cognitiveservices.azure.com/.default".
+	Scope string `yaml:"scope,omitempty" json:"scope,omitempty"`
+
+	// AuthorityHost overrides the login endpoint for sovereign clouds;
+	// defaults to "https://login.microsoftonline.com".
+	AuthorityHost string `yaml:"authority-host,omitempty" json:"authority-host,omitempty"`
 }
 
+// DefaultAzureOpenAIAPIVersion is used when an Azure provider does not set api-version.
+const DefaultAzureOpenAIAPIVersion = "2024-10-21"
+
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
 type OpenAICompatibilityAPIKey struct {
 	// APIKey is the authentication key for accessing the external API services.
@@ -670,6 +857,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize per-client API key expiry timestamps.
 	cfg.SanitizeAPIKeyExpiry()
 
+	// Drop incomplete API key rotation entries.
+	cfg.SanitizeAPIKeyRotations()
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
@@ -682,6 +872,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Claude key headers
 	cfg.SanitizeClaudeKeys()
 
+	// Sanitize Bedrock credentials: drop entries without access keys or profile
+	cfg.SanitizeBedrockKeys()
+
 	// Sanitize OpenAI compatibility providers: drop entries without base-url
 	cfg.SanitizeOpenAICompatibility()
 
@@ -694,6 +887,20 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize prompt profiles and drop empty entries.
+	cfg.SanitizePromptProfiles()
+
+	// Normalize auth pools and pool priority.
+	cfg.SanitizeAuthPools()
+
+	// Normalize model prices and per-key budgets.
+	cfg.SanitizePricing()
+	cfg.SanitizeAPIKeyBudgets()
+	cfg.SanitizeDrain()
+	cfg.SanitizeSchedules()
+	cfg.SanitizeExternalExecutors()
+	cfg.SanitizeTenants()
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
@@ -715,8 +922,60 @@ func (cfg *Config) SanitizePayloadRules() {
 	if cfg == nil {
 		return
 	}
-	cfg.Payload.DefaultRaw = sanitizePayloadRawRules(cfg.Payload.DefaultRaw, "default-raw")
-	cfg.Payload.OverrideRaw = sanitizePayloadRawRules(cfg.Payload.OverrideRaw, "override-raw")
+	sanitizePayloadConfig(&cfg.Payload)
+}
+
+func sanitizePayloadConfig(p *PayloadConfig) {
+	p.DefaultRaw = sanitizePayloadRawRules(p.DefaultRaw, "default-raw")
+	p.OverrideRaw = sanitizePayloadRawRules(p.OverrideRaw, "override-raw")
+	p.Default = sanitizePayloadRuleConditions(p.Default, "default")
+	p.DefaultRaw = sanitizePayloadRuleConditions(p.DefaultRaw, "default-raw")
+	p.Override = sanitizePayloadRuleConditions(p.Override, "override")
+	p.OverrideRaw = sanitizePayloadRuleConditions(p.OverrideRaw, "override-raw")
+	p.Append = sanitizePayloadRuleConditions(p.Append, "append")
+	p.Prepend = sanitizePayloadRuleConditions(p.Prepend, "prepend")
+	if len(p.Filter) > 0 {
+		out := make([]PayloadFilterRule, 0, len(p.Filter))
+		for i, rule := range p.Filter {
+			if err := ValidatePayloadCondition(rule.When); err != nil {
+				logDroppedPayloadCondition("filter", i, err)
+				continue
+			}
+			out = append(out, rule)
+		}
+		p.Filter = out
+	}
+}
+
+// ValidatePayloadCondition reports whether a rule condition's expression compiles.
+func ValidatePayloadCondition(cond *PayloadCondition) error {
+	if cond == nil || strings.TrimSpace(cond.Expr) == "" {
+		return nil
+	}
+	_, err := payloadexpr.Cached(strings.TrimSpace(cond.Expr))
+	return err
+}
+
+func sanitizePayloadRuleConditions(rules []PayloadRule, section string) []PayloadRule {
+	if len(rules) == 0 {
+		return rules
+	}
+	out := make([]PayloadRule, 0, len(rules))
+	for i, rule := range rules {
+		if err := ValidatePayloadCondition(rule.When); err != nil {
+			logDroppedPayloadCondition(section, i, err)
+			continue
+		}
+		out = append(out, rule)
+	}
+	return out
+}
+
+func logDroppedPayloadCondition(section string, index int, err error) {
+	log.WithFields(log.Fields{
+		"section":    section,
+		"rule_index": index + 1,
+	}).Warnf("payload rule dropped: %v", err)
 }
 
 // SanitizeAPIKeyAuth normalizes per-client API key auth permissions.
@@ -837,6 +1096,27 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 			// Skip providers with no base-url; treated as removed
 			continue
 		}
+		if e.Azure != nil {
+			azure := *e.Azure
+			azure.APIVersion = strings.TrimSpace(azure.APIVersion)
+			if azure.APIVersion == "" {
+				azure.APIVersion = DefaultAzureOpenAIAPIVersion
+			}
+			if azure.Entra != nil {
+				entra := *azure.Entra
+				entra.TenantID = strings.TrimSpace(entra.TenantID)
+				entra.ClientID = strings.TrimSpace(entra.ClientID)
+				entra.ClientSecret = strings.TrimSpace(entra.ClientSecret)
+				entra.Scope = strings.TrimSpace(entra.Scope)
+				entra.AuthorityHost = strings.TrimRight(strings.TrimSpace(entra.AuthorityHost), "/")
+				if entra.TenantID == "" || entra.ClientID == "" || entra.ClientSecret == "" {
+					azure.Entra = nil
+				} else {
+					azure.Entra = &entra
+				}
+			}
+			e.Azure = &azure
+		}
 		out = append(out, e)
 	}
 	cfg.OpenAICompatibility = out
@@ -1124,7 +1404,10 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "oauth-model-alias")
 	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "api-key-auth")
 	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "api-key-expiry")
+	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "api-key-rotations")
 	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "proxy-routing-auth")
+	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "auth-pools")
+	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "api-key-budgets")
 
 	// Merge generated into original in-place, preserving comments/order of existing nodes.
 	mergeMappingPreserve(original.Content[0], generated.Content[0])
@@ -1950,6 +2233,9 @@ type ProxyRouting struct {
 	// Qwen specifies the reverse proxy ID for Qwen requests.
 	Qwen string `yaml:"qwen,omitempty" json:"qwen,omitempty"`
 
+	// Copilot specifies the reverse proxy ID for GitHub Copilot requests.
+	Copilot string `yaml:"copilot,omitempty" json:"copilot,omitempty"`
+
 	// IFlow specifies the reverse proxy ID for IFlow requests.
 	IFlow string `yaml:"iflow,omitempty" json:"iflow,omitempty"`
 }
@@ -0,0 +1,45 @@
+package config
+
+import "time"
+
+const (
+	// DefaultDrainTimeout is how long a drain waits for active requests when unset.
+	DefaultDrainTimeout = 5 * time.Minute
+	// DefaultDrainRetryAfter is the Retry-After hint sent while draining when unset.
+	DefaultDrainRetryAfter = 30
+)
+
+// DrainConfig controls how the server drains before shutting down.
+type DrainConfig struct {
+	// Timeout is the number of seconds to wait for active requests and streams to finish
+	// before the server shuts down anyway. Zero uses DefaultDrainTimeout.
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// RetryAfter is the Retry-After value, in seconds, of 503 responses sent to new requests
+	// while draining. Zero uses DefaultDrainRetryAfter.
+	RetryAfter int `yaml:"retry-after,omitempty" json:"retry-after,omitempty"`
+}
+
+// SanitizeDrain drops negative drain settings so the defaults apply.
+func (cfg *Config) SanitizeDrain() {
+	if cfg == nil {
+		return
+	}
+	cfg.Drain.Timeout = max(cfg.Drain.Timeout, 0)
+	cfg.Drain.RetryAfter = max(cfg.Drain.RetryAfter, 0)
+}
+
+// TimeoutDuration returns the drain deadline with the default applied.
+func (d DrainConfig) TimeoutDuration() time.Duration {
+	if d.Timeout <= 0 {
+		return DefaultDrainTimeout
+	}
+	return time.Duration(d.Timeout) * time.Second
+}
+
+// RetryAfterSeconds returns the Retry-After hint with the default applied.
+func (d DrainConfig) RetryAfterSeconds() int {
+	if d.RetryAfter <= 0 {
+		return DefaultDrainRetryAfter
+	}
+	return d.RetryAfter
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ExternalExecutorConfig lets out-of-process workers serve providers over the websocket
+// protocol described in docs/external-executors.md.
+type ExternalExecutorConfig struct {
+	// Enable accepts worker connections on /v1/executor/ws.
+	Enable bool `yaml:"enable" json:"enable"`
+	// SecretKeys authenticate workers, which send one as "Authorization: Bearer <key>".
+	// Workers are rejected while the list is empty.
+	SecretKeys []string `yaml:"secret-keys,omitempty" json:"secret-keys,omitempty"`
+}
+
+// SanitizeExternalExecutors trims worker secret keys and drops empty ones.
+func (cfg *Config) SanitizeExternalExecutors() {
+	if cfg == nil {
+		return
+	}
+	keys := cfg.ExternalExecutors.SecretKeys[:0]
+	for _, key := range cfg.ExternalExecutors.SecretKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	cfg.ExternalExecutors.SecretKeys = keys
+	if cfg.ExternalExecutors.Enable && len(keys) == 0 {
+		log.Warn("external-executors: enabled without secret-keys; worker connections will be rejected")
+	}
+}
+
+// AllowsWorkerKey reports whether key may connect an external executor worker.
+func (c ExternalExecutorConfig) AllowsWorkerKey(key string) bool {
+	if !c.Enable || key == "" {
+		return false
+	}
+	for _, allowed := range c.SecretKeys {
+		if subtle.ConstantTimeCompare([]byte(allowed), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"math"
+	"strings"
+)
+
+// Budget units and periods accepted by api-key-budgets.
+const (
+	BudgetUnitCost   = "cost"
+	BudgetUnitTokens = "tokens"
+
+	BudgetPeriodDaily   = "daily"
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodTotal   = "total"
+)
+
+// PricingConfig configures the model price table.
+type PricingConfig struct {
+	// File is a YAML price table with the same "models" list as below. Its entries take
+	// precedence over the built-in table. It is read when the configuration is loaded.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// DisableDefaults drops the built-in price table so only File and Models are used.
+	DisableDefaults bool `yaml:"disable-defaults,omitempty" json:"disable-defaults,omitempty"`
+
+	// Models add or override prices and take precedence over File and the built-in table.
+	Models []ModelPrice `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// ModelPrice sets token prices for models matching a pattern. Prices are USD per million tokens.
+type ModelPrice struct {
+	// Match is a model name pattern where '*' matches any sequence of characters.
+	Match string `yaml:"match" json:"match"`
+
+	// Provider limits the entry to one provider (e.g. "gemini-cli", "vertex" or an
+	// openai-compatibility name). Provider entries win over generic ones.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+
+	// Input is the price of uncached prompt tokens.
+	Input float64 `yaml:"input" json:"input"`
+
+	// Output is the price of completion tokens.
+	Output float64 `yaml:"output" json:"output"`
+
+	// Cached is the price of prompt tokens served from cache. Zero bills them at Input.
+	Cached float64 `yaml:"cached,omitempty" json:"cached,omitempty"`
+
+	// Reasoning is the price of reasoning tokens. Zero bills them at Output.
+	Reasoning float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+}
+
+// APIKeyBudget caps the spend of a client API key over a period.
+type APIKeyBudget struct {
+	// Limit is the maximum spend in Unit. Requests are rejected with 429 once it is reached.
+	Limit float64 `yaml:"limit" json:"limit"`
+
+	// Unit is "cost" (USD, default) or "tokens".
+	Unit string `yaml:"unit,omitempty" json:"unit,omitempty"`
+
+	// Period is "daily", "monthly" (default) or "total".
+	Period string `yaml:"period,omitempty" json:"period,omitempty"`
+}
+
+// SanitizePricing trims price patterns and drops entries without a pattern or with
+// negative prices.
+func (cfg *Config) SanitizePricing() {
+	if cfg == nil {
+		return
+	}
+	cfg.Pricing.File = strings.TrimSpace(cfg.Pricing.File)
+	cfg.Pricing.Models = NormalizeModelPrices(cfg.Pricing.Models)
+}
+
+// NormalizeModelPrices returns a copy of prices with trimmed patterns and providers.
+// Entries without a pattern or with a negative or non-finite price are dropped.
+func NormalizeModelPrices(prices []ModelPrice) []ModelPrice {
+	if len(prices) == 0 {
+		return nil
+	}
+	out := make([]ModelPrice, 0, len(prices))
+	for _, price := range prices {
+		price.Match = strings.TrimSpace(price.Match)
+		price.Provider = strings.ToLower(strings.TrimSpace(price.Provider))
+		if price.Match == "" {
+			continue
+		}
+		if !validPrice(price.Input) || !validPrice(price.Output) || !validPrice(price.Cached) || !validPrice(price.Reasoning) {
+			continue
+		}
+		out = append(out, price)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func validPrice(v float64) bool {
+	return v >= 0 && !math.IsInf(v, 0) && !math.IsNaN(v)
+}
+
+// SanitizeAPIKeyBudgets normalizes per-client API key budgets.
+func (cfg *Config) SanitizeAPIKeyBudgets() {
+	if cfg == nil {
+		return
+	}
+	cfg.APIKeyBudgets = NormalizeAPIKeyBudgets(cfg.APIKeyBudgets)
+}
+
+// NormalizeAPIKeyBudgets trims keys, fills in the default unit and period and drops entries
+// with an empty key, a non-positive limit or an unknown unit or period.
+func NormalizeAPIKeyBudgets(entries map[string]APIKeyBudget) map[string]APIKeyBudget {
+	if len(entries) == 0 {
+		return nil
+	}
+	out := make(map[string]APIKeyBudget, len(entries))
+	for rawKey, budget := range entries {
+		key := strings.TrimSpace(rawKey)
+		if key == "" || !validPrice(budget.Limit) || budget.Limit <= 0 {
+			continue
+		}
+		budget.Unit = strings.ToLower(strings.TrimSpace(budget.Unit))
+		if budget.Unit == "" {
+			budget.Unit = BudgetUnitCost
+		}
+		budget.Period = strings.ToLower(strings.TrimSpace(budget.Period))
+		if budget.Period == "" {
+			budget.Period = BudgetPeriodMonthly
+		}
+		switch budget.Unit {
+		case BudgetUnitCost, BudgetUnitTokens:
+		default:
+			continue
+		}
+		switch budget.Period {
+		case BudgetPeriodDaily, BudgetPeriodMonthly, BudgetPeriodTotal:
+		default:
+			continue
+		}
+		out[key] = budget
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package config
+
+import "strings"
+
+// Prompt profile positions relative to the client's own system prompt.
+const (
+	PromptPositionPrepend = "prepend"
+	PromptPositionAppend  = "append"
+)
+
+// PromptProfile injects a standing system prompt and named prompt templates into requests
+// from matching clients.
+type PromptProfile struct {
+	// Name identifies the profile in the management API.
+	Name string `yaml:"name" json:"name"`
+
+	// APIKeys limits the profile to these client API keys. Empty applies to all clients.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Models limits the profile to requested model names or aliases ('*' wildcard).
+	// Empty applies to all models.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Label is substituted for {{key_label}}. Defaults to the profile name.
+	Label string `yaml:"label,omitempty" json:"label,omitempty"`
+
+	// System is the standing system prompt.
+	System string `yaml:"system,omitempty" json:"system,omitempty"`
+
+	// Position is "prepend" (default) or "append" relative to the client's system prompt.
+	Position string `yaml:"position,omitempty" json:"position,omitempty"`
+
+	// Templates are named prompts a client selects with a "+name" model suffix or the
+	// X-Prompt-Template header.
+	Templates map[string]string `yaml:"templates,omitempty" json:"templates,omitempty"`
+}
+
+// SanitizePromptProfiles normalizes prompt profiles and drops unnamed or empty entries and
+// duplicate names.
+func (cfg *Config) SanitizePromptProfiles() {
+	if cfg == nil || len(cfg.PromptProfiles) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.PromptProfiles))
+	out := make([]PromptProfile, 0, len(cfg.PromptProfiles))
+	for _, profile := range cfg.PromptProfiles {
+		NormalizePromptProfile(&profile)
+		if profile.Name == "" || (profile.System == "" && len(profile.Templates) == 0) {
+			continue
+		}
+		key := strings.ToLower(profile.Name)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, profile)
+	}
+	cfg.PromptProfiles = out
+}
+
+// NormalizePromptProfile trims the fields of a profile in place.
+func NormalizePromptProfile(profile *PromptProfile) {
+	if profile == nil {
+		return
+	}
+	profile.Name = strings.TrimSpace(profile.Name)
+	profile.Label = strings.TrimSpace(profile.Label)
+	profile.System = strings.TrimSpace(profile.System)
+	profile.APIKeys = normalizeStringList(profile.APIKeys)
+	profile.Models = normalizeStringList(profile.Models)
+	switch strings.ToLower(strings.TrimSpace(profile.Position)) {
+	case PromptPositionAppend:
+		profile.Position = PromptPositionAppend
+	default:
+		profile.Position = ""
+	}
+	if len(profile.Templates) == 0 {
+		profile.Templates = nil
+		return
+	}
+	templates := make(map[string]string, len(profile.Templates))
+	for name, text := range profile.Templates {
+		name = strings.TrimSpace(name)
+		text = strings.TrimSpace(text)
+		if name == "" || text == "" {
+			continue
+		}
+		templates[name] = text
+	}
+	if len(templates) == 0 {
+		templates = nil
+	}
+	profile.Templates = templates
+}
+
+func normalizeStringList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, exists := seen[value]; exists {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Schedule restricts a credential to time windows. Outside every window the credential is
+// treated like a cooled-down one and requests report when it becomes available again.
+type Schedule struct {
+	// Timezone is an IANA zone name (e.g. "Europe/Berlin"). Empty uses the server's local zone.
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	// Windows are cron-like "<weekdays> [HH:MM-HH:MM]" entries, e.g. "mon-fri 18:00-08:00" or
+	// "sat,sun". Weekdays use cron day-of-week syntax (names or 0-6, ranges, lists, "*"). A range
+	// ending before it starts runs past midnight into the next day; without a range the whole day
+	// is available. The credential is available while any window matches.
+	Windows []string `yaml:"windows,omitempty" json:"windows,omitempty"`
+}
+
+// ScheduleWindow is a parsed schedule window.
+type ScheduleWindow struct {
+	// Days has bit n set when the window starts on time.Weekday(n).
+	Days uint8
+	// Start and End are offsets from midnight; End <= Start wraps to the next day.
+	Start time.Duration
+	End   time.Duration
+}
+
+// CompiledSchedule is a validated schedule ready for evaluation.
+type CompiledSchedule struct {
+	location *time.Location
+	windows  []ScheduleWindow
+}
+
+// NormalizeSchedule trims the schedule and returns nil when it has no windows.
+func NormalizeSchedule(schedule *Schedule) *Schedule {
+	if schedule == nil {
+		return nil
+	}
+	out := &Schedule{Timezone: strings.TrimSpace(schedule.Timezone)}
+	for _, window := range schedule.Windows {
+		if window = strings.Join(strings.Fields(window), " "); window != "" {
+			out.Windows = append(out.Windows, window)
+		}
+	}
+	if len(out.Windows) == 0 {
+		return nil
+	}
+	return out
+}
+
+// SanitizeSchedules normalizes the schedules of API key entries. Invalid schedules are kept
+// and logged; such a credential stays unavailable until the schedule is fixed.
+func (cfg *Config) SanitizeSchedules() {
+	if cfg == nil {
+		return
+	}
+	sanitize := func(kind string, index int, schedule **Schedule) {
+		*schedule = NormalizeSchedule(*schedule)
+		if _, err := CompileSchedule(*schedule); err != nil {
+			log.Warnf("%s[%d]: %v; the credential stays unavailable", kind, index, err)
+		}
+	}
+	for i := range cfg.GeminiKey {
+		sanitize("gemini-api-key", i, &cfg.GeminiKey[i].Schedule)
+	}
+	for i := range cfg.ClaudeKey {
+		sanitize("claude-api-key", i, &cfg.ClaudeKey[i].Schedule)
+	}
+	for i := range cfg.CodexKey {
+		sanitize("codex-api-key", i, &cfg.CodexKey[i].Schedule)
+	}
+	for i := range cfg.BedrockKey {
+		sanitize("bedrock-api-key", i, &cfg.BedrockKey[i].Schedule)
+	}
+	for i := range cfg.VertexCompatAPIKey {
+		sanitize("vertex-api-key", i, &cfg.VertexCompatAPIKey[i].Schedule)
+	}
+	for i := range cfg.OpenAICompatibility {
+		sanitize("openai-compatibility", i, &cfg.OpenAICompatibility[i].Schedule)
+	}
+}
+
+// CompileSchedule validates schedule. A nil schedule compiles to nil (always available).
+func CompileSchedule(schedule *Schedule) (*CompiledSchedule, error) {
+	schedule = NormalizeSchedule(schedule)
+	if schedule == nil {
+		return nil, nil
+	}
+	location := time.Local
+	if schedule.Timezone != "" {
+		loaded, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule timezone %q: %w", schedule.Timezone, err)
+		}
+		location = loaded
+	}
+	compiled := &CompiledSchedule{location: location}
+	for _, raw := range schedule.Windows {
+		window, err := ParseScheduleWindow(raw)
+		if err != nil {
+			return nil, err
+		}
+		compiled.windows = append(compiled.windows, window)
+	}
+	return compiled, nil
+}
+
+// ParseScheduleWindow parses a "<weekdays> [HH:MM-HH:MM]" window.
+func ParseScheduleWindow(raw string) (ScheduleWindow, error) {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 || len(fields) > 2 {
+		return ScheduleWindow{}, fmt.Errorf("invalid schedule window %q: want \"<weekdays> [HH:MM-HH:MM]\"", raw)
+	}
+	days, err := parseWeekdays(fields[0])
+	if err != nil {
+		return ScheduleWindow{}, fmt.Errorf("invalid schedule window %q: %w", raw, err)
+	}
+	window := ScheduleWindow{Days: days, End: 24 * time.Hour}
+	if len(fields) == 2 {
+		start, end, ok := strings.Cut(fields[1], "-")
+		if !ok {
+			return ScheduleWindow{}, fmt.Errorf("invalid schedule window %q: time range must be HH:MM-HH:MM", raw)
+		}
+		if window.Start, err = parseClock(start, false); err == nil {
+			window.End, err = parseClock(end, true)
+		}
+		if err != nil {
+			return ScheduleWindow{}, fmt.Errorf("invalid schedule window %q: %w", raw, err)
+		}
+		if window.Start == window.End {
+			return ScheduleWindow{}, fmt.Errorf("invalid schedule window %q: empty time range", raw)
+		}
+	}
+	return window, nil
+}
+
+// Available reports whether now falls inside a window. When it does not, next is the start
+// of the following window, or zero when no window ever opens.
+func (s *CompiledSchedule) Available(now time.Time) (bool, time.Time) {
+	if s == nil {
+		return true, time.Time{}
+	}
+	local := now.In(s.location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)
+	// Compare wall clock offsets so daylight saving changes keep the configured hours.
+	offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+	for _, window := range s.windows {
+		if window.End > window.Start {
+			if window.has(today) && offset >= window.Start && offset < window.End {
+				return true, time.Time{}
+			}
+			continue
+		}
+		if (window.has(today) && offset >= window.Start) || (window.has(yesterday) && offset < window.End) {
+			return true, time.Time{}
+		}
+	}
+	var next time.Time
+	for day := 0; day <= 7; day++ {
+		date := midnight.AddDate(0, 0, day)
+		for _, window := range s.windows {
+			if !window.has(date.Weekday()) {
+				continue
+			}
+			start := time.Date(date.Year(), date.Month(), date.Day(), int(window.Start/time.Hour), int(window.Start%time.Hour/time.Minute), 0, 0, s.location)
+			if start.After(now) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			break
+		}
+	}
+	return false, next
+}
+
+func (w ScheduleWindow) has(day time.Weekday) bool {
+	return w.Days&(1<<uint(day)) != 0
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+func parseWeekdays(raw string) (uint8, error) {
+	var days uint8
+	for _, part := range strings.Split(strings.ToLower(raw), ",") {
+		if part == "*" {
+			days = 0x7f
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := parseWeekday(from)
+		if err != nil {
+			return 0, err
+		}
+		end := start
+		if isRange {
+			if end, err = parseWeekday(to); err != nil {
+				return 0, err
+			}
+		}
+		for day := start; ; day = (day + 1) % 7 {
+			days |= 1 << uint(day)
+			if day == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseWeekday(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if day, ok := weekdayNames[raw]; ok {
+		return day, nil
+	}
+	if len(raw) > 3 {
+		if day, ok := weekdayNames[raw[:3]]; ok {
+			return day, nil
+		}
+	}
+	day, err := strconv.Atoi(raw)
+	if err != nil || day < 0 || day > 7 {
+		return 0, fmt.Errorf("unknown weekday %q", raw)
+	}
+	// Cron accepts both 0 and 7 for Sunday.
+	return day % 7, nil
+}
+
+func parseClock(raw string, allowMidnightEnd bool) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(raw), ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && (m != 0 || !allowMidnightEnd)) {
+		return 0, fmt.Errorf("invalid time %q", raw)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
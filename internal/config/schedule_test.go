@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleAvailable(t *testing.T) {
+	compiled, err := CompileSchedule(&Schedule{Timezone: "UTC", Windows: []string{"mon-fri 18:00-08:00", "sat,sun"}})
+	if err != nil {
+		t.Fatalf("CompileSchedule() error = %v", err)
+	}
+	cases := []struct {
+		name     string
+		now      time.Time
+		want     bool
+		wantNext time.Time
+	}{
+		{"weekday evening", time.Date(2026, 3, 16, 20, 0, 0, 0, time.UTC), true, time.Time{}},
+		{"after midnight", time.Date(2026, 3, 17, 7, 59, 0, 0, time.UTC), true, time.Time{}},
+		{"working hours", time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC), false, time.Date(2026, 3, 17, 18, 0, 0, 0, time.UTC)},
+		{"monday morning after weekend", time.Date(2026, 3, 16, 7, 0, 0, 0, time.UTC), false, time.Date(2026, 3, 16, 18, 0, 0, 0, time.UTC)},
+		{"saturday", time.Date(2026, 3, 21, 12, 0, 0, 0, time.UTC), true, time.Time{}},
+		{"friday night into saturday", time.Date(2026, 3, 20, 23, 0, 0, 0, time.UTC), true, time.Time{}},
+	}
+	for _, tc := range cases {
+		got, next := compiled.Available(tc.now)
+		if got != tc.want || !next.Equal(tc.wantNext) {
+			t.Errorf("%s: Available() = %v, %v; want %v, %v", tc.name, got, next, tc.want, tc.wantNext)
+		}
+	}
+}
+
+func TestScheduleUsesTimezone(t *testing.T) {
+	compiled, err := CompileSchedule(&Schedule{Timezone: "America/New_York", Windows: []string{"* 09:00-17:00"}})
+	if err != nil {
+		t.Fatalf("CompileSchedule() error = %v", err)
+	}
+	// 14:00 UTC is 10:00 in New York during daylight saving time.
+	if ok, _ := compiled.Available(time.Date(2026, 7, 1, 14, 0, 0, 0, time.UTC)); !ok {
+		t.Fatal("expected the window to be open at 10:00 New York time")
+	}
+	if ok, _ := compiled.Available(time.Date(2026, 7, 1, 22, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("expected the window to be closed at 18:00 New York time")
+	}
+}
+
+func TestParseScheduleWindowErrors(t *testing.T) {
+	for _, raw := range []string{"", "funday", "mon 9-17", "mon 09:00-09:00", "mon 25:00-26:00", "mon 09:00-17:00 extra"} {
+		if _, err := ParseScheduleWindow(raw); err == nil {
+			t.Errorf("ParseScheduleWindow(%q) succeeded, want error", raw)
+		}
+	}
+	window, err := ParseScheduleWindow("fri-mon")
+	if err != nil {
+		t.Fatalf("ParseScheduleWindow() error = %v", err)
+	}
+	if want := uint8(1<<5 | 1<<6 | 1<<0 | 1<<1); window.Days != want {
+		t.Fatalf("days = %07b, want %07b", window.Days, want)
+	}
+	if _, err = CompileSchedule(&Schedule{Timezone: "Mars/Olympus", Windows: []string{"*"}}); err == nil {
+		t.Fatal("expected an invalid timezone error")
+	}
+}
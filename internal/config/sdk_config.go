@@ -29,6 +29,114 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// Batch configures the offline Batch API emulation (/v1/files, /v1/batches).
+	Batch BatchConfig `yaml:"batch,omitempty" json:"batch,omitempty"`
+
+	// StructuredOutput validates model output against the JSON schema requested by the client.
+	StructuredOutput StructuredOutputConfig `yaml:"structured-output,omitempty" json:"structured-output,omitempty"`
+
+	// Guardrails screens request and response content for sensitive data.
+	Guardrails GuardrailsConfig `yaml:"guardrails,omitempty" json:"guardrails,omitempty"`
+
+	// PromptProfiles inject standing system prompts and named templates per client API key or model.
+	PromptProfiles []PromptProfile `yaml:"prompt-profiles,omitempty" json:"prompt-profiles,omitempty"`
+
+	// TokenCounting controls local token estimation.
+	TokenCounting TokenCountingConfig `yaml:"token-counting,omitempty" json:"token-counting,omitempty"`
+}
+
+// TokenCountingConfig controls how prompt tokens are estimated with the embedded tokenizers.
+type TokenCountingConfig struct {
+	// DisableLocalFallback stops answering count-tokens requests locally when the upstream
+	// cannot count (unsupported, no credential available, or cooling down).
+	DisableLocalFallback bool `yaml:"disable-local-fallback,omitempty" json:"disable-local-fallback,omitempty"`
+
+	// ContextPreflight rejects requests whose estimated prompt exceeds the model's input limit
+	// with a 400 before any upstream call is made.
+	ContextPreflight bool `yaml:"context-preflight,omitempty" json:"context-preflight,omitempty"`
+}
+
+// GuardrailsConfig controls content screening of requests and responses.
+type GuardrailsConfig struct {
+	// Enabled turns on guardrail screening.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// LookaheadChars is the amount of streamed text held back before it is screened, so
+	// values split across chunks are still detected. <= 0 uses the default (64).
+	LookaheadChars int `yaml:"lookahead-chars,omitempty" json:"lookahead-chars,omitempty"`
+
+	// Rules are evaluated in order for every request they apply to.
+	Rules []GuardrailRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// GuardrailRule applies an action to content found by its detectors.
+type GuardrailRule struct {
+	// Name identifies the rule in logs.
+	Name string `yaml:"name" json:"name"`
+
+	// Models limits the rule to model name patterns ('*' wildcard). Empty applies to all models.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// APIKeys limits the rule to these client API keys. Empty applies to all clients.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Direction is "request", "response" or "both" (default).
+	Direction string `yaml:"direction,omitempty" json:"direction,omitempty"`
+
+	// Action is "tokenize" (replace with a placeholder that is restored in the response),
+	// "redact", "block" or "log". Tokenize only applies to requests.
+	Action string `yaml:"action" json:"action"`
+
+	// Detectors find the content the action applies to.
+	Detectors []GuardrailDetector `yaml:"detectors" json:"detectors"`
+
+	// Allow lists values that never trigger the rule (matched case-insensitively).
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+}
+
+// GuardrailDetector finds sensitive content.
+type GuardrailDetector struct {
+	// Type is "email", "api-key", "credit-card", "regex" or "dictionary".
+	Type string `yaml:"type" json:"type"`
+
+	// Name labels matches in placeholders and logs. Defaults to the type.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Pattern is the regular expression of a "regex" detector.
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+
+	// Words are the whole-word terms of a "dictionary" detector.
+	Words []string `yaml:"words,omitempty" json:"words,omitempty"`
+
+	// CaseSensitive makes dictionary matching case-sensitive.
+	CaseSensitive bool `yaml:"case-sensitive,omitempty" json:"case-sensitive,omitempty"`
+}
+
+// StructuredOutputConfig controls server-side validation of structured (JSON schema) output.
+type StructuredOutputConfig struct {
+	// Enabled validates the final or accumulated model output of requests that ask for
+	// JSON schema output and reports mismatches as errors.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// MaxRetries re-asks the model with the validation errors up to this many times for
+	// non-streaming requests before failing. Streams are validated once at the end because
+	// their output has already been delivered.
+	MaxRetries int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+}
+
+// BatchConfig holds limits for background batch execution.
+type BatchConfig struct {
+	// MaxConcurrency caps the number of batch requests executing at once across all jobs.
+	// <= 0 uses the default (8).
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// PerAuthConcurrency caps in-flight batch requests per available credential of a model,
+	// so background jobs never saturate a single account. <= 0 uses the default (2).
+	PerAuthConcurrency int `yaml:"per-auth-concurrency,omitempty" json:"per-auth-concurrency,omitempty"`
+
+	// MaxFileSizeMB limits uploads to /v1/files. <= 0 uses the default (100).
+	MaxFileSizeMB int `yaml:"max-file-size-mb,omitempty" json:"max-file-size-mb,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
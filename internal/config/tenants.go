@@ -0,0 +1,158 @@
+package config
+
+import (
+	"crypto/subtle"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TenantsDirName is the auth-dir subdirectory holding one directory of auth files per tenant.
+const TenantsDirName = "tenants"
+
+// TenantConfig isolates a team sharing the proxy. Its auth files live in
+// <auth-dir>/tenants/<name>, provider keys join it through their "tenant" field, and its
+// clients only reach those accounts.
+type TenantConfig struct {
+	// Name identifies the tenant; lowercase letters, digits, '-' and '_'.
+	Name string `yaml:"name" json:"name"`
+
+	// ManagementKey scopes /v0/management to the tenant. Plaintext or a bcrypt hash.
+	ManagementKey string `yaml:"management-key,omitempty" json:"-"`
+
+	// APIKeys authenticate the tenant's clients.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Payload replaces the global payload rules for the tenant's requests.
+	Payload PayloadConfig `yaml:"payload,omitempty" json:"payload,omitempty"`
+}
+
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// SanitizeTenants normalizes tenant names and keys. Tenants with invalid or duplicate names
+// are dropped, as are client API keys already used globally or by another tenant.
+func (cfg *Config) SanitizeTenants() {
+	if cfg == nil || len(cfg.Tenants) == 0 {
+		return
+	}
+	usedKeys := make(map[string]struct{}, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		usedKeys[key] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(cfg.Tenants))
+	out := make([]TenantConfig, 0, len(cfg.Tenants))
+	for i := range cfg.Tenants {
+		tenant := cfg.Tenants[i]
+		tenant.Name = strings.ToLower(strings.TrimSpace(tenant.Name))
+		if !tenantNamePattern.MatchString(tenant.Name) {
+			log.Warnf("tenants[%d]: invalid name %q dropped", i, tenant.Name)
+			continue
+		}
+		if _, dup := seen[tenant.Name]; dup {
+			log.Warnf("tenants[%d]: duplicate name %q dropped", i, tenant.Name)
+			continue
+		}
+		seen[tenant.Name] = struct{}{}
+		tenant.ManagementKey = strings.TrimSpace(tenant.ManagementKey)
+		keys := make([]string, 0, len(tenant.APIKeys))
+		for _, key := range tenant.APIKeys {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if _, used := usedKeys[key]; used {
+				log.Warnf("tenant %s: api key already in use elsewhere; dropped", tenant.Name)
+				continue
+			}
+			usedKeys[key] = struct{}{}
+			keys = append(keys, key)
+		}
+		tenant.APIKeys = keys
+		sanitizePayloadConfig(&tenant.Payload)
+		out = append(out, tenant)
+	}
+	cfg.Tenants = out
+}
+
+// Tenant returns the named tenant, or nil.
+func (cfg *Config) Tenant(name string) *TenantConfig {
+	if cfg == nil || name == "" {
+		return nil
+	}
+	for i := range cfg.Tenants {
+		if cfg.Tenants[i].Name == name {
+			return &cfg.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// TenantAPIKeys maps every tenant client API key to its tenant.
+func (cfg *Config) TenantAPIKeys() map[string]string {
+	if cfg == nil || len(cfg.Tenants) == 0 {
+		return nil
+	}
+	out := make(map[string]string)
+	for i := range cfg.Tenants {
+		for _, key := range cfg.Tenants[i].APIKeys {
+			out[key] = cfg.Tenants[i].Name
+		}
+	}
+	return out
+}
+
+// HasTenantManagementKeys reports whether any tenant can use the management API.
+func (cfg *Config) HasTenantManagementKeys() bool {
+	if cfg == nil {
+		return false
+	}
+	for i := range cfg.Tenants {
+		if cfg.Tenants[i].ManagementKey != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantForManagementKey returns the tenant whose management key matches provided.
+func (cfg *Config) TenantForManagementKey(provided string) (string, bool) {
+	if cfg == nil || provided == "" {
+		return "", false
+	}
+	for i := range cfg.Tenants {
+		secret := cfg.Tenants[i].ManagementKey
+		if secret == "" {
+			continue
+		}
+		if looksLikeBcrypt(secret) {
+			if bcrypt.CompareHashAndPassword([]byte(secret), []byte(provided)) == nil {
+				return cfg.Tenants[i].Name, true
+			}
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(provided)) == 1 {
+			return cfg.Tenants[i].Name, true
+		}
+	}
+	return "", false
+}
+
+// PayloadRulesFor returns the payload rules applied to requests of tenant; requests without a
+// tenant use the global rules.
+func (cfg *Config) PayloadRulesFor(tenant string) PayloadConfig {
+	if t := cfg.Tenant(tenant); t != nil {
+		return t.Payload
+	}
+	return cfg.Payload
+}
+
+// TenantAuthDir returns the directory holding the auth files of tenant.
+func TenantAuthDir(authDir, tenant string) string {
+	if tenant == "" {
+		return authDir
+	}
+	return filepath.Join(authDir, TenantsDirName, tenant)
+}
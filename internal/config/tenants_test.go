@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSanitizeTenants(t *testing.T) {
+	cfg := &Config{
+		SDKConfig: SDKConfig{APIKeys: []string{"global-key"}},
+		Tenants: []TenantConfig{
+			{Name: " Team-A ", APIKeys: []string{"a-key", " ", "global-key"}},
+			{Name: "team-a", APIKeys: []string{"dup-tenant"}},
+			{Name: "bad/name"},
+			{Name: "team-b", APIKeys: []string{"a-key", "b-key"}},
+		},
+	}
+	cfg.SanitizeTenants()
+
+	if len(cfg.Tenants) != 2 || cfg.Tenants[0].Name != "team-a" || cfg.Tenants[1].Name != "team-b" {
+		t.Fatalf("tenants = %+v, want team-a and team-b", cfg.Tenants)
+	}
+	keys := cfg.TenantAPIKeys()
+	if len(keys) != 2 || keys["a-key"] != "team-a" || keys["b-key"] != "team-b" {
+		t.Fatalf("TenantAPIKeys() = %v", keys)
+	}
+}
+
+func TestTenantForManagementKey(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("b-secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Tenants: []TenantConfig{
+		{Name: "team-a", ManagementKey: "a-secret"},
+		{Name: "team-b", ManagementKey: string(hash)},
+	}}
+	for key, want := range map[string]string{"a-secret": "team-a", "b-secret": "team-b", "other": ""} {
+		got, ok := cfg.TenantForManagementKey(key)
+		if got != want || ok != (want != "") {
+			t.Errorf("TenantForManagementKey(%q) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+}
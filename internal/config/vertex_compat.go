@@ -20,6 +20,15 @@ type VertexCompatKey struct {
 	// Prefix optionally namespaces model aliases for this credential (e.g., "teamA/vertex-pro").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Tags label the credential for auth-pools selectors (e.g., team: research).
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Tenant assigns the credential to a tenant; only that tenant's clients can use it.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// Schedule limits the credential to time windows (e.g., outside working hours).
+	Schedule *Schedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
 	// BaseURL is the base URL for the Vertex-compatible API endpoint.
 	// The executor will append "/v1/publishers/google/models/{model}:action" to this.
 	// Example: "https://zenmux.ai/api" becomes "https://zenmux.ai/api/v1/publishers/google/models/..."
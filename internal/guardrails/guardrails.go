@@ -0,0 +1,303 @@
+// Package guardrails screens request and response content for sensitive data.
+//
+// Rules pair detectors (built-in patterns, custom regular expressions and word lists) with
+// an action. Tokenized request values are replaced with placeholders such as
+// "[[EMAIL_1]]" before the request leaves the proxy and restored when the placeholders come
+// back in the response, so the upstream never sees the original values.
+package guardrails
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultLookaheadChars is the streamed text held back for screening when none is configured.
+const DefaultLookaheadChars = 64
+
+// Directions a rule applies to.
+const (
+	DirectionRequest  = "request"
+	DirectionResponse = "response"
+	DirectionBoth     = "both"
+)
+
+// Actions a rule can take.
+const (
+	ActionTokenize = "tokenize"
+	ActionRedact   = "redact"
+	ActionBlock    = "block"
+	ActionLog      = "log"
+)
+
+var builtinPatterns = map[string]string{
+	"email":       `[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`,
+	"api-key":     `\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}|AIza[0-9A-Za-z_\-]{35}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abpr]-[A-Za-z0-9\-]{10,})`,
+	"credit-card": `\b(?:\d[ \-]?){12,18}\d\b`,
+}
+
+var labelSanitizer = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// Engine holds compiled guardrail rules.
+type Engine struct {
+	rules     []*rule
+	lookahead int
+}
+
+type rule struct {
+	name      string
+	models    []string
+	apiKeys   map[string]struct{}
+	request   bool
+	response  bool
+	action    string
+	detectors []*detector
+	allow     map[string]struct{}
+}
+
+type detector struct {
+	name  string
+	label string
+	re    *regexp.Regexp
+	luhn  bool
+}
+
+// Compile builds an engine from cfg. Invalid rules and detectors are skipped and logged.
+// It returns nil when guardrails are disabled or no rule is usable.
+func Compile(cfg config.GuardrailsConfig) *Engine {
+	if !cfg.Enabled {
+		return nil
+	}
+	engine := &Engine{lookahead: cfg.LookaheadChars}
+	if engine.lookahead <= 0 {
+		engine.lookahead = DefaultLookaheadChars
+	}
+	for i, rc := range cfg.Rules {
+		r, err := compileRule(rc)
+		if err != nil {
+			log.Warnf("guardrails: skipping rule %d (%s): %v", i, rc.Name, err)
+			continue
+		}
+		engine.rules = append(engine.rules, r)
+	}
+	if len(engine.rules) == 0 {
+		return nil
+	}
+	return engine
+}
+
+// Validate reports the first invalid rule of cfg.
+func Validate(cfg config.GuardrailsConfig) error {
+	for i, rc := range cfg.Rules {
+		if _, err := compileRule(rc); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i, rc.Name, err)
+		}
+	}
+	return nil
+}
+
+func compileRule(rc config.GuardrailRule) (*rule, error) {
+	r := &rule{
+		name:   strings.TrimSpace(rc.Name),
+		action: strings.ToLower(strings.TrimSpace(rc.Action)),
+	}
+	if r.name == "" {
+		r.name = "unnamed"
+	}
+	switch r.action {
+	case ActionTokenize, ActionRedact, ActionBlock, ActionLog:
+	default:
+		return nil, fmt.Errorf("unknown action %q", rc.Action)
+	}
+	switch strings.ToLower(strings.TrimSpace(rc.Direction)) {
+	case "", DirectionBoth:
+		r.request, r.response = true, true
+	case DirectionRequest:
+		r.request = true
+	case DirectionResponse:
+		r.response = true
+	default:
+		return nil, fmt.Errorf("unknown direction %q", rc.Direction)
+	}
+	// Placeholders are restored rather than screened on the way back.
+	if r.action == ActionTokenize {
+		if !r.request {
+			return nil, fmt.Errorf("tokenize only applies to requests")
+		}
+		r.response = false
+	}
+	for _, pattern := range rc.Models {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			r.models = append(r.models, pattern)
+		}
+	}
+	for _, key := range rc.APIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			if r.apiKeys == nil {
+				r.apiKeys = make(map[string]struct{})
+			}
+			r.apiKeys[key] = struct{}{}
+		}
+	}
+	for _, value := range rc.Allow {
+		if value = strings.TrimSpace(value); value != "" {
+			if r.allow == nil {
+				r.allow = make(map[string]struct{})
+			}
+			r.allow[strings.ToLower(value)] = struct{}{}
+		}
+	}
+	for _, dc := range rc.Detectors {
+		d, err := compileDetector(dc)
+		if err != nil {
+			return nil, err
+		}
+		r.detectors = append(r.detectors, d)
+	}
+	if len(r.detectors) == 0 {
+		return nil, fmt.Errorf("no detectors")
+	}
+	return r, nil
+}
+
+func compileDetector(dc config.GuardrailDetector) (*detector, error) {
+	kind := strings.ToLower(strings.TrimSpace(dc.Type))
+	d := &detector{name: strings.TrimSpace(dc.Name)}
+	if d.name == "" {
+		d.name = kind
+	}
+	d.label = strings.Trim(labelSanitizer.ReplaceAllString(strings.ToUpper(d.name), "_"), "_")
+	if d.label == "" {
+		d.label = "VALUE"
+	}
+	var pattern string
+	switch kind {
+	case "email", "api-key", "credit-card":
+		pattern = builtinPatterns[kind]
+		d.luhn = kind == "credit-card"
+	case "regex":
+		pattern = dc.Pattern
+		if strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("regex detector %s has no pattern", d.name)
+		}
+	case "dictionary":
+		words := make([]string, 0, len(dc.Words))
+		for _, word := range dc.Words {
+			if word = strings.TrimSpace(word); word != "" {
+				words = append(words, regexp.QuoteMeta(word))
+			}
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("dictionary detector %s has no words", d.name)
+		}
+		pattern = `\b(?:` + strings.Join(words, "|") + `)\b`
+		if !dc.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+	default:
+		return nil, fmt.Errorf("unknown detector type %q", dc.Type)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("detector %s: %w", d.name, err)
+	}
+	d.re = re
+	return d, nil
+}
+
+// applies reports whether the rule covers the client key and any of the model names.
+func (r *rule) applies(apiKey string, models []string) bool {
+	if r.apiKeys != nil {
+		if _, ok := r.apiKeys[apiKey]; !ok {
+			return false
+		}
+	}
+	if len(r.models) == 0 {
+		return true
+	}
+	for _, pattern := range r.models {
+		for _, model := range models {
+			if model != "" && matchModelPattern(pattern, model) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *rule) allowed(value string) bool {
+	if r.allow == nil {
+		return false
+	}
+	_, ok := r.allow[strings.ToLower(strings.TrimSpace(value))]
+	return ok
+}
+
+// matches returns the byte ranges of text found by d.
+func (d *detector) matches(text string) [][]int {
+	found := d.re.FindAllStringIndex(text, -1)
+	if !d.luhn || len(found) == 0 {
+		return found
+	}
+	valid := found[:0]
+	for _, loc := range found {
+		if luhnValid(text[loc[0]:loc[1]]) {
+			valid = append(valid, loc)
+		}
+	}
+	return valid
+}
+
+func luhnValid(value string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(value) - 1; i >= 0; i-- {
+		c := value[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		n := int(c - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// matchModelPattern performs simple wildcard matching where '*' matches zero or more characters.
+func matchModelPattern(pattern, model string) bool {
+	if pattern == "*" {
+		return true
+	}
+	pi, si := 0, 0
+	starIdx, matchIdx := -1, 0
+	for si < len(model) {
+		switch {
+		case pi < len(pattern) && pattern[pi] == model[si]:
+			pi++
+			si++
+		case pi < len(pattern) && pattern[pi] == '*':
+			starIdx, matchIdx = pi, si
+			pi++
+		case starIdx != -1:
+			pi = starIdx + 1
+			matchIdx++
+			si = matchIdx
+		default:
+			return false
+		}
+	}
+	for pi < len(pattern) && pattern[pi] == '*' {
+		pi++
+	}
+	return pi == len(pattern)
+}
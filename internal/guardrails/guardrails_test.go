@@ -0,0 +1,166 @@
+package guardrails
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func newTestEngine(t *testing.T, rules ...config.GuardrailRule) *Engine {
+	t.Helper()
+	cfg := config.GuardrailsConfig{Enabled: true, LookaheadChars: 16, Rules: rules}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	engine := Compile(cfg)
+	if engine == nil {
+		t.Fatalf("expected engine")
+	}
+	return engine
+}
+
+func TestTokenizeAndRestore(t *testing.T) {
+	engine := newTestEngine(t, config.GuardrailRule{
+		Name:      "pii",
+		Action:    ActionTokenize,
+		Detectors: []config.GuardrailDetector{{Type: "email"}, {Type: "credit-card"}},
+		Allow:     []string{"Support@example.com"},
+	})
+	session := engine.NewSession("key", "gpt-5")
+	request := []byte(`{"model":"a@b.io","messages":[{"role":"user","content":"mail jane@corp.io or support@example.com, card 4111 1111 1111 1111, jane@corp.io again"}]}`)
+	guarded, err := session.GuardRequest(request)
+	if err != nil {
+		t.Fatalf("GuardRequest: %v", err)
+	}
+	content := gjson.GetBytes(guarded, "messages.0.content").String()
+	if content != "mail [[EMAIL_1]] or support@example.com, card [[CREDIT_CARD_1]], [[EMAIL_1]] again" {
+		t.Fatalf("unexpected guarded content: %q", content)
+	}
+	if gjson.GetBytes(guarded, "model").String() != "a@b.io" {
+		t.Fatalf("identifier fields must not be screened: %s", guarded)
+	}
+
+	response := []byte(`{"choices":[{"message":{"content":"Wrote to [[EMAIL_1]]."}}]}`)
+	restored, err := session.GuardResponse(response)
+	if err != nil {
+		t.Fatalf("GuardResponse: %v", err)
+	}
+	if got := gjson.GetBytes(restored, "choices.0.message.content").String(); got != "Wrote to jane@corp.io." {
+		t.Fatalf("unexpected restored content: %q", got)
+	}
+	decisions := session.Decisions()
+	if len(decisions) != 2 || decisions[0].Detector != "email" || decisions[0].Count != 2 || decisions[1].Detector != "credit-card" {
+		t.Fatalf("unexpected decisions: %+v", decisions)
+	}
+	if log := string(session.Log()); strings.Contains(log, "jane@corp.io") || !strings.Contains(log, `rule="pii"`) {
+		t.Fatalf("unexpected log: %q", log)
+	}
+}
+
+func TestRuleScopeAndBlock(t *testing.T) {
+	engine := newTestEngine(t, config.GuardrailRule{
+		Name:      "codenames",
+		Models:    []string{"claude-*"},
+		APIKeys:   []string{"team-a"},
+		Action:    ActionBlock,
+		Detectors: []config.GuardrailDetector{{Type: "dictionary", Words: []string{"Falcon"}}},
+	})
+	if engine.NewSession("team-b", "claude-sonnet") != nil {
+		t.Fatalf("rule applied to another client")
+	}
+	if engine.NewSession("team-a", "gpt-5") != nil {
+		t.Fatalf("rule applied to another model")
+	}
+	session := engine.NewSession("team-a", "claude-sonnet(high)", "claude-sonnet")
+	_, err := session.GuardRequest([]byte(`{"messages":[{"content":"status of falcon?"}]}`))
+	var blocked *BlockError
+	if !errors.As(err, &blocked) || blocked.Rule != "codenames" || blocked.Direction != DirectionRequest {
+		t.Fatalf("expected request block, got %v", err)
+	}
+	if _, err = session.GuardRequest([]byte(`{"messages":[{"content":"falconry"}]}`)); err != nil {
+		t.Fatalf("dictionary must match whole words: %v", err)
+	}
+}
+
+func TestValidateRejectsInvalidRules(t *testing.T) {
+	cases := []config.GuardrailRule{
+		{Action: "shred", Detectors: []config.GuardrailDetector{{Type: "email"}}},
+		{Action: ActionRedact},
+		{Action: ActionRedact, Detectors: []config.GuardrailDetector{{Type: "regex", Pattern: "("}}},
+		{Action: ActionTokenize, Direction: DirectionResponse, Detectors: []config.GuardrailDetector{{Type: "email"}}},
+	}
+	for i, rule := range cases {
+		if err := Validate(config.GuardrailsConfig{Rules: []config.GuardrailRule{rule}}); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestStreamFilterRestoresSplitPlaceholders(t *testing.T) {
+	engine := newTestEngine(t,
+		config.GuardrailRule{Name: "pii", Action: ActionTokenize, Detectors: []config.GuardrailDetector{{Type: "email"}}},
+		config.GuardrailRule{Name: "keys", Direction: DirectionResponse, Action: ActionRedact, Detectors: []config.GuardrailDetector{{Type: "api-key"}}},
+	)
+	session := engine.NewSession("", "gpt-5")
+	if _, err := session.GuardRequest([]byte(`{"messages":[{"content":"jane@corp.io"}]}`)); err != nil {
+		t.Fatalf("GuardRequest: %v", err)
+	}
+
+	filter := session.NewStreamFilter("openai")
+	deltas := []string{"Sending to [[EM", "AIL_1]] with key sk-abcdefghij", "klmnopqrstuvwxyz now ", "done"}
+	var out []string
+	for _, delta := range deltas {
+		chunk := []byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":" + quote(delta) + "}}]}\n\n")
+		ready, err := filter.Write(chunk)
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		for _, c := range ready {
+			out = append(out, string(c))
+		}
+	}
+	ready, err := filter.Write([]byte("data: [DONE]\n\n"))
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	for _, c := range ready {
+		out = append(out, string(c))
+	}
+	if len(out) != 5 || out[4] != "data: [DONE]\n\n" {
+		t.Fatalf("unexpected chunk sequence: %q", out)
+	}
+	var text strings.Builder
+	for _, c := range out[:4] {
+		text.WriteString(gjson.Get(strings.TrimPrefix(strings.TrimSpace(c), "data: "), "choices.0.delta.content").String())
+	}
+	if text.String() != "Sending to jane@corp.io with key [REDACTED:API_KEY] now done" {
+		t.Fatalf("unexpected streamed text: %q", text.String())
+	}
+}
+
+func TestStreamFilterBlocksSplitValues(t *testing.T) {
+	engine := newTestEngine(t, config.GuardrailRule{
+		Name:      "secret",
+		Direction: DirectionResponse,
+		Action:    ActionBlock,
+		Detectors: []config.GuardrailDetector{{Type: "regex", Pattern: `SECRET-\d+`}},
+	})
+	filter := engine.NewSession("", "m").NewStreamFilter("claude")
+	write := func(text string) error {
+		_, err := filter.Write([]byte(`{"type":"content_block_delta","delta":{"type":"text_delta","text":` + quote(text) + `}}`))
+		return err
+	}
+	if err := write("the value is definitely SECRET-"); err != nil {
+		t.Fatalf("unexpected block: %v", err)
+	}
+	if err := write("12 "); err == nil {
+		t.Fatalf("expected block")
+	}
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,345 @@
+package guardrails
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// skippedKeys hold identifiers, signatures and binary data rather than user content.
+var skippedKeys = map[string]struct{}{
+	"id":                   {},
+	"model":                {},
+	"type":                 {},
+	"role":                 {},
+	"object":               {},
+	"status":               {},
+	"signature":            {},
+	"thoughtSignature":     {},
+	"thought_signature":    {},
+	"encrypted_content":    {},
+	"data":                 {},
+	"mime_type":            {},
+	"mimeType":             {},
+	"media_type":           {},
+	"call_id":              {},
+	"tool_call_id":         {},
+	"tool_use_id":          {},
+	"finish_reason":        {},
+	"stop_reason":          {},
+	"system_fingerprint":   {},
+	"previous_response_id": {},
+}
+
+// BlockError reports content rejected by a block rule.
+type BlockError struct {
+	Rule      string
+	Detector  string
+	Direction string
+}
+
+func (e *BlockError) Error() string {
+	return fmt.Sprintf("content blocked by guardrail %q (%s detected in %s)", e.Rule, e.Detector, e.Direction)
+}
+
+// Decision summarises how often a rule acted on a request.
+type Decision struct {
+	Direction string
+	Rule      string
+	Detector  string
+	Action    string
+	Count     int
+}
+
+// Session applies the rules that cover one request and remembers its placeholders.
+// A nil Session is valid and leaves content unchanged.
+type Session struct {
+	lookahead     int
+	requestRules  []*rule
+	responseRules []*rule
+
+	mu        sync.Mutex
+	tokens    map[string]string // original value -> placeholder
+	originals map[string]string // placeholder -> original value
+	counters  map[string]int
+	decisions map[Decision]int
+	order     []Decision
+}
+
+// NewSession returns a session for the rules covering apiKey and any of models, or nil when
+// no rule applies.
+func (e *Engine) NewSession(apiKey string, models ...string) *Session {
+	if e == nil {
+		return nil
+	}
+	s := &Session{lookahead: e.lookahead}
+	for _, r := range e.rules {
+		if !r.applies(apiKey, models) {
+			continue
+		}
+		if r.request {
+			s.requestRules = append(s.requestRules, r)
+		}
+		if r.response {
+			s.responseRules = append(s.responseRules, r)
+		}
+	}
+	if len(s.requestRules) == 0 && len(s.responseRules) == 0 {
+		return nil
+	}
+	return s
+}
+
+// GuardRequest screens the string values of a JSON request body.
+func (s *Session) GuardRequest(body []byte) ([]byte, error) {
+	if s == nil || len(s.requestRules) == 0 {
+		return body, nil
+	}
+	return transformStrings(body, s.requestText)
+}
+
+// GuardResponse restores placeholders in a JSON response body and screens its string values.
+func (s *Session) GuardResponse(body []byte) ([]byte, error) {
+	if s == nil || !gjson.ValidBytes(body) {
+		return body, nil
+	}
+	return transformStrings(body, s.responseText)
+}
+
+// Decisions returns the actions taken so far in the order they first occurred.
+func (s *Session) Decisions() []Decision {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Decision, 0, len(s.order))
+	for _, key := range s.order {
+		d := key
+		d.Count = s.decisions[key]
+		out = append(out, d)
+	}
+	return out
+}
+
+// Log formats the decisions for the request log. It never includes matched values.
+func (s *Session) Log() []byte {
+	decisions := s.Decisions()
+	if len(decisions) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, d := range decisions {
+		fmt.Fprintf(&b, "%s rule=%q detector=%s action=%s count=%d\n", d.Direction, d.Rule, d.Detector, d.Action, d.Count)
+	}
+	return []byte(b.String())
+}
+
+func (s *Session) requestText(text string) (string, error) {
+	return s.apply(text, s.requestRules, DirectionRequest)
+}
+
+func (s *Session) responseText(text string) (string, error) {
+	return s.apply(s.restore(text), s.responseRules, DirectionResponse)
+}
+
+// restore replaces placeholders with the values they stand for.
+func (s *Session) restore(text string) string {
+	if !strings.Contains(text, "[[") {
+		return text
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, original := range s.originals {
+		text = strings.ReplaceAll(text, token, original)
+	}
+	return text
+}
+
+// apply runs rules over text in order, each seeing the output of the previous one.
+func (s *Session) apply(text string, rules []*rule, direction string) (string, error) {
+	if text == "" {
+		return text, nil
+	}
+	for _, r := range rules {
+		for _, d := range r.detectors {
+			locs := d.matches(text)
+			if len(locs) == 0 {
+				continue
+			}
+			var b strings.Builder
+			last, count := 0, 0
+			for _, loc := range locs {
+				value := text[loc[0]:loc[1]]
+				if r.allowed(value) {
+					continue
+				}
+				count++
+				if r.action == ActionBlock {
+					s.record(Decision{Direction: direction, Rule: r.name, Detector: d.name, Action: r.action}, 1)
+					return "", &BlockError{Rule: r.name, Detector: d.name, Direction: direction}
+				}
+				if r.action == ActionLog {
+					continue
+				}
+				b.WriteString(text[last:loc[0]])
+				if r.action == ActionTokenize {
+					b.WriteString(s.tokenFor(d.label, value))
+				} else {
+					b.WriteString("[REDACTED:" + d.label + "]")
+				}
+				last = loc[1]
+			}
+			if count == 0 {
+				continue
+			}
+			s.record(Decision{Direction: direction, Rule: r.name, Detector: d.name, Action: r.action}, count)
+			if r.action != ActionLog {
+				b.WriteString(text[last:])
+				text = b.String()
+			}
+		}
+	}
+	return text, nil
+}
+
+// blocked reports whether text contains content rejected by a response block rule. Streams use
+// it on text that straddles already-emitted output, which can no longer be rewritten.
+func (s *Session) blocked(text string) error {
+	for _, r := range s.responseRules {
+		if r.action != ActionBlock {
+			continue
+		}
+		for _, d := range r.detectors {
+			for _, loc := range d.matches(text) {
+				if !r.allowed(text[loc[0]:loc[1]]) {
+					s.record(Decision{Direction: DirectionResponse, Rule: r.name, Detector: d.name, Action: r.action}, 1)
+					return &BlockError{Rule: r.name, Detector: d.name, Direction: DirectionResponse}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// tokenFor returns the placeholder of value, reusing it for repeated values.
+func (s *Session) tokenFor(label, value string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, ok := s.tokens[value]; ok {
+		return token
+	}
+	if s.tokens == nil {
+		s.tokens = make(map[string]string)
+		s.originals = make(map[string]string)
+		s.counters = make(map[string]int)
+	}
+	s.counters[label]++
+	token := fmt.Sprintf("[[%s_%d]]", label, s.counters[label])
+	s.tokens[value] = token
+	s.originals[token] = value
+	return token
+}
+
+func (s *Session) record(d Decision, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.decisions == nil {
+		s.decisions = make(map[Decision]int)
+	}
+	if _, ok := s.decisions[d]; !ok {
+		s.order = append(s.order, d)
+	}
+	s.decisions[d] += count
+}
+
+// transformStrings applies fn to every string value of a JSON document outside skippedKeys.
+func transformStrings(body []byte, fn func(string) (string, error)) ([]byte, error) {
+	if !gjson.ValidBytes(body) {
+		return body, nil
+	}
+	type edit struct {
+		path  string
+		value string
+	}
+	var edits []edit
+	var walkErr error
+	var walk func(value gjson.Result, path string)
+	walk = func(value gjson.Result, path string) {
+		if walkErr != nil {
+			return
+		}
+		switch {
+		case value.IsObject():
+			value.ForEach(func(key, child gjson.Result) bool {
+				if _, skip := skippedKeys[key.String()]; skip {
+					return true
+				}
+				walk(child, joinPath(path, escapePathKey(key.String())))
+				return walkErr == nil
+			})
+		case value.IsArray():
+			index := 0
+			value.ForEach(func(_, child gjson.Result) bool {
+				walk(child, joinPath(path, fmt.Sprint(index)))
+				index++
+				return walkErr == nil
+			})
+		case value.Type == gjson.String:
+			// Inline media (data URLs) is not user text.
+			if path == "" || strings.HasPrefix(value.Str, "data:") {
+				return
+			}
+			out, err := fn(value.Str)
+			if err != nil {
+				walkErr = err
+				return
+			}
+			if out != value.Str {
+				edits = append(edits, edit{path: path, value: out})
+			}
+		}
+	}
+	walk(gjson.ParseBytes(body), "")
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	for _, e := range edits {
+		updated, err := sjson.SetBytes(body, e.path, e.value)
+		if err != nil {
+			return nil, err
+		}
+		body = updated
+	}
+	return body, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// escapePathKey escapes object keys for gjson/sjson paths. Numeric keys are prefixed with ':'
+// so they are not mistaken for array indexes.
+func escapePathKey(key string) string {
+	var b strings.Builder
+	numeric := key != ""
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			numeric = false
+		}
+		if !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	if numeric {
+		return ":" + b.String()
+	}
+	return b.String()
+}
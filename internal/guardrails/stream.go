@@ -0,0 +1,232 @@
+package guardrails
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxHeldChunks bounds how many text chunks are held back while waiting for a word boundary.
+const maxHeldChunks = 32
+
+// StreamFilter screens a streamed response in the client format. Text deltas are held back
+// until at least the lookahead amount of text ending on a word boundary has arrived, so values
+// split across chunks are detected and placeholders are restored whole. The screened text is
+// written into the first held chunk and the deltas of the others are emptied.
+type StreamFilter struct {
+	session *Session
+	format  string
+	held    []*streamChunk
+	pending strings.Builder
+	// tail is the end of the already emitted text, kept to catch blocked values that straddle
+	// a flush.
+	tail string
+}
+
+type streamChunk struct {
+	lines  [][]byte
+	events []streamEvent
+	text   int // index of the event carrying a text delta, or -1
+}
+
+type streamEvent struct {
+	line       int
+	start, end int
+	textPath   string
+}
+
+// NewStreamFilter returns a filter for a stream in format, or nil for a nil session.
+func (s *Session) NewStreamFilter(format string) *StreamFilter {
+	if s == nil {
+		return nil
+	}
+	return &StreamFilter{session: s, format: format}
+}
+
+// Write accepts the next chunk of the stream and returns the chunks that are ready to be sent.
+// A *BlockError ends the stream.
+func (f *StreamFilter) Write(chunk []byte) ([][]byte, error) {
+	c := parseStreamChunk(f.format, chunk)
+	if c.text >= 0 {
+		f.held = append(f.held, c)
+		event := c.events[c.text]
+		f.pending.WriteString(gjson.GetBytes(c.lines[event.line][event.start:event.end], event.textPath).String())
+		if !f.ready() {
+			return nil, nil
+		}
+		return f.Flush()
+	}
+	out, err := f.Flush()
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := f.render(c, "")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, rendered), nil
+}
+
+// Flush screens and returns all held chunks. It must be called when the stream ends.
+func (f *StreamFilter) Flush() ([][]byte, error) {
+	if f == nil || len(f.held) == 0 {
+		return nil, nil
+	}
+	text := f.pending.String()
+	f.pending.Reset()
+	held := f.held
+	f.held = nil
+
+	processed, err := f.session.responseText(text)
+	if err != nil {
+		return nil, err
+	}
+	window := f.tail + processed
+	if f.tail != "" {
+		if err = f.session.blocked(window); err != nil {
+			return nil, err
+		}
+	}
+	f.tail = suffix(window, f.session.lookahead)
+
+	out := make([][]byte, 0, len(held))
+	for i, c := range held {
+		delta := ""
+		if i == 0 {
+			delta = processed
+		}
+		rendered, errRender := f.render(c, delta)
+		if errRender != nil {
+			return nil, errRender
+		}
+		out = append(out, rendered)
+	}
+	return out, nil
+}
+
+// ready reports whether the held text can be screened without cutting a value in two.
+func (f *StreamFilter) ready() bool {
+	if len(f.held) >= maxHeldChunks {
+		return true
+	}
+	if f.pending.Len() < f.session.lookahead {
+		return false
+	}
+	text := f.pending.String()
+	if open := strings.LastIndex(text, "[["); open >= 0 && !strings.Contains(text[open:], "]]") {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return unicode.IsSpace(last)
+}
+
+// render rebuilds a chunk with its text delta replaced by delta and its other events screened.
+func (f *StreamFilter) render(c *streamChunk, delta string) ([]byte, error) {
+	if len(c.events) == 0 {
+		return bytes.Clone(bytes.Join(c.lines, nil)), nil
+	}
+	lines := make([][]byte, len(c.lines))
+	copy(lines, c.lines)
+	for i, event := range c.events {
+		line := lines[event.line]
+		body := line[event.start:event.end]
+		var updated []byte
+		var err error
+		if i == c.text {
+			updated, err = sjson.SetBytes(bytes.Clone(body), event.textPath, delta)
+		} else {
+			updated, err = transformStrings(bytes.Clone(body), f.session.responseText)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rebuilt := make([]byte, 0, len(line)-len(body)+len(updated))
+		rebuilt = append(rebuilt, line[:event.start]...)
+		rebuilt = append(rebuilt, updated...)
+		rebuilt = append(rebuilt, line[event.end:]...)
+		lines[event.line] = rebuilt
+	}
+	return bytes.Join(lines, nil), nil
+}
+
+// parseStreamChunk locates the JSON events of a chunk: SSE data lines, bare JSON lines and
+// elements of Gemini's array streaming.
+func parseStreamChunk(format string, chunk []byte) *streamChunk {
+	c := &streamChunk{lines: bytes.SplitAfter(chunk, []byte("\n")), text: -1}
+	for i, line := range c.lines {
+		start, end := 0, len(line)
+		for start < end && (line[start] == ' ' || line[start] == '\t') {
+			start++
+		}
+		if bytes.HasPrefix(line[start:end], []byte("data:")) {
+			start += len("data:")
+		}
+		for start < end && (line[start] == ' ' || line[start] == '\t' || line[start] == '[' || line[start] == ',') {
+			start++
+		}
+		for end > start && (line[end-1] == '\n' || line[end-1] == '\r' || line[end-1] == ' ' || line[end-1] == ',' || line[end-1] == ']') {
+			end--
+		}
+		if start >= end || line[start] != '{' || !json.Valid(line[start:end]) {
+			continue
+		}
+		event := streamEvent{line: i, start: start, end: end}
+		if c.text < 0 {
+			event.textPath = textDeltaPath(format, gjson.ParseBytes(line[start:end]))
+			if event.textPath != "" {
+				c.text = len(c.events)
+			}
+		}
+		c.events = append(c.events, event)
+	}
+	return c
+}
+
+// textDeltaPath returns the path of the streamed assistant text in event, if any.
+func textDeltaPath(format string, event gjson.Result) string {
+	switch format {
+	case constant.OpenAI:
+		if event.Get("choices.0.delta.content").Type == gjson.String && len(event.Get("choices").Array()) == 1 {
+			return "choices.0.delta.content"
+		}
+	case constant.OpenaiResponse:
+		if event.Get("type").String() == "response.output_text.delta" {
+			return "delta"
+		}
+	case constant.Claude:
+		if event.Get("type").String() == "content_block_delta" && event.Get("delta.type").String() == "text_delta" {
+			return "delta.text"
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		root := ""
+		if format == constant.GeminiCLI {
+			root = "response."
+		}
+		if len(event.Get(root+"candidates").Array()) != 1 {
+			return ""
+		}
+		parts := event.Get(root + "candidates.0.content.parts").Array()
+		if len(parts) == 1 && parts[0].Get("text").Type == gjson.String && !parts[0].Get("thought").Bool() {
+			return root + "candidates.0.content.parts.0.text"
+		}
+	}
+	return ""
+}
+
+// suffix returns at most n trailing bytes of text, starting on a rune boundary.
+func suffix(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	cut := len(text) - n
+	for cut < len(text) && !utf8.RuneStart(text[cut]) {
+		cut++
+	}
+	return text[cut:]
+}
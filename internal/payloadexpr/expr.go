@@ -0,0 +1,512 @@
+// Package payloadexpr implements the small boolean expression language used by payload rule
+// conditions. Expressions read values from a JSON request body through gjson paths:
+//
+//	len(tools) > 20
+//	exists(response_format) && !stream
+//	messages.0.role == "system" || model =~ "^gpt-5"
+//	contains(metadata.user_id, "team-a")
+//
+// Bare identifiers are gjson paths (missing paths evaluate to null). Literals are numbers,
+// quoted strings, true, false and null. Operators are ==, !=, <, <=, >, >=, =~ (regular
+// expression match), !, &&, || and parentheses; and, or and not are accepted as aliases.
+// Functions are len, exists, lower and contains.
+package payloadexpr
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+// Expr is a compiled expression.
+type Expr struct {
+	source string
+	root   node
+}
+
+// String returns the expression source.
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+	return e.source
+}
+
+// Compile parses an expression.
+func Compile(source string) (*Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("payload expression %q: %w", source, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("payload expression %q: %w", source, err)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+var cache sync.Map // source -> *Expr or error
+
+// Cached compiles an expression once and reuses the result for later calls.
+func Cached(source string) (*Expr, error) {
+	if v, ok := cache.Load(source); ok {
+		if expr, isExpr := v.(*Expr); isExpr {
+			return expr, nil
+		}
+		return nil, v.(error)
+	}
+	expr, err := Compile(source)
+	if err != nil {
+		cache.Store(source, err)
+		return nil, err
+	}
+	cache.Store(source, expr)
+	return expr, nil
+}
+
+// Match evaluates the expression against document. Paths are resolved relative to root
+// when root is non-empty (for example "request" for Gemini CLI envelopes).
+func (e *Expr) Match(document []byte, root string) bool {
+	if e == nil {
+		return true
+	}
+	doc := gjson.ParseBytes(document)
+	if root = strings.TrimSpace(root); root != "" {
+		doc = doc.Get(root)
+	}
+	return truthy(e.root.eval(doc))
+}
+
+type node interface {
+	eval(doc gjson.Result) any
+}
+
+type literal struct{ value any }
+
+func (n literal) eval(gjson.Result) any { return n.value }
+
+type pathRef struct{ path string }
+
+func (n pathRef) eval(doc gjson.Result) any {
+	result := doc.Get(n.path)
+	if !result.Exists() {
+		return nil
+	}
+	return result.Value()
+}
+
+type unary struct{ operand node }
+
+func (n unary) eval(doc gjson.Result) any { return !truthy(n.operand.eval(doc)) }
+
+type binary struct {
+	op          string
+	left, right node
+	re          *regexp.Regexp
+}
+
+func (n binary) eval(doc gjson.Result) any {
+	switch n.op {
+	case "&&":
+		return truthy(n.left.eval(doc)) && truthy(n.right.eval(doc))
+	case "||":
+		return truthy(n.left.eval(doc)) || truthy(n.right.eval(doc))
+	}
+	left, right := n.left.eval(doc), n.right.eval(doc)
+	switch n.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	case "=~":
+		s, ok := left.(string)
+		if !ok || n.re == nil {
+			return false
+		}
+		return n.re.MatchString(s)
+	}
+	cmp, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (n call) eval(doc gjson.Result) any {
+	switch n.name {
+	case "len":
+		switch v := n.args[0].eval(doc).(type) {
+		case string:
+			return float64(len([]rune(v)))
+		case []any:
+			return float64(len(v))
+		case map[string]any:
+			return float64(len(v))
+		}
+		return float64(0)
+	case "exists":
+		if ref, ok := n.args[0].(pathRef); ok {
+			return doc.Get(ref.path).Exists()
+		}
+		return n.args[0].eval(doc) != nil
+	case "lower":
+		if s, ok := n.args[0].eval(doc).(string); ok {
+			return strings.ToLower(s)
+		}
+		return nil
+	case "contains":
+		needle := n.args[1].eval(doc)
+		switch haystack := n.args[0].eval(doc).(type) {
+		case string:
+			s, ok := needle.(string)
+			return ok && strings.Contains(haystack, s)
+		case []any:
+			for _, item := range haystack {
+				if equal(item, needle) {
+					return true
+				}
+			}
+		case map[string]any:
+			s, ok := needle.(string)
+			if ok {
+				_, found := haystack[s]
+				return found
+			}
+		}
+		return false
+	}
+	return nil
+}
+
+var functionArity = map[string]int{"len": 1, "exists": 1, "lower": 1, "contains": 2}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	case []any:
+		return len(t) > 0
+	case map[string]any:
+		return len(t) > 0
+	}
+	return true
+}
+
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
+
+// --- lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokString, text: b.String()})
+			i = j + 1
+		case c >= '0' && c <= '9' || (c == '-' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+			j := i + 1
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", src[i:j])
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[i:j], num: n})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && isIdentPart(src[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "=~", "&&", "||", "<", ">", "!", "(", ")", ","} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '@' || c == '#' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isIdentPart accepts the characters of gjson paths such as "messages.0.content" or
+// "tools.#.name".
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '*' || c == '?'
+}
+
+// --- parser ---
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) acceptOp(ops ...string) (string, bool) {
+	t := p.peek()
+	for _, op := range ops {
+		if (t.kind == tokOp && t.text == op) || (t.kind == tokIdent && keywordOp(t.text) == op) {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func keywordOp(word string) string {
+	switch word {
+	case "and":
+		return "&&"
+	case "or":
+		return "||"
+	case "not":
+		return "!"
+	}
+	return ""
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("||"); !ok {
+			return left, nil
+		}
+		right, errRight := p.parseAnd()
+		if errRight != nil {
+			return nil, errRight
+		}
+		left = binary{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("&&"); !ok {
+			return left, nil
+		}
+		right, errRight := p.parseNot()
+		if errRight != nil {
+			return nil, errRight
+		}
+		left = binary{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.acceptOp("!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return unary{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.acceptOp("==", "!=", "<=", ">=", "=~", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	n := binary{op: op, left: left, right: right}
+	if op == "=~" {
+		lit, isLiteral := right.(literal)
+		pattern, isString := lit.value.(string)
+		if !isLiteral || !isString {
+			return nil, fmt.Errorf("=~ requires a string literal pattern")
+		}
+		if n.re, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return literal{value: t.num}, nil
+	case tokString:
+		return literal{value: t.text}, nil
+	case tokOp:
+		if t.text != "(" {
+			return nil, fmt.Errorf("unexpected %q", t.text)
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.acceptOp(")"); !ok {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		case "null":
+			return literal{value: nil}, nil
+		}
+		if _, ok := p.acceptOp("("); ok {
+			return p.parseCall(t.text)
+		}
+		return pathRef{path: t.text}, nil
+	}
+	return nil, fmt.Errorf("unexpected end of expression")
+}
+
+func (p *parser) parseCall(name string) (node, error) {
+	arity, known := functionArity[name]
+	if !known {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	var args []node
+	if _, ok := p.acceptOp(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, more := p.acceptOp(","); more {
+				continue
+			}
+			if _, closed := p.acceptOp(")"); !closed {
+				return nil, fmt.Errorf("missing closing parenthesis after %s arguments", name)
+			}
+			break
+		}
+	}
+	if len(args) != arity {
+		return nil, fmt.Errorf("%s expects %d argument(s), got %d", name, arity, len(args))
+	}
+	return call{name: name, args: args}, nil
+}
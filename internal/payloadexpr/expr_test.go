@@ -0,0 +1,51 @@
+package payloadexpr
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	doc := []byte(`{"model":"gpt-5-mini","stream":true,"tools":[{"name":"a"},{"name":"b"},{"name":"c"}],` +
+		`"messages":[{"role":"system","content":"Be brief"}],"metadata":{"user_id":"team-a-42"},"tags":["x","y"]}`)
+	cases := map[string]bool{
+		`len(tools) > 2`:                          true,
+		`len(tools) > 20`:                         false,
+		`exists(response_format)`:                 false,
+		`!exists(response_format) && stream`:      true,
+		`messages.0.role == "system"`:             true,
+		`model =~ "^gpt-5"`:                       true,
+		`model =~ "^claude" or len(tools) >= 3`:   true,
+		`not (stream and model == 'gpt-5-mini')`:  false,
+		`contains(metadata.user_id, "team-a")`:    true,
+		`contains(tags, "z")`:                     false,
+		`lower(messages.0.content) == "be brief"`: true,
+		`tools.#.name == null`:                    false,
+		`missing.path == null`:                    true,
+		`temperature < 1`:                         false,
+	}
+	for source, want := range cases {
+		expr, err := Compile(source)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", source, err)
+		}
+		if got := expr.Match(doc, ""); got != want {
+			t.Fatalf("%q = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestMatchRoot(t *testing.T) {
+	expr, err := Compile(`len(contents) == 1`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if !expr.Match([]byte(`{"request":{"contents":[{}]}}`), "request") {
+		t.Fatalf("expected match relative to root")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, source := range []string{`len(tools) >`, `unknown(tools)`, `len(a, b)`, `model =~ other`, `"open`, `(a == 1`, `a == 1 b`} {
+		if _, err := Compile(source); err == nil {
+			t.Fatalf("expected error for %q", source)
+		}
+	}
+}
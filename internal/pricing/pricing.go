@@ -0,0 +1,283 @@
+// Package pricing computes the cost of requests from a model price table.
+//
+// The table is layered: prices from the configuration win over the optional price file,
+// which wins over the built-in defaults. Within a layer the first matching entry wins and
+// an entry for the request's provider beats a generic one.
+package pricing
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed defaults.yaml
+var defaultTable []byte
+
+// Price holds token prices in USD per million tokens.
+type Price struct {
+	Input     float64 `json:"input"`
+	Output    float64 `json:"output"`
+	Cached    float64 `json:"cached"`
+	Reasoning float64 `json:"reasoning"`
+}
+
+// Usage is the token breakdown of a request as reported by the upstream.
+type Usage struct {
+	InputTokens     int64
+	OutputTokens    int64
+	ReasoningTokens int64
+	CachedTokens    int64
+	TotalTokens     int64
+}
+
+// Table resolves model prices.
+type Table struct {
+	layers [][]entry
+}
+
+type entry struct {
+	match    string
+	provider string
+	price    Price
+}
+
+type priceFile struct {
+	Models []config.ModelPrice `yaml:"models"`
+}
+
+var current atomic.Pointer[Table]
+
+// Configure builds the table for cfg and makes it the one returned by Current. A price file
+// that cannot be read is logged and skipped.
+func Configure(cfg config.PricingConfig) {
+	table, err := Build(cfg)
+	if err != nil {
+		log.Warnf("pricing: %v", err)
+	}
+	current.Store(table)
+}
+
+// Current returns the table installed by Configure, or the built-in table when Configure
+// was never called.
+func Current() *Table {
+	if table := current.Load(); table != nil {
+		return table
+	}
+	table, _ := Build(config.PricingConfig{})
+	current.CompareAndSwap(nil, table)
+	return current.Load()
+}
+
+// Build assembles a table from cfg. The returned table is usable even when err is non-nil;
+// it then lacks the layer that failed to load.
+func Build(cfg config.PricingConfig) (*Table, error) {
+	table := &Table{}
+	table.addLayer(config.NormalizeModelPrices(cfg.Models))
+	var errFile error
+	if cfg.File != "" {
+		prices, err := ReadFile(cfg.File)
+		if err != nil {
+			errFile = err
+		} else {
+			table.addLayer(prices)
+		}
+	}
+	if !cfg.DisableDefaults {
+		prices, err := Parse(defaultTable)
+		if err != nil {
+			return table, fmt.Errorf("built-in price table: %w", err)
+		}
+		table.addLayer(prices)
+	}
+	return table, errFile
+}
+
+// ReadFile reads a YAML price table from path.
+func ReadFile(path string) ([]config.ModelPrice, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read price file %s: %w", path, err)
+	}
+	prices, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse price file %s: %w", path, err)
+	}
+	return prices, nil
+}
+
+// Parse decodes a YAML price table with a top-level "models" list.
+func Parse(data []byte) ([]config.ModelPrice, error) {
+	var file priceFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return config.NormalizeModelPrices(file.Models), nil
+}
+
+func (t *Table) addLayer(prices []config.ModelPrice) {
+	if len(prices) == 0 {
+		return
+	}
+	layer := make([]entry, 0, len(prices))
+	for _, p := range prices {
+		layer = append(layer, entry{
+			match:    strings.ToLower(p.Match),
+			provider: p.Provider,
+			price: Price{
+				Input:     p.Input,
+				Output:    p.Output,
+				Cached:    p.Cached,
+				Reasoning: p.Reasoning,
+			},
+		})
+	}
+	t.layers = append(t.layers, layer)
+}
+
+// Lookup returns the price of model served by provider. A model prefix ("team/") and the
+// thinking suffix are ignored when the full name has no price.
+func (t *Table) Lookup(provider, model string) (Price, bool) {
+	if t == nil {
+		return Price{}, false
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return Price{}, false
+	}
+	names := []string{model}
+	if idx := strings.LastIndex(model, "/"); idx >= 0 && idx < len(model)-1 {
+		names = append(names, model[idx+1:])
+	}
+	for _, name := range names {
+		if base := thinking.ParseSuffix(name).ModelName; base != "" && base != name {
+			names = append(names, base)
+		}
+	}
+	for _, layer := range t.layers {
+		var generic *entry
+		for i := range layer {
+			e := &layer[i]
+			if !matchesAny(e.match, names) {
+				continue
+			}
+			if e.provider == "" {
+				if generic == nil {
+					generic = e
+				}
+				continue
+			}
+			if e.provider == provider {
+				return e.price, true
+			}
+		}
+		if generic != nil {
+			return generic.price, true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost returns the USD cost of usage for model served by provider, and false when the model
+// has no price.
+func (t *Table) Cost(provider, model string, usage Usage) (float64, bool) {
+	price, ok := t.Lookup(provider, model)
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(provider, model, usage), true
+}
+
+// Cost returns the USD cost of usage at this price.
+//
+// Upstreams count tokens differently: cached prompt tokens are part of the input count
+// everywhere except the Anthropic API, and reasoning tokens are part of the output count
+// unless the total shows them reported on top of it (Gemini). Cost bills each token once.
+func (p Price) Cost(provider, model string, usage Usage) float64 {
+	input := usage.InputTokens
+	if cachedIncludedInInput(provider, model) {
+		input -= usage.CachedTokens
+	}
+	output := usage.OutputTokens
+	if reasoningIncludedInOutput(usage) {
+		output -= usage.ReasoningTokens
+	}
+	cachedPrice := p.Cached
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	reasoningPrice := p.Reasoning
+	if reasoningPrice == 0 {
+		reasoningPrice = p.Output
+	}
+	total := float64(max(input, 0))*p.Input +
+		float64(max(usage.CachedTokens, 0))*cachedPrice +
+		float64(max(output, 0))*p.Output +
+		float64(max(usage.ReasoningTokens, 0))*reasoningPrice
+	return total / 1_000_000
+}
+
+// cachedIncludedInInput reports whether the upstream counts cache reads as input tokens.
+// Claude models served through the Anthropic API, Bedrock or Vertex report them separately.
+func cachedIncludedInInput(provider, model string) bool {
+	switch strings.ToLower(provider) {
+	case "claude", "bedrock", "vertex":
+		return !strings.Contains(strings.ToLower(model), "claude")
+	}
+	return true
+}
+
+// reasoningIncludedInOutput reports whether reasoning tokens are part of the output count.
+// Upstreams that report them separately include them in the total on top of the output.
+func reasoningIncludedInOutput(usage Usage) bool {
+	if usage.ReasoningTokens <= 0 {
+		return false
+	}
+	return usage.TotalTokens < usage.InputTokens+usage.OutputTokens+usage.ReasoningTokens
+}
+
+func matchesAny(pattern string, names []string) bool {
+	for _, name := range names {
+		if matchModelPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModelPattern performs simple wildcard matching where '*' matches zero or more characters.
+func matchModelPattern(pattern, model string) bool {
+	if pattern == "*" {
+		return true
+	}
+	pi, si := 0, 0
+	starIdx, matchIdx := -1, 0
+	for si < len(model) {
+		switch {
+		case pi < len(pattern) && pattern[pi] == model[si]:
+			pi++
+			si++
+		case pi < len(pattern) && pattern[pi] == '*':
+			starIdx, matchIdx = pi, si
+			pi++
+		case starIdx != -1:
+			pi = starIdx + 1
+			matchIdx++
+			si = matchIdx
+		default:
+			return false
+		}
+	}
+	for pi < len(pattern) && pattern[pi] == '*' {
+		pi++
+	}
+	return pi == len(pattern)
+}
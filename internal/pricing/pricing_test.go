@@ -0,0 +1,101 @@
+package pricing
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestDefaultTableLookup(t *testing.T) {
+	table, err := Build(config.PricingConfig{})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	cases := map[string]float64{
+		"gpt-5":                      1.25,
+		"gpt-5-mini":                 0.25,
+		"gpt-5-codex-mini":           0.25,
+		"team/gpt-4o-mini(high)":     0.15,
+		"claude-sonnet-4-5-20250929": 3,
+		"claude-opus-4-5-20251101":   5,
+		"claude-opus-4-1-20250805":   15,
+		"gemini-2.5-flash-lite":      0.1,
+		"gemini-2.5-flash":           0.3,
+	}
+	for model, want := range cases {
+		price, ok := table.Lookup("", model)
+		if !ok || !approx(price.Input, want) {
+			t.Errorf("Lookup(%q) = %+v, %t; want input %v", model, price, ok, want)
+		}
+	}
+	if _, ok := table.Lookup("", "some-unknown-model"); ok {
+		t.Fatalf("unknown model should have no price")
+	}
+}
+
+func TestLayerAndProviderPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prices.yaml")
+	if err := os.WriteFile(path, []byte("models:\n  - match: \"gemini-2.5-pro*\"\n    input: 9\n    output: 9\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	table, err := Build(config.PricingConfig{
+		File: path,
+		Models: []config.ModelPrice{
+			{Match: "gemini-2.5-pro*", Provider: "gemini-cli", Input: 0, Output: 0},
+			{Match: "gpt-5*", Input: 7, Output: 7},
+		},
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if price, _ := table.Lookup("gemini-cli", "gemini-2.5-pro"); price.Input != 0 {
+		t.Fatalf("provider entry not used: %+v", price)
+	}
+	if price, _ := table.Lookup("gemini", "gemini-2.5-pro"); price.Input != 9 {
+		t.Fatalf("price file should beat defaults: %+v", price)
+	}
+	if price, _ := table.Lookup("codex", "gpt-5"); price.Input != 7 {
+		t.Fatalf("config entry should beat defaults: %+v", price)
+	}
+}
+
+func TestBuildMissingFileKeepsOtherLayers(t *testing.T) {
+	table, err := Build(config.PricingConfig{File: filepath.Join(t.TempDir(), "missing.yaml")})
+	if err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+	if _, ok := table.Lookup("", "gpt-5"); !ok {
+		t.Fatalf("defaults should still be loaded")
+	}
+}
+
+func TestCostTokenSemantics(t *testing.T) {
+	price := Price{Input: 2, Output: 10, Cached: 0.5, Reasoning: 0}
+
+	// OpenAI: cached tokens are part of prompt tokens, reasoning part of completion tokens.
+	openai := Usage{InputTokens: 1_000_000, CachedTokens: 400_000, OutputTokens: 500_000, ReasoningTokens: 200_000, TotalTokens: 1_500_000}
+	want := 0.6*2 + 0.4*0.5 + 0.3*10 + 0.2*10
+	if got := price.Cost("codex", "gpt-5", openai); !approx(got, want) {
+		t.Fatalf("openai cost = %v, want %v", got, want)
+	}
+
+	// Claude: cache reads are reported next to input tokens.
+	claude := Usage{InputTokens: 1_000_000, CachedTokens: 400_000, OutputTokens: 500_000, TotalTokens: 1_500_000}
+	want = 1*2 + 0.4*0.5 + 0.5*10
+	if got := price.Cost("claude", "claude-sonnet-4-5", claude); !approx(got, want) {
+		t.Fatalf("claude cost = %v, want %v", got, want)
+	}
+
+	// Gemini: thoughts are reported on top of candidates.
+	gemini := Usage{InputTokens: 1_000_000, OutputTokens: 500_000, ReasoningTokens: 200_000, TotalTokens: 1_700_000}
+	want = 1*2 + 0.5*10 + 0.2*10
+	if got := price.Cost("gemini", "gemini-2.5-pro", gemini); !approx(got, want) {
+		t.Fatalf("gemini cost = %v, want %v", got, want)
+	}
+}
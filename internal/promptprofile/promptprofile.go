@@ -0,0 +1,334 @@
+// Package promptprofile injects configured system prompts and prompt templates into client
+// requests in their source format, before they are translated for the upstream provider.
+package promptprofile
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// TemplateHeader selects a named prompt template for a request.
+const TemplateHeader = "X-Prompt-Template"
+
+// templateSuffixPattern matches "model+template" with an optional trailing thinking suffix,
+// e.g. "gpt-5+review" or "gpt-5+review(high)".
+var templateSuffixPattern = regexp.MustCompile(`^(.+?)\+([A-Za-z0-9_.\-]+)(\([^()]*\))?$`)
+
+// Request describes the client request profiles are resolved for.
+type Request struct {
+	APIKey string
+	Model  string
+	// Template is the template requested through TemplateHeader.
+	Template string
+	// User is the client-supplied end-user identifier, if any.
+	User string
+	Now  time.Time
+}
+
+// Selection holds the prompt text to inject around the client's own system prompt.
+type Selection struct {
+	Prepend []string
+	Append  []string
+}
+
+// Empty reports whether nothing is injected.
+func (s *Selection) Empty() bool {
+	return s == nil || (len(s.Prepend) == 0 && len(s.Append) == 0)
+}
+
+// Resolve collects the prompts of the profiles matching req. A "+name" model suffix naming a
+// template of a matching profile selects that template and is removed from the returned model;
+// other suffixes are left untouched.
+func Resolve(profiles []config.PromptProfile, req Request) (string, *Selection) {
+	if len(profiles) == 0 {
+		return req.Model, nil
+	}
+	model := req.Model
+	templateName := strings.TrimSpace(req.Template)
+	var matched []*config.PromptProfile
+	if parts := templateSuffixPattern.FindStringSubmatch(model); parts != nil {
+		base := parts[1] + parts[3]
+		candidates := matchProfiles(profiles, req.APIKey, base)
+		if findTemplate(candidates, parts[2]) != nil {
+			model, matched = base, candidates
+			if templateName == "" {
+				templateName = parts[2]
+			}
+		}
+	}
+	if matched == nil {
+		matched = matchProfiles(profiles, req.APIKey, model)
+	}
+	if len(matched) == 0 {
+		return model, nil
+	}
+
+	sel := &Selection{}
+	for _, profile := range matched {
+		if profile.System == "" {
+			continue
+		}
+		sel.add(profile, expand(profile.System, profile, req, model))
+	}
+	if templateName != "" {
+		if profile := findTemplate(matched, templateName); profile != nil {
+			sel.add(profile, expand(profile.Templates[templateName], profile, req, model))
+		}
+	}
+	if sel.Empty() {
+		return model, nil
+	}
+	return model, sel
+}
+
+func (s *Selection) add(profile *config.PromptProfile, text string) {
+	if strings.EqualFold(profile.Position, config.PromptPositionAppend) {
+		s.Append = append(s.Append, text)
+		return
+	}
+	s.Prepend = append(s.Prepend, text)
+}
+
+func matchProfiles(profiles []config.PromptProfile, apiKey, model string) []*config.PromptProfile {
+	var matched []*config.PromptProfile
+	for i := range profiles {
+		profile := &profiles[i]
+		if len(profile.APIKeys) > 0 && !containsString(profile.APIKeys, apiKey) {
+			continue
+		}
+		if len(profile.Models) > 0 && !matchesAnyModel(profile.Models, model) {
+			continue
+		}
+		matched = append(matched, profile)
+	}
+	return matched
+}
+
+func findTemplate(profiles []*config.PromptProfile, name string) *config.PromptProfile {
+	for _, profile := range profiles {
+		if _, ok := profile.Templates[name]; ok {
+			return profile
+		}
+	}
+	return nil
+}
+
+// expand substitutes {{date}}, {{datetime}}, {{user}}, {{key_label}} and {{model}}.
+func expand(text string, profile *config.PromptProfile, req Request, model string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	now := req.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	label := profile.Label
+	if label == "" {
+		label = profile.Name
+	}
+	return strings.NewReplacer(
+		"{{date}}", now.Format("2006-01-02"),
+		"{{datetime}}", now.Format(time.RFC3339),
+		"{{user}}", req.User,
+		"{{key_label}}", label,
+		"{{model}}", model,
+	).Replace(text)
+}
+
+// UserFromRequest returns the end-user identifier a client sent in its request body.
+func UserFromRequest(format string, body []byte) string {
+	switch format {
+	case constant.OpenAI, constant.OpenaiResponse:
+		return strings.TrimSpace(gjson.GetBytes(body, "user").String())
+	case constant.Claude:
+		return strings.TrimSpace(gjson.GetBytes(body, "metadata.user_id").String())
+	}
+	return ""
+}
+
+// Inject adds the selected prompts to body as system instructions of the source format.
+func Inject(format string, body []byte, sel *Selection) []byte {
+	if sel.Empty() || !gjson.ValidBytes(body) {
+		return body
+	}
+	switch format {
+	case constant.OpenAI:
+		return injectOpenAI(body, sel)
+	case constant.OpenaiResponse:
+		return injectString(body, "instructions", sel)
+	case constant.Claude:
+		return injectClaude(body, sel)
+	case constant.Gemini:
+		return injectGemini(body, "", sel)
+	case constant.GeminiCLI:
+		return injectGemini(body, "request.", sel)
+	}
+	return body
+}
+
+// injectOpenAI inserts system messages before the conversation, or after the client's leading
+// system and developer messages when appending.
+func injectOpenAI(body []byte, sel *Selection) []byte {
+	messages := gjson.GetBytes(body, "messages")
+	if !messages.IsArray() {
+		return body
+	}
+	existing := messages.Array()
+	leading := 0
+	for leading < len(existing) {
+		role := existing[leading].Get("role").String()
+		if role != "system" && role != "developer" {
+			break
+		}
+		leading++
+	}
+	raw := make([]string, 0, len(existing)+len(sel.Prepend)+len(sel.Append))
+	for _, text := range sel.Prepend {
+		raw = append(raw, systemMessage(text))
+	}
+	for i, message := range existing {
+		if i == leading {
+			for _, text := range sel.Append {
+				raw = append(raw, systemMessage(text))
+			}
+		}
+		raw = append(raw, message.Raw)
+	}
+	if leading == len(existing) {
+		for _, text := range sel.Append {
+			raw = append(raw, systemMessage(text))
+		}
+	}
+	out, err := sjson.SetRawBytes(body, "messages", []byte("["+strings.Join(raw, ",")+"]"))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func systemMessage(text string) string {
+	out, _ := sjson.Set(`{"role":"system"}`, "content", text)
+	return out
+}
+
+// injectString joins the prompts with a string field such as the Responses API instructions.
+func injectString(body []byte, path string, sel *Selection) []byte {
+	parts := append([]string(nil), sel.Prepend...)
+	if current := gjson.GetBytes(body, path); current.Type == gjson.String && strings.TrimSpace(current.Str) != "" {
+		parts = append(parts, current.Str)
+	}
+	parts = append(parts, sel.Append...)
+	out, err := sjson.SetBytes(body, path, strings.Join(parts, "\n\n"))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// injectClaude handles both the string and the content-block form of the system field.
+func injectClaude(body []byte, sel *Selection) []byte {
+	system := gjson.GetBytes(body, "system")
+	if !system.IsArray() {
+		return injectString(body, "system", sel)
+	}
+	blocks := make([]string, 0, len(system.Array())+len(sel.Prepend)+len(sel.Append))
+	for _, text := range sel.Prepend {
+		blocks = append(blocks, textBlock(`{"type":"text"}`, text))
+	}
+	for _, block := range system.Array() {
+		blocks = append(blocks, block.Raw)
+	}
+	for _, text := range sel.Append {
+		blocks = append(blocks, textBlock(`{"type":"text"}`, text))
+	}
+	out, err := sjson.SetRawBytes(body, "system", []byte("["+strings.Join(blocks, ",")+"]"))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// injectGemini adds text parts to systemInstruction, accepting the snake_case spelling too.
+func injectGemini(body []byte, root string, sel *Selection) []byte {
+	path := root + "systemInstruction"
+	if !gjson.GetBytes(body, path).Exists() && gjson.GetBytes(body, root+"system_instruction").Exists() {
+		path = root + "system_instruction"
+	}
+	existing := gjson.GetBytes(body, path+".parts").Array()
+	parts := make([]string, 0, len(existing)+len(sel.Prepend)+len(sel.Append))
+	for _, text := range sel.Prepend {
+		parts = append(parts, textBlock(`{}`, text))
+	}
+	for _, part := range existing {
+		parts = append(parts, part.Raw)
+	}
+	for _, text := range sel.Append {
+		parts = append(parts, textBlock(`{}`, text))
+	}
+	out, err := sjson.SetRawBytes(body, path+".parts", []byte("["+strings.Join(parts, ",")+"]"))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func textBlock(base, text string) string {
+	out, _ := sjson.Set(base, "text", text)
+	return out
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyModel matches the model with and without its thinking suffix.
+func matchesAnyModel(patterns []string, model string) bool {
+	base := thinking.ParseSuffix(model).ModelName
+	for _, pattern := range patterns {
+		if matchModelPattern(pattern, model) || (base != model && matchModelPattern(pattern, base)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModelPattern performs simple wildcard matching where '*' matches zero or more characters.
+func matchModelPattern(pattern, model string) bool {
+	if pattern == "*" {
+		return true
+	}
+	pi, si := 0, 0
+	starIdx, matchIdx := -1, 0
+	for si < len(model) {
+		switch {
+		case pi < len(pattern) && pattern[pi] == model[si]:
+			pi++
+			si++
+		case pi < len(pattern) && pattern[pi] == '*':
+			starIdx, matchIdx = pi, si
+			pi++
+		case starIdx != -1:
+			pi = starIdx + 1
+			matchIdx++
+			si = matchIdx
+		default:
+			return false
+		}
+	}
+	for pi < len(pattern) && pattern[pi] == '*' {
+		pi++
+	}
+	return pi == len(pattern)
+}
@@ -0,0 +1,86 @@
+package promptprofile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+var testProfiles = []config.PromptProfile{
+	{
+		Name:    "platform",
+		APIKeys: []string{"key-a"},
+		Label:   "Platform",
+		System:  "Team {{key_label}} on {{date}} for {{user}}.",
+		Templates: map[string]string{
+			"review": "Review {{model}}.",
+		},
+	},
+	{
+		Name:     "safety",
+		Models:   []string{"gpt-*"},
+		Position: config.PromptPositionAppend,
+		System:   "Be safe.",
+	},
+}
+
+func TestResolveSelectsProfilesAndTemplate(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	model, sel := Resolve(testProfiles, Request{APIKey: "key-a", Model: "gpt-5+review(high)", User: "jane", Now: now})
+	if model != "gpt-5(high)" {
+		t.Fatalf("model = %q", model)
+	}
+	if len(sel.Prepend) != 2 || sel.Prepend[0] != "Team Platform on 2026-03-04 for jane." || sel.Prepend[1] != "Review gpt-5(high)." {
+		t.Fatalf("unexpected prepend: %q", sel.Prepend)
+	}
+	if len(sel.Append) != 1 || sel.Append[0] != "Be safe." {
+		t.Fatalf("unexpected append: %q", sel.Append)
+	}
+
+	// Unknown suffixes are not templates and stay part of the model name.
+	model, sel = Resolve(testProfiles, Request{APIKey: "key-b", Model: "claude+extra"})
+	if model != "claude+extra" || sel != nil {
+		t.Fatalf("unexpected resolution: %q %+v", model, sel)
+	}
+
+	// The header selects a template without a model suffix.
+	_, sel = Resolve(testProfiles, Request{APIKey: "key-a", Model: "claude", Template: "review"})
+	if len(sel.Prepend) != 2 || sel.Prepend[1] != "Review claude." {
+		t.Fatalf("unexpected header template: %+v", sel)
+	}
+}
+
+func TestInjectPerFormat(t *testing.T) {
+	sel := &Selection{Prepend: []string{"P"}, Append: []string{"A"}}
+
+	openai := Inject("openai", []byte(`{"messages":[{"role":"system","content":"S"},{"role":"user","content":"hi"}]}`), sel)
+	roles := gjson.GetBytes(openai, "messages.#.content").String()
+	if roles != `["P","S","A","hi"]` {
+		t.Fatalf("openai messages = %s", roles)
+	}
+
+	responses := Inject("openai-response", []byte(`{"instructions":"S","input":"hi"}`), sel)
+	if got := gjson.GetBytes(responses, "instructions").String(); got != "P\n\nS\n\nA" {
+		t.Fatalf("responses instructions = %q", got)
+	}
+
+	claude := Inject("claude", []byte(`{"system":[{"type":"text","text":"S","cache_control":{"type":"ephemeral"}}]}`), sel)
+	if got := gjson.GetBytes(claude, "system.#.text").String(); got != `["P","S","A"]` {
+		t.Fatalf("claude system = %s", got)
+	}
+	claude = Inject("claude", []byte(`{"messages":[]}`), sel)
+	if got := gjson.GetBytes(claude, "system").String(); got != "P\n\nA" {
+		t.Fatalf("claude system = %q", got)
+	}
+
+	gemini := Inject("gemini", []byte(`{"contents":[]}`), sel)
+	if got := gjson.GetBytes(gemini, "systemInstruction.parts.#.text").String(); got != `["P","A"]` {
+		t.Fatalf("gemini systemInstruction = %s", got)
+	}
+	cli := Inject("gemini-cli", []byte(`{"request":{"system_instruction":{"parts":[{"text":"S"}]}}}`), sel)
+	if got := gjson.GetBytes(cli, "request.system_instruction.parts.#.text").String(); got != `["P","S","A"]` {
+		t.Fatalf("gemini-cli system_instruction = %s", got)
+	}
+}
@@ -203,6 +203,36 @@ func GetGeminiModels() []*ModelInfo {
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
 		},
+		{
+			ID:                         "gemini-2.5-flash-preview-tts",
+			Object:                     "model",
+			Created:                    1747699200,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/gemini-2.5-flash-preview-tts",
+			Version:                    "2.5",
+			DisplayName:                "Gemini 2.5 Flash Preview TTS",
+			Description:                "Gemini 2.5 Flash text-to-speech model",
+			InputTokenLimit:            8192,
+			OutputTokenLimit:           16384,
+			SupportedGenerationMethods: []string{"generateContent", "countTokens"},
+			// TTS models don't support thinkingConfig; leave Thinking nil
+		},
+		{
+			ID:                         "gemini-2.5-pro-preview-tts",
+			Object:                     "model",
+			Created:                    1747699200,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/gemini-2.5-pro-preview-tts",
+			Version:                    "2.5",
+			DisplayName:                "Gemini 2.5 Pro Preview TTS",
+			Description:                "Gemini 2.5 Pro text-to-speech model",
+			InputTokenLimit:            8192,
+			OutputTokenLimit:           16384,
+			SupportedGenerationMethods: []string{"generateContent", "countTokens"},
+			// TTS models don't support thinkingConfig; leave Thinking nil
+		},
 	}
 }
 
@@ -596,6 +626,36 @@ func GetAIStudioModels() []*ModelInfo {
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			// image models don't support thinkingConfig; leave Thinking nil
 		},
+		{
+			ID:                         "gemini-2.5-flash-preview-tts",
+			Object:                     "model",
+			Created:                    1747699200,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/gemini-2.5-flash-preview-tts",
+			Version:                    "2.5",
+			DisplayName:                "Gemini 2.5 Flash Preview TTS",
+			Description:                "Gemini 2.5 Flash text-to-speech model",
+			InputTokenLimit:            8192,
+			OutputTokenLimit:           16384,
+			SupportedGenerationMethods: []string{"generateContent", "countTokens"},
+			// TTS models don't support thinkingConfig; leave Thinking nil
+		},
+		{
+			ID:                         "gemini-2.5-pro-preview-tts",
+			Object:                     "model",
+			Created:                    1747699200,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/gemini-2.5-pro-preview-tts",
+			Version:                    "2.5",
+			DisplayName:                "Gemini 2.5 Pro Preview TTS",
+			Description:                "Gemini 2.5 Pro text-to-speech model",
+			InputTokenLimit:            8192,
+			OutputTokenLimit:           16384,
+			SupportedGenerationMethods: []string{"generateContent", "countTokens"},
+			// TTS models don't support thinkingConfig; leave Thinking nil
+		},
 	}
 }
 
@@ -846,6 +906,39 @@ func GetIFlowModels() []*ModelInfo {
 	return models
 }
 
+// GetCopilotModels returns the GitHub Copilot chat models used when the account's model list
+// cannot be fetched.
+func GetCopilotModels() []*ModelInfo {
+	entries := []struct {
+		ID            string
+		DisplayName   string
+		ContextLength int
+	}{
+		{ID: "gpt-4.1", DisplayName: "GPT-4.1", ContextLength: 128000},
+		{ID: "gpt-4o", DisplayName: "GPT-4o", ContextLength: 128000},
+		{ID: "gpt-5-mini", DisplayName: "GPT-5 mini", ContextLength: 128000},
+		{ID: "gpt-5", DisplayName: "GPT-5", ContextLength: 128000},
+		{ID: "o4-mini", DisplayName: "o4-mini", ContextLength: 128000},
+		{ID: "claude-sonnet-4", DisplayName: "Claude Sonnet 4", ContextLength: 128000},
+		{ID: "claude-sonnet-4.5", DisplayName: "Claude Sonnet 4.5", ContextLength: 128000},
+		{ID: "gemini-2.5-pro", DisplayName: "Gemini 2.5 Pro", ContextLength: 128000},
+	}
+	models := make([]*ModelInfo, 0, len(entries))
+	for _, entry := range entries {
+		models = append(models, &ModelInfo{
+			ID:            entry.ID,
+			Object:        "model",
+			Created:       1743465600,
+			OwnedBy:       "copilot",
+			Type:          "copilot",
+			DisplayName:   entry.DisplayName,
+			Description:   "GitHub Copilot " + entry.DisplayName,
+			ContextLength: entry.ContextLength,
+		})
+	}
+	return models
+}
+
 // AntigravityModelConfig captures static antigravity model overrides, including
 // Thinking budget limits and provider max completion tokens.
 type AntigravityModelConfig struct {
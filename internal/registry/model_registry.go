@@ -5,6 +5,8 @@ package registry
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sort"
 	"strings"
@@ -1160,6 +1162,38 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		}
 		return result
 
+	case "ollama":
+		modifiedAt := time.Unix(model.Created, 0).UTC()
+		if model.Created <= 0 {
+			modifiedAt = time.Unix(0, 0).UTC()
+		}
+		digest := sha256.Sum256([]byte(model.ID))
+		family := model.OwnedBy
+		if family == "" {
+			family = model.Type
+		}
+		result := map[string]any{
+			"name":        model.ID,
+			"model":       model.ID,
+			"modified_at": modifiedAt.Format(time.RFC3339),
+			"size":        0,
+			"digest":      hex.EncodeToString(digest[:]),
+			"details": map[string]any{
+				"parent_model":       "",
+				"format":             "remote",
+				"family":             family,
+				"families":           []string{family},
+				"parameter_size":     "",
+				"quantization_level": "",
+			},
+		}
+		if model.ContextLength > 0 {
+			result["context_length"] = model.ContextLength
+		} else if model.InputTokenLimit > 0 {
+			result["context_length"] = model.InputTokenLimit
+		}
+		return result
+
 	default:
 		// Generic format
 		result := map[string]any{
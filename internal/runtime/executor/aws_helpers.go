@@ -0,0 +1,329 @@
+package executor
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsSigningAlgorithm = "AWS4-HMAC-SHA256"
+	awsAmzDateFormat    = "20060102T150405Z"
+	// awsEventStreamMaxMessage bounds a single event-stream frame (16 MiB, the protocol limit).
+	awsEventStreamMaxMessage = 16 * 1024 * 1024
+)
+
+// awsCredentials holds the key material used to sign AWS requests.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// awsSignedHeaders lists the request headers covered by the SigV4 signature when present.
+var awsSignedHeaders = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date", "x-amz-security-token"}
+
+// signAWSRequestV4 signs req in place with AWS Signature Version 4.
+// The body must be the exact payload that will be sent.
+func signAWSRequestV4(req *http.Request, body []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(awsAmzDateFormat)
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	} else {
+		req.Header.Del("X-Amz-Security-Token")
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headerValues := make(map[string]string, len(awsSignedHeaders))
+	signed := make([]string, 0, len(awsSignedHeaders))
+	for _, name := range awsSignedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = host
+		}
+		if value == "" {
+			continue
+		}
+		headerValues[name] = strings.Join(strings.Fields(value), " ")
+		signed = append(signed, name)
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signed {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteByte(':')
+		canonicalHeaders.WriteString(headerValues[name])
+		canonicalHeaders.WriteByte('\n')
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalURI(req.URL.EscapedPath()),
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := awsSigningAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// awsCanonicalURI re-encodes every segment of an already escaped path, as SigV4
+// requires for all services except S3.
+func awsCanonicalURI(escapedPath string) string {
+	if escapedPath == "" {
+		return "/"
+	}
+	segments := strings.Split(escapedPath, "/")
+	for i := range segments {
+		segments[i] = awsURIEncode(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(values map[string][]string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(values))
+	for key, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, awsURIEncode(key)+"="+awsURIEncode(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode percent-encodes every byte outside the RFC 3986 unreserved set.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEventStreamMessage is a decoded frame of the application/vnd.amazon.eventstream encoding.
+type awsEventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// readAWSEventStreamMessage reads one event-stream frame, validating both CRCs.
+// It returns io.EOF when the stream ends cleanly between frames.
+func readAWSEventStreamMessage(r io.Reader) (awsEventStreamMessage, error) {
+	var msg awsEventStreamMessage
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(r, prelude); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return msg, fmt.Errorf("event stream: truncated prelude")
+		}
+		return msg, err
+	}
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return msg, fmt.Errorf("event stream: prelude checksum mismatch")
+	}
+	if totalLength < 16 || totalLength > awsEventStreamMaxMessage || headersLength > totalLength-16 {
+		return msg, fmt.Errorf("event stream: invalid frame length %d", totalLength)
+	}
+	rest := make([]byte, totalLength-12)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return msg, fmt.Errorf("event stream: truncated frame: %w", err)
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(prelude)
+	crc.Write(rest[:len(rest)-4])
+	if crc.Sum32() != binary.BigEndian.Uint32(rest[len(rest)-4:]) {
+		return msg, fmt.Errorf("event stream: message checksum mismatch")
+	}
+	headers, err := parseAWSEventStreamHeaders(rest[:headersLength])
+	if err != nil {
+		return msg, err
+	}
+	msg.Headers = headers
+	msg.Payload = rest[headersLength : len(rest)-4]
+	return msg, nil
+}
+
+// parseAWSEventStreamHeaders decodes frame headers. String and byte-array values are kept;
+// other value types are skipped because Bedrock only uses string headers.
+func parseAWSEventStreamHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 1+nameLen+1 {
+			return nil, fmt.Errorf("event stream: truncated header")
+		}
+		name := string(data[1 : 1+nameLen])
+		valueType := data[1+nameLen]
+		data = data[2+nameLen:]
+		var size int
+		switch valueType {
+		case 0, 1: // bool true / false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // int16
+			size = 2
+		case 4: // int32
+			size = 4
+		case 5, 8: // int64, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // byte array, string
+			if len(data) < 2 {
+				return nil, fmt.Errorf("event stream: truncated header value")
+			}
+			valueLen := int(binary.BigEndian.Uint16(data[:2]))
+			if len(data) < 2+valueLen {
+				return nil, fmt.Errorf("event stream: truncated header value")
+			}
+			headers[name] = string(data[2 : 2+valueLen])
+			data = data[2+valueLen:]
+			continue
+		default:
+			return nil, fmt.Errorf("event stream: unknown header type %d", valueType)
+		}
+		if len(data) < size {
+			return nil, fmt.Errorf("event stream: truncated header value")
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
+
+// loadAWSProfileCredentials reads a named profile from the shared credentials file,
+// falling back to the shared config file. AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE
+// override the default locations under ~/.aws.
+func loadAWSProfileCredentials(profile string) (awsCredentials, error) {
+	profile = strings.TrimSpace(profile)
+	if profile == "" {
+		profile = "default"
+	}
+	home, _ := os.UserHomeDir()
+	credentialsPath := strings.TrimSpace(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"))
+	if credentialsPath == "" && home != "" {
+		credentialsPath = filepath.Join(home, ".aws", "credentials")
+	}
+	configPath := strings.TrimSpace(os.Getenv("AWS_CONFIG_FILE"))
+	if configPath == "" && home != "" {
+		configPath = filepath.Join(home, ".aws", "config")
+	}
+
+	sources := []struct {
+		path    string
+		section string
+	}{
+		{credentialsPath, profile},
+		{configPath, "profile " + profile},
+	}
+	if profile == "default" {
+		sources = append(sources, struct {
+			path    string
+			section string
+		}{configPath, "default"})
+	}
+	for _, source := range sources {
+		if source.path == "" {
+			continue
+		}
+		values, err := readINISection(source.path, source.section)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return awsCredentials{}, err
+		}
+		creds := awsCredentials{
+			AccessKeyID:     values["aws_access_key_id"],
+			SecretAccessKey: values["aws_secret_access_key"],
+			SessionToken:    values["aws_session_token"],
+		}
+		if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+			return creds, nil
+		}
+	}
+	return awsCredentials{}, fmt.Errorf("aws profile %q: no static credentials found", profile)
+}
+
+// readINISection returns the key/value pairs of one section of an INI file.
+func readINISection(path, section string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	values := make(map[string]string)
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			continue
+		}
+		if current != section {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return values, scanner.Err()
+}
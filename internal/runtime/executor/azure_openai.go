@@ -0,0 +1,173 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	azureDefaultEntraScope     = "https://cognitiveservices.azure.com/.default"
+	azureDefaultAuthorityHost  = "https://login.microsoftonline.com"
+	azureEntraTokenRefreshSkew = 2 * time.Minute
+)
+
+// azureOpenAISettings describes how an OpenAI-compatibility auth talks to Azure OpenAI.
+type azureOpenAISettings struct {
+	apiVersion    string
+	responses     bool
+	tenantID      string
+	clientID      string
+	clientSecret  string
+	scope         string
+	authorityHost string
+}
+
+// azureSettingsFromAuth returns the Azure settings recorded on the auth, or nil when the
+// provider uses plain OpenAI-compatible conventions.
+func azureSettingsFromAuth(auth *cliproxyauth.Auth) *azureOpenAISettings {
+	if auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	apiVersion := strings.TrimSpace(auth.Attributes["azure_api_version"])
+	if apiVersion == "" {
+		return nil
+	}
+	settings := &azureOpenAISettings{
+		apiVersion:    apiVersion,
+		responses:     strings.EqualFold(auth.Attributes["azure_responses"], "true"),
+		tenantID:      strings.TrimSpace(auth.Attributes["azure_tenant_id"]),
+		clientID:      strings.TrimSpace(auth.Attributes["azure_client_id"]),
+		clientSecret:  strings.TrimSpace(auth.Attributes["azure_client_secret"]),
+		scope:         strings.TrimSpace(auth.Attributes["azure_scope"]),
+		authorityHost: strings.TrimRight(strings.TrimSpace(auth.Attributes["azure_authority_host"]), "/"),
+	}
+	if settings.scope == "" {
+		settings.scope = azureDefaultEntraScope
+	}
+	if settings.authorityHost == "" {
+		settings.authorityHost = azureDefaultAuthorityHost
+	}
+	return settings
+}
+
+func (s *azureOpenAISettings) usesEntra() bool {
+	return s != nil && s.tenantID != "" && s.clientID != "" && s.clientSecret != ""
+}
+
+// endpointURL maps an OpenAI endpoint ("/chat/completions", "/responses") to its Azure URL.
+// Chat completions are deployment scoped; the v1 API and Responses take the deployment
+// as the model in the request body.
+func (s *azureOpenAISettings) endpointURL(baseURL, endpoint, deployment string) string {
+	base := strings.TrimSuffix(baseURL, "/")
+	base = strings.TrimSuffix(base, "/openai")
+	if strings.EqualFold(s.apiVersion, "v1") {
+		return base + "/openai/v1" + endpoint
+	}
+	query := "?api-version=" + url.QueryEscape(s.apiVersion)
+	if endpoint == "/chat/completions" {
+		return base + "/openai/deployments/" + url.PathEscape(deployment) + endpoint + query
+	}
+	return base + "/openai" + endpoint + query
+}
+
+// applyAuthorization sets the credential header for the upstream request: a Bearer API key
+// for generic providers, the api-key header for Azure keys, or an Entra ID access token.
+func (e *OpenAICompatExecutor) applyAuthorization(ctx context.Context, req *http.Request, auth *cliproxyauth.Auth, azure *azureOpenAISettings, apiKey string) error {
+	switch {
+	case azure.usesEntra():
+		token, err := azureEntraToken(ctx, newProxyAwareHTTPClient(ctx, e.cfg, auth, 30*time.Second), azure)
+		if err != nil {
+			return statusErr{code: http.StatusUnauthorized, msg: err.Error()}
+		}
+		req.Header.Del("api-key")
+		req.Header.Set("Authorization", "Bearer "+token)
+	case azure != nil:
+		req.Header.Del("Authorization")
+		if apiKey != "" {
+			req.Header.Set("api-key", apiKey)
+		}
+	case apiKey != "":
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return nil
+}
+
+// azureEntraCachedToken caches the token of one credential. lock serialises refreshes of that
+// credential only, so a slow token endpoint never holds up other credentials.
+type azureEntraCachedToken struct {
+	lock        chan struct{}
+	accessToken string
+	expiresAt   time.Time
+}
+
+var (
+	azureEntraTokenMu    sync.Mutex
+	azureEntraTokenCache = make(map[string]*azureEntraCachedToken)
+)
+
+// azureEntraToken returns a cached client-credential access token, requesting a new one
+// from the Entra ID token endpoint shortly before the cached token expires.
+func azureEntraToken(ctx context.Context, client *http.Client, s *azureOpenAISettings) (string, error) {
+	cacheKey := s.authorityHost + "|" + s.tenantID + "|" + s.clientID + "|" + s.scope + "|" + sha256Hex([]byte(s.clientSecret))
+	azureEntraTokenMu.Lock()
+	entry, ok := azureEntraTokenCache[cacheKey]
+	if !ok {
+		entry = &azureEntraCachedToken{lock: make(chan struct{}, 1)}
+		azureEntraTokenCache[cacheKey] = entry
+	}
+	azureEntraTokenMu.Unlock()
+
+	select {
+	case entry.lock <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-entry.lock }()
+	if entry.accessToken != "" && time.Now().Add(azureEntraTokenRefreshSkew).Before(entry.expiresAt) {
+		return entry.accessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", s.clientID)
+	form.Set("client_secret", s.clientSecret)
+	form.Set("scope", s.scope)
+	tokenURL := s.authorityHost + "/" + url.PathEscape(s.tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("azure entra token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("azure entra token response read failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("azure entra token request failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	token := gjson.GetBytes(body, "access_token").String()
+	if token == "" {
+		return "", fmt.Errorf("azure entra token response missing access_token")
+	}
+	expiresIn := gjson.GetBytes(body, "expires_in").Int()
+	if expiresIn <= 0 {
+		expiresIn = 3600
+	}
+	entry.accessToken = token
+	entry.expiresAt = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	return token, nil
+}
@@ -0,0 +1,165 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestAzureEndpointURL(t *testing.T) {
+	s := &azureOpenAISettings{apiVersion: "2024-10-21"}
+	cases := []struct {
+		base, endpoint, want string
+	}{
+		{"https://res.openai.azure.com", "/chat/completions", "https://res.openai.azure.com/openai/deployments/gpt-4o-prod/chat/completions?api-version=2024-10-21"},
+		{"https://res.openai.azure.com/openai/", "/chat/completions", "https://res.openai.azure.com/openai/deployments/gpt-4o-prod/chat/completions?api-version=2024-10-21"},
+		{"https://res.openai.azure.com", "/responses", "https://res.openai.azure.com/openai/responses?api-version=2024-10-21"},
+	}
+	for _, tc := range cases {
+		if got := s.endpointURL(tc.base, tc.endpoint, "gpt-4o-prod"); got != tc.want {
+			t.Fatalf("endpointURL(%q, %q) = %q, want %q", tc.base, tc.endpoint, got, tc.want)
+		}
+	}
+	v1 := &azureOpenAISettings{apiVersion: "v1"}
+	if got := v1.endpointURL("https://res.openai.azure.com", "/chat/completions", "gpt-4o-prod"); got != "https://res.openai.azure.com/openai/v1/chat/completions" {
+		t.Fatalf("v1 endpointURL = %q", got)
+	}
+}
+
+func TestOpenAICompatExecutorAzureAPIKey(t *testing.T) {
+	var gotPath, gotVersion, gotKey, gotAuthorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		gotAuthorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("azure", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url":          server.URL,
+		"api_key":           "azure-key",
+		"azure_api_version": "2024-10-21",
+	}}
+	_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-4o-prod",
+		Payload: []byte(`{"model":"gpt-4o-prod","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotVersion != "2024-10-21" {
+		t.Fatalf("api-version = %q", gotVersion)
+	}
+	if gotKey != "azure-key" || gotAuthorization != "" {
+		t.Fatalf("api-key = %q, authorization = %q", gotKey, gotAuthorization)
+	}
+}
+
+func TestOpenAICompatExecutorAzureEntraResponses(t *testing.T) {
+	var tokenRequests atomic.Int32
+	var gotPath, gotAuthorization, gotKey string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+			tokenRequests.Add(1)
+			_ = r.ParseForm()
+			if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_id") != "client" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"entra-token","expires_in":3600}`))
+			return
+		}
+		gotPath = r.URL.Path
+		gotAuthorization = r.Header.Get("Authorization")
+		gotKey = r.Header.Get("api-key")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"resp_1","object":"response","usage":{"input_tokens":1,"output_tokens":2,"total_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("azure", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url":             server.URL,
+		"azure_api_version":    "preview",
+		"azure_responses":      "true",
+		"azure_tenant_id":      "tenant-responses",
+		"azure_client_id":      "client",
+		"azure_client_secret":  "secret",
+		"azure_authority_host": server.URL,
+	}}
+	for i := 0; i < 2; i++ {
+		resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+			Model:   "codex-prod",
+			Payload: []byte(`{"model":"codex-prod","input":[{"role":"user","content":"hi"}]}`),
+		}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")})
+		if err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		if gjson.GetBytes(resp.Payload, "id").String() != "resp_1" {
+			t.Fatalf("payload = %s", resp.Payload)
+		}
+	}
+	if gotPath != "/openai/responses" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotAuthorization != "Bearer entra-token" || gotKey != "" {
+		t.Fatalf("authorization = %q, api-key = %q", gotAuthorization, gotKey)
+	}
+	if !gjson.GetBytes(gotBody, "input").Exists() || gjson.GetBytes(gotBody, "messages").Exists() {
+		t.Fatalf("expected responses body to pass through, got %s", gotBody)
+	}
+	if n := tokenRequests.Load(); n != 1 {
+		t.Fatalf("token requests = %d, want 1 (cached)", n)
+	}
+}
+
+func TestAzureEntraTokenDoesNotBlockOtherCredentials(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/tenant-slow/") {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	settings := func(tenant string) *azureOpenAISettings {
+		return &azureOpenAISettings{tenantID: tenant, clientID: "client", clientSecret: "secret", scope: azureDefaultEntraScope, authorityHost: server.URL}
+	}
+	go func() { _, _ = azureEntraToken(context.Background(), server.Client(), settings("tenant-slow")) }()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := azureEntraToken(ctx, server.Client(), settings("tenant-fast")); err != nil {
+		t.Fatalf("token of another credential blocked: %v", err)
+	}
+	waiting, cancelWaiting := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelWaiting()
+	if _, err := azureEntraToken(waiting, server.Client(), settings("tenant-slow")); err == nil {
+		t.Fatal("expected a caller waiting on the slow credential to honour its context")
+	}
+}
@@ -0,0 +1,549 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	bedrockService          = "bedrock"
+	bedrockAnthropicVersion = "bedrock-2023-05-31"
+)
+
+// bedrockClaudeModelIDs maps Anthropic model IDs to Bedrock foundation model IDs.
+var bedrockClaudeModelIDs = map[string]string{
+	"claude-haiku-4-5-20251001":  "anthropic.claude-haiku-4-5-20251001-v1:0",
+	"claude-sonnet-4-5-20250929": "anthropic.claude-sonnet-4-5-20250929-v1:0",
+	"claude-opus-4-6":            "anthropic.claude-opus-4-6-v1",
+	"claude-opus-4-5-20251101":   "anthropic.claude-opus-4-5-20251101-v1:0",
+	"claude-opus-4-1-20250805":   "anthropic.claude-opus-4-1-20250805-v1:0",
+	"claude-opus-4-20250514":     "anthropic.claude-opus-4-20250514-v1:0",
+	"claude-sonnet-4-20250514":   "anthropic.claude-sonnet-4-20250514-v1:0",
+	"claude-3-7-sonnet-20250219": "anthropic.claude-3-7-sonnet-20250219-v1:0",
+	"claude-3-5-haiku-20241022":  "anthropic.claude-3-5-haiku-20241022-v1:0",
+}
+
+// BedrockExecutor serves Claude models through the AWS Bedrock runtime InvokeModel APIs.
+// Requests are translated to the Anthropic messages body and signed with SigV4; streamed
+// responses arrive as AWS event-stream frames and are re-emitted as Claude SSE events.
+type BedrockExecutor struct {
+	cfg *config.Config
+}
+
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor { return &BedrockExecutor{cfg: cfg} }
+
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// PrepareRequest applies custom headers and signs the outgoing HTTP request with SigV4.
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	creds, err := bedrockCredentials(auth)
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	_, region := bedrockEndpoint(auth)
+	signAWSRequestV4(req, body, creds, region, bedrockService, time.Now())
+	return nil
+}
+
+// HttpRequest signs the request with the Bedrock credentials and executes it.
+func (e *BedrockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bedrock executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	// Use streaming translation to preserve function calling, except for claude.
+	stream := from != to
+	bodyForTranslation, body, err := e.translateRequest(ctx, req, opts, baseModel, stream)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := e.invoke(ctx, auth, baseModel, body, stream)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+
+	var data []byte
+	if stream {
+		var sse bytes.Buffer
+		for {
+			line, errRead := readBedrockStreamEvent(httpResp.Body)
+			if errors.Is(errRead, io.EOF) {
+				break
+			}
+			if errRead != nil {
+				recordAPIResponseError(ctx, e.cfg, errRead)
+				return resp, errRead
+			}
+			if line == nil {
+				continue
+			}
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			sse.Write(line)
+			sse.WriteString("\n\n")
+		}
+		data = sse.Bytes()
+	} else {
+		data, err = io.ReadAll(httpResp.Body)
+		if err != nil {
+			recordAPIResponseError(ctx, e.cfg, err)
+			return resp, err
+		}
+		appendAPIResponseChunk(ctx, e.cfg, data)
+		reporter.publish(ctx, parseClaudeUsage(data))
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(
+		ctx,
+		to,
+		from,
+		req.Model,
+		opts.OriginalRequest,
+		bodyForTranslation,
+		data,
+		&param,
+	)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	bodyForTranslation, body, err := e.translateRequest(ctx, req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := e.invoke(ctx, auth, baseModel, body, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("response body close error: %v", errClose)
+			}
+		}()
+
+		var param any
+		for {
+			line, errRead := readBedrockStreamEvent(httpResp.Body)
+			if errors.Is(errRead, io.EOF) {
+				return
+			}
+			if errRead != nil {
+				recordAPIResponseError(ctx, e.cfg, errRead)
+				reporter.publishFailure(ctx)
+				out <- cliproxyexecutor.StreamChunk{Err: errRead}
+				return
+			}
+			if line == nil {
+				continue
+			}
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			// Claude → Claude: forward the event in SSE form without translation
+			if from == to {
+				event := gjson.GetBytes(line[len("data: "):], "type").String()
+				payload := make([]byte, 0, len(event)+len(line)+10)
+				payload = append(payload, "event: "...)
+				payload = append(payload, event...)
+				payload = append(payload, '\n')
+				payload = append(payload, line...)
+				payload = append(payload, '\n', '\n')
+				out <- cliproxyexecutor.StreamChunk{Payload: payload}
+				continue
+			}
+			chunks := sdktranslator.TranslateStream(
+				ctx,
+				to,
+				from,
+				req.Model,
+				opts.OriginalRequest,
+				bodyForTranslation,
+				line,
+				&param,
+			)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+	}()
+	return stream, nil
+}
+
+// CountTokens calls the Bedrock CountTokens API with the InvokeModel body of the request.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	stream := from != to
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	_, body = extractAndRemoveBetas(body)
+	body = bedrockRequestBody(body, nil)
+	// count_tokens requests may omit max_tokens, which InvokeModel requires.
+	if !gjson.GetBytes(body, "max_tokens").Exists() {
+		body, _ = sjson.SetBytes(body, "max_tokens", 1)
+	}
+
+	countBody := []byte(`{"input":{"invokeModel":{"body":""}}}`)
+	countBody, _ = sjson.SetBytes(countBody, "input.invokeModel.body", base64.StdEncoding.EncodeToString(body))
+
+	baseURL, region := bedrockEndpoint(auth)
+	// CountTokens accepts foundation model IDs only, never inference profiles.
+	modelID := resolveBedrockModelID(baseModel, region, true)
+	url := baseURL + "/model/" + awsURIEncode(modelID) + "/count-tokens"
+	data, err := e.doJSON(ctx, auth, url, countBody, "application/json")
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	count := gjson.GetBytes(data, "inputTokens").Int()
+	claudeCount, _ := sjson.SetBytes([]byte(`{}`), "input_tokens", count)
+	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, claudeCount)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+// Refresh is a no-op: static and profile credentials are resolved on every request.
+func (e *BedrockExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("bedrock executor: refresh called")
+	return auth, nil
+}
+
+// translateRequest converts the client payload into the Bedrock InvokeModel body.
+// It returns the Claude request used for response translation and the upstream body.
+func (e *BedrockExecutor) translateRequest(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string, stream bool) ([]byte, []byte, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadRequestInfoFrom(ctx, opts))
+
+	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
+	body = disableThinkingIfToolChoiceForced(body)
+
+	var betas []string
+	betas, body = extractAndRemoveBetas(body)
+	return body, bedrockRequestBody(body, betas), nil
+}
+
+// invoke sends the body to InvokeModel or InvokeModelWithResponseStream and returns the
+// successful response. Non-2xx responses are converted into status errors.
+func (e *BedrockExecutor) invoke(ctx context.Context, auth *cliproxyauth.Auth, baseModel string, body []byte, stream bool) (*http.Response, error) {
+	baseURL, region := bedrockEndpoint(auth)
+	disableProfile := auth != nil && auth.Attributes != nil && strings.EqualFold(auth.Attributes["disable_inference_profile"], "true")
+	modelID := resolveBedrockModelID(baseModel, region, disableProfile)
+	action, accept := "invoke", "application/json"
+	if stream {
+		action, accept = "invoke-with-response-stream", "application/vnd.amazon.eventstream"
+	}
+	url := baseURL + "/model/" + awsURIEncode(modelID) + "/" + action
+	httpResp, err := e.send(ctx, auth, url, body, accept)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+// doJSON performs a signed request and returns the body of a successful response.
+func (e *BedrockExecutor) doJSON(ctx context.Context, auth *cliproxyauth.Auth, url string, body []byte, accept string) ([]byte, error) {
+	httpResp, err := e.send(ctx, auth, url, body, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, nil
+}
+
+func (e *BedrockExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, url string, body []byte, accept string) (*http.Response, error) {
+	url = resolveReverseProxyURLForAuth(e.cfg, auth, e.Identifier(), url)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", accept)
+	if err = e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: err.Error()}
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	return httpResp, nil
+}
+
+// bedrockRequestBody adapts an Anthropic messages body to the InvokeModel schema: the model
+// moves to the URL, streaming is selected by endpoint and betas travel in the body.
+func bedrockRequestBody(body []byte, betas []string) []byte {
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.DeleteBytes(body, "metadata")
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	if len(betas) > 0 {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	return body
+}
+
+// readBedrockStreamEvent reads the next event-stream frame and returns the Claude event it
+// carries as an SSE "data: " line. Frames without an event yield a nil line; exception
+// frames are returned as status errors.
+func readBedrockStreamEvent(r io.Reader) ([]byte, error) {
+	msg, err := readAWSEventStreamMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	switch msg.Headers[":message-type"] {
+	case "exception", "error":
+		return nil, bedrockStreamError(msg)
+	}
+	if msg.Headers[":event-type"] != "chunk" {
+		return nil, nil
+	}
+	encoded := gjson.GetBytes(msg.Payload, "bytes").String()
+	if encoded == "" {
+		return nil, nil
+	}
+	event, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock executor: invalid chunk encoding: %w", err)
+	}
+	line := make([]byte, 0, len(event)+6)
+	line = append(line, "data: "...)
+	line = append(line, bytes.TrimSpace(event)...)
+	return line, nil
+}
+
+// bedrockStreamError converts an event-stream exception frame into a Claude-shaped error.
+func bedrockStreamError(msg awsEventStreamMessage) error {
+	exceptionType := msg.Headers[":exception-type"]
+	if exceptionType == "" {
+		exceptionType = msg.Headers[":error-code"]
+	}
+	message := gjson.GetBytes(msg.Payload, "message").String()
+	if message == "" {
+		message = strings.TrimSpace(string(msg.Payload))
+	}
+	code, errType := http.StatusInternalServerError, "api_error"
+	switch exceptionType {
+	case "throttlingException":
+		code, errType = http.StatusTooManyRequests, "rate_limit_error"
+	case "validationException":
+		code, errType = http.StatusBadRequest, "invalid_request_error"
+	case "accessDeniedException":
+		code, errType = http.StatusForbidden, "permission_error"
+	case "serviceUnavailableException":
+		code, errType = http.StatusServiceUnavailable, "overloaded_error"
+	case "modelTimeoutException":
+		code, errType = http.StatusGatewayTimeout, "timeout_error"
+	}
+	body := []byte(`{"type":"error","error":{"type":"","message":""}}`)
+	body, _ = sjson.SetBytes(body, "error.type", errType)
+	body, _ = sjson.SetBytes(body, "error.message", fmt.Sprintf("%s: %s", exceptionType, message))
+	return statusErr{code: code, msg: string(body)}
+}
+
+// resolveBedrockModelID maps a Claude model ID to its Bedrock model ID. Built-in models use
+// the cross-region inference profile of the region's geography unless disabled; names that
+// already look like Bedrock IDs or ARNs are returned unchanged.
+func resolveBedrockModelID(model, region string, disableInferenceProfile bool) string {
+	model = strings.TrimSpace(model)
+	if strings.HasPrefix(model, "arn:") || strings.Contains(model, ".") {
+		return model
+	}
+	id, ok := bedrockClaudeModelIDs[strings.ToLower(model)]
+	if !ok {
+		return model
+	}
+	if disableInferenceProfile {
+		return id
+	}
+	if geo := bedrockInferenceProfileGeo(region); geo != "" {
+		return geo + "." + id
+	}
+	return id
+}
+
+// bedrockInferenceProfileGeo returns the inference profile prefix for a region.
+func bedrockInferenceProfileGeo(region string) string {
+	region = strings.ToLower(strings.TrimSpace(region))
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "us-gov"
+	case strings.HasPrefix(region, "us-"):
+		return "us"
+	case strings.HasPrefix(region, "eu-"):
+		return "eu"
+	case strings.HasPrefix(region, "ap-"):
+		return "apac"
+	default:
+		return ""
+	}
+}
+
+// bedrockEndpoint returns the runtime endpoint and signing region for the auth.
+func bedrockEndpoint(a *cliproxyauth.Auth) (baseURL, region string) {
+	if a != nil && a.Attributes != nil {
+		baseURL = strings.TrimRight(strings.TrimSpace(a.Attributes["base_url"]), "/")
+		region = strings.TrimSpace(a.Attributes["region"])
+	}
+	if region == "" {
+		region = config.DefaultBedrockRegion
+	}
+	if baseURL == "" {
+		baseURL = "https://bedrock-runtime." + region + ".amazonaws.com"
+	}
+	return baseURL, region
+}
+
+// bedrockCredentials resolves the signing credentials for the auth, reading the shared
+// credentials file when the entry references a profile.
+func bedrockCredentials(a *cliproxyauth.Auth) (awsCredentials, error) {
+	if a == nil || a.Attributes == nil {
+		return awsCredentials{}, fmt.Errorf("bedrock executor: missing credentials")
+	}
+	if profile := strings.TrimSpace(a.Attributes["profile"]); profile != "" {
+		return loadAWSProfileCredentials(profile)
+	}
+	creds := awsCredentials{
+		AccessKeyID:     strings.TrimSpace(a.Attributes["api_key"]),
+		SecretAccessKey: strings.TrimSpace(a.Attributes["secret_access_key"]),
+		SessionToken:    strings.TrimSpace(a.Attributes["session_token"]),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("bedrock executor: missing credentials")
+	}
+	return creds, nil
+}
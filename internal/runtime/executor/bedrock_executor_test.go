@@ -0,0 +1,237 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// encodeEventStreamFrame builds an application/vnd.amazon.eventstream frame with string headers.
+func encodeEventStreamFrame(headers map[string]string, payload []byte) []byte {
+	var hdr bytes.Buffer
+	for name, value := range headers {
+		hdr.WriteByte(byte(len(name)))
+		hdr.WriteString(name)
+		hdr.WriteByte(7)
+		_ = binary.Write(&hdr, binary.BigEndian, uint16(len(value)))
+		hdr.WriteString(value)
+	}
+	total := 12 + hdr.Len() + len(payload) + 4
+	var frame bytes.Buffer
+	_ = binary.Write(&frame, binary.BigEndian, uint32(total))
+	_ = binary.Write(&frame, binary.BigEndian, uint32(hdr.Len()))
+	_ = binary.Write(&frame, binary.BigEndian, crc32.ChecksumIEEE(frame.Bytes()))
+	frame.Write(hdr.Bytes())
+	frame.Write(payload)
+	_ = binary.Write(&frame, binary.BigEndian, crc32.ChecksumIEEE(frame.Bytes()))
+	return frame.Bytes()
+}
+
+func bedrockChunkFrame(event string) []byte {
+	payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+	return encodeEventStreamFrame(map[string]string{
+		":message-type": "event",
+		":event-type":   "chunk",
+		":content-type": "application/json",
+	}, []byte(payload))
+}
+
+func TestSignAWSRequestV4_ReferenceVector(t *testing.T) {
+	// "get-vanilla" from the AWS SigV4 test suite.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequestV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestResolveBedrockModelID(t *testing.T) {
+	tests := []struct {
+		model, region string
+		disable       bool
+		want          string
+	}{
+		{"claude-sonnet-4-5-20250929", "us-west-2", false, "us.anthropic.claude-sonnet-4-5-20250929-v1:0"},
+		{"claude-sonnet-4-5-20250929", "eu-central-1", false, "eu.anthropic.claude-sonnet-4-5-20250929-v1:0"},
+		{"claude-sonnet-4-5-20250929", "ap-northeast-1", false, "apac.anthropic.claude-sonnet-4-5-20250929-v1:0"},
+		{"claude-sonnet-4-5-20250929", "us-west-2", true, "anthropic.claude-sonnet-4-5-20250929-v1:0"},
+		{"eu.anthropic.claude-3-7-sonnet-20250219-v1:0", "us-east-1", false, "eu.anthropic.claude-3-7-sonnet-20250219-v1:0"},
+		{"arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc", "us-east-1", false, "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc"},
+		{"custom-model", "us-east-1", false, "custom-model"},
+	}
+	for _, tt := range tests {
+		if got := resolveBedrockModelID(tt.model, tt.region, tt.disable); got != tt.want {
+			t.Errorf("resolveBedrockModelID(%q, %q, %v) = %q, want %q", tt.model, tt.region, tt.disable, got, tt.want)
+		}
+	}
+}
+
+func TestBedrockExecutorExecuteStream_DecodesEventStream(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		_, _ = w.Write(bedrockChunkFrame(`{"type":"message_start","message":{"id":"msg_1","role":"assistant","content":[],"usage":{"input_tokens":3,"output_tokens":0}}}`))
+		_, _ = w.Write(bedrockChunkFrame(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hello"}}`))
+		_, _ = w.Write(bedrockChunkFrame(`{"type":"message_stop"}`))
+	}))
+	defer server.Close()
+
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "bedrock", Attributes: map[string]string{
+		"api_key":           "AKIDEXAMPLE",
+		"secret_access_key": "secret",
+		"session_token":     "token",
+		"base_url":          server.URL,
+		"region":            "us-west-2",
+	}}
+	stream, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-5-20250929",
+		Payload: []byte(`{"model":"claude-sonnet-4-5-20250929","max_tokens":16,"stream":true,"betas":["context-1m-2025-08-07"],"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var out strings.Builder
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		out.Write(chunk.Payload)
+	}
+
+	if gotPath != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%3A0/invoke-with-response-stream" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(gotAuth, "/us-west-2/bedrock/aws4_request") || !strings.Contains(gotAuth, "x-amz-security-token") {
+		t.Fatalf("Authorization = %q", gotAuth)
+	}
+	if gjson.GetBytes(gotBody, "model").Exists() || gjson.GetBytes(gotBody, "stream").Exists() {
+		t.Fatalf("model and stream must not be sent: %s", gotBody)
+	}
+	if got := gjson.GetBytes(gotBody, "anthropic_version").String(); got != bedrockAnthropicVersion {
+		t.Fatalf("anthropic_version = %q", got)
+	}
+	if got := gjson.GetBytes(gotBody, "anthropic_beta.0").String(); got != "context-1m-2025-08-07" {
+		t.Fatalf("anthropic_beta = %s", gjson.GetBytes(gotBody, "anthropic_beta").Raw)
+	}
+	want := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hello\"}}\n\n"
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("stream output missing delta event:\n%s", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n") {
+		t.Fatalf("stream output missing message_stop:\n%s", out.String())
+	}
+}
+
+func TestBedrockExecutorExecuteStream_ExceptionFrame(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(encodeEventStreamFrame(map[string]string{
+			":message-type":   "exception",
+			":exception-type": "throttlingException",
+		}, []byte(`{"message":"Too many requests"}`)))
+	}))
+	defer server.Close()
+
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "bedrock", Attributes: map[string]string{
+		"api_key":           "AKIDEXAMPLE",
+		"secret_access_key": "secret",
+		"base_url":          server.URL,
+	}}
+	stream, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-5-20250929",
+		Payload: []byte(`{"max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var streamErr error
+	for chunk := range stream {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+		}
+	}
+	se, ok := streamErr.(statusErr)
+	if !ok || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("stream error = %v, want 429 status error", streamErr)
+	}
+	if got := gjson.Get(se.msg, "error.type").String(); got != "rate_limit_error" {
+		t.Fatalf("error.type = %q", got)
+	}
+}
+
+func TestBedrockExecutorExecute_InvokeModel(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "bedrock", Attributes: map[string]string{
+		"api_key":                   "AKIDEXAMPLE",
+		"secret_access_key":         "secret",
+		"base_url":                  server.URL,
+		"region":                    "eu-west-1",
+		"disable_inference_profile": "true",
+	}}
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-haiku-20241022",
+		Payload: []byte(`{"max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/model/anthropic.claude-3-5-haiku-20241022-v1%3A0/invoke" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if got := gjson.GetBytes(resp.Payload, "content.0.text").String(); got != "hi" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestLoadAWSProfileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/credentials"
+	content := "[default]\naws_access_key_id = AKIADEFAULT\naws_secret_access_key = d\n\n[bedrock]\naws_access_key_id = AKIABEDROCK\naws_secret_access_key = s\naws_session_token = tok\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", path)
+	t.Setenv("AWS_CONFIG_FILE", dir+"/missing")
+
+	creds, err := loadAWSProfileCredentials("bedrock")
+	if err != nil {
+		t.Fatalf("loadAWSProfileCredentials error: %v", err)
+	}
+	if creds.AccessKeyID != "AKIABEDROCK" || creds.SecretAccessKey != "s" || creds.SessionToken != "tok" {
+		t.Fatalf("creds = %+v", creds)
+	}
+	if _, err = loadAWSProfileCredentials("absent"); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+}
@@ -0,0 +1,536 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// copilotTokenSkew is how close to expiry a Copilot token may get before requests mint a new one.
+const copilotTokenSkew = 2 * time.Minute
+
+// CopilotExecutor is a stateless executor for GitHub Copilot's OpenAI-compatible chat endpoint.
+// Auths carry a long-lived GitHub token and a short-lived Copilot token minted from it.
+type CopilotExecutor struct {
+	cfg *config.Config
+}
+
+func NewCopilotExecutor(cfg *config.Config) *CopilotExecutor { return &CopilotExecutor{cfg: cfg} }
+
+func (e *CopilotExecutor) Identifier() string { return "copilot" }
+
+// PrepareRequest injects Copilot credentials and editor headers into the outgoing HTTP request.
+func (e *CopilotExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	token, _, _, err := e.ensureCopilotToken(req.Context(), auth)
+	if err != nil {
+		return err
+	}
+	applyCopilotHeaders(req, token, false)
+	return nil
+}
+
+// HttpRequest injects Copilot credentials into the request and executes it.
+func (e *CopilotExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("copilot executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	token, baseURL, updatedAuth, err := e.ensureCopilotToken(ctx, auth)
+	if err != nil {
+		return resp, err
+	}
+	if updatedAuth != nil {
+		auth = updatedAuth
+	}
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return resp, err
+	}
+
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadRequestInfoFrom(ctx, opts))
+
+	url := baseURL + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	applyCopilotHeaders(httpReq, token, false)
+	if copilotHasImages(body) {
+		httpReq.Header.Set("Copilot-Vision-Request", "true")
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("copilot executor: close response body error: %v", errClose)
+		}
+	}()
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		return resp, err
+	}
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+	reporter.publish(ctx, parseOpenAIUsage(data))
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	token, baseURL, updatedAuth, err := e.ensureCopilotToken(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+	if updatedAuth != nil {
+		auth = updatedAuth
+	}
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadRequestInfoFrom(ctx, opts))
+
+	url := baseURL + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	applyCopilotHeaders(httpReq, token, true)
+	if copilotHasImages(body) {
+		httpReq.Header.Set("Copilot-Vision-Request", "true")
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("copilot executor: close response body error: %v", errClose)
+		}
+		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("copilot executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseOpenAIStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, line, &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		doneChunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, []byte("[DONE]"), &param)
+		for i := range doneChunks {
+			out <- cliproxyexecutor.StreamChunk{Payload: []byte(doneChunks[i])}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+	}()
+	return stream, nil
+}
+
+func (e *CopilotExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	modelName := gjson.GetBytes(body, "model").String()
+	if strings.TrimSpace(modelName) == "" {
+		modelName = baseModel
+	}
+
+	enc, err := tokenizerForModel(modelName)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("copilot executor: tokenizer init failed: %w", err)
+	}
+
+	count, err := countOpenAIChatTokens(enc, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("copilot executor: token counting failed: %w", err)
+	}
+
+	usageJSON := buildOpenAIUsageJSON(count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+}
+
+// Refresh mints a new Copilot token from the stored GitHub token.
+func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("copilot executor: refresh called")
+	if auth == nil {
+		return nil, fmt.Errorf("copilot executor: auth is nil")
+	}
+	githubToken := metaStringValue(auth.Metadata, "github_token")
+	if githubToken == "" {
+		// Nothing to refresh
+		return auth, nil
+	}
+
+	svc := copilotauth.NewCopilotAuthWithEndpoints(newProxyAwareHTTPClient(ctx, e.cfg, auth, 30*time.Second), copilotauth.Endpoints{})
+	td, err := svc.ExchangeToken(ctx, githubToken)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	auth.Metadata["access_token"] = td.Token
+	auth.Metadata["api_endpoint"] = td.APIEndpoint
+	auth.Metadata["expired"] = td.Expire
+	auth.Metadata["type"] = "copilot"
+	auth.Metadata["last_refresh"] = time.Now().Format(time.RFC3339)
+	return auth, nil
+}
+
+// copilotCachedToken holds the Copilot token last minted for one auth. lock serialises mints
+// for that auth so concurrent requests share a single token exchange.
+type copilotCachedToken struct {
+	lock     chan struct{}
+	metadata map[string]any
+}
+
+var (
+	copilotTokenMu    sync.Mutex
+	copilotTokenCache = make(map[string]*copilotCachedToken)
+)
+
+// ensureCopilotToken returns a usable Copilot token and API base URL. When the stored token
+// is missing or about to expire, the token minted for the auth is cached and written into the
+// metadata of a clone of auth, which is returned so the caller can use it for the rest of the
+// request; a new token is only minted once the cached one is about to expire. Persistence is
+// left to the auto refresh.
+func (e *CopilotExecutor) ensureCopilotToken(ctx context.Context, auth *cliproxyauth.Auth) (string, string, *cliproxyauth.Auth, error) {
+	if auth == nil {
+		return "", "", nil, statusErr{code: http.StatusUnauthorized, msg: "missing auth"}
+	}
+	token, baseURL := copilotCreds(auth)
+	if copilotTokenUsable(token, auth.Metadata) {
+		return token, baseURL, nil, nil
+	}
+	githubToken := metaStringValue(auth.Metadata, "github_token")
+	if githubToken == "" {
+		if token != "" {
+			return token, baseURL, nil, nil
+		}
+		return "", "", nil, statusErr{code: http.StatusUnauthorized, msg: "copilot executor: missing github token"}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cacheKey := auth.ID + "|" + sha256Hex([]byte(githubToken))
+	copilotTokenMu.Lock()
+	entry, ok := copilotTokenCache[cacheKey]
+	if !ok {
+		entry = &copilotCachedToken{lock: make(chan struct{}, 1)}
+		copilotTokenCache[cacheKey] = entry
+	}
+	copilotTokenMu.Unlock()
+
+	select {
+	case entry.lock <- struct{}{}:
+	case <-ctx.Done():
+		return "", "", nil, ctx.Err()
+	}
+	defer func() { <-entry.lock }()
+
+	updated := auth.Clone()
+	if copilotTokenUsable(metaStringValue(entry.metadata, "access_token"), entry.metadata) {
+		if updated.Metadata == nil {
+			updated.Metadata = make(map[string]any, len(entry.metadata))
+		}
+		for key, value := range entry.metadata {
+			updated.Metadata[key] = value
+		}
+	} else {
+		refreshed, err := e.Refresh(ctx, updated)
+		if err != nil {
+			return "", "", nil, statusErr{code: http.StatusUnauthorized, msg: err.Error()}
+		}
+		updated = refreshed
+		entry.metadata = make(map[string]any, 4)
+		for _, key := range []string{"access_token", "api_endpoint", "expired", "last_refresh"} {
+			entry.metadata[key] = updated.Metadata[key]
+		}
+	}
+	token, baseURL = copilotCreds(updated)
+	return token, baseURL, updated, nil
+}
+
+// copilotTokenUsable reports whether token is set and not about to expire according to metadata.
+func copilotTokenUsable(token string, metadata map[string]any) bool {
+	if token == "" {
+		return false
+	}
+	expiry := tokenExpiry(metadata)
+	return expiry.IsZero() || expiry.After(time.Now().Add(copilotTokenSkew))
+}
+
+// FetchCopilotModels lists the chat models enabled for the account, falling back to the static
+// model list when the upstream list is unavailable.
+func FetchCopilotModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	exec := &CopilotExecutor{cfg: cfg}
+	token, baseURL, updatedAuth, err := exec.ensureCopilotToken(ctx, auth)
+	if err != nil || token == "" {
+		log.Debugf("copilot executor: using static models: %v", err)
+		return registry.GetCopilotModels()
+	}
+	if updatedAuth != nil {
+		auth = updatedAuth
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/models", nil)
+	if err != nil {
+		return registry.GetCopilotModels()
+	}
+	applyCopilotHeaders(httpReq, token, false)
+	httpResp, err := newProxyAwareHTTPClient(ctx, cfg, auth, 0).Do(httpReq)
+	if err != nil {
+		log.Debugf("copilot executor: models request failed, using static models: %v", err)
+		return registry.GetCopilotModels()
+	}
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errClose := httpResp.Body.Close(); errClose != nil {
+		log.Errorf("copilot executor: close response body error: %v", errClose)
+	}
+	if errRead != nil || httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		log.Debugf("copilot executor: models request returned %d, using static models", httpResp.StatusCode)
+		return registry.GetCopilotModels()
+	}
+
+	now := time.Now().Unix()
+	var models []*registry.ModelInfo
+	seen := make(map[string]struct{})
+	for _, item := range gjson.GetBytes(data, "data").Array() {
+		id := strings.TrimSpace(item.Get("id").String())
+		if id == "" {
+			continue
+		}
+		if kind := item.Get("capabilities.type").String(); kind != "" && kind != "chat" {
+			continue
+		}
+		if enabled := item.Get("policy.state"); enabled.Exists() && enabled.String() != "enabled" {
+			continue
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		displayName := item.Get("name").String()
+		if displayName == "" {
+			displayName = id
+		}
+		models = append(models, &registry.ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "copilot",
+			Type:                "copilot",
+			DisplayName:         displayName,
+			Description:         "GitHub Copilot " + displayName,
+			Version:             item.Get("version").String(),
+			ContextLength:       int(item.Get("capabilities.limits.max_context_window_tokens").Int()),
+			MaxCompletionTokens: int(item.Get("capabilities.limits.max_output_tokens").Int()),
+		})
+	}
+	if len(models) == 0 {
+		return registry.GetCopilotModels()
+	}
+	return models
+}
+
+func applyCopilotHeaders(r *http.Request, token string, stream bool) {
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer "+token)
+	r.Header.Set("User-Agent", copilotauth.UserAgent)
+	r.Header.Set("Editor-Version", copilotauth.EditorVersion)
+	r.Header.Set("Editor-Plugin-Version", copilotauth.EditorPluginVersion)
+	r.Header.Set("Copilot-Integration-Id", copilotauth.IntegrationID)
+	r.Header.Set("Openai-Intent", "conversation-panel")
+	r.Header.Set("X-Github-Api-Version", copilotauth.APIVersion)
+	r.Header.Set("X-Request-Id", uuid.NewString())
+	if stream {
+		r.Header.Set("Accept", "text/event-stream")
+		return
+	}
+	r.Header.Set("Accept", "application/json")
+}
+
+func copilotHasImages(body []byte) bool {
+	for _, message := range gjson.GetBytes(body, "messages").Array() {
+		for _, part := range message.Get("content").Array() {
+			if part.Get("type").String() == "image_url" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func copilotCreds(a *cliproxyauth.Auth) (token, baseURL string) {
+	if a == nil {
+		return "", ""
+	}
+	if a.Attributes != nil {
+		token = a.Attributes["api_key"]
+		baseURL = a.Attributes["base_url"]
+	}
+	if token == "" {
+		token = metaStringValue(a.Metadata, "access_token")
+	}
+	if baseURL == "" {
+		baseURL = metaStringValue(a.Metadata, "api_endpoint")
+	}
+	if baseURL == "" {
+		baseURL = copilotauth.DefaultAPIEndpoint
+	}
+	return token, strings.TrimSuffix(baseURL, "/")
+}
@@ -0,0 +1,138 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newCopilotTestAuth(baseURL string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{
+		ID:       "copilot-octocat.json",
+		Provider: "copilot",
+		Metadata: map[string]any{
+			"type":         "copilot",
+			"github_token": "gho_test",
+			"access_token": "copilot-token",
+			"api_endpoint": baseURL,
+			"expired":      time.Now().Add(20 * time.Minute).Format(time.RFC3339),
+		},
+	}
+}
+
+func TestCopilotExecutor_ExecuteSendsEditorHeaders(t *testing.T) {
+	var gotPath, gotModel string
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeader = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		gotModel = gjson.GetBytes(body, "model").String()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4.1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+	}))
+	defer server.Close()
+
+	executor := NewCopilotExecutor(&config.Config{})
+	resp, err := executor.Execute(context.Background(), newCopilotTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "gpt-4.1",
+		Payload: []byte(`{"model":"gpt-4.1","messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if gotPath != "/chat/completions" {
+		t.Fatalf("path = %q, want /chat/completions", gotPath)
+	}
+	if gotModel != "gpt-4.1" {
+		t.Fatalf("model = %q, want gpt-4.1", gotModel)
+	}
+	if got := gotHeader.Get("Authorization"); got != "Bearer copilot-token" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	for _, name := range []string{"Editor-Version", "Editor-Plugin-Version", "Copilot-Integration-Id", "X-Request-Id"} {
+		if gotHeader.Get(name) == "" {
+			t.Fatalf("missing %s header", name)
+		}
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hi" {
+		t.Fatalf("content = %q, want hi", got)
+	}
+}
+
+func TestFetchCopilotModels_ListsChatModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"gpt-4.1","name":"GPT-4.1","capabilities":{"type":"chat","limits":{"max_context_window_tokens":128000,"max_output_tokens":16384}}},
+			{"id":"claude-sonnet-4","name":"Claude Sonnet 4","capabilities":{"type":"chat"},"policy":{"state":"enabled"}},
+			{"id":"gemini-2.5-pro","name":"Gemini 2.5 Pro","capabilities":{"type":"chat"},"policy":{"state":"unconfigured"}},
+			{"id":"text-embedding-3-small","capabilities":{"type":"embeddings"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	models := FetchCopilotModels(context.Background(), newCopilotTestAuth(server.URL), &config.Config{})
+	if len(models) != 2 {
+		t.Fatalf("models = %d, want 2", len(models))
+	}
+	if models[0].ID != "gpt-4.1" || models[0].ContextLength != 128000 || models[0].MaxCompletionTokens != 16384 {
+		t.Fatalf("unexpected first model: %+v", models[0])
+	}
+	if models[1].ID != "claude-sonnet-4" || models[1].Type != "copilot" {
+		t.Fatalf("unexpected second model: %+v", models[1])
+	}
+}
+
+func TestFetchCopilotModels_FallsBackToStaticList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	models := FetchCopilotModels(context.Background(), newCopilotTestAuth(server.URL), &config.Config{})
+	if len(models) == 0 {
+		t.Fatal("expected static fallback models")
+	}
+}
+
+func TestCopilotExecutor_ReusesMintedToken(t *testing.T) {
+	var exchanges int
+	rt := vertexTestRoundTripper(func(req *http.Request) *http.Response {
+		exchanges++
+		body := fmt.Sprintf(`{"token":"minted-%d","expires_at":%d,"endpoints":{"api":"https://copilot.test"}}`, exchanges, time.Now().Add(30*time.Minute).Unix())
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}
+	})
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(rt))
+	auth := newCopilotTestAuth("https://copilot.test")
+	auth.ID = "copilot-reuse.json"
+	auth.Metadata["expired"] = time.Now().Add(-time.Minute).Format(time.RFC3339)
+
+	executor := NewCopilotExecutor(&config.Config{})
+	for i := 0; i < 3; i++ {
+		token, _, updated, err := executor.ensureCopilotToken(ctx, auth)
+		if err != nil {
+			t.Fatalf("ensureCopilotToken() error = %v", err)
+		}
+		if token != "minted-1" || metaStringValue(updated.Metadata, "access_token") != "minted-1" {
+			t.Fatalf("token = %q, metadata token = %q", token, metaStringValue(updated.Metadata, "access_token"))
+		}
+	}
+	if exchanges != 1 {
+		t.Fatalf("token exchanges = %d, want 1", exchanges)
+	}
+}
@@ -42,8 +42,8 @@ func (e *OpenAICompatExecutor) PrepareRequest(req *http.Request, auth *cliproxya
 		return nil
 	}
 	_, apiKey := e.resolveCredentials(auth)
-	if strings.TrimSpace(apiKey) != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
+	if err := e.applyAuthorization(req.Context(), req, auth, azureSettingsFromAuth(auth), strings.TrimSpace(apiKey)); err != nil {
+		return err
 	}
 	applyReverseProxyHeaders(req, e.cfg, auth, e.Identifier())
 	var attrs map[string]string
@@ -85,9 +85,18 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("openai")
 	endpoint := "/chat/completions"
+	azure := azureSettingsFromAuth(auth)
 	if opts.Alt == "responses/compact" {
+		if azure != nil {
+			err = statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported by Azure OpenAI"}
+			return
+		}
 		to = sdktranslator.FromString("openai-response")
 		endpoint = "/responses/compact"
+	} else if azure != nil && azure.responses && from == sdktranslator.FromString("openai-response") {
+		// Azure serves the Responses API natively; forward Codex-style requests unchanged.
+		to = from
+		endpoint = "/responses"
 	}
 	originalPayload := req.Payload
 	if len(opts.OriginalRequest) > 0 {
@@ -96,7 +105,7 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, opts.Stream)
 	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, opts.Stream)
 	requestedModel := payloadRequestedModel(opts, req.Model)
-	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel, payloadRequestInfoFrom(ctx, opts))
 	if opts.Alt == "responses/compact" {
 		if updated, errDelete := sjson.DeleteBytes(translated, "stream"); errDelete == nil {
 			translated = updated
@@ -109,6 +118,9 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	}
 
 	originalURL := strings.TrimSuffix(baseURL, "/") + endpoint
+	if azure != nil {
+		originalURL = azure.endpointURL(baseURL, endpoint, baseModel)
+	}
 	proxyRoute := resolveReverseProxyRouteForAuth(e.cfg, auth, e.Identifier(), originalURL)
 	url := proxyRoute.URL
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
@@ -116,8 +128,8 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 		return resp, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	if apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	if err = e.applyAuthorization(ctx, httpReq, auth, azure, apiKey); err != nil {
+		return resp, err
 	}
 	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 	applyReverseProxyHeaders(httpReq, e.cfg, auth, e.Identifier())
@@ -167,8 +179,8 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 				return resp, err
 			}
 			httpReq.Header.Set("Content-Type", "application/json")
-			if apiKey != "" {
-				httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+			if err = e.applyAuthorization(ctx, httpReq, auth, azure, apiKey); err != nil {
+				return resp, err
 			}
 			httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 			applyReverseProxyHeaders(httpReq, e.cfg, auth, e.Identifier())
@@ -243,6 +255,14 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("openai")
+	endpoint := "/chat/completions"
+	azure := azureSettingsFromAuth(auth)
+	if azure != nil && azure.responses && from == sdktranslator.FromString("openai-response") {
+		// Azure serves the Responses API natively; forward Codex-style requests unchanged.
+		to = from
+		endpoint = "/responses"
+	}
+	passthrough := from == to
 	originalPayload := req.Payload
 	if len(opts.OriginalRequest) > 0 {
 		originalPayload = opts.OriginalRequest
@@ -250,14 +270,17 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
 	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
 	requestedModel := payloadRequestedModel(opts, req.Model)
-	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel, payloadRequestInfoFrom(ctx, opts))
 
 	translated, err = thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
 		return nil, err
 	}
 
-	originalURL := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
+	originalURL := strings.TrimSuffix(baseURL, "/") + endpoint
+	if azure != nil {
+		originalURL = azure.endpointURL(baseURL, endpoint, baseModel)
+	}
 	proxyRoute := resolveReverseProxyRouteForAuth(e.cfg, auth, e.Identifier(), originalURL)
 	url := proxyRoute.URL
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
@@ -265,8 +288,8 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	if apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	if err = e.applyAuthorization(ctx, httpReq, auth, azure, apiKey); err != nil {
+		return nil, err
 	}
 	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 	applyReverseProxyHeaders(httpReq, e.cfg, auth, e.Identifier())
@@ -318,8 +341,8 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 				return nil, err
 			}
 			httpReq.Header.Set("Content-Type", "application/json")
-			if apiKey != "" {
-				httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+			if err = e.applyAuthorization(ctx, httpReq, auth, azure, apiKey); err != nil {
+				return nil, err
 			}
 			httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
 			applyReverseProxyHeaders(httpReq, e.cfg, auth, e.Identifier())
@@ -373,6 +396,18 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		for scanner.Scan() {
 			line := scanner.Bytes()
 			appendAPIResponseChunk(ctx, e.cfg, line)
+			if passthrough {
+				// Responses API events are forwarded line by line, keeping event framing intact.
+				if bytes.HasPrefix(line, []byte("data:")) {
+					if detail, ok := parseCodexUsage(bytes.TrimSpace(line[5:])); ok {
+						reporter.publish(ctx, detail)
+					}
+				}
+				cloned := make([]byte, len(line))
+				copy(cloned, line)
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				continue
+			}
 			if detail, ok := parseOpenAIStreamUsage(line); ok {
 				reporter.publish(ctx, detail)
 			}
@@ -1,27 +1,90 @@
 package executor
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 
+	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/payloadexpr"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
+// PayloadRequestInfo describes the client request that payload rule conditions are
+// evaluated against.
+type PayloadRequestInfo struct {
+	// APIKey is the client API key that authenticated the request.
+	APIKey string
+	// SourceFormat is the client request format (e.g. "openai", "claude").
+	SourceFormat string
+	// Stream reports whether the client requested a streaming response.
+	Stream bool
+	// Headers are the client request headers.
+	Headers http.Header
+	// Tenant is the tenant of the client; its payload rules replace the global ones.
+	Tenant string
+}
+
+// payloadRequestInfoFrom collects the request attributes used by payload rule conditions
+// from the gin context and execution options.
+func payloadRequestInfoFrom(ctx context.Context, opts cliproxyexecutor.Options) PayloadRequestInfo {
+	info := PayloadRequestInfo{
+		SourceFormat: opts.SourceFormat.String(),
+		Stream:       opts.Stream,
+		Headers:      opts.Headers,
+	}
+	info.Tenant, _ = opts.Metadata[cliproxyexecutor.TenantMetadataKey].(string)
+	if ctx == nil {
+		return info
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			if key, isString := v.(string); isString {
+				info.APIKey = key
+			}
+		}
+		if ginCtx.Request != nil {
+			info.Headers = ginCtx.Request.Header
+		}
+	}
+	return info
+}
+
+// ApplyPayloadRules applies the payload rules of cfg to payload the way executors do and
+// returns the result together with the matched rules as "section[index]" labels (indexes
+// are zero-based). It backs the management rule test endpoint.
+func ApplyPayloadRules(cfg *config.Config, model, protocol string, payload []byte, info PayloadRequestInfo) ([]byte, []string) {
+	var matched []string
+	out := applyPayloadRules(cfg, model, protocol, "", payload, nil, "", info, func(section string, index int) {
+		matched = append(matched, fmt.Sprintf("%s[%d]", section, index))
+	})
+	return out, matched
+}
+
 // applyPayloadConfigWithRoot behaves like applyPayloadConfig but treats all parameter
 // paths as relative to the provided root path (for example, "request" for Gemini CLI)
 // and restricts matches to the given protocol when supplied. Defaults are checked
 // against the original payload when provided. requestedModel carries the client-visible
-// model name before alias resolution so payload rules can target aliases precisely.
-func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload, original []byte, requestedModel string) []byte {
+// model name before alias resolution so payload rules can target aliases precisely, and
+// info carries the client request attributes checked by rule conditions.
+func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload, original []byte, requestedModel string, info PayloadRequestInfo) []byte {
+	return applyPayloadRules(cfg, model, protocol, root, payload, original, requestedModel, info, nil)
+}
+
+func applyPayloadRules(cfg *config.Config, model, protocol, root string, payload, original []byte, requestedModel string, info PayloadRequestInfo, trace func(section string, index int)) []byte {
 	if cfg == nil || len(payload) == 0 {
 		return payload
 	}
-	rules := cfg.Payload
-	if len(rules.Default) == 0 && len(rules.DefaultRaw) == 0 && len(rules.Override) == 0 && len(rules.OverrideRaw) == 0 && len(rules.Filter) == 0 {
+	rules := cfg.PayloadRulesFor(info.Tenant)
+	if len(rules.Default) == 0 && len(rules.DefaultRaw) == 0 && len(rules.Override) == 0 && len(rules.OverrideRaw) == 0 &&
+		len(rules.Append) == 0 && len(rules.Prepend) == 0 && len(rules.Filter) == 0 {
 		return payload
 	}
 	model = strings.TrimSpace(model)
@@ -30,6 +93,16 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 		return payload
 	}
 	candidates := payloadModelCandidates(model, requestedModel)
+	// Conditions are evaluated against the payload as it was before any rule applied.
+	matches := func(section string, index int, models []config.PayloadModelRule, when *config.PayloadCondition) bool {
+		if !payloadModelRulesMatch(models, protocol, candidates) || !payloadConditionMatches(when, info, payload, root) {
+			return false
+		}
+		if trace != nil {
+			trace(section, index)
+		}
+		return true
+	}
 	out := payload
 	source := original
 	if len(source) == 0 {
@@ -39,7 +112,7 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	// Apply default rules: first write wins per field across all matching rules.
 	for i := range rules.Default {
 		rule := &rules.Default[i]
-		if !payloadModelRulesMatch(rule.Models, protocol, candidates) {
+		if !matches("default", i, rule.Models, rule.When) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -64,7 +137,7 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	// Apply default raw rules: first write wins per field across all matching rules.
 	for i := range rules.DefaultRaw {
 		rule := &rules.DefaultRaw[i]
-		if !payloadModelRulesMatch(rule.Models, protocol, candidates) {
+		if !matches("default-raw", i, rule.Models, rule.When) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -93,7 +166,7 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	// Apply override rules: last write wins per field across all matching rules.
 	for i := range rules.Override {
 		rule := &rules.Override[i]
-		if !payloadModelRulesMatch(rule.Models, protocol, candidates) {
+		if !matches("override", i, rule.Models, rule.When) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -111,7 +184,7 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	// Apply override raw rules: last write wins per field across all matching rules.
 	for i := range rules.OverrideRaw {
 		rule := &rules.OverrideRaw[i]
-		if !payloadModelRulesMatch(rule.Models, protocol, candidates) {
+		if !matches("override-raw", i, rule.Models, rule.When) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -130,10 +203,32 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 			out = updated
 		}
 	}
+	// Apply append and prepend rules: add values to arrays, creating missing arrays.
+	for _, section := range []struct {
+		name    string
+		rules   []config.PayloadRule
+		prepend bool
+	}{{"append", rules.Append, false}, {"prepend", rules.Prepend, true}} {
+		for i := range section.rules {
+			rule := &section.rules[i]
+			if !matches(section.name, i, rule.Models, rule.When) {
+				continue
+			}
+			for _, path := range sortedPayloadParamKeys(rule.Params) {
+				fullPath := buildPayloadPath(root, path)
+				if fullPath == "" {
+					continue
+				}
+				if updated, ok := insertPayloadArrayValues(out, fullPath, rule.Params[path], section.prepend); ok {
+					out = updated
+				}
+			}
+		}
+	}
 	// Apply filter rules: remove matching paths from payload.
 	for i := range rules.Filter {
 		rule := &rules.Filter[i]
-		if !payloadModelRulesMatch(rule.Models, protocol, candidates) {
+		if !matches("filter", i, rule.Models, rule.When) {
 			continue
 		}
 		for _, path := range rule.Params {
@@ -151,6 +246,99 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	return out
 }
 
+// payloadConditionMatches reports whether the request satisfies every field set in cond.
+func payloadConditionMatches(cond *config.PayloadCondition, info PayloadRequestInfo, payload []byte, root string) bool {
+	if cond == nil {
+		return true
+	}
+	if len(cond.APIKeys) > 0 {
+		found := false
+		for _, key := range cond.APIKeys {
+			if key = strings.TrimSpace(key); key != "" && key == info.APIKey {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(cond.SourceFormats) > 0 {
+		found := false
+		for _, format := range cond.SourceFormats {
+			if strings.EqualFold(strings.TrimSpace(format), info.SourceFormat) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if cond.Stream != nil && *cond.Stream != info.Stream {
+		return false
+	}
+	for name, pattern := range cond.Headers {
+		value := strings.TrimSpace(info.Headers.Get(name))
+		if value == "" {
+			return false
+		}
+		if pattern = strings.TrimSpace(pattern); pattern != "" && !matchModelPattern(pattern, value) {
+			return false
+		}
+	}
+	if source := strings.TrimSpace(cond.Expr); source != "" {
+		expr, err := payloadexpr.Cached(source)
+		if err != nil || !expr.Match(payload, root) {
+			return false
+		}
+	}
+	return true
+}
+
+// insertPayloadArrayValues adds value (or each element of a list value) to the array at
+// path. A missing path becomes a new array; a non-array value is left untouched.
+func insertPayloadArrayValues(payload []byte, path string, value any, prepend bool) ([]byte, bool) {
+	existing := gjson.GetBytes(payload, path)
+	if existing.Exists() && !existing.IsArray() {
+		return payload, false
+	}
+	values, isList := value.([]any)
+	if !isList {
+		values = []any{value}
+	}
+	added := make([]string, 0, len(values))
+	for _, v := range values {
+		raw, errMarshal := json.Marshal(v)
+		if errMarshal != nil {
+			return payload, false
+		}
+		added = append(added, string(raw))
+	}
+	current := make([]string, 0, len(existing.Array()))
+	for _, item := range existing.Array() {
+		current = append(current, item.Raw)
+	}
+	items := append(current, added...)
+	if prepend {
+		items = append(added, current...)
+	}
+	updated, errSet := sjson.SetRawBytes(payload, path, []byte("["+strings.Join(items, ",")+"]"))
+	if errSet != nil {
+		return payload, false
+	}
+	return updated, true
+}
+
+func sortedPayloadParamKeys(params map[string]any) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func payloadModelRulesMatch(rules []config.PayloadModelRule, protocol string, models []string) bool {
 	if len(rules) == 0 || len(models) == 0 {
 		return false
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPayloadRulesConditions(t *testing.T) {
+	stream := true
+	cfg := &config.Config{Payload: config.PayloadConfig{
+		Override: []config.PayloadRule{
+			{
+				Models: []config.PayloadModelRule{{Name: "gpt-*"}},
+				When:   &config.PayloadCondition{APIKeys: []string{"team-a"}, Expr: "len(tools) > 1"},
+				Params: map[string]any{"parallel_tool_calls": false},
+			},
+			{
+				Models: []config.PayloadModelRule{{Name: "*"}},
+				When:   &config.PayloadCondition{SourceFormats: []string{"claude"}, Stream: &stream, Headers: map[string]string{"X-Tenant": "acme*"}},
+				Params: map[string]any{"user": "acme"},
+			},
+		},
+		Prepend: []config.PayloadRule{{
+			Models: []config.PayloadModelRule{{Name: "gpt-*", Protocol: "openai"}},
+			When:   &config.PayloadCondition{APIKeys: []string{"team-a"}},
+			Params: map[string]any{"messages": map[string]any{"role": "system", "content": "Be brief."}},
+		}},
+		Append: []config.PayloadRule{{
+			Models: []config.PayloadModelRule{{Name: "gpt-*"}},
+			Params: map[string]any{
+				"tools":  []any{map[string]any{"type": "function", "function": map[string]any{"name": "lookup"}}},
+				"stop":   "END",
+				"model":  "ignored-because-not-an-array",
+				"extras": "created",
+			},
+		}},
+	}}
+	payload := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function"},{"type":"function"}]}`)
+	headers := http.Header{}
+	headers.Set("X-Tenant", "acme-prod")
+
+	out, matched := ApplyPayloadRules(cfg, "gpt-5", "openai", payload, PayloadRequestInfo{APIKey: "team-a", SourceFormat: "claude", Stream: true, Headers: headers})
+	if want := []string{"override[0]", "override[1]", "append[0]", "prepend[0]"}; !reflect.DeepEqual(matched, want) {
+		t.Fatalf("matched = %v, want %v", matched, want)
+	}
+	if gjson.GetBytes(out, "parallel_tool_calls").Bool() || !gjson.GetBytes(out, "parallel_tool_calls").Exists() {
+		t.Fatalf("expected parallel_tool_calls override: %s", out)
+	}
+	if gjson.GetBytes(out, "user").String() != "acme" {
+		t.Fatalf("expected header-scoped override: %s", out)
+	}
+	if gjson.GetBytes(out, "messages.0.role").String() != "system" || gjson.GetBytes(out, "messages.#").Int() != 2 {
+		t.Fatalf("expected prepended system message: %s", out)
+	}
+	if gjson.GetBytes(out, "tools.#").Int() != 3 || gjson.GetBytes(out, "tools.2.function.name").String() != "lookup" {
+		t.Fatalf("expected appended tool: %s", out)
+	}
+	if gjson.GetBytes(out, "stop").Raw != `["END"]` || gjson.GetBytes(out, "model").String() != "gpt-5" {
+		t.Fatalf("unexpected array handling: %s", out)
+	}
+
+	_, matched = ApplyPayloadRules(cfg, "gpt-5", "openai", payload, PayloadRequestInfo{APIKey: "team-b", SourceFormat: "openai"})
+	if want := []string{"append[0]"}; !reflect.DeepEqual(matched, want) {
+		t.Fatalf("matched = %v, want %v", matched, want)
+	}
+}
+
+func TestPayloadRequestInfoFromGinContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set("X-Tenant", "acme")
+	ginCtx.Set("apiKey", "team-a")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	info := payloadRequestInfoFrom(ctx, cliproxyexecutor.Options{Stream: true, SourceFormat: "claude"})
+	if info.APIKey != "team-a" || info.SourceFormat != "claude" || !info.Stream || info.Headers.Get("X-Tenant") != "acme" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+}
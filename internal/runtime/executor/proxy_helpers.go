@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -169,6 +170,8 @@ func resolveProxyIDForProvider(cfg *config.Config, provider string) string {
 		return cfg.ProxyRouting.AIStudio
 	case "qwen":
 		return cfg.ProxyRouting.Qwen
+	case "copilot":
+		return cfg.ProxyRouting.Copilot
 	case "iflow":
 		return cfg.ProxyRouting.IFlow
 	default:
@@ -199,6 +202,26 @@ func resolveProxyIDForAuth(cfg *config.Config, auth *cliproxyauth.Auth) string {
 		}
 	}
 
+	// Pool references apply after direct references; the first matching pool in sorted
+	// order wins so the result is stable.
+	if len(auth.Tags) > 0 {
+		refs := make([]string, 0, len(cfg.ProxyRoutingAuth))
+		for ref := range cfg.ProxyRoutingAuth {
+			if _, ok := cliproxyauth.PoolNameFromRef(ref); ok {
+				refs = append(refs, ref)
+			}
+		}
+		sort.Strings(refs)
+		for _, ref := range refs {
+			name, _ := cliproxyauth.PoolNameFromRef(ref)
+			if selector, ok := cliproxyauth.PoolSelector(cfg, name); ok && selector.Matches(auth.Tags) {
+				if proxyID := strings.TrimSpace(cfg.ProxyRoutingAuth[ref]); proxyID != "" {
+					return proxyID
+				}
+			}
+		}
+	}
+
 	return ""
 }
 
@@ -176,3 +176,26 @@ func TestApplyReverseProxyHeaders_PrefersAuthRoutingOverProviderRouting(t *testi
 		t.Fatalf("expected auth-routed token, got %q", got)
 	}
 }
+
+func TestResolveProxyIDForAuth_PoolReference(t *testing.T) {
+	cfg := &config.Config{
+		AuthPools: map[string]string{"research": "team=research"},
+		ProxyRoutingAuth: map[string]string{
+			"pool:research": "deno-research",
+			"auth-direct":   "deno-direct",
+		},
+	}
+
+	member := &cliproxyauth.Auth{ID: "auth-1", Tags: map[string]string{"team": "research"}}
+	if got := resolveProxyIDForAuth(cfg, member); got != "deno-research" {
+		t.Fatalf("expected pool route, got %q", got)
+	}
+	direct := &cliproxyauth.Auth{ID: "auth-direct", Tags: map[string]string{"team": "research"}}
+	if got := resolveProxyIDForAuth(cfg, direct); got != "deno-direct" {
+		t.Fatalf("expected direct route to win, got %q", got)
+	}
+	other := &cliproxyauth.Auth{ID: "auth-2", Tags: map[string]string{"team": "ops"}}
+	if got := resolveProxyIDForAuth(cfg, other); got != "" {
+		t.Fatalf("expected no route, got %q", got)
+	}
+}
@@ -0,0 +1,34 @@
+package executor
+
+import "testing"
+
+func TestTokenizerForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{model: "", want: "cl100k_base"},
+		{model: "gpt-5", want: "o200k_base"},
+		{model: "gpt-5.1-codex", want: "o200k_base"},
+		{model: "gpt-4.1-mini", want: "o200k_base"},
+		{model: "gpt-4o", want: "o200k_base"},
+		{model: "gpt-4.5-preview", want: "cl100k_base"},
+		{model: "gpt-4", want: "cl100k_base"},
+		{model: "gpt-3.5-turbo", want: "cl100k_base"},
+		{model: "o1", want: "o200k_base"},
+		{model: "o3-mini", want: "o200k_base"},
+		{model: "o4-mini", want: "o200k_base"},
+		{model: "qwen3-coder-plus", want: "o200k_base"},
+		{model: "deepseek-v3", want: "o200k_base"},
+		{model: "claude-sonnet-4", want: "o200k_base"},
+	}
+	for _, tt := range tests {
+		enc, err := tokenizerForModel(tt.model)
+		if err != nil {
+			t.Fatalf("tokenizerForModel(%q): %v", tt.model, err)
+		}
+		if got := enc.GetName(); got != tt.want {
+			t.Errorf("tokenizerForModel(%q) = %s, want %s", tt.model, got, tt.want)
+		}
+	}
+}
@@ -23,6 +23,7 @@ type usageReporter struct {
 	authID      string
 	authIndex   string
 	apiKey      string
+	tenant      string
 	source      string
 	sessionID   string
 	requestID   string
@@ -46,6 +47,7 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 	if auth != nil {
 		reporter.authID = auth.ID
 		reporter.authIndex = auth.EnsureIndex()
+		reporter.tenant = cliproxyauth.AuthTenant(auth)
 	}
 	return reporter
 }
@@ -188,19 +190,21 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 		}
 
 		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			RequestID:   r.requestID,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			SessionID:   r.sessionID,
-			RequestedAt: r.requestedAt,
-			Failed:      failed,
-			StatusCode:  statusCode,
-			DurationMs:  durationMs,
-			Detail:      detail,
+			Provider:     r.provider,
+			Model:        r.model,
+			Source:       r.source,
+			APIKey:       r.apiKey,
+			Tenant:       r.tenant,
+			RequestID:    r.requestID,
+			AuthID:       r.authID,
+			AuthIndex:    r.authIndex,
+			SessionID:    r.sessionID,
+			RequestedAt:  r.requestedAt,
+			Failed:       failed,
+			StatusCode:   statusCode,
+			DurationMs:   durationMs,
+			FirstChunkMs: cliproxyauth.FirstChunkLatency(ctx).Milliseconds(),
+			Detail:       detail,
 		})
 	})
 }
@@ -227,19 +231,21 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 		}
 
 		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			RequestID:   r.requestID,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			SessionID:   r.sessionID,
-			RequestedAt: r.requestedAt,
-			Failed:      false,
-			StatusCode:  statusCode,
-			DurationMs:  durationMs,
-			Detail:      usage.Detail{},
+			Provider:     r.provider,
+			Model:        r.model,
+			Source:       r.source,
+			APIKey:       r.apiKey,
+			Tenant:       r.tenant,
+			RequestID:    r.requestID,
+			AuthID:       r.authID,
+			AuthIndex:    r.authIndex,
+			SessionID:    r.sessionID,
+			RequestedAt:  r.requestedAt,
+			Failed:       false,
+			StatusCode:   statusCode,
+			DurationMs:   durationMs,
+			FirstChunkMs: cliproxyauth.FirstChunkLatency(ctx).Milliseconds(),
+			Detail:       usage.Detail{},
 		})
 	})
 }
@@ -0,0 +1,325 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// vertexClaudeAnthropicVersion is the messages API version Vertex expects in the request body.
+const vertexClaudeAnthropicVersion = "vertex-2023-10-16"
+
+// isVertexClaudeModel reports whether the model is an Anthropic model served through
+// the Vertex AI publishers/anthropic endpoints.
+func isVertexClaudeModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(model)), "claude-")
+}
+
+// vertexClaudeModelID maps an Anthropic model ID to its Vertex form, where the release
+// date is separated by "@" (claude-sonnet-4-5-20250929 -> claude-sonnet-4-5@20250929).
+// IDs without a date suffix, or already in Vertex form, are returned unchanged.
+func vertexClaudeModelID(model string) string {
+	model = strings.TrimSpace(model)
+	if strings.Contains(model, "@") {
+		return model
+	}
+	idx := strings.LastIndex(model, "-")
+	if idx < 0 || len(model)-idx-1 != 8 {
+		return model
+	}
+	for _, c := range model[idx+1:] {
+		if c < '0' || c > '9' {
+			return model
+		}
+	}
+	return model[:idx] + "@" + model[idx+1:]
+}
+
+// vertexClaudeLocation returns the region used for Claude requests. Anthropic models are
+// offered in fewer regions than Gemini, so credentials may override it with claude_location.
+func vertexClaudeLocation(auth *cliproxyauth.Auth, location string) string {
+	if auth != nil && auth.Metadata != nil {
+		if v, ok := auth.Metadata["claude_location"].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return location
+}
+
+// vertexClaudeURL builds the publishers/anthropic endpoint for the given model and action.
+func vertexClaudeURL(projectID, location, model, action string) string {
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/anthropic/models/%s:%s",
+		vertexBaseURL(location), vertexAPIVersion, projectID, location, model, action)
+}
+
+// vertexClaudeRequestBody adapts an Anthropic messages body to the Vertex rawPredict schema:
+// the model moves to the URL and anthropic_version is required in the body.
+func vertexClaudeRequestBody(body []byte, stream bool) []byte {
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "metadata")
+	body, _ = sjson.SetBytes(body, "anthropic_version", vertexClaudeAnthropicVersion)
+	if stream {
+		body, _ = sjson.SetBytes(body, "stream", true)
+	} else {
+		body, _ = sjson.DeleteBytes(body, "stream")
+	}
+	return body
+}
+
+// executeClaudeWithServiceAccount serves a Claude model through Vertex rawPredict.
+func (e *GeminiVertexExecutor) executeClaudeWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	// Use streaming translation to preserve function calling, except for claude.
+	stream := from != to
+	bodyForTranslation, body, betas, err := e.translateClaudeRequest(ctx, req, opts, baseModel, stream)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := e.sendClaude(ctx, auth, projectID, location, saJSON, baseModel, body, betas, stream)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+	if stream {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+		}
+	} else {
+		reporter.publish(ctx, parseClaudeUsage(data))
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, bodyForTranslation, data, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+// executeClaudeStreamWithServiceAccount serves a Claude model through Vertex streamRawPredict,
+// which emits standard Anthropic SSE events.
+func (e *GeminiVertexExecutor) executeClaudeStreamWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	bodyForTranslation, body, betas, err := e.translateClaudeRequest(ctx, req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := e.sendClaude(ctx, auth, projectID, location, saJSON, baseModel, body, betas, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("vertex executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, streamScannerBuffer)
+		var param any
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			// Claude → Claude: forward the SSE stream without translation
+			if from == to {
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				continue
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, bodyForTranslation, line, &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+	}()
+	return stream, nil
+}
+
+// countClaudeTokensWithServiceAccount calls the Vertex count-tokens rawPredict endpoint,
+// which takes the model in the body rather than the URL.
+func (e *GeminiVertexExecutor) countClaudeTokensWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	var betas []string
+	betas, body = extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.DeleteBytes(body, "max_tokens")
+	body, _ = sjson.SetBytes(body, "model", vertexClaudeModelID(baseModel))
+
+	location = vertexClaudeLocation(auth, location)
+	url := vertexClaudeURL(projectID, location, "count-tokens", "rawPredict")
+	httpResp, err := e.postClaude(ctx, auth, url, saJSON, body, betas)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return cliproxyexecutor.Response{}, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return cliproxyexecutor.Response{}, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	count := gjson.GetBytes(data, "input_tokens").Int()
+	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+// translateClaudeRequest converts the client payload into the Vertex rawPredict body. It returns
+// the Claude request used for response translation, the upstream body and the beta flags.
+func (e *GeminiVertexExecutor) translateClaudeRequest(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string, stream bool) ([]byte, []byte, []string, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadRequestInfoFrom(ctx, opts))
+
+	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
+	body = disableThinkingIfToolChoiceForced(body)
+
+	var betas []string
+	betas, body = extractAndRemoveBetas(body)
+	return body, vertexClaudeRequestBody(body, stream), betas, nil
+}
+
+// sendClaude posts the body to rawPredict or streamRawPredict and returns the successful response.
+func (e *GeminiVertexExecutor) sendClaude(ctx context.Context, auth *cliproxyauth.Auth, projectID, location string, saJSON []byte, baseModel string, body []byte, betas []string, stream bool) (*http.Response, error) {
+	action := "rawPredict"
+	if stream {
+		action = "streamRawPredict"
+	}
+	location = vertexClaudeLocation(auth, location)
+	url := vertexClaudeURL(projectID, location, vertexClaudeModelID(baseModel), action)
+	httpResp, err := e.postClaude(ctx, auth, url, saJSON, body, betas)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+func (e *GeminiVertexExecutor) postClaude(ctx context.Context, auth *cliproxyauth.Auth, url string, saJSON []byte, body []byte, betas []string) (*http.Response, error) {
+	url = resolveReverseProxyURLForAuth(e.cfg, auth, e.Identifier(), url)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if len(betas) > 0 {
+		httpReq.Header.Set("anthropic-beta", strings.Join(betas, ","))
+	}
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+	if errTok != nil {
+		log.Errorf("vertex executor: access token error: %v", errTok)
+		return nil, statusErr{code: http.StatusInternalServerError, msg: "internal server error"}
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	return httpResp, nil
+}
@@ -0,0 +1,106 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+type vertexTestRoundTripper func(*http.Request) *http.Response
+
+func (f vertexTestRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+func TestVertexClaudeModelID(t *testing.T) {
+	cases := map[string]string{
+		"claude-sonnet-4-5-20250929": "claude-sonnet-4-5@20250929",
+		"claude-3-7-sonnet-20250219": "claude-3-7-sonnet@20250219",
+		"claude-opus-4-6":            "claude-opus-4-6",
+		"claude-opus-4-1@20250805":   "claude-opus-4-1@20250805",
+	}
+	for in, want := range cases {
+		if got := vertexClaudeModelID(in); got != want {
+			t.Fatalf("vertexClaudeModelID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGeminiVertexExecutorClaudeRawPredict(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var gotURL string
+	var gotBody []byte
+	var gotAuthorization string
+	rt := vertexTestRoundTripper(func(req *http.Request) *http.Response {
+		rec := httptest.NewRecorder()
+		if req.URL.Host == "oauth2.example.test" {
+			rec.Header().Set("Content-Type", "application/json")
+			_, _ = rec.WriteString(`{"access_token":"vertex-token","token_type":"Bearer","expires_in":3600}`)
+			return rec.Result()
+		}
+		gotURL = req.URL.String()
+		gotAuthorization = req.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(req.Body)
+		rec.Header().Set("Content-Type", "application/json")
+		_, _ = rec.WriteString(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":3,"output_tokens":1}}`)
+		return rec.Result()
+	})
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(rt))
+
+	auth := &cliproxyauth.Auth{Metadata: map[string]any{
+		"project_id":      "demo-project",
+		"location":        "us-central1",
+		"claude_location": "us-east5",
+		"service_account": map[string]any{
+			"type":           "service_account",
+			"project_id":     "demo-project",
+			"private_key_id": "key-1",
+			"private_key":    string(pemKey),
+			"client_email":   "svc@demo-project.iam.gserviceaccount.com",
+			"client_id":      "1",
+			"token_uri":      "https://oauth2.example.test/token",
+		},
+	}}
+	executor := NewGeminiVertexExecutor(&config.Config{})
+	resp, err := executor.Execute(ctx, auth, cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-5-20250929",
+		Payload: []byte(`{"model":"claude-sonnet-4-5-20250929","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	wantURL := "https://us-east5-aiplatform.googleapis.com/v1/projects/demo-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4-5@20250929:rawPredict"
+	if gotURL != wantURL {
+		t.Fatalf("url = %q, want %q", gotURL, wantURL)
+	}
+	if gotAuthorization != "Bearer vertex-token" {
+		t.Fatalf("authorization = %q", gotAuthorization)
+	}
+	if gjson.GetBytes(gotBody, "anthropic_version").String() != vertexClaudeAnthropicVersion {
+		t.Fatalf("missing anthropic_version in %s", gotBody)
+	}
+	if gjson.GetBytes(gotBody, "model").Exists() {
+		t.Fatalf("model should move to the URL: %s", gotBody)
+	}
+	if !strings.Contains(string(resp.Payload), `"msg_1"`) {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
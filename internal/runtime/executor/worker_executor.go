@@ -0,0 +1,322 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/sjson"
+)
+
+// WorkerFormatAttribute names the auth attribute holding the request format a worker accepts.
+const WorkerFormatAttribute = "worker_format"
+
+// WorkerExecutor serves a provider registered by external executor workers. Every connected
+// worker is an auth of the provider; requests are translated into the worker's format and
+// relayed over its websocket connection, and replies are translated back.
+type WorkerExecutor struct {
+	provider string
+	relay    *wsrelay.Manager
+	cfg      *config.Config
+}
+
+// NewWorkerExecutor creates an executor for a worker-backed provider.
+func NewWorkerExecutor(cfg *config.Config, provider string, relay *wsrelay.Manager) *WorkerExecutor {
+	return &WorkerExecutor{provider: strings.ToLower(strings.TrimSpace(provider)), relay: relay, cfg: cfg}
+}
+
+// Identifier returns the provider name announced by the workers.
+func (e *WorkerExecutor) Identifier() string { return e.provider }
+
+// PrepareRequest is a no-op: workers hold their own upstream credentials.
+func (e *WorkerExecutor) PrepareRequest(_ *http.Request, _ *cliproxyauth.Auth) error {
+	return nil
+}
+
+// HttpRequest is not supported because workers only accept model requests.
+func (e *WorkerExecutor) HttpRequest(_ context.Context, _ *cliproxyauth.Auth, _ *http.Request) (*http.Response, error) {
+	return nil, statusErr{code: http.StatusNotImplemented, msg: "external executor workers do not accept raw HTTP requests"}
+}
+
+func (e *WorkerExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	if e.relay == nil || auth == nil || auth.ID == "" {
+		return resp, statusErr{code: http.StatusServiceUnavailable, msg: "external executor worker unavailable"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	format := workerFormat(auth)
+	body, err := e.translateRequest(ctx, req, opts, format, false)
+	if err != nil {
+		return resp, err
+	}
+	e.recordRequest(ctx, auth, body)
+
+	wsResp, err := e.relay.Execute(ctx, auth.ID, e.executeRequest(auth, req, opts, baseModel, format, body, false))
+	if err != nil {
+		err = workerError(err)
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, wsResp.Status, wsResp.Headers.Clone())
+	if len(wsResp.Body) > 0 {
+		appendAPIResponseChunk(ctx, e.cfg, bytes.Clone(wsResp.Body))
+	}
+	if wsResp.Status < 200 || wsResp.Status >= 300 {
+		return resp, statusErr{code: wsResp.Status, msg: string(wsResp.Body)}
+	}
+	reporter.publish(ctx, workerUsage(format, wsResp.Body))
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, sdktranslator.FromString(format), opts.SourceFormat, req.Model, opts.OriginalRequest, body, wsResp.Body, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *WorkerExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	if e.relay == nil || auth == nil || auth.ID == "" {
+		return nil, statusErr{code: http.StatusServiceUnavailable, msg: "external executor worker unavailable"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	format := workerFormat(auth)
+	to := sdktranslator.FromString(format)
+	body, err := e.translateRequest(ctx, req, opts, format, true)
+	if err != nil {
+		return nil, err
+	}
+	e.recordRequest(ctx, auth, body)
+
+	wsStream, err := e.relay.ExecuteStream(ctx, auth.ID, e.executeRequest(auth, req, opts, baseModel, format, body, true))
+	if err != nil {
+		err = workerError(err)
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	// Wait for the first event so failures before any output reach the conductor as errors,
+	// which lets it retry another worker and cool this one down.
+	first, ok := <-wsStream
+	if !ok {
+		err = statusErr{code: http.StatusBadGateway, msg: "external executor worker closed the stream before replying"}
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	switch {
+	case first.Err != nil:
+		err = workerError(first.Err)
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	case first.Status != 0 && (first.Status < 200 || first.Status >= 300):
+		recordAPIResponseMetadata(ctx, e.cfg, first.Status, first.Headers.Clone())
+		appendAPIResponseChunk(ctx, e.cfg, bytes.Clone(first.Payload))
+		return nil, statusErr{code: first.Status, msg: string(first.Payload)}
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		var param any
+		emit := func(data []byte) {
+			for _, line := range bytes.Split(data, []byte("\n")) {
+				line = bytes.TrimRight(line, "\r")
+				if len(bytes.TrimSpace(line)) == 0 {
+					continue
+				}
+				if detail, okUsage := workerStreamUsage(format, line); okUsage {
+					reporter.publish(ctx, detail)
+				}
+				chunks := sdktranslator.TranslateStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, body, line, &param)
+				for i := range chunks {
+					out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+				}
+			}
+		}
+		finish := func() {
+			chunks := sdktranslator.TranslateStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, body, []byte("[DONE]"), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		handle := func(event wsrelay.StreamEvent) bool {
+			if event.Err != nil {
+				errEvent := workerError(event.Err)
+				recordAPIResponseError(ctx, e.cfg, errEvent)
+				reporter.publishFailure(ctx)
+				out <- cliproxyexecutor.StreamChunk{Err: errEvent}
+				return false
+			}
+			switch event.Type {
+			case wsrelay.MessageTypeStreamStart:
+				recordAPIResponseMetadata(ctx, e.cfg, event.Status, event.Headers.Clone())
+			case wsrelay.MessageTypeStreamChunk:
+				appendAPIResponseChunk(ctx, e.cfg, bytes.Clone(event.Payload))
+				emit(event.Payload)
+			case wsrelay.MessageTypeStreamEnd:
+				finish()
+				return false
+			case wsrelay.MessageTypeHTTPResp:
+				// The worker answered a streaming request with a single body; relay it as one event.
+				recordAPIResponseMetadata(ctx, e.cfg, event.Status, event.Headers.Clone())
+				appendAPIResponseChunk(ctx, e.cfg, bytes.Clone(event.Payload))
+				emit(event.Payload)
+				finish()
+				return false
+			}
+			return true
+		}
+		if !handle(first) {
+			return
+		}
+		for event := range wsStream {
+			if !handle(event) {
+				return
+			}
+		}
+	}()
+	return stream, nil
+}
+
+// CountTokens estimates tokens locally; workers are not asked to count.
+func (e *WorkerExecutor) CountTokens(ctx context.Context, _ *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	to := sdktranslator.FromString("openai")
+	body := sdktranslator.TranslateRequest(opts.SourceFormat, to, baseModel, req.Payload, false)
+
+	enc, err := tokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("worker executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("worker executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, opts.SourceFormat, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+}
+
+// Refresh is a no-op: worker auths live as long as their connection.
+func (e *WorkerExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *WorkerExecutor) translateRequest(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, format string, stream bool) ([]byte, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString(format)
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	if format != "gemini" {
+		body, _ = sjson.SetBytes(body, "model", baseModel)
+	}
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	if stream && format == "openai" {
+		body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
+	}
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	return applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadRequestInfoFrom(ctx, opts)), nil
+}
+
+func (e *WorkerExecutor) executeRequest(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel, format string, body []byte, stream bool) *wsrelay.ExecuteRequest {
+	return &wsrelay.ExecuteRequest{
+		Model:  baseModel,
+		Format: format,
+		Stream: stream,
+		Body:   body,
+		Metadata: map[string]any{
+			"auth_id":         auth.ID,
+			"requested_model": req.Model,
+			"source_format":   opts.SourceFormat.String(),
+		},
+	}
+}
+
+func (e *WorkerExecutor) recordRequest(ctx context.Context, auth *cliproxyauth.Auth, body []byte) {
+	authType, authValue := auth.AccountInfo()
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       "worker://" + auth.ID,
+		Method:    wsrelay.MessageTypeExecute,
+		Body:      bytes.Clone(body),
+		Provider:  e.Identifier(),
+		AuthID:    auth.ID,
+		AuthLabel: auth.Label,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+}
+
+func workerFormat(auth *cliproxyauth.Auth) string {
+	if auth != nil && auth.Attributes != nil {
+		if format := strings.ToLower(strings.TrimSpace(auth.Attributes[WorkerFormatAttribute])); format != "" {
+			return format
+		}
+	}
+	return "openai"
+}
+
+// workerError maps errors reported by a worker to statusErr so the conductor applies its usual
+// cooldown and retry handling.
+func workerError(err error) error {
+	var relayErr *wsrelay.StatusError
+	if !errors.As(err, &relayErr) {
+		return statusErr{code: http.StatusBadGateway, msg: err.Error()}
+	}
+	code := relayErr.Status
+	if code == 0 {
+		code = http.StatusBadGateway
+	}
+	sErr := statusErr{code: code, msg: relayErr.Message}
+	if relayErr.RetryAfter > 0 {
+		retryAfter := relayErr.RetryAfter
+		sErr.retryAfter = &retryAfter
+	}
+	return sErr
+}
+
+func workerUsage(format string, data []byte) usage.Detail {
+	switch format {
+	case "claude":
+		return parseClaudeUsage(data)
+	case "gemini":
+		return parseGeminiUsage(data)
+	default:
+		return parseOpenAIUsage(data)
+	}
+}
+
+func workerStreamUsage(format string, line []byte) (usage.Detail, bool) {
+	switch format {
+	case "claude":
+		return parseClaudeStreamUsage(line)
+	case "gemini":
+		return parseGeminiStreamUsage(line)
+	default:
+		return parseOpenAIStreamUsage(line)
+	}
+}
@@ -394,6 +394,53 @@ func (s *GitTokenStore) PersistAuthFiles(_ context.Context, message string, path
 	return s.commitAndPushLocked(message, filtered...)
 }
 
+// SaveState writes auxiliary runtime state (batch files, checkpoints, session bindings) to the
+// local clone. State changes far more often than auths, so it is neither committed nor pushed.
+func (s *GitTokenStore) SaveState(ctx context.Context, namespace, key string, data []byte) error {
+	if err := s.EnsureRepository(); err != nil {
+		return err
+	}
+	return s.stateStore().SaveState(ctx, namespace, key, data)
+}
+
+// LoadState reads auxiliary runtime state from the local clone.
+func (s *GitTokenStore) LoadState(ctx context.Context, namespace, key string) ([]byte, error) {
+	if err := s.EnsureRepository(); err != nil {
+		return nil, err
+	}
+	return s.stateStore().LoadState(ctx, namespace, key)
+}
+
+// ListState lists the state keys stored in a namespace.
+func (s *GitTokenStore) ListState(ctx context.Context, namespace string) ([]string, error) {
+	if err := s.EnsureRepository(); err != nil {
+		return nil, err
+	}
+	return s.stateStore().ListState(ctx, namespace)
+}
+
+// DeleteState removes a state entry from the local clone.
+func (s *GitTokenStore) DeleteState(ctx context.Context, namespace, key string) error {
+	if err := s.EnsureRepository(); err != nil {
+		return err
+	}
+	return s.stateStore().DeleteState(ctx, namespace, key)
+}
+
+// StateIsLocal reports that runtime state is kept in the local clone and not shared through
+// the remote, so features that share state between replicas must not rely on it.
+func (s *GitTokenStore) StateIsLocal() bool { return true }
+
+// stateStore keeps state inside the .git directory, outside the work tree, so it never ends
+// up in a commit.
+func (s *GitTokenStore) stateStore() *cliproxyauth.DirStateStore {
+	repoDir := s.repoDirSnapshot()
+	if repoDir == "" {
+		return cliproxyauth.NewDirStateStore("")
+	}
+	return cliproxyauth.NewDirStateStore(filepath.Join(repoDir, ".git", "cliproxy-state"))
+}
+
 func (s *GitTokenStore) resolveDeletePath(id string) (string, error) {
 	if strings.ContainsRune(id, os.PathSeparator) || filepath.IsAbs(id) {
 		return id, nil
@@ -442,6 +489,7 @@ func (s *GitTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 	if email, ok := metadata["email"].(string); ok && email != "" {
 		auth.Attributes["email"] = email
 	}
+	cliproxyauth.SetTenantFromPath(auth, id)
 	return auth, nil
 }
 
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -23,8 +24,9 @@ import (
 )
 
 const (
-	objectStoreConfigKey  = "config/config.yaml"
-	objectStoreAuthPrefix = "auths"
+	objectStoreConfigKey   = "config/config.yaml"
+	objectStoreAuthPrefix  = "auths"
+	objectStoreStatePrefix = "state"
 )
 
 // ObjectStoreConfig captures configuration for the object storage-backed token store.
@@ -324,6 +326,87 @@ func (s *ObjectTokenStore) PersistConfig(ctx context.Context) error {
 	return s.putObject(ctx, objectStoreConfigKey, data, "application/x-yaml")
 }
 
+// SaveState stores auxiliary runtime state directly in the bucket so every replica sees it.
+func (s *ObjectTokenStore) SaveState(ctx context.Context, namespace, key string, data []byte) error {
+	objectKey, err := s.stateKey(namespace, key)
+	if err != nil {
+		return err
+	}
+	fullKey := s.prefixedKey(objectKey)
+	_, err = s.client.PutObject(ctx, s.cfg.Bucket, fullKey, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	if err != nil {
+		return fmt.Errorf("object store: put state %s: %w", fullKey, err)
+	}
+	return nil
+}
+
+// LoadState fetches auxiliary runtime state from the bucket.
+func (s *ObjectTokenStore) LoadState(ctx context.Context, namespace, key string) ([]byte, error) {
+	objectKey, err := s.stateKey(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	fullKey := s.prefixedKey(objectKey)
+	object, err := s.client.GetObject(ctx, s.cfg.Bucket, fullKey, minio.GetObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, cliproxyauth.ErrStateNotFound
+		}
+		return nil, fmt.Errorf("object store: fetch state %s: %w", fullKey, err)
+	}
+	defer object.Close()
+	data, err := io.ReadAll(object)
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, cliproxyauth.ErrStateNotFound
+		}
+		return nil, fmt.Errorf("object store: read state %s: %w", fullKey, err)
+	}
+	return data, nil
+}
+
+// ListState lists the state keys stored in a namespace.
+func (s *ObjectTokenStore) ListState(ctx context.Context, namespace string) ([]string, error) {
+	if err := cliproxyauth.ValidateStateKey(namespace); err != nil {
+		return nil, err
+	}
+	prefix := s.prefixedKey(objectStoreStatePrefix + "/" + namespace + "/")
+	keys := make([]string, 0)
+	for object := range s.client.ListObjects(ctx, s.cfg.Bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("object store: list state objects: %w", object.Err)
+		}
+		key := strings.TrimPrefix(object.Key, prefix)
+		if key == "" || strings.Contains(key, "/") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DeleteState removes a state entry from the bucket.
+func (s *ObjectTokenStore) DeleteState(ctx context.Context, namespace, key string) error {
+	objectKey, err := s.stateKey(namespace, key)
+	if err != nil {
+		return err
+	}
+	return s.deleteObject(ctx, objectKey)
+}
+
+func (s *ObjectTokenStore) stateKey(namespace, key string) (string, error) {
+	if err := cliproxyauth.ValidateStateKey(namespace); err != nil {
+		return "", err
+	}
+	if err := cliproxyauth.ValidateStateKey(key); err != nil {
+		return "", err
+	}
+	return objectStoreStatePrefix + "/" + namespace + "/" + key, nil
+}
+
 func (s *ObjectTokenStore) ensureBucket(ctx context.Context) error {
 	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
 	if err != nil {
@@ -595,6 +678,7 @@ func (s *ObjectTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Aut
 		LastRefreshedAt:  time.Time{},
 		NextRefreshAfter: time.Time{},
 	}
+	cliproxyauth.SetTenantFromPath(auth, rel)
 	return auth, nil
 }
 
@@ -22,6 +22,7 @@ import (
 const (
 	defaultConfigTable = "config_store"
 	defaultAuthTable   = "auth_store"
+	defaultStateTable  = "state_store"
 	defaultConfigKey   = "config"
 )
 
@@ -31,6 +32,7 @@ type PostgresStoreConfig struct {
 	Schema      string
 	ConfigTable string
 	AuthTable   string
+	StateTable  string
 	SpoolDir    string
 }
 
@@ -58,6 +60,9 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.AuthTable == "" {
 		cfg.AuthTable = defaultAuthTable
 	}
+	if cfg.StateTable == "" {
+		cfg.StateTable = defaultStateTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -140,6 +145,19 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	stateTable := s.fullTableName(s.cfg.StateTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			namespace TEXT NOT NULL,
+			id TEXT NOT NULL,
+			content BYTEA NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (namespace, id)
+		)
+	`, stateTable)); err != nil {
+		return fmt.Errorf("postgres store: create state table: %w", err)
+	}
 	return nil
 }
 
@@ -310,6 +328,7 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 			LastRefreshedAt:  time.Time{},
 			NextRefreshAfter: time.Time{},
 		}
+		cliproxyauth.SetTenantFromPath(auth, auth.ID)
 		auths = append(auths, auth)
 	}
 	if err = rows.Err(); err != nil {
@@ -391,6 +410,86 @@ func (s *PostgresStore) PersistConfig(ctx context.Context) error {
 	return s.persistConfig(ctx, data)
 }
 
+// SaveState stores auxiliary runtime state in PostgreSQL.
+func (s *PostgresStore) SaveState(ctx context.Context, namespace, key string, data []byte) error {
+	if err := validateStateKeys(namespace, key); err != nil {
+		return err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (namespace, id, content, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (namespace, id)
+		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+	`, s.fullTableName(s.cfg.StateTable))
+	if _, err := s.db.ExecContext(ctx, query, namespace, key, data); err != nil {
+		return fmt.Errorf("postgres store: upsert state: %w", err)
+	}
+	return nil
+}
+
+// LoadState reads auxiliary runtime state from PostgreSQL.
+func (s *PostgresStore) LoadState(ctx context.Context, namespace, key string) ([]byte, error) {
+	if err := validateStateKeys(namespace, key); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT content FROM %s WHERE namespace = $1 AND id = $2", s.fullTableName(s.cfg.StateTable))
+	var data []byte
+	if err := s.db.QueryRowContext(ctx, query, namespace, key).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, cliproxyauth.ErrStateNotFound
+		}
+		return nil, fmt.Errorf("postgres store: load state: %w", err)
+	}
+	return data, nil
+}
+
+// ListState lists the state keys stored in a namespace.
+func (s *PostgresStore) ListState(ctx context.Context, namespace string) ([]string, error) {
+	if err := cliproxyauth.ValidateStateKey(namespace); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT id FROM %s WHERE namespace = $1 ORDER BY id", s.fullTableName(s.cfg.StateTable))
+	rows, err := s.db.QueryContext(ctx, query, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: list state: %w", err)
+	}
+	defer rows.Close()
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("postgres store: scan state key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate state keys: %w", err)
+	}
+	return keys, nil
+}
+
+// DeleteState removes a state entry from PostgreSQL.
+func (s *PostgresStore) DeleteState(ctx context.Context, namespace, key string) error {
+	if err := validateStateKeys(namespace, key); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE namespace = $1 AND id = $2", s.fullTableName(s.cfg.StateTable))
+	if _, err := s.db.ExecContext(ctx, query, namespace, key); err != nil {
+		return fmt.Errorf("postgres store: delete state: %w", err)
+	}
+	return nil
+}
+
+func validateStateKeys(namespace, key string) error {
+	if err := cliproxyauth.ValidateStateKey(namespace); err != nil {
+		return err
+	}
+	return cliproxyauth.ValidateStateKey(key)
+}
+
 // syncConfigFromDatabase writes the database-stored config to disk or seeds the database from template.
 func (s *PostgresStore) syncConfigFromDatabase(ctx context.Context, exampleConfigPath string) error {
 	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
@@ -0,0 +1,351 @@
+package structuredoutput
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Spec is the structured-output contract extracted from a client request.
+type Spec struct {
+	// Name is the schema name supplied by the client, if any.
+	Name string
+	// Schema validates the output; nil means any valid JSON document is accepted
+	// (OpenAI json_object mode, Gemini application/json without a schema).
+	Schema *Schema
+}
+
+// Validate checks model output text against the spec.
+func (s *Spec) Validate(output string) []ValidationError {
+	if s == nil {
+		return nil
+	}
+	return s.Schema.Validate([]byte(strings.TrimSpace(output)))
+}
+
+// SpecFromRequest returns the structured-output spec requested in a payload of the given
+// handler format, or nil when the client did not ask for JSON output. A schema that cannot
+// be parsed is reported as an error so the caller can reject the request.
+func SpecFromRequest(format string, raw []byte) (*Spec, error) {
+	var schema gjson.Result
+	var name string
+	jsonOnly := false
+	switch format {
+	case constant.OpenAI:
+		rf := gjson.GetBytes(raw, "response_format")
+		switch rf.Get("type").String() {
+		case "json_schema":
+			schema = rf.Get("json_schema.schema")
+			name = rf.Get("json_schema.name").String()
+		case "json_object":
+			jsonOnly = true
+		}
+	case constant.OpenaiResponse:
+		tf := gjson.GetBytes(raw, "text.format")
+		switch tf.Get("type").String() {
+		case "json_schema":
+			schema = tf.Get("schema")
+			name = tf.Get("name").String()
+		case "json_object":
+			jsonOnly = true
+		}
+	case constant.Claude:
+		of := gjson.GetBytes(raw, "output_format")
+		if !of.Exists() {
+			of = gjson.GetBytes(raw, "output_config.format")
+		}
+		if of.Get("type").String() == "json_schema" {
+			schema = of.Get("schema")
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		gc := gjson.GetBytes(raw, "generationConfig")
+		if format == constant.GeminiCLI {
+			gc = gjson.GetBytes(raw, "request.generationConfig")
+		}
+		for _, key := range []string{"responseJsonSchema", "response_json_schema", "responseSchema", "response_schema"} {
+			if candidate := gc.Get(key); candidate.IsObject() {
+				schema = candidate
+				break
+			}
+		}
+		if !schema.Exists() {
+			mime := gc.Get("responseMimeType").String()
+			if mime == "" {
+				mime = gc.Get("response_mime_type").String()
+			}
+			jsonOnly = strings.EqualFold(mime, "application/json")
+		}
+	}
+	if schema.Exists() && schema.IsObject() {
+		compiled, err := CompileSchema([]byte(schema.Raw))
+		if err != nil {
+			return nil, err
+		}
+		return &Spec{Name: name, Schema: compiled}, nil
+	}
+	if jsonOnly {
+		return &Spec{}, nil
+	}
+	return nil, nil
+}
+
+// Output is the model text gathered from a response for validation.
+type Output struct {
+	// Text is the concatenated assistant text.
+	Text string
+	// ToolCall is true when the model answered with a tool or function call instead of
+	// text; such turns are not subject to the output schema.
+	ToolCall bool
+}
+
+// OutputFromResponse extracts the assistant text from a non-streaming response payload.
+func OutputFromResponse(format string, payload []byte) Output {
+	var out Output
+	var text strings.Builder
+	switch format {
+	case constant.OpenAI:
+		msg := gjson.GetBytes(payload, "choices.0.message")
+		text.WriteString(msg.Get("content").String())
+		out.ToolCall = len(msg.Get("tool_calls").Array()) > 0 || msg.Get("function_call").Exists()
+	case constant.OpenaiResponse:
+		for _, item := range gjson.GetBytes(payload, "output").Array() {
+			switch item.Get("type").String() {
+			case "message":
+				for _, part := range item.Get("content").Array() {
+					if part.Get("type").String() == "output_text" {
+						text.WriteString(part.Get("text").String())
+					}
+				}
+			case "function_call", "custom_tool_call":
+				out.ToolCall = true
+			}
+		}
+	case constant.Claude:
+		for _, block := range gjson.GetBytes(payload, "content").Array() {
+			switch block.Get("type").String() {
+			case "text":
+				text.WriteString(block.Get("text").String())
+			case "tool_use":
+				out.ToolCall = true
+			}
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		root := gjson.ParseBytes(payload)
+		if format == constant.GeminiCLI && root.Get("response").Exists() {
+			root = root.Get("response")
+		}
+		collectGeminiParts(root, &text, &out)
+	}
+	out.Text = text.String()
+	return out
+}
+
+func collectGeminiParts(root gjson.Result, text *strings.Builder, out *Output) {
+	for _, part := range root.Get("candidates.0.content.parts").Array() {
+		if part.Get("functionCall").Exists() {
+			out.ToolCall = true
+			continue
+		}
+		if part.Get("thought").Bool() {
+			continue
+		}
+		text.WriteString(part.Get("text").String())
+	}
+}
+
+// StreamAccumulator gathers assistant text from the chunks of a streaming response as
+// they are forwarded to the client.
+type StreamAccumulator struct {
+	format  string
+	pending []byte
+	text    strings.Builder
+	tool    bool
+}
+
+// NewStreamAccumulator creates an accumulator for streams in the given handler format.
+func NewStreamAccumulator(format string) *StreamAccumulator {
+	return &StreamAccumulator{format: format}
+}
+
+// Add consumes one forwarded chunk. Chunks may hold several SSE lines or end mid-line.
+func (a *StreamAccumulator) Add(chunk []byte) {
+	a.pending = append(a.pending, chunk...)
+	for {
+		idx := bytes.IndexByte(a.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		a.consumeLine(a.pending[:idx])
+		a.pending = a.pending[idx+1:]
+	}
+	// Some formats (Gemini without alt=sse) emit whole JSON objects without a newline.
+	if trimmed := bytes.TrimSpace(a.pending); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		a.consumeLine(trimmed)
+		a.pending = a.pending[:0]
+	}
+}
+
+// Output returns the accumulated assistant text.
+func (a *StreamAccumulator) Output() Output {
+	if trimmed := bytes.TrimSpace(a.pending); len(trimmed) > 0 {
+		a.consumeLine(trimmed)
+		a.pending = nil
+	}
+	return Output{Text: a.text.String(), ToolCall: a.tool}
+}
+
+func (a *StreamAccumulator) consumeLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if bytes.HasPrefix(line, []byte("data:")) {
+		line = bytes.TrimSpace(line[len("data:"):])
+	}
+	// Gemini array streaming wraps objects in "[", "," and "]".
+	line = bytes.TrimLeft(line, "[,")
+	line = bytes.TrimRight(line, "],")
+	if len(line) == 0 || line[0] != '{' || !json.Valid(line) {
+		return
+	}
+	event := gjson.ParseBytes(line)
+	switch a.format {
+	case constant.OpenAI:
+		delta := event.Get("choices.0.delta")
+		a.text.WriteString(delta.Get("content").String())
+		if len(delta.Get("tool_calls").Array()) > 0 || delta.Get("function_call").Exists() {
+			a.tool = true
+		}
+	case constant.OpenaiResponse:
+		switch event.Get("type").String() {
+		case "response.output_text.delta":
+			a.text.WriteString(event.Get("delta").String())
+		case "response.output_item.added":
+			switch event.Get("item.type").String() {
+			case "function_call", "custom_tool_call":
+				a.tool = true
+			}
+		}
+	case constant.Claude:
+		switch event.Get("type").String() {
+		case "content_block_delta":
+			if event.Get("delta.type").String() == "text_delta" {
+				a.text.WriteString(event.Get("delta.text").String())
+			}
+		case "content_block_start":
+			if event.Get("content_block.type").String() == "tool_use" {
+				a.tool = true
+			}
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		root := event
+		if root.Get("response").Exists() {
+			root = root.Get("response")
+		}
+		var out Output
+		collectGeminiParts(root, &a.text, &out)
+		a.tool = a.tool || out.ToolCall
+	}
+}
+
+// RepairPrompt is the follow-up user message sent when output fails validation.
+func RepairPrompt(errs []ValidationError) string {
+	var b strings.Builder
+	b.WriteString("Your previous response did not satisfy the required JSON schema:\n")
+	for _, e := range errs {
+		b.WriteString("- ")
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	b.WriteString("Respond again with only a JSON document that satisfies the schema, without code fences or commentary.")
+	return b.String()
+}
+
+// RepairRequest appends the invalid assistant output and a correction prompt to the request
+// so the model can try again. ok is false when the request shape cannot carry the follow-up.
+func RepairRequest(format string, raw []byte, output string, errs []ValidationError) (repaired []byte, ok bool) {
+	prompt := RepairPrompt(errs)
+	var err error
+	switch format {
+	case constant.OpenAI, constant.Claude:
+		if !gjson.GetBytes(raw, "messages").IsArray() {
+			return nil, false
+		}
+		repaired, err = appendTurns(raw, "messages",
+			map[string]any{"role": "assistant", "content": output},
+			map[string]any{"role": "user", "content": prompt})
+	case constant.OpenaiResponse:
+		repaired = raw
+		input := gjson.GetBytes(raw, "input")
+		if input.Type == gjson.String {
+			repaired, err = sjson.SetBytes(repaired, "input", []map[string]any{{"role": "user", "content": input.String()}})
+			if err != nil {
+				return nil, false
+			}
+		} else if !input.IsArray() {
+			return nil, false
+		}
+		repaired, err = appendTurns(repaired, "input",
+			map[string]any{"role": "assistant", "content": output},
+			map[string]any{"role": "user", "content": prompt})
+	case constant.Gemini, constant.GeminiCLI:
+		path := "contents"
+		if format == constant.GeminiCLI {
+			path = "request.contents"
+		}
+		if !gjson.GetBytes(raw, path).IsArray() {
+			return nil, false
+		}
+		repaired, err = appendTurns(raw, path,
+			map[string]any{"role": "model", "parts": []map[string]any{{"text": output}}},
+			map[string]any{"role": "user", "parts": []map[string]any{{"text": prompt}}})
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return repaired, true
+}
+
+func appendTurns(raw []byte, path string, turns ...map[string]any) ([]byte, error) {
+	var err error
+	for _, turn := range turns {
+		if raw, err = sjson.SetBytes(raw, path+".-1", turn); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+// ErrorBody builds the error payload returned when output still fails validation, shaped
+// like a Claude error for Claude clients and like an OpenAI error otherwise.
+func ErrorBody(format string, errs []ValidationError) []byte {
+	message := "model output does not match the requested JSON schema"
+	if len(errs) > 0 {
+		message = fmt.Sprintf("%s: %s", message, errs[0].String())
+	}
+	var body []byte
+	if format == constant.Claude {
+		body, _ = json.Marshal(map[string]any{
+			"type": "error",
+			"error": map[string]any{
+				"type":              "invalid_response_error",
+				"message":           message,
+				"validation_errors": errs,
+			},
+		})
+		return body
+	}
+	body, _ = json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message":           message,
+			"type":              "invalid_response_error",
+			"code":              "json_schema_validation_failed",
+			"validation_errors": errs,
+		},
+	})
+	return body
+}
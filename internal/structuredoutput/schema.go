@@ -0,0 +1,405 @@
+// Package structuredoutput validates model output against the JSON schema a client requested
+// through response_format (OpenAI), output_format (Claude) or responseJsonSchema (Gemini),
+// so strict structured output behaves the same regardless of the backend.
+package structuredoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReportedErrors bounds the number of validation errors collected for one document.
+const maxReportedErrors = 20
+
+// ValidationError describes one schema violation at a location in the validated document.
+type ValidationError struct {
+	// Path locates the offending value, e.g. "$.items[2].name".
+	Path string `json:"path"`
+	// Message explains the violated constraint.
+	Message string `json:"message"`
+}
+
+func (e ValidationError) String() string {
+	return e.Path + ": " + e.Message
+}
+
+// Schema is a compiled JSON schema supporting the subset of draft 2020-12 keywords that
+// structured-output APIs accept, plus the OpenAPI dialect used by Gemini (upper-case types
+// and nullable).
+type Schema struct {
+	root any
+}
+
+// CompileSchema parses raw JSON schema bytes.
+func CompileSchema(raw []byte) (*Schema, error) {
+	var root any
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("structured output: invalid schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, fmt.Errorf("structured output: schema must be an object or boolean")
+	}
+	return &Schema{root: root}, nil
+}
+
+// Validate checks the JSON document against the schema. It returns nil when the document
+// conforms; a document that is not valid JSON yields a single error at "$".
+func (s *Schema) Validate(document []byte) []ValidationError {
+	var value any
+	if err := json.Unmarshal(document, &value); err != nil {
+		return []ValidationError{{Path: "$", Message: "output is not valid JSON: " + err.Error()}}
+	}
+	if s == nil {
+		return nil
+	}
+	v := &validator{root: s.root}
+	v.validate(s.root, value, "$", 0)
+	return v.errs
+}
+
+type validator struct {
+	root any
+	errs []ValidationError
+}
+
+// maxRefDepth stops runaway recursion through self-referencing $ref chains.
+const maxRefDepth = 64
+
+func (v *validator) fail(path, format string, args ...any) {
+	if len(v.errs) >= maxReportedErrors {
+		return
+	}
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// probe validates without recording errors and reports whether the value conforms.
+func (v *validator) probe(schema, value any, path string, depth int) bool {
+	sub := &validator{root: v.root}
+	sub.validate(schema, value, path, depth)
+	return len(sub.errs) == 0
+}
+
+func (v *validator) validate(schema, value any, path string, depth int) {
+	if depth > maxRefDepth {
+		v.fail(path, "schema nesting too deep")
+		return
+	}
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "no value is allowed here")
+		}
+		return
+	case map[string]any:
+		v.validateObjectSchema(s, value, path, depth)
+	}
+}
+
+func (v *validator) validateObjectSchema(s map[string]any, value any, path string, depth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, found := v.resolveRef(ref)
+		if !found {
+			v.fail(path, "unresolvable $ref %q", ref)
+			return
+		}
+		v.validate(target, value, path, depth+1)
+	}
+
+	if value == nil && isTrue(s["nullable"]) {
+		return
+	}
+	if types := schemaTypes(s["type"]); len(types) > 0 && !matchesAnyType(types, value) {
+		v.fail(path, "expected %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value is not one of the allowed enum values")
+		}
+	}
+	if constant, ok := s["const"]; ok && !jsonEqual(constant, value) {
+		v.fail(path, "value does not match const")
+	}
+
+	switch typed := value.(type) {
+	case string:
+		v.validateString(s, typed, path)
+	case float64:
+		v.validateNumber(s, typed, path)
+	case []any:
+		v.validateArray(s, typed, path, depth)
+	case map[string]any:
+		v.validateObject(s, typed, path, depth)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			v.validate(sub, value, path, depth+1)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.probe(sub, value, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "value does not match any schema in anyOf")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if v.probe(sub, value, path, depth+1) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(path, "value must match exactly one schema in oneOf (matched %d)", matches)
+		}
+	}
+	if not, ok := s["not"]; ok && v.probe(not, value, path, depth+1) {
+		v.fail(path, "value must not match the schema in not")
+	}
+}
+
+func (v *validator) validateString(s map[string]any, value, path string) {
+	length := utf8.RuneCountInString(value)
+	if n, ok := schemaNumber(s, "minLength"); ok && float64(length) < n {
+		v.fail(path, "string shorter than minLength %v", n)
+	}
+	if n, ok := schemaNumber(s, "maxLength"); ok && float64(length) > n {
+		v.fail(path, "string longer than maxLength %v", n)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		// Patterns outside RE2 syntax cannot be checked and are skipped.
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+			v.fail(path, "string does not match pattern %q", pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(s map[string]any, value float64, path string) {
+	if n, ok := schemaNumber(s, "minimum"); ok && value < n {
+		v.fail(path, "number less than minimum %v", n)
+	}
+	if n, ok := schemaNumber(s, "maximum"); ok && value > n {
+		v.fail(path, "number greater than maximum %v", n)
+	}
+	if n, ok := schemaNumber(s, "exclusiveMinimum"); ok && value <= n {
+		v.fail(path, "number must be greater than %v", n)
+	}
+	if n, ok := schemaNumber(s, "exclusiveMaximum"); ok && value >= n {
+		v.fail(path, "number must be less than %v", n)
+	}
+	if n, ok := schemaNumber(s, "multipleOf"); ok && n > 0 {
+		quotient := value / n
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.fail(path, "number is not a multiple of %v", n)
+		}
+	}
+}
+
+func (v *validator) validateArray(s map[string]any, value []any, path string, depth int) {
+	if n, ok := schemaNumber(s, "minItems"); ok && float64(len(value)) < n {
+		v.fail(path, "array has fewer than minItems %v", n)
+	}
+	if n, ok := schemaNumber(s, "maxItems"); ok && float64(len(value)) > n {
+		v.fail(path, "array has more than maxItems %v", n)
+	}
+	prefix, _ := s["prefixItems"].([]any)
+	for i, item := range value {
+		itemPath := path + "[" + strconv.Itoa(i) + "]"
+		if i < len(prefix) {
+			v.validate(prefix[i], item, itemPath, depth+1)
+			continue
+		}
+		if items, ok := s["items"]; ok {
+			v.validate(items, item, itemPath, depth+1)
+		}
+	}
+	if isTrue(s["uniqueItems"]) {
+		for i := 0; i < len(value); i++ {
+			for j := i + 1; j < len(value); j++ {
+				if jsonEqual(value[i], value[j]) {
+					v.fail(path, "array items %d and %d are not unique", i, j)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) validateObject(s map[string]any, value map[string]any, path string, depth int) {
+	if n, ok := schemaNumber(s, "minProperties"); ok && float64(len(value)) < n {
+		v.fail(path, "object has fewer than minProperties %v", n)
+	}
+	if n, ok := schemaNumber(s, "maxProperties"); ok && float64(len(value)) > n {
+		v.fail(path, "object has more than maxProperties %v", n)
+	}
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, exists := value[key]; key != "" && !exists {
+				v.fail(path, "missing required property %q", key)
+			}
+		}
+	}
+	properties, _ := s["properties"].(map[string]any)
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		propPath := path + "." + key
+		if sub, ok := properties[key]; ok {
+			v.validate(sub, value[key], propPath, depth+1)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(path, "additional property %q is not allowed", key)
+			}
+		case map[string]any:
+			v.validate(additional, value[key], propPath, depth+1)
+		}
+	}
+}
+
+// resolveRef follows local references ("#", "#/$defs/name", "#/definitions/name").
+func (v *validator) resolveRef(ref string) (any, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	current := v.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[token]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// schemaTypes normalizes the type keyword; Gemini schemas use upper-case type names.
+func schemaTypes(raw any) []string {
+	switch t := raw.(type) {
+	case string:
+		return []string{strings.ToLower(t)}
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				out = append(out, strings.ToLower(name))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func matchesAnyType(types []string, value any) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := value.(float64); ok && n == math.Trunc(n) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]any); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]any); ok {
+				return true
+			}
+		default:
+			// Unknown type names are not enforced.
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func schemaNumber(s map[string]any, key string) (float64, bool) {
+	switch n := s[key].(type) {
+	case float64:
+		return n, true
+	case string:
+		// Gemini's OpenAPI schema encodes int64 limits such as minItems as strings.
+		if parsed, err := strconv.ParseFloat(n, 64); err == nil {
+			return parsed, true
+		}
+	}
+	return 0, false
+}
+
+func isTrue(v any) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,131 @@
+package structuredoutput
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func mustCompile(t *testing.T, raw string) *Schema {
+	t.Helper()
+	schema, err := CompileSchema([]byte(raw))
+	if err != nil {
+		t.Fatalf("CompileSchema: %v", err)
+	}
+	return schema
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := mustCompile(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "maxItems": 2},
+			"score": {"type": "integer", "minimum": 0},
+			"kind": {"enum": ["a", "b"]}
+		},
+		"required": ["name", "score"],
+		"additionalProperties": false,
+		"$defs": {"tag": {"type": "string", "pattern": "^[a-z]+$"}}
+	}`)
+
+	if errs := schema.Validate([]byte(`{"name":"x","score":3,"tags":["ok"],"kind":"a"}`)); len(errs) != 0 {
+		t.Fatalf("expected valid document, got %v", errs)
+	}
+
+	errs := schema.Validate([]byte(`{"name":"","score":1.5,"tags":["OK","b","c"],"kind":"z","extra":true}`))
+	want := []string{
+		`$: additional property "extra" is not allowed`,
+		`$.kind: value is not one of the allowed enum values`,
+		`$.name: string shorter than minLength 1`,
+		`$.score: expected integer, got number`,
+		`$.tags: array has more than maxItems 2`,
+		`$.tags[0]: string does not match pattern "^[a-z]+$"`,
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.String())
+	}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			if g == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("missing error %q in %v", w, got)
+		}
+	}
+
+	if errs := schema.Validate([]byte(`{"name":`)); len(errs) != 1 || errs[0].Path != "$" {
+		t.Fatalf("expected invalid JSON error, got %v", errs)
+	}
+}
+
+func TestSchemaValidateGeminiDialect(t *testing.T) {
+	schema := mustCompile(t, `{"type":"OBJECT","properties":{"note":{"type":"STRING","nullable":true}},"required":["note"]}`)
+	if errs := schema.Validate([]byte(`{"note":null}`)); len(errs) != 0 {
+		t.Fatalf("expected nullable to accept null, got %v", errs)
+	}
+	if errs := schema.Validate([]byte(`{"note":1}`)); len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+}
+
+func TestSpecFromRequest(t *testing.T) {
+	cases := []struct {
+		format, raw string
+		schema      bool
+	}{
+		{"openai", `{"response_format":{"type":"json_schema","json_schema":{"name":"n","schema":{"type":"object"}}}}`, true},
+		{"openai", `{"response_format":{"type":"json_object"}}`, false},
+		{"openai-response", `{"text":{"format":{"type":"json_schema","name":"n","schema":{"type":"object"}}}}`, true},
+		{"claude", `{"output_format":{"type":"json_schema","schema":{"type":"object"}}}`, true},
+		{"gemini", `{"generationConfig":{"responseMimeType":"application/json","responseJsonSchema":{"type":"object"}}}`, true},
+		{"gemini", `{"generationConfig":{"responseMimeType":"application/json"}}`, false},
+	}
+	for _, tc := range cases {
+		spec, err := SpecFromRequest(tc.format, []byte(tc.raw))
+		if err != nil || spec == nil {
+			t.Fatalf("%s %s: spec=%v err=%v", tc.format, tc.raw, spec, err)
+		}
+		if (spec.Schema != nil) != tc.schema {
+			t.Fatalf("%s %s: schema presence = %v", tc.format, tc.raw, spec.Schema != nil)
+		}
+	}
+	if spec, _ := SpecFromRequest("openai", []byte(`{"messages":[]}`)); spec != nil {
+		t.Fatalf("expected no spec for plain request")
+	}
+}
+
+func TestStreamAccumulatorClaude(t *testing.T) {
+	acc := NewStreamAccumulator("claude")
+	acc.Add([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"{\\\"a\\\"\"}}\n"))
+	acc.Add([]byte("\nevent: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_de"))
+	acc.Add([]byte("lta\",\"text\":\":1}\"}}\n\n"))
+	if out := acc.Output(); out.Text != `{"a":1}` || out.ToolCall {
+		t.Fatalf("output = %+v", out)
+	}
+}
+
+func TestRepairRequestAndErrorBody(t *testing.T) {
+	errs := []ValidationError{{Path: "$.a", Message: "expected string, got number"}}
+	repaired, ok := RepairRequest("gemini", []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`), `{"a":1}`, errs)
+	if !ok {
+		t.Fatalf("expected repair request")
+	}
+	contents := gjson.GetBytes(repaired, "contents").Array()
+	if len(contents) != 3 || contents[1].Get("role").String() != "model" || !strings.Contains(contents[2].Get("parts.0.text").String(), "$.a") {
+		t.Fatalf("unexpected repaired request: %s", repaired)
+	}
+
+	if body := ErrorBody("claude", errs); gjson.GetBytes(body, "type").String() != "error" || gjson.GetBytes(body, "error.validation_errors.0.path").String() != "$.a" {
+		t.Fatalf("unexpected claude error body: %s", body)
+	}
+	if body := ErrorBody("openai", errs); gjson.GetBytes(body, "error.code").String() != "json_schema_validation_failed" {
+		t.Fatalf("unexpected openai error body: %s", body)
+	}
+}
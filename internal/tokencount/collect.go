@@ -0,0 +1,340 @@
+package tokencount
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+)
+
+// CountRequest estimates the prompt tokens of a request body in the given client format using
+// the tokenizer family of model.
+func CountRequest(format, model string, payload []byte) (int64, error) {
+	joined := strings.TrimSpace(strings.Join(Segments(format, payload), "\n"))
+	return FamilyForModel(model).Count(joined)
+}
+
+// Segments extracts the prompt text of a request body: messages, system instructions, tool
+// definitions and structured output schemas. Unknown formats are read as OpenAI chat requests.
+func Segments(format string, payload []byte) []string {
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return nil
+	}
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+	switch format {
+	case constant.Claude:
+		collectClaude(root, &segments)
+	case constant.Gemini:
+		collectGemini(root, &segments)
+	case constant.GeminiCLI:
+		collectGemini(root.Get("request"), &segments)
+	case constant.OpenaiResponse:
+		collectOpenAIResponses(root, &segments)
+	default:
+		collectOpenAIChat(root, &segments)
+	}
+	return segments
+}
+
+func collectOpenAIChat(root gjson.Result, segments *[]string) {
+	collectOpenAIMessages(root.Get("messages"), segments)
+	collectOpenAITools(root.Get("tools"), segments)
+	collectOpenAIFunctions(root.Get("functions"), segments)
+	collectOpenAIToolChoice(root.Get("tool_choice"), segments)
+	collectOpenAIResponseFormat(root.Get("response_format"), segments)
+	addIfNotEmpty(segments, root.Get("input").String())
+	addIfNotEmpty(segments, root.Get("prompt").String())
+}
+
+func collectOpenAIResponses(root gjson.Result, segments *[]string) {
+	addIfNotEmpty(segments, root.Get("instructions").String())
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		addIfNotEmpty(segments, input.String())
+	} else if input.IsArray() {
+		input.ForEach(func(_, item gjson.Result) bool {
+			switch item.Get("type").String() {
+			case "function_call", "custom_tool_call":
+				addIfNotEmpty(segments, item.Get("name").String())
+				addIfNotEmpty(segments, item.Get("arguments").String())
+				addIfNotEmpty(segments, item.Get("input").String())
+			case "function_call_output", "custom_tool_call_output":
+				collectOpenAIContent(item.Get("output"), segments)
+			case "reasoning":
+				item.Get("summary").ForEach(func(_, part gjson.Result) bool {
+					addIfNotEmpty(segments, part.Get("text").String())
+					return true
+				})
+			default:
+				addIfNotEmpty(segments, item.Get("role").String())
+				collectOpenAIContent(item.Get("content"), segments)
+			}
+			return true
+		})
+	}
+	collectOpenAITools(root.Get("tools"), segments)
+	collectOpenAIToolChoice(root.Get("tool_choice"), segments)
+	if format := root.Get("text.format"); format.Exists() {
+		collectOpenAIResponseFormat(format, segments)
+	}
+}
+
+func collectClaude(root gjson.Result, segments *[]string) {
+	collectClaudeContent(root.Get("system"), segments)
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		addIfNotEmpty(segments, message.Get("role").String())
+		collectClaudeContent(message.Get("content"), segments)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		addIfNotEmpty(segments, tool.Get("name").String())
+		addIfNotEmpty(segments, tool.Get("description").String())
+		if schema := tool.Get("input_schema"); schema.Exists() {
+			addIfNotEmpty(segments, schema.Raw)
+		}
+		return true
+	})
+	if format := root.Get("output_format"); format.Exists() {
+		addIfNotEmpty(segments, format.Get("schema").Raw)
+	}
+}
+
+func collectClaudeContent(content gjson.Result, segments *[]string) {
+	if content.Type == gjson.String {
+		addIfNotEmpty(segments, content.String())
+		return
+	}
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			addIfNotEmpty(segments, block.Get("text").String())
+		case "thinking":
+			addIfNotEmpty(segments, block.Get("thinking").String())
+		case "tool_use", "server_tool_use":
+			addIfNotEmpty(segments, block.Get("name").String())
+			addIfNotEmpty(segments, block.Get("input").Raw)
+		case "tool_result":
+			collectClaudeContent(block.Get("content"), segments)
+		case "document":
+			if block.Get("source.type").String() == "text" {
+				addIfNotEmpty(segments, block.Get("source.data").String())
+			}
+		}
+		return true
+	})
+}
+
+func collectGemini(root gjson.Result, segments *[]string) {
+	// countTokens accepts the request either directly or wrapped in generateContentRequest.
+	if wrapped := root.Get("generateContentRequest"); wrapped.Exists() {
+		root = wrapped
+	}
+	system := root.Get("systemInstruction")
+	if !system.Exists() {
+		system = root.Get("system_instruction")
+	}
+	collectGeminiParts(system.Get("parts"), segments)
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		addIfNotEmpty(segments, content.Get("role").String())
+		collectGeminiParts(content.Get("parts"), segments)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		declarations := tool.Get("functionDeclarations")
+		if !declarations.Exists() {
+			declarations = tool.Get("function_declarations")
+		}
+		declarations.ForEach(func(_, decl gjson.Result) bool {
+			addIfNotEmpty(segments, decl.Get("name").String())
+			addIfNotEmpty(segments, decl.Get("description").String())
+			if params := decl.Get("parameters"); params.Exists() {
+				addIfNotEmpty(segments, params.Raw)
+			}
+			if params := decl.Get("parametersJsonSchema"); params.Exists() {
+				addIfNotEmpty(segments, params.Raw)
+			}
+			return true
+		})
+		return true
+	})
+	if schema := root.Get("generationConfig.responseJsonSchema"); schema.Exists() {
+		addIfNotEmpty(segments, schema.Raw)
+	}
+}
+
+func collectGeminiParts(parts gjson.Result, segments *[]string) {
+	parts.ForEach(func(_, part gjson.Result) bool {
+		addIfNotEmpty(segments, part.Get("text").String())
+		if call := part.Get("functionCall"); call.Exists() {
+			addIfNotEmpty(segments, call.Get("name").String())
+			addIfNotEmpty(segments, call.Get("args").Raw)
+		}
+		if resp := part.Get("functionResponse"); resp.Exists() {
+			addIfNotEmpty(segments, resp.Get("name").String())
+			addIfNotEmpty(segments, resp.Get("response").Raw)
+		}
+		return true
+	})
+}
+
+func collectOpenAIMessages(messages gjson.Result, segments *[]string) {
+	if !messages.Exists() || !messages.IsArray() {
+		return
+	}
+	messages.ForEach(func(_, message gjson.Result) bool {
+		addIfNotEmpty(segments, message.Get("role").String())
+		addIfNotEmpty(segments, message.Get("name").String())
+		collectOpenAIContent(message.Get("content"), segments)
+		collectOpenAIToolCalls(message.Get("tool_calls"), segments)
+		collectOpenAIFunctionCall(message.Get("function_call"), segments)
+		return true
+	})
+}
+
+func collectOpenAIContent(content gjson.Result, segments *[]string) {
+	if !content.Exists() {
+		return
+	}
+	if content.Type == gjson.String {
+		addIfNotEmpty(segments, content.String())
+		return
+	}
+	if content.IsArray() {
+		content.ForEach(func(_, part gjson.Result) bool {
+			partType := part.Get("type").String()
+			switch partType {
+			case "text", "input_text", "output_text":
+				addIfNotEmpty(segments, part.Get("text").String())
+			case "image_url":
+				addIfNotEmpty(segments, part.Get("image_url.url").String())
+			case "input_audio", "output_audio", "audio":
+				addIfNotEmpty(segments, part.Get("id").String())
+			case "tool_result":
+				addIfNotEmpty(segments, part.Get("name").String())
+				collectOpenAIContent(part.Get("content"), segments)
+			default:
+				if part.IsArray() {
+					collectOpenAIContent(part, segments)
+					return true
+				}
+				if part.Type == gjson.JSON {
+					addIfNotEmpty(segments, part.Raw)
+					return true
+				}
+				addIfNotEmpty(segments, part.String())
+			}
+			return true
+		})
+		return
+	}
+	if content.Type == gjson.JSON {
+		addIfNotEmpty(segments, content.Raw)
+	}
+}
+
+func collectOpenAIToolCalls(calls gjson.Result, segments *[]string) {
+	if !calls.Exists() || !calls.IsArray() {
+		return
+	}
+	calls.ForEach(func(_, call gjson.Result) bool {
+		addIfNotEmpty(segments, call.Get("id").String())
+		addIfNotEmpty(segments, call.Get("type").String())
+		function := call.Get("function")
+		if function.Exists() {
+			addIfNotEmpty(segments, function.Get("name").String())
+			addIfNotEmpty(segments, function.Get("description").String())
+			addIfNotEmpty(segments, function.Get("arguments").String())
+			if params := function.Get("parameters"); params.Exists() {
+				addIfNotEmpty(segments, params.Raw)
+			}
+		}
+		return true
+	})
+}
+
+func collectOpenAIFunctionCall(call gjson.Result, segments *[]string) {
+	if !call.Exists() {
+		return
+	}
+	addIfNotEmpty(segments, call.Get("name").String())
+	addIfNotEmpty(segments, call.Get("arguments").String())
+}
+
+func collectOpenAITools(tools gjson.Result, segments *[]string) {
+	if !tools.Exists() {
+		return
+	}
+	if tools.IsArray() {
+		tools.ForEach(func(_, tool gjson.Result) bool {
+			appendToolPayload(tool, segments)
+			return true
+		})
+		return
+	}
+	appendToolPayload(tools, segments)
+}
+
+func collectOpenAIFunctions(functions gjson.Result, segments *[]string) {
+	if !functions.Exists() || !functions.IsArray() {
+		return
+	}
+	functions.ForEach(func(_, function gjson.Result) bool {
+		addIfNotEmpty(segments, function.Get("name").String())
+		addIfNotEmpty(segments, function.Get("description").String())
+		if params := function.Get("parameters"); params.Exists() {
+			addIfNotEmpty(segments, params.Raw)
+		}
+		return true
+	})
+}
+
+func collectOpenAIToolChoice(choice gjson.Result, segments *[]string) {
+	if !choice.Exists() {
+		return
+	}
+	if choice.Type == gjson.String {
+		addIfNotEmpty(segments, choice.String())
+		return
+	}
+	addIfNotEmpty(segments, choice.Raw)
+}
+
+func collectOpenAIResponseFormat(format gjson.Result, segments *[]string) {
+	if !format.Exists() {
+		return
+	}
+	addIfNotEmpty(segments, format.Get("type").String())
+	addIfNotEmpty(segments, format.Get("name").String())
+	if schema := format.Get("json_schema"); schema.Exists() {
+		addIfNotEmpty(segments, schema.Raw)
+	}
+	if schema := format.Get("schema"); schema.Exists() {
+		addIfNotEmpty(segments, schema.Raw)
+	}
+}
+
+func appendToolPayload(tool gjson.Result, segments *[]string) {
+	if !tool.Exists() {
+		return
+	}
+	addIfNotEmpty(segments, tool.Get("type").String())
+	addIfNotEmpty(segments, tool.Get("name").String())
+	addIfNotEmpty(segments, tool.Get("description").String())
+	if function := tool.Get("function"); function.Exists() {
+		addIfNotEmpty(segments, function.Get("name").String())
+		addIfNotEmpty(segments, function.Get("description").String())
+		if params := function.Get("parameters"); params.Exists() {
+			addIfNotEmpty(segments, params.Raw)
+		}
+	}
+}
+
+func addIfNotEmpty(segments *[]string, value string) {
+	if segments == nil {
+		return
+	}
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		*segments = append(*segments, trimmed)
+	}
+}
@@ -0,0 +1,146 @@
+// Package tokencount estimates prompt token counts locally. A registry maps model families to
+// tokenizers whose vocabularies are embedded in the binary (cl100k and o200k); families without
+// a public tokenizer (Claude, Gemini) are approximated with the closest vocabulary and a scale.
+package tokencount
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Family describes how token counts of a model family are estimated.
+type Family struct {
+	// Name identifies the family, e.g. "o200k" or "claude".
+	Name string
+	// Encoding is the embedded vocabulary used for counting.
+	Encoding tokenizer.Encoding
+	// Scale corrects counts of families that are approximated with another vocabulary.
+	// Zero means 1.
+	Scale float64
+}
+
+// Built-in families.
+var (
+	O200k  = Family{Name: "o200k", Encoding: tokenizer.O200kBase}
+	Cl100k = Family{Name: "cl100k", Encoding: tokenizer.Cl100kBase}
+	// Claude's tokenizer is not public; it yields somewhat more tokens than cl100k on typical
+	// English and code prompts.
+	Claude = Family{Name: "claude", Encoding: tokenizer.Cl100kBase, Scale: 1.1}
+	// Gemini's SentencePiece vocabulary is close in size and density to o200k.
+	Gemini = Family{Name: "gemini", Encoding: tokenizer.O200kBase}
+)
+
+type prefixRule struct {
+	prefix string
+	family Family
+}
+
+var (
+	registryMu sync.RWMutex
+	rules      = []prefixRule{
+		{"gpt-5", O200k},
+		{"gpt-4.1", O200k},
+		{"gpt-4o", O200k},
+		{"gpt-4.5", O200k},
+		{"gpt-oss", O200k},
+		{"chatgpt", O200k},
+		{"codex", O200k},
+		{"o1", O200k},
+		{"o3", O200k},
+		{"o4", O200k},
+		{"gpt-4", Cl100k},
+		{"gpt-3", Cl100k},
+		{"text-embedding", Cl100k},
+		{"qwen", Cl100k},
+		{"deepseek", Cl100k},
+		{"glm", Cl100k},
+		{"kimi", Cl100k},
+		{"claude", Claude},
+		{"gemini", Gemini},
+		{"gemma", Gemini},
+	}
+	codecs sync.Map // tokenizer.Encoding -> tokenizer.Codec
+)
+
+// Register maps model ids starting with prefix (case-insensitive) to family. The longest
+// matching prefix wins; registering an existing prefix replaces its family.
+func Register(prefix string, family Family) {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for i := range rules {
+		if rules[i].prefix == prefix {
+			rules[i].family = family
+			return
+		}
+	}
+	rules = append(rules, prefixRule{prefix: prefix, family: family})
+}
+
+// FamilyForModel returns the family of a model id. Provider prefixes ("team/gpt-5") and
+// thinking suffixes are ignored; unknown models use o200k.
+func FamilyForModel(model string) Family {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	if idx := strings.Index(name, "("); idx > 0 {
+		name = name[:idx]
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	best, bestLen := O200k, 0
+	for _, rule := range rules {
+		if len(rule.prefix) > bestLen && strings.HasPrefix(name, rule.prefix) {
+			best, bestLen = rule.family, len(rule.prefix)
+		}
+	}
+	return best
+}
+
+// Codec returns the shared codec of the family's vocabulary.
+func (f Family) Codec() (tokenizer.Codec, error) {
+	if cached, ok := codecs.Load(f.Encoding); ok {
+		return cached.(tokenizer.Codec), nil
+	}
+	codec, err := tokenizer.Get(f.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := codecs.LoadOrStore(f.Encoding, codec)
+	return actual.(tokenizer.Codec), nil
+}
+
+// Count estimates the number of tokens in text.
+func (f Family) Count(text string) (int64, error) {
+	if text == "" {
+		return 0, nil
+	}
+	codec, err := f.Codec()
+	if err != nil {
+		return 0, err
+	}
+	count, err := codec.Count(text)
+	if err != nil {
+		return 0, err
+	}
+	return f.scale(count), nil
+}
+
+func (f Family) scale(count int) int64 {
+	if f.Scale <= 0 || f.Scale == 1 {
+		return int64(count)
+	}
+	return int64(math.Ceil(float64(count) * f.Scale))
+}
+
+// CodecForModel returns the codec used for a model id.
+func CodecForModel(model string) (tokenizer.Codec, error) {
+	return FamilyForModel(model).Codec()
+}
@@ -0,0 +1,88 @@
+package tokencount
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+)
+
+func TestFamilyForModel(t *testing.T) {
+	cases := map[string]string{
+		"gpt-5":                      "o200k",
+		"gpt-4o-mini":                "o200k",
+		"gpt-4-turbo":                "cl100k",
+		"team/gpt-4.1(high)":         "o200k",
+		"claude-sonnet-4-5-20250929": "claude",
+		"gemini-2.5-pro":             "gemini",
+		"qwen3-coder-plus":           "cl100k",
+		"some-unknown-model":         "o200k",
+	}
+	for model, want := range cases {
+		if got := FamilyForModel(model).Name; got != want {
+			t.Errorf("FamilyForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestRegisterLongestPrefixWins(t *testing.T) {
+	Register("gpt-4-legacy-o", O200k)
+	t.Cleanup(func() { Register("gpt-4-legacy-o", Cl100k) })
+	if got := FamilyForModel("gpt-4-legacy-other").Name; got != "o200k" {
+		t.Fatalf("registered prefix not used, got %q", got)
+	}
+	if got := FamilyForModel("gpt-4-0613").Name; got != "cl100k" {
+		t.Fatalf("shorter prefix changed, got %q", got)
+	}
+}
+
+func TestCountAppliesScale(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog. func main() { fmt.Println(42) }"
+	base, err := Cl100k.Count(text)
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	scaled, err := Claude.Count(text)
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if base == 0 || scaled <= base {
+		t.Fatalf("expected scaled count above %d, got %d", base, scaled)
+	}
+}
+
+func TestCountRequestFormats(t *testing.T) {
+	cases := []struct {
+		format  string
+		payload string
+	}{
+		{constant.OpenAI, `{"messages":[{"role":"system","content":"Be terse."},{"role":"user","content":"What is the capital of France?"}]}`},
+		{constant.OpenaiResponse, `{"instructions":"Be terse.","input":[{"role":"user","content":[{"type":"input_text","text":"What is the capital of France?"}]}]}`},
+		{constant.Claude, `{"system":[{"type":"text","text":"Be terse."}],"messages":[{"role":"user","content":[{"type":"text","text":"What is the capital of France?"}]}]}`},
+		{constant.Gemini, `{"systemInstruction":{"parts":[{"text":"Be terse."}]},"contents":[{"role":"user","parts":[{"text":"What is the capital of France?"}]}]}`},
+		{constant.Gemini, `{"generateContentRequest":{"contents":[{"role":"user","parts":[{"text":"Be terse. What is the capital of France?"}]}]}}`},
+		{constant.GeminiCLI, `{"request":{"contents":[{"role":"user","parts":[{"text":"Be terse. What is the capital of France?"}]}]}}`},
+	}
+	for _, tc := range cases {
+		count, err := CountRequest(tc.format, "gpt-5", []byte(tc.payload))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.format, err)
+		}
+		if count < 8 || count > 20 {
+			t.Errorf("%s: unexpected count %d for %s", tc.format, count, tc.payload)
+		}
+	}
+}
+
+func TestSegmentsIncludeTools(t *testing.T) {
+	payload := `{"messages":[{"role":"user","content":"hi"}],"tools":[{"name":"get_weather","description":"Weather lookup","input_schema":{"type":"object"}}]}`
+	segments := Segments(constant.Claude, []byte(payload))
+	found := false
+	for _, segment := range segments {
+		if segment == "get_weather" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("tool name missing from %q", segments)
+	}
+}
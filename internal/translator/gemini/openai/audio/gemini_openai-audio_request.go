@@ -0,0 +1,141 @@
+// Package audio converts OpenAI Audio API payloads (transcriptions, translations and
+// speech) to Gemini generateContent requests, and converts the Gemini responses back
+// to the text, subtitle and audio shapes returned by OpenAI.
+package audio
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// transcriptSegmentsSchema asks Gemini for a timestamped transcript when the client
+// requested a format that needs segments (verbose_json, srt, vtt).
+const transcriptSegmentsSchema = `{"type":"OBJECT","properties":{"language":{"type":"STRING"},"duration":{"type":"NUMBER"},"text":{"type":"STRING"},"segments":{"type":"ARRAY","items":{"type":"OBJECT","properties":{"start":{"type":"NUMBER"},"end":{"type":"NUMBER"},"text":{"type":"STRING"}},"required":["start","end","text"]}}},"required":["text","segments"]}`
+
+// openAIVoices maps OpenAI voice names to Gemini prebuilt voices with a similar character.
+var openAIVoices = map[string]string{
+	"alloy":   "Kore",
+	"ash":     "Puck",
+	"ballad":  "Sulafat",
+	"coral":   "Zephyr",
+	"echo":    "Charon",
+	"fable":   "Fenrir",
+	"nova":    "Aoede",
+	"onyx":    "Orus",
+	"sage":    "Schedar",
+	"shimmer": "Leda",
+	"verse":   "Achird",
+}
+
+// SegmentedFormat reports whether the OpenAI response_format needs timestamped segments.
+func SegmentedFormat(responseFormat string) bool {
+	switch strings.ToLower(strings.TrimSpace(responseFormat)) {
+	case "verbose_json", "srt", "vtt":
+		return true
+	default:
+		return false
+	}
+}
+
+// ConvertOpenAITranscriptionRequestToGemini converts an OpenAI transcription or
+// translation request into a Gemini generateContent request.
+//
+// The handler folds the multipart upload into JSON with the audio in
+// "file": {"mime_type","data"} (base64 data). "task" selects between
+// "transcribe" (default) and "translate" (to English).
+//
+// Parameters:
+//   - modelName: The name of the model (unused, kept for translator symmetry)
+//   - inputRawJSON: The folded OpenAI audio request
+//
+// Returns:
+//   - []byte: A Gemini generateContent request body
+func ConvertOpenAITranscriptionRequestToGemini(modelName string, inputRawJSON []byte) []byte {
+	_ = modelName
+	root := gjson.ParseBytes(inputRawJSON)
+
+	var instruction strings.Builder
+	if strings.EqualFold(root.Get("task").String(), "translate") {
+		instruction.WriteString("Translate the speech in this audio into English.")
+	} else {
+		instruction.WriteString("Transcribe the speech in this audio verbatim.")
+		if language := strings.TrimSpace(root.Get("language").String()); language != "" {
+			instruction.WriteString(" The spoken language is ")
+			instruction.WriteString(language)
+			instruction.WriteString(".")
+		}
+	}
+	if hint := strings.TrimSpace(root.Get("prompt").String()); hint != "" {
+		instruction.WriteString(" Use the following context for spelling and vocabulary: ")
+		instruction.WriteString(hint)
+	}
+	segmented := SegmentedFormat(root.Get("response_format").String())
+	if segmented {
+		instruction.WriteString(" Split the transcript into segments of at most a few sentences and give each segment its start and end time in seconds. Also report the ISO-639-1 language code and the total duration in seconds.")
+	} else {
+		instruction.WriteString(" Output only the transcript, without any commentary, labels or formatting.")
+	}
+
+	out := `{"contents":[{"role":"user","parts":[]}],"generationConfig":{}}`
+	audio := `{"inlineData":{"mime_type":"","data":""}}`
+	audio, _ = sjson.Set(audio, "inlineData.mime_type", root.Get("file.mime_type").String())
+	audio, _ = sjson.Set(audio, "inlineData.data", root.Get("file.data").String())
+	out, _ = sjson.SetRaw(out, "contents.0.parts.-1", audio)
+	text := `{"text":""}`
+	text, _ = sjson.Set(text, "text", instruction.String())
+	out, _ = sjson.SetRaw(out, "contents.0.parts.-1", text)
+
+	if temperature := root.Get("temperature"); temperature.Exists() {
+		out, _ = sjson.Set(out, "generationConfig.temperature", temperature.Float())
+	}
+	if segmented {
+		out, _ = sjson.Set(out, "generationConfig.responseMimeType", "application/json")
+		out, _ = sjson.SetRaw(out, "generationConfig.responseSchema", transcriptSegmentsSchema)
+	}
+	return []byte(out)
+}
+
+// ConvertOpenAISpeechRequestToGemini converts an OpenAI /v1/audio/speech request into
+// a Gemini TTS generateContent request.
+//
+// Parameters:
+//   - modelName: The name of the model (unused, kept for translator symmetry)
+//   - inputRawJSON: The OpenAI speech request
+//
+// Returns:
+//   - []byte: A Gemini generateContent request body
+func ConvertOpenAISpeechRequestToGemini(modelName string, inputRawJSON []byte) []byte {
+	_ = modelName
+	root := gjson.ParseBytes(inputRawJSON)
+
+	input := root.Get("input").String()
+	if instructions := strings.TrimSpace(root.Get("instructions").String()); instructions != "" {
+		input = instructions + ": " + input
+	}
+	if speed := root.Get("speed").Float(); speed > 0 && speed != 1 {
+		if speed > 1 {
+			input = "Speak quickly. " + input
+		} else {
+			input = "Speak slowly. " + input
+		}
+	}
+
+	out := `{"contents":[{"role":"user","parts":[{"text":""}]}],"generationConfig":{"responseModalities":["AUDIO"]}}`
+	out, _ = sjson.Set(out, "contents.0.parts.0.text", input)
+	if voice := GeminiVoiceName(root.Get("voice").String()); voice != "" {
+		out, _ = sjson.Set(out, "generationConfig.speechConfig.voiceConfig.prebuiltVoiceConfig.voiceName", voice)
+	}
+	return []byte(out)
+}
+
+// GeminiVoiceName resolves an OpenAI voice name to a Gemini prebuilt voice.
+// Unknown names are passed through so clients can address Gemini voices directly.
+func GeminiVoiceName(voice string) string {
+	voice = strings.TrimSpace(voice)
+	if mapped, ok := openAIVoices[strings.ToLower(voice)]; ok {
+		return mapped
+	}
+	return voice
+}
@@ -0,0 +1,225 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// defaultTTSSampleRate is the PCM sample rate returned by Gemini TTS models.
+	defaultTTSSampleRate = 24000
+)
+
+// ConvertGeminiResponseToOpenAITranscription renders a Gemini transcription response
+// in the OpenAI response_format requested by the client.
+//
+// Parameters:
+//   - rawJSON: The Gemini generateContent response
+//   - responseFormat: json (default), text, verbose_json, srt or vtt
+//   - task: "transcribe" or "translate", echoed in verbose_json
+//
+// Returns:
+//   - []byte: The response body
+//   - string: The response content type
+func ConvertGeminiResponseToOpenAITranscription(rawJSON []byte, responseFormat, task string) ([]byte, string) {
+	root := gjson.ParseBytes(rawJSON)
+	if r := root.Get("response"); r.Exists() {
+		root = r
+	}
+	var text strings.Builder
+	root.Get("candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+		if t := part.Get("text"); t.Exists() && !part.Get("thought").Bool() {
+			text.WriteString(t.String())
+		}
+		return true
+	})
+	output := strings.TrimSpace(text.String())
+
+	format := strings.ToLower(strings.TrimSpace(responseFormat))
+	if !SegmentedFormat(format) {
+		if format == "text" {
+			return []byte(output + "\n"), "text/plain; charset=utf-8"
+		}
+		out := `{"text":""}`
+		out, _ = sjson.Set(out, "text", output)
+		if usage := root.Get("usageMetadata"); usage.Exists() {
+			out, _ = sjson.Set(out, "usage.type", "tokens")
+			out, _ = sjson.Set(out, "usage.input_tokens", usage.Get("promptTokenCount").Int())
+			out, _ = sjson.Set(out, "usage.output_tokens", usage.Get("candidatesTokenCount").Int())
+			out, _ = sjson.Set(out, "usage.total_tokens", usage.Get("totalTokenCount").Int())
+		}
+		return []byte(out), "application/json"
+	}
+
+	transcript := gjson.Parse(output)
+	if !transcript.IsObject() {
+		// The model ignored the schema; fall back to a single untimed segment.
+		fallback := `{"text":"","segments":[{"start":0,"end":0,"text":""}]}`
+		fallback, _ = sjson.Set(fallback, "text", output)
+		fallback, _ = sjson.Set(fallback, "segments.0.text", output)
+		transcript = gjson.Parse(fallback)
+	}
+	segments := transcript.Get("segments").Array()
+
+	switch format {
+	case "srt":
+		var b strings.Builder
+		for i, segment := range segments {
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, formatTimestamp(segment.Get("start").Float(), ","), formatTimestamp(segment.Get("end").Float(), ","), strings.TrimSpace(segment.Get("text").String()))
+		}
+		return []byte(b.String()), "text/plain; charset=utf-8"
+	case "vtt":
+		var b strings.Builder
+		b.WriteString("WEBVTT\n\n")
+		for _, segment := range segments {
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatTimestamp(segment.Get("start").Float(), "."), formatTimestamp(segment.Get("end").Float(), "."), strings.TrimSpace(segment.Get("text").String()))
+		}
+		return []byte(b.String()), "text/vtt; charset=utf-8"
+	}
+
+	if task == "" {
+		task = "transcribe"
+	}
+	out := `{"task":"","language":"","duration":0,"text":"","segments":[]}`
+	out, _ = sjson.Set(out, "task", task)
+	out, _ = sjson.Set(out, "language", transcript.Get("language").String())
+	duration := transcript.Get("duration").Float()
+	if len(segments) > 0 {
+		if end := segments[len(segments)-1].Get("end").Float(); end > duration {
+			duration = end
+		}
+	}
+	out, _ = sjson.Set(out, "duration", duration)
+	fullText := strings.TrimSpace(transcript.Get("text").String())
+	if fullText == "" {
+		parts := make([]string, 0, len(segments))
+		for _, segment := range segments {
+			parts = append(parts, strings.TrimSpace(segment.Get("text").String()))
+		}
+		fullText = strings.Join(parts, " ")
+	}
+	out, _ = sjson.Set(out, "text", fullText)
+	for i, segment := range segments {
+		item := `{"id":0,"seek":0,"start":0,"end":0,"text":"","tokens":[],"temperature":0,"avg_logprob":0,"compression_ratio":0,"no_speech_prob":0}`
+		item, _ = sjson.Set(item, "id", i)
+		item, _ = sjson.Set(item, "start", segment.Get("start").Float())
+		item, _ = sjson.Set(item, "end", segment.Get("end").Float())
+		item, _ = sjson.Set(item, "text", segment.Get("text").String())
+		out, _ = sjson.SetRaw(out, "segments.-1", item)
+	}
+	return []byte(out), "application/json"
+}
+
+// formatTimestamp renders seconds as HH:MM:SS<sep>mmm for SRT (",") and VTT (".").
+func formatTimestamp(seconds float64, sep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	totalMillis := int64(seconds*1000 + 0.5)
+	hours := totalMillis / 3_600_000
+	minutes := (totalMillis / 60_000) % 60
+	secs := (totalMillis / 1000) % 60
+	millis := totalMillis % 1000
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", hours, minutes, secs, sep, millis)
+}
+
+// ConvertGeminiResponseToOpenAISpeech extracts the audio produced by a Gemini TTS model.
+// Gemini returns raw 16-bit little-endian mono PCM; "pcm" returns it unchanged and
+// every other format is wrapped in a WAV container, because the proxy does not ship
+// lossy encoders.
+//
+// Parameters:
+//   - rawJSON: The Gemini generateContent response
+//   - responseFormat: The OpenAI response_format (mp3, opus, aac, flac, wav, pcm)
+//
+// Returns:
+//   - []byte: The audio bytes
+//   - string: The response content type
+//   - error: An error when the response carries no audio
+func ConvertGeminiResponseToOpenAISpeech(rawJSON []byte, responseFormat string) ([]byte, string, error) {
+	root := gjson.ParseBytes(rawJSON)
+	if r := root.Get("response"); r.Exists() {
+		root = r
+	}
+	var pcm bytes.Buffer
+	sampleRate := defaultTTSSampleRate
+	root.Get("candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+		inline := part.Get("inlineData")
+		if !inline.Exists() {
+			inline = part.Get("inline_data")
+		}
+		data := inline.Get("data").String()
+		if data == "" {
+			return true
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return true
+		}
+		mimeType := inline.Get("mimeType").String()
+		if mimeType == "" {
+			mimeType = inline.Get("mime_type").String()
+		}
+		if rate := sampleRateFromMimeType(mimeType); rate > 0 {
+			sampleRate = rate
+		}
+		pcm.Write(decoded)
+		return true
+	})
+	if pcm.Len() == 0 {
+		return nil, "", fmt.Errorf("model returned no audio data")
+	}
+	if strings.EqualFold(strings.TrimSpace(responseFormat), "pcm") {
+		return pcm.Bytes(), "audio/pcm", nil
+	}
+	return wrapPCMAsWAV(pcm.Bytes(), sampleRate), "audio/wav", nil
+}
+
+// sampleRateFromMimeType parses "audio/L16;codec=pcm;rate=24000".
+func sampleRateFromMimeType(mimeType string) int {
+	for _, param := range strings.Split(mimeType, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(key, "rate") {
+			continue
+		}
+		if rate, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return rate
+		}
+	}
+	return 0
+}
+
+// wrapPCMAsWAV prefixes 16-bit mono PCM samples with a canonical RIFF/WAVE header.
+func wrapPCMAsWAV(pcm []byte, sampleRate int) []byte {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+	)
+	byteRate := sampleRate * channels * bitsPerSample / 8
+	blockAlign := channels * bitsPerSample / 8
+
+	var buf bytes.Buffer
+	buf.Grow(44 + len(pcm))
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(byteRate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(blockAlign))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
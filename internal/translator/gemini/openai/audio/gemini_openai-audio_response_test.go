@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAITranscriptionRequestToGeminiSegmented(t *testing.T) {
+	input := []byte(`{"task":"transcribe","model":"gemini-2.5-flash","language":"de","response_format":"srt","file":{"mime_type":"audio/wav","data":"UklGRg=="}}`)
+	out := ConvertOpenAITranscriptionRequestToGemini("gemini-2.5-flash", input)
+
+	if got := gjson.GetBytes(out, "contents.0.parts.0.inlineData.mime_type").String(); got != "audio/wav" {
+		t.Fatalf("mime_type = %q", got)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q", got)
+	}
+	if !strings.Contains(gjson.GetBytes(out, "contents.0.parts.1.text").String(), "de") {
+		t.Fatalf("language hint missing: %s", out)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAITranscriptionFormats(t *testing.T) {
+	transcript := `{"language":"en","duration":3.5,"text":"Hello there. General Kenobi.","segments":[{"start":0,"end":1.25,"text":"Hello there."},{"start":1.25,"end":3.5,"text":"General Kenobi."}]}`
+	resp := `{"candidates":[{"content":{"parts":[{"text":` + quote(transcript) + `}]}}]}`
+
+	srt, contentType := ConvertGeminiResponseToOpenAITranscription([]byte(resp), "srt", "transcribe")
+	if !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("srt content type = %q", contentType)
+	}
+	if !strings.Contains(string(srt), "2\n00:00:01,250 --> 00:00:03,500\nGeneral Kenobi.") {
+		t.Fatalf("unexpected srt:\n%s", srt)
+	}
+
+	vtt, _ := ConvertGeminiResponseToOpenAITranscription([]byte(resp), "vtt", "transcribe")
+	if !strings.HasPrefix(string(vtt), "WEBVTT\n\n00:00:00.000 --> 00:00:01.250") {
+		t.Fatalf("unexpected vtt:\n%s", vtt)
+	}
+
+	verbose, _ := ConvertGeminiResponseToOpenAITranscription([]byte(resp), "verbose_json", "transcribe")
+	if got := gjson.GetBytes(verbose, "segments.1.text").String(); got != "General Kenobi." {
+		t.Fatalf("segments.1.text = %q", got)
+	}
+	if got := gjson.GetBytes(verbose, "duration").Float(); got != 3.5 {
+		t.Fatalf("duration = %v", got)
+	}
+
+	plain := `{"candidates":[{"content":{"parts":[{"text":"Hello there."}]}}]}`
+	jsonOut, _ := ConvertGeminiResponseToOpenAITranscription([]byte(plain), "", "transcribe")
+	if got := gjson.GetBytes(jsonOut, "text").String(); got != "Hello there." {
+		t.Fatalf("text = %q", got)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAISpeechWrapsWAV(t *testing.T) {
+	pcm := []byte{0x01, 0x00, 0x02, 0x00}
+	resp := `{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"audio/L16;codec=pcm;rate=16000","data":"` + base64.StdEncoding.EncodeToString(pcm) + `"}}]}}]}`
+
+	wav, contentType, err := ConvertGeminiResponseToOpenAISpeech([]byte(resp), "mp3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if contentType != "audio/wav" || string(wav[:4]) != "RIFF" || len(wav) != 44+len(pcm) {
+		t.Fatalf("unexpected wav output: %q len=%d", contentType, len(wav))
+	}
+	if rate := int(wav[24]) | int(wav[25])<<8; rate != 16000 {
+		t.Fatalf("sample rate = %d, want 16000", rate)
+	}
+
+	raw, contentType, _ := ConvertGeminiResponseToOpenAISpeech([]byte(resp), "pcm")
+	if contentType != "audio/pcm" || len(raw) != len(pcm) {
+		t.Fatalf("unexpected pcm output: %q len=%d", contentType, len(raw))
+	}
+
+	if _, _, err = ConvertGeminiResponseToOpenAISpeech([]byte(`{"candidates":[]}`), "wav"); err == nil {
+		t.Fatal("expected error for response without audio")
+	}
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
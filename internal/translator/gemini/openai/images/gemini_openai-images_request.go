@@ -0,0 +1,116 @@
+// Package images converts OpenAI Images API payloads (generations and edits)
+// to Gemini generateContent requests that ask for image response modalities,
+// and converts the resulting Gemini responses back to OpenAI image objects.
+package images
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// geminiAspectRatios lists the aspect ratios accepted by Gemini image models.
+var geminiAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// ConvertOpenAIImageRequestToGemini converts an OpenAI image request into a Gemini
+// generateContent request.
+//
+// The input is the JSON body of /v1/images/generations. For /v1/images/edits the
+// handler folds the multipart upload into the same shape and adds an "image" array
+// of {"mime_type","data"} objects (base64 data) plus an optional "mask" object.
+//
+// Parameters:
+//   - modelName: The name of the model (unused, kept for translator symmetry)
+//   - inputRawJSON: The OpenAI image request
+//
+// Returns:
+//   - []byte: A Gemini generateContent request body
+func ConvertOpenAIImageRequestToGemini(modelName string, inputRawJSON []byte) []byte {
+	_ = modelName
+	root := gjson.ParseBytes(inputRawJSON)
+
+	out := `{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseModalities":["TEXT","IMAGE"]}}`
+
+	images := root.Get("image")
+	if images.Exists() && !images.IsArray() {
+		out = appendInlineImage(out, images)
+	} else {
+		images.ForEach(func(_, image gjson.Result) bool {
+			out = appendInlineImage(out, image)
+			return true
+		})
+	}
+
+	prompt := strings.TrimSpace(root.Get("prompt").String())
+	if mask := root.Get("mask"); mask.Exists() && mask.Get("data").String() != "" {
+		out = appendInlineImage(out, mask)
+		prompt = "The last image is a mask: only change the areas where the mask is transparent and keep everything else unchanged.\n\n" + prompt
+	}
+	if style := strings.TrimSpace(root.Get("style").String()); style != "" {
+		prompt += "\n\nStyle: " + style
+	}
+	if background := strings.TrimSpace(root.Get("background").String()); background == "transparent" {
+		prompt += "\n\nUse a transparent background."
+	}
+	part := `{"text":""}`
+	part, _ = sjson.Set(part, "text", prompt)
+	out, _ = sjson.SetRaw(out, "contents.0.parts.-1", part)
+
+	if aspectRatio := AspectRatioForSize(root.Get("size").String()); aspectRatio != "" {
+		out, _ = sjson.Set(out, "generationConfig.imageConfig.aspectRatio", aspectRatio)
+	}
+
+	return []byte(out)
+}
+
+func appendInlineImage(out string, image gjson.Result) string {
+	data := image.Get("data").String()
+	if data == "" {
+		return out
+	}
+	mimeType := image.Get("mime_type").String()
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	part := `{"inlineData":{"mime_type":"","data":""}}`
+	part, _ = sjson.Set(part, "inlineData.mime_type", mimeType)
+	part, _ = sjson.Set(part, "inlineData.data", data)
+	out, _ = sjson.SetRaw(out, "contents.0.parts.-1", part)
+	return out
+}
+
+// AspectRatioForSize maps an OpenAI size string ("1024x1536", "auto", ...) to the
+// closest aspect ratio supported by Gemini image models. It returns an empty string
+// when the size is absent, "auto", or cannot be parsed.
+func AspectRatioForSize(size string) string {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size == "" || size == "auto" {
+		return ""
+	}
+	width, height, ok := strings.Cut(size, "x")
+	if !ok {
+		return ""
+	}
+	w, errW := strconv.ParseFloat(strings.TrimSpace(width), 64)
+	h, errH := strconv.ParseFloat(strings.TrimSpace(height), 64)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return ""
+	}
+	target := w / h
+	best := ""
+	bestDelta := math.MaxFloat64
+	for _, ratio := range geminiAspectRatios {
+		num, den, _ := strings.Cut(ratio, ":")
+		n, _ := strconv.ParseFloat(num, 64)
+		d, _ := strconv.ParseFloat(den, 64)
+		delta := math.Abs(math.Log(target) - math.Log(n/d))
+		if delta < bestDelta {
+			bestDelta = delta
+			best = ratio
+		}
+	}
+	return best
+}
@@ -0,0 +1,70 @@
+package images
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIImageRequestToGemini(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-flash-image","prompt":"a red fox","size":"1792x1024","image":[{"mime_type":"image/jpeg","data":"AAAA"}]}`)
+	out := ConvertOpenAIImageRequestToGemini("gemini-2.5-flash-image", input)
+
+	parts := gjson.GetBytes(out, "contents.0.parts").Array()
+	if len(parts) != 2 {
+		t.Fatalf("parts = %d, want 2: %s", len(parts), out)
+	}
+	if got := parts[0].Get("inlineData.mime_type").String(); got != "image/jpeg" {
+		t.Fatalf("inline mime = %q, want image/jpeg", got)
+	}
+	if got := parts[1].Get("text").String(); got != "a red fox" {
+		t.Fatalf("prompt = %q", got)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.imageConfig.aspectRatio").String(); got != "16:9" {
+		t.Fatalf("aspectRatio = %q, want 16:9", got)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.responseModalities").Raw; got != `["TEXT","IMAGE"]` {
+		t.Fatalf("responseModalities = %s", got)
+	}
+}
+
+func TestAspectRatioForSize(t *testing.T) {
+	cases := map[string]string{
+		"":          "",
+		"auto":      "",
+		"1024x1024": "1:1",
+		"1024x1536": "2:3",
+		"1536x1024": "3:2",
+		"1024x1792": "9:16",
+		"bogus":     "",
+	}
+	for size, want := range cases {
+		if got := AspectRatioForSize(size); got != want {
+			t.Errorf("AspectRatioForSize(%q) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestConvertGeminiResponsesToOpenAIImages(t *testing.T) {
+	first := []byte(`{"candidates":[{"content":{"parts":[{"text":"A fox."},{"inlineData":{"mimeType":"image/png","data":"QUJD"}}]}}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":1290,"totalTokenCount":1295}}`)
+	second := []byte(`{"response":{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"image/png","data":"REVG"}}]}}]}}`)
+
+	out, count := ConvertGeminiResponsesToOpenAIImages([][]byte{first, second}, "b64_json", 42)
+	if count != 2 {
+		t.Fatalf("count = %d, want 2", count)
+	}
+	if got := gjson.GetBytes(out, "data.0.b64_json").String(); got != "QUJD" {
+		t.Fatalf("data.0.b64_json = %q", got)
+	}
+	if got := gjson.GetBytes(out, "data.0.revised_prompt").String(); got != "A fox." {
+		t.Fatalf("revised_prompt = %q", got)
+	}
+	if got := gjson.GetBytes(out, "usage.total_tokens").Int(); got != 1295 {
+		t.Fatalf("usage.total_tokens = %d", got)
+	}
+
+	out, _ = ConvertGeminiResponsesToOpenAIImages([][]byte{second}, "url", 42)
+	if got := gjson.GetBytes(out, "data.0.url").String(); got != "data:image/png;base64,REVG" {
+		t.Fatalf("data.0.url = %q", got)
+	}
+}
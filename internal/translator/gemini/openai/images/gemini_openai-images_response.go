@@ -0,0 +1,99 @@
+package images
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiResponsesToOpenAIImages merges one or more Gemini generateContent
+// responses into a single OpenAI Images API response.
+//
+// Every inline image part becomes one entry in "data". Text parts emitted alongside
+// an image are reported as "revised_prompt". When responseFormat is "url" the image
+// is returned as a data URL, since the proxy does not host generated files.
+//
+// Parameters:
+//   - responses: Gemini responses, one per upstream call
+//   - responseFormat: "b64_json" (default) or "url"
+//   - created: Unix timestamp for the "created" field
+//
+// Returns:
+//   - []byte: The OpenAI image response body
+//   - int: The number of images found
+func ConvertGeminiResponsesToOpenAIImages(responses [][]byte, responseFormat string, created int64) ([]byte, int) {
+	out := `{"created":0,"data":[]}`
+	out, _ = sjson.Set(out, "created", created)
+
+	useURL := strings.EqualFold(strings.TrimSpace(responseFormat), "url")
+	count := 0
+	var promptTokens, outputTokens, totalTokens int64
+
+	for _, raw := range responses {
+		root := gjson.ParseBytes(raw)
+		if r := root.Get("response"); r.Exists() {
+			root = r
+		}
+		root.Get("candidates").ForEach(func(_, candidate gjson.Result) bool {
+			var text strings.Builder
+			var entries []string
+			candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+				if t := part.Get("text"); t.Exists() && !part.Get("thought").Bool() {
+					text.WriteString(t.String())
+					return true
+				}
+				inline := part.Get("inlineData")
+				if !inline.Exists() {
+					inline = part.Get("inline_data")
+				}
+				data := inline.Get("data").String()
+				if data == "" {
+					return true
+				}
+				mimeType := inline.Get("mimeType").String()
+				if mimeType == "" {
+					mimeType = inline.Get("mime_type").String()
+				}
+				if mimeType == "" {
+					mimeType = "image/png"
+				}
+				entry := `{}`
+				if useURL {
+					entry, _ = sjson.Set(entry, "url", "data:"+mimeType+";base64,"+data)
+				} else {
+					entry, _ = sjson.Set(entry, "b64_json", data)
+				}
+				entries = append(entries, entry)
+				return true
+			})
+			revised := strings.TrimSpace(text.String())
+			for _, entry := range entries {
+				if revised != "" {
+					entry, _ = sjson.Set(entry, "revised_prompt", revised)
+				}
+				out, _ = sjson.SetRaw(out, "data.-1", entry)
+				count++
+			}
+			return true
+		})
+
+		usage := root.Get("usageMetadata")
+		promptTokens += usage.Get("promptTokenCount").Int()
+		outputTokens += usage.Get("candidatesTokenCount").Int()
+		totalTokens += usage.Get("totalTokenCount").Int()
+	}
+
+	if totalTokens > 0 || promptTokens > 0 || outputTokens > 0 {
+		if totalTokens == 0 {
+			totalTokens = promptTokens + outputTokens
+		}
+		out, _ = sjson.Set(out, "usage.input_tokens", promptTokens)
+		out, _ = sjson.Set(out, "usage.output_tokens", outputTokens)
+		out, _ = sjson.Set(out, "usage.total_tokens", totalTokens)
+		out, _ = sjson.Set(out, "usage.input_tokens_details.text_tokens", promptTokens)
+		out, _ = sjson.Set(out, "usage.input_tokens_details.image_tokens", 0)
+	}
+
+	return []byte(out), count
+}
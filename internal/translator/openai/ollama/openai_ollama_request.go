@@ -0,0 +1,191 @@
+// Package ollama provides request translation functionality for Ollama to OpenAI API.
+// It converts Ollama /api/chat requests (messages with base64 images, object-valued tool
+// call arguments, sampling options and structured output formats) into OpenAI Chat
+// Completions requests so every backend reachable from the OpenAI format can serve them.
+package ollama
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOllamaRequestToOpenAI parses and transforms an Ollama chat request into OpenAI Chat
+// Completions API format.
+//
+// Parameters:
+//   - modelName: The name of the model to use for the request
+//   - inputRawJSON: The raw JSON request data from the Ollama API
+//   - stream: A boolean indicating if the request is for a streaming response
+//
+// Returns:
+//   - []byte: The transformed request data in OpenAI Chat Completions format
+func ConvertOllamaRequestToOpenAI(modelName string, inputRawJSON []byte, stream bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+	out := `{"model":"","messages":[]}`
+	out, _ = sjson.Set(out, "model", modelName)
+	out, _ = sjson.Set(out, "stream", stream)
+	if stream {
+		out, _ = sjson.Set(out, "stream_options.include_usage", true)
+	}
+
+	// Sampling options
+	options := root.Get("options")
+	if v := options.Get("temperature"); v.Exists() {
+		out, _ = sjson.Set(out, "temperature", v.Float())
+	}
+	if v := options.Get("top_p"); v.Exists() {
+		out, _ = sjson.Set(out, "top_p", v.Float())
+	}
+	if v := options.Get("num_predict"); v.Exists() && v.Int() > 0 {
+		out, _ = sjson.Set(out, "max_tokens", v.Int())
+	}
+	if v := options.Get("seed"); v.Exists() {
+		out, _ = sjson.Set(out, "seed", v.Int())
+	}
+	if v := options.Get("presence_penalty"); v.Exists() {
+		out, _ = sjson.Set(out, "presence_penalty", v.Float())
+	}
+	if v := options.Get("frequency_penalty"); v.Exists() {
+		out, _ = sjson.Set(out, "frequency_penalty", v.Float())
+	}
+	if v := options.Get("stop"); v.Exists() {
+		if v.IsArray() {
+			out, _ = sjson.SetRaw(out, "stop", v.Raw)
+		} else if v.String() != "" {
+			out, _ = sjson.Set(out, "stop", v.String())
+		}
+	}
+
+	// Structured output: "json" or a JSON schema object
+	if format := root.Get("format"); format.Exists() {
+		switch {
+		case format.IsObject():
+			out, _ = sjson.Set(out, "response_format.type", "json_schema")
+			out, _ = sjson.Set(out, "response_format.json_schema.name", "response")
+			out, _ = sjson.SetRaw(out, "response_format.json_schema.schema", format.Raw)
+		case strings.EqualFold(format.String(), "json"):
+			out, _ = sjson.Set(out, "response_format.type", "json_object")
+		}
+	}
+
+	// Thinking: boolean or an effort level
+	if think := root.Get("think"); think.Exists() {
+		switch think.Type {
+		case gjson.True:
+			out, _ = sjson.Set(out, "reasoning_effort", "medium")
+		case gjson.False:
+			out, _ = sjson.Set(out, "reasoning_effort", "none")
+		case gjson.String:
+			if level := strings.ToLower(strings.TrimSpace(think.String())); level != "" {
+				out, _ = sjson.Set(out, "reasoning_effort", level)
+			}
+		}
+	}
+
+	// Tools already use the OpenAI function schema
+	if tools := root.Get("tools"); tools.IsArray() && len(tools.Array()) > 0 {
+		out, _ = sjson.SetRaw(out, "tools", tools.Raw)
+	}
+
+	// Messages. Ollama tool results reference calls by function name, so pending call IDs
+	// are tracked per name and consumed in order.
+	pending := make(map[string][]string)
+	callIndex := 0
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		role := message.Get("role").String()
+		msg := `{}`
+		msg, _ = sjson.Set(msg, "role", role)
+
+		switch role {
+		case "tool":
+			name := message.Get("tool_name").String()
+			if name == "" {
+				name = message.Get("name").String()
+			}
+			callID := fmt.Sprintf("call_%d", callIndex)
+			if ids := pending[name]; len(ids) > 0 {
+				callID = ids[0]
+				pending[name] = ids[1:]
+			}
+			msg, _ = sjson.Set(msg, "tool_call_id", callID)
+			msg, _ = sjson.Set(msg, "content", message.Get("content").String())
+		case "assistant":
+			msg, _ = sjson.Set(msg, "content", message.Get("content").String())
+			message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+				name := call.Get("function.name").String()
+				callID := call.Get("id").String()
+				if callID == "" {
+					callID = fmt.Sprintf("call_%d", callIndex)
+				}
+				callIndex++
+				pending[name] = append(pending[name], callID)
+				arguments := call.Get("function.arguments")
+				argsJSON := arguments.Raw
+				if arguments.Type == gjson.String {
+					argsJSON = arguments.String()
+				} else {
+					var compact bytes.Buffer
+					if errCompact := json.Compact(&compact, []byte(argsJSON)); errCompact == nil {
+						argsJSON = compact.String()
+					}
+				}
+				if argsJSON == "" {
+					argsJSON = "{}"
+				}
+				tc := `{"type":"function","function":{}}`
+				tc, _ = sjson.Set(tc, "id", callID)
+				tc, _ = sjson.Set(tc, "function.name", name)
+				tc, _ = sjson.Set(tc, "function.arguments", argsJSON)
+				msg, _ = sjson.SetRaw(msg, "tool_calls.-1", tc)
+				return true
+			})
+		default:
+			images := message.Get("images").Array()
+			if len(images) == 0 {
+				msg, _ = sjson.Set(msg, "content", message.Get("content").String())
+				break
+			}
+			msg, _ = sjson.SetRaw(msg, "content", `[]`)
+			if text := message.Get("content").String(); text != "" {
+				part := `{"type":"text"}`
+				part, _ = sjson.Set(part, "text", text)
+				msg, _ = sjson.SetRaw(msg, "content.-1", part)
+			}
+			for _, image := range images {
+				part := `{"type":"image_url"}`
+				part, _ = sjson.Set(part, "image_url.url", imageDataURL(image.String()))
+				msg, _ = sjson.SetRaw(msg, "content.-1", part)
+			}
+		}
+		out, _ = sjson.SetRaw(out, "messages.-1", msg)
+		return true
+	})
+
+	return []byte(out)
+}
+
+// imageDataURL wraps a base64 encoded image in a data URL, sniffing its media type.
+func imageDataURL(data string) string {
+	data = strings.TrimSpace(data)
+	if strings.HasPrefix(data, "data:") {
+		return data
+	}
+	mimeType := "image/png"
+	head := data
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(head[:len(head)/4*4]); err == nil {
+		if sniffed := http.DetectContentType(decoded); strings.HasPrefix(sniffed, "image/") {
+			mimeType = sniffed
+		}
+	}
+	return "data:" + mimeType + ";base64," + data
+}
@@ -0,0 +1,80 @@
+package ollama
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOllamaRequestToOpenAI_MessagesToolsAndOptions(t *testing.T) {
+	input := `{
+		"model": "llama3",
+		"messages": [
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": "what is this?", "images": ["iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="]},
+			{"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "get_weather", "arguments": {"city": "Paris"}}}]},
+			{"role": "tool", "tool_name": "get_weather", "content": "sunny"}
+		],
+		"format": {"type": "object", "properties": {"answer": {"type": "string"}}},
+		"options": {"temperature": 0.2, "num_predict": 64, "stop": ["END"]},
+		"think": true
+	}`
+	out := gjson.ParseBytes(ConvertOllamaRequestToOpenAI("llama3", []byte(input), true))
+
+	if out.Get("model").String() != "llama3" || !out.Get("stream_options.include_usage").Bool() {
+		t.Fatalf("unexpected header fields: %s", out.Raw)
+	}
+	if out.Get("temperature").Float() != 0.2 || out.Get("max_tokens").Int() != 64 || out.Get("stop.0").String() != "END" {
+		t.Fatalf("options were not mapped: %s", out.Raw)
+	}
+	if out.Get("response_format.type").String() != "json_schema" || !out.Get("response_format.json_schema.schema.properties.answer").Exists() {
+		t.Fatalf("format was not mapped: %s", out.Raw)
+	}
+	if out.Get("reasoning_effort").String() != "medium" {
+		t.Fatalf("reasoning_effort = %q", out.Get("reasoning_effort").String())
+	}
+	if url := out.Get("messages.1.content.1.image_url.url").String(); !strings.HasPrefix(url, "data:image/png;base64,") {
+		t.Fatalf("image url = %q", url)
+	}
+	callID := out.Get("messages.2.tool_calls.0.id").String()
+	if callID == "" || out.Get("messages.2.tool_calls.0.function.arguments").String() != `{"city":"Paris"}` {
+		t.Fatalf("tool call was not mapped: %s", out.Get("messages.2").Raw)
+	}
+	if out.Get("messages.3.tool_call_id").String() != callID {
+		t.Fatalf("tool result must reference call %q: %s", callID, out.Get("messages.3").Raw)
+	}
+}
+
+func TestConvertOpenAIResponseToOllama_StreamAccumulatesToolCalls(t *testing.T) {
+	var param any
+	ctx := context.Background()
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"lookup","arguments":"{\"q\":"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"x\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3}}`,
+		`[DONE]`,
+	}
+	var lines []string
+	for _, chunk := range chunks {
+		lines = append(lines, ConvertOpenAIResponseToOllama(ctx, "m", nil, nil, []byte(chunk), &param)...)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("lines = %d: %v", len(lines), lines)
+	}
+	if gjson.Get(lines[0], "message.content").String() != "Hi" || gjson.Get(lines[0], "done").Bool() {
+		t.Fatalf("unexpected content line: %s", lines[0])
+	}
+	if gjson.Get(lines[1], "message.tool_calls.0.function.arguments.q").String() != "x" {
+		t.Fatalf("unexpected tool call line: %s", lines[1])
+	}
+	last := gjson.Parse(lines[2])
+	if !last.Get("done").Bool() || last.Get("done_reason").String() != "stop" || last.Get("prompt_eval_count").Int() != 7 || last.Get("eval_count").Int() != 3 {
+		t.Fatalf("unexpected final line: %s", lines[2])
+	}
+	if extra := ConvertOpenAIResponseToOllama(ctx, "m", nil, nil, []byte(`[DONE]`), &param); len(extra) != 0 {
+		t.Fatalf("expected no output after done, got %v", extra)
+	}
+}
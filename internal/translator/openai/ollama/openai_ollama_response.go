@@ -0,0 +1,223 @@
+// Package ollama provides response translation functionality for OpenAI to Ollama API.
+// This package converts OpenAI Chat Completions responses into Ollama /api/chat responses.
+// Streaming chunks become newline-delimited message objects terminated by a single
+// done object carrying the stop reason and token counts; tool call fragments are
+// accumulated because Ollama delivers complete calls with object-valued arguments.
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+var dataTag = []byte("data:")
+
+// ConvertOpenAIResponseToOllamaParams holds the streaming conversion state.
+type ConvertOpenAIResponseToOllamaParams struct {
+	// StartedAt marks the first chunk; used to report durations.
+	StartedAt time.Time
+	// FinishReason is the last OpenAI finish reason observed.
+	FinishReason string
+	// PromptTokens and CompletionTokens come from the usage chunk, when present.
+	PromptTokens     int64
+	CompletionTokens int64
+	// ToolCalls accumulates streamed tool call fragments by index.
+	ToolCalls map[int]*ToolCallAccumulator
+	// ToolCallsSent reports whether accumulated tool calls were already emitted.
+	ToolCallsSent bool
+	// Done reports whether the terminal object was emitted.
+	Done bool
+}
+
+// ToolCallAccumulator holds the state for accumulating tool call data.
+type ToolCallAccumulator struct {
+	ID        string
+	Name      string
+	Arguments strings.Builder
+}
+
+// ConvertOpenAIResponseToOllama converts OpenAI streaming chunks into Ollama chat stream
+// objects. The "[DONE]" marker produces the terminal object.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The model name reported back to the client
+//   - originalRequestRawJSON: The original Ollama request
+//   - requestRawJSON: The translated OpenAI request
+//   - rawJSON: A single OpenAI streaming chunk, optionally prefixed with "data:"
+//   - param: A pointer to the conversion state
+//
+// Returns:
+//   - []string: Zero or more Ollama stream objects
+func ConvertOpenAIResponseToOllama(_ context.Context, modelName string, _, _, rawJSON []byte, param *any) []string {
+	if *param == nil {
+		*param = &ConvertOpenAIResponseToOllamaParams{StartedAt: time.Now(), ToolCalls: make(map[int]*ToolCallAccumulator)}
+	}
+	state := (*param).(*ConvertOpenAIResponseToOllamaParams)
+	if state.Done {
+		return nil
+	}
+
+	rawJSON = bytes.TrimSpace(rawJSON)
+	if bytes.HasPrefix(rawJSON, dataTag) {
+		rawJSON = bytes.TrimSpace(rawJSON[len(dataTag):])
+	}
+	if len(rawJSON) == 0 {
+		return nil
+	}
+	if string(rawJSON) == "[DONE]" {
+		return []string{finishOllamaStream(modelName, state)}
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		return nil
+	}
+
+	root := gjson.ParseBytes(rawJSON)
+	if usage := root.Get("usage"); usage.Exists() && usage.Type != gjson.Null {
+		state.PromptTokens = usage.Get("prompt_tokens").Int()
+		state.CompletionTokens = usage.Get("completion_tokens").Int()
+	}
+
+	var results []string
+	choice := root.Get("choices.0")
+	if !choice.Exists() {
+		return nil
+	}
+	delta := choice.Get("delta")
+	content := delta.Get("content").String()
+	thinking := delta.Get("reasoning_content").String()
+	if content != "" || thinking != "" {
+		results = append(results, ollamaChatChunk(modelName, content, thinking, ""))
+	}
+	delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		index := int(call.Get("index").Int())
+		acc := state.ToolCalls[index]
+		if acc == nil {
+			acc = &ToolCallAccumulator{}
+			state.ToolCalls[index] = acc
+		}
+		if id := call.Get("id").String(); id != "" {
+			acc.ID = id
+		}
+		if name := call.Get("function.name").String(); name != "" {
+			acc.Name = name
+		}
+		acc.Arguments.WriteString(call.Get("function.arguments").String())
+		return true
+	})
+	if reason := choice.Get("finish_reason").String(); reason != "" {
+		state.FinishReason = reason
+		if calls := state.toolCallsJSON(); calls != "" {
+			state.ToolCallsSent = true
+			results = append(results, ollamaChatChunk(modelName, "", "", calls))
+		}
+	}
+	return results
+}
+
+// ConvertOpenAIResponseToOllamaNonStream converts a non-streaming OpenAI chat completion
+// into an Ollama chat response.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The model name reported back to the client
+//   - originalRequestRawJSON: The original Ollama request
+//   - requestRawJSON: The translated OpenAI request
+//   - rawJSON: The OpenAI chat completion
+//   - param: Unused conversion state
+//
+// Returns:
+//   - string: The Ollama chat response
+func ConvertOpenAIResponseToOllamaNonStream(_ context.Context, modelName string, _, _, rawJSON []byte, _ *any) string {
+	root := gjson.ParseBytes(rawJSON)
+	message := root.Get("choices.0.message")
+
+	out := `{"model":"","created_at":"","message":{"role":"assistant","content":""},"done":true}`
+	out, _ = sjson.Set(out, "model", modelName)
+	out, _ = sjson.Set(out, "created_at", time.Now().UTC().Format(time.RFC3339Nano))
+	out, _ = sjson.Set(out, "message.content", message.Get("content").String())
+	if thinking := message.Get("reasoning_content").String(); thinking != "" {
+		out, _ = sjson.Set(out, "message.thinking", thinking)
+	}
+	message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		out, _ = sjson.SetRaw(out, "message.tool_calls.-1", ollamaToolCall(call.Get("function.name").String(), call.Get("function.arguments").String()))
+		return true
+	})
+	out, _ = sjson.Set(out, "done_reason", ollamaDoneReason(root.Get("choices.0.finish_reason").String()))
+	out, _ = sjson.Set(out, "total_duration", 0)
+	out, _ = sjson.Set(out, "prompt_eval_count", root.Get("usage.prompt_tokens").Int())
+	out, _ = sjson.Set(out, "eval_count", root.Get("usage.completion_tokens").Int())
+	return out
+}
+
+func finishOllamaStream(modelName string, state *ConvertOpenAIResponseToOllamaParams) string {
+	state.Done = true
+	out := ollamaChatChunk(modelName, "", "", "")
+	if !state.ToolCallsSent {
+		if calls := state.toolCallsJSON(); calls != "" {
+			out, _ = sjson.SetRaw(out, "message.tool_calls", calls)
+		}
+	}
+	elapsed := time.Since(state.StartedAt).Nanoseconds()
+	out, _ = sjson.Set(out, "done", true)
+	out, _ = sjson.Set(out, "done_reason", ollamaDoneReason(state.FinishReason))
+	out, _ = sjson.Set(out, "total_duration", elapsed)
+	out, _ = sjson.Set(out, "prompt_eval_count", state.PromptTokens)
+	out, _ = sjson.Set(out, "eval_count", state.CompletionTokens)
+	out, _ = sjson.Set(out, "eval_duration", elapsed)
+	return out
+}
+
+func ollamaChatChunk(modelName, content, thinking, toolCalls string) string {
+	out := `{"model":"","created_at":"","message":{"role":"assistant","content":""},"done":false}`
+	out, _ = sjson.Set(out, "model", modelName)
+	out, _ = sjson.Set(out, "created_at", time.Now().UTC().Format(time.RFC3339Nano))
+	out, _ = sjson.Set(out, "message.content", content)
+	if thinking != "" {
+		out, _ = sjson.Set(out, "message.thinking", thinking)
+	}
+	if toolCalls != "" {
+		out, _ = sjson.SetRaw(out, "message.tool_calls", toolCalls)
+	}
+	return out
+}
+
+func (s *ConvertOpenAIResponseToOllamaParams) toolCallsJSON() string {
+	if len(s.ToolCalls) == 0 {
+		return ""
+	}
+	indexes := make([]int, 0, len(s.ToolCalls))
+	for index := range s.ToolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	calls := `[]`
+	for _, index := range indexes {
+		acc := s.ToolCalls[index]
+		calls, _ = sjson.SetRaw(calls, "-1", ollamaToolCall(acc.Name, acc.Arguments.String()))
+	}
+	return calls
+}
+
+// ollamaToolCall builds an Ollama tool call; Ollama expects arguments as a JSON object.
+func ollamaToolCall(name, arguments string) string {
+	call := `{"function":{"name":"","arguments":{}}}`
+	call, _ = sjson.Set(call, "function.name", name)
+	if args := strings.TrimSpace(arguments); args != "" && gjson.Valid(args) && gjson.Parse(args).IsObject() {
+		call, _ = sjson.SetRaw(call, "function.arguments", args)
+	}
+	return call
+}
+
+func ollamaDoneReason(finishReason string) string {
+	if finishReason == "length" {
+		return "length"
+	}
+	return "stop"
+}
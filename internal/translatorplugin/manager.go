@@ -0,0 +1,274 @@
+package translatorplugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// pluginExt is the file extension of plugin scripts.
+const pluginExt = ".star"
+
+// reloadDebounce coalesces bursts of file events (editors often write several times).
+const reloadDebounce = 200 * time.Millisecond
+
+// Manager owns the plugins loaded from a directory and applies them as translator
+// pipeline middleware. The loaded set is swapped atomically on reload, so in-flight
+// translations keep the plugins they started with.
+type Manager struct {
+	plugins atomic.Pointer[[]*Plugin]
+
+	mu       sync.Mutex
+	dir      string
+	maxSteps uint64
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewManager creates an empty manager.
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Register installs the manager as request and response middleware on the pipeline.
+func (m *Manager) Register(pipeline *sdktranslator.Pipeline) {
+	if m == nil || pipeline == nil {
+		return
+	}
+	pipeline.UseRequest(m.requestMiddleware)
+	pipeline.UseResponse(m.responseMiddleware)
+}
+
+// Plugins returns the currently loaded plugins in application order.
+func (m *Manager) Plugins() []*Plugin {
+	if m == nil {
+		return nil
+	}
+	if loaded := m.plugins.Load(); loaded != nil {
+		return *loaded
+	}
+	return nil
+}
+
+// Configure points the manager at dir and starts watching it for changes. An empty dir
+// unloads all plugins and stops watching. Calling Configure with unchanged settings is a no-op.
+func (m *Manager) Configure(dir string, maxSteps uint64) {
+	if m == nil {
+		return
+	}
+	if dir != "" {
+		dir = filepath.Clean(dir)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if dir == m.dir && maxSteps == m.maxSteps && m.cancel != nil {
+		return
+	}
+	m.stopLocked()
+	m.dir = dir
+	m.maxSteps = maxSteps
+	if dir == "" {
+		m.plugins.Store(nil)
+		return
+	}
+	// Watch before the initial load so files written in between are not missed.
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorf("translator plugins: failed to create watcher: %v", err)
+	} else if err = watcher.Add(dir); err != nil {
+		log.Warnf("translator plugins: failed to watch %s: %v", dir, err)
+		_ = watcher.Close()
+		watcher = nil
+	}
+	m.reload(dir, maxSteps)
+	if watcher == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	go m.watch(ctx, watcher, dir, maxSteps, m.done)
+}
+
+// Reload re-reads the plugin directory immediately.
+func (m *Manager) Reload() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	dir, maxSteps := m.dir, m.maxSteps
+	m.mu.Unlock()
+	if dir == "" {
+		return
+	}
+	m.reload(dir, maxSteps)
+}
+
+// Stop stops watching the plugin directory. Loaded plugins stay active.
+func (m *Manager) Stop() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopLocked()
+}
+
+func (m *Manager) stopLocked() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+	m.cancel = nil
+	m.done = nil
+}
+
+// reload compiles every plugin in dir. A plugin that fails to compile is skipped and
+// logged; the remaining plugins are still applied.
+func (m *Manager) reload(dir string, maxSteps uint64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("translator plugins: failed to read %s: %v", dir, err)
+		}
+		m.plugins.Store(nil)
+		return
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), pluginExt) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	loaded := make([]*Plugin, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		src, errRead := os.ReadFile(path)
+		if errRead != nil {
+			log.Warnf("translator plugins: failed to read %s: %v", path, errRead)
+			continue
+		}
+		plugin, errCompile := Compile(path, src, maxSteps)
+		if errCompile != nil {
+			log.Errorf("translator plugins: %v", errCompile)
+			continue
+		}
+		loaded = append(loaded, plugin)
+	}
+	m.plugins.Store(&loaded)
+	log.Infof("translator plugins: loaded %d plugin(s) from %s", len(loaded), dir)
+}
+
+func (m *Manager) watch(ctx context.Context, watcher *fsnotify.Watcher, dir string, maxSteps uint64, done chan struct{}) {
+	defer close(done)
+	defer func() { _ = watcher.Close() }()
+
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !strings.EqualFold(filepath.Ext(event.Name), pluginExt) {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(reloadDebounce)
+			} else {
+				timer.Reset(reloadDebounce)
+			}
+			timerC = timer.C
+		case errWatch, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("translator plugins: watcher error: %v", errWatch)
+		case <-timerC:
+			timerC = nil
+			m.reload(dir, maxSteps)
+		}
+	}
+}
+
+func (m *Manager) requestMiddleware(ctx context.Context, req sdktranslator.RequestEnvelope, next sdktranslator.RequestHandler) (sdktranslator.RequestEnvelope, error) {
+	out, err := next(ctx, req)
+	if err != nil {
+		return out, err
+	}
+	source, target := req.Format.String(), out.Format.String()
+	for _, plugin := range m.Plugins() {
+		if plugin.request == nil || !plugin.Matches(source, target, req.Model) {
+			continue
+		}
+		body, errTransform := plugin.TransformRequest(source, target, req.Model, req.Stream, out.Body)
+		if errTransform != nil {
+			log.Warnf("translator plugin %s: request transform failed: %v", plugin.Name, errTransform)
+			continue
+		}
+		out.Body = body
+	}
+	return out, nil
+}
+
+// responseMiddleware runs after translation. Response translation flows from the upstream
+// format (resp.Format) to the client format (out.Format).
+func (m *Manager) responseMiddleware(ctx context.Context, resp sdktranslator.ResponseEnvelope, next sdktranslator.ResponseHandler) (sdktranslator.ResponseEnvelope, error) {
+	out, err := next(ctx, resp)
+	if err != nil {
+		return out, err
+	}
+	source, target := out.Format.String(), resp.Format.String()
+	for _, plugin := range m.Plugins() {
+		if !plugin.Matches(source, target, resp.Model) {
+			continue
+		}
+		if !resp.Stream {
+			if plugin.response == nil {
+				continue
+			}
+			body, errTransform := plugin.TransformResponse(source, target, resp.Model, out.Body)
+			if errTransform != nil {
+				log.Warnf("translator plugin %s: response transform failed: %v", plugin.Name, errTransform)
+				continue
+			}
+			out.Body = body
+			continue
+		}
+		if plugin.chunk == nil {
+			continue
+		}
+		chunks := make([]string, 0, len(out.Chunks))
+		for _, chunk := range out.Chunks {
+			transformed, keep, errTransform := plugin.TransformChunk(source, target, resp.Model, chunk)
+			if errTransform != nil {
+				log.Warnf("translator plugin %s: chunk transform failed: %v", plugin.Name, errTransform)
+			}
+			if keep {
+				chunks = append(chunks, transformed)
+			}
+		}
+		out.Chunks = chunks
+	}
+	return out, nil
+}
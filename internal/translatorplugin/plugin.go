@@ -0,0 +1,403 @@
+// Package translatorplugin loads declarative request/response transforms written in Starlark
+// from a plugin directory and applies them through the default translator pipeline, so
+// operators can patch provider quirks without rebuilding the proxy.
+//
+// A plugin is a *.star file that declares a scope and one or more hooks:
+//
+//	plugin = {
+//	    "name": "strip-metadata",   # optional, defaults to the file name
+//	    "source": ["openai"],       # client formats, omitted or "*" matches all
+//	    "target": ["claude"],       # upstream formats, omitted or "*" matches all
+//	    "models": ["claude-*"],     # model patterns, '*' is a wildcard
+//	}
+//
+//	def transform_request(req):    # req: source, target, model, stream, body
+//	    req["body"].pop("metadata", None)
+//	    return req["body"]
+//
+//	def transform_response(resp):  # non-streaming client response: source, target, model, body
+//	    return None                 # None keeps the payload unchanged
+//
+//	def transform_chunk(chunk):    # streaming client chunk: source, target, model, chunk, data
+//	    return chunk["chunk"]
+//
+// Request hooks see the translated upstream payload; response hooks see the translated
+// client payload. Scripts run without file, network or load() access and with a bounded
+// number of execution steps per call.
+package translatorplugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// DefaultMaxSteps bounds the Starlark execution steps spent in a single hook call.
+const DefaultMaxSteps = 1_000_000
+
+const (
+	hookRequest  = "transform_request"
+	hookResponse = "transform_response"
+	hookChunk    = "transform_chunk"
+)
+
+// Plugin is one compiled plugin file.
+type Plugin struct {
+	// Name identifies the plugin in logs.
+	Name string
+	// Path is the file the plugin was loaded from.
+	Path string
+
+	sources  []string
+	targets  []string
+	models   []string
+	maxSteps uint64
+
+	request  starlark.Callable
+	response starlark.Callable
+	chunk    starlark.Callable
+}
+
+var fileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// Compile executes a plugin source and extracts its scope and hooks.
+func Compile(path string, src []byte, maxSteps uint64) (*Plugin, error) {
+	if maxSteps == 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	thread := newThread(path, maxSteps)
+	predeclared := starlark.StringDict{"json": json.Module}
+	globals, err := starlark.ExecFileOptions(fileOptions, thread, path, src, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("translator plugin %s: %w", path, err)
+	}
+
+	p := &Plugin{
+		Name:     strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path:     path,
+		maxSteps: maxSteps,
+	}
+	if raw, ok := globals["plugin"]; ok {
+		spec, ok := raw.(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("translator plugin %s: plugin must be a dict, got %s", path, raw.Type())
+		}
+		if err = p.applySpec(spec); err != nil {
+			return nil, fmt.Errorf("translator plugin %s: %w", path, err)
+		}
+	}
+	for name, dst := range map[string]*starlark.Callable{hookRequest: &p.request, hookResponse: &p.response, hookChunk: &p.chunk} {
+		raw, ok := globals[name]
+		if !ok {
+			continue
+		}
+		fn, ok := raw.(starlark.Callable)
+		if !ok {
+			return nil, fmt.Errorf("translator plugin %s: %s must be a function", path, name)
+		}
+		*dst = fn
+	}
+	if p.request == nil && p.response == nil && p.chunk == nil {
+		return nil, fmt.Errorf("translator plugin %s: no %s, %s or %s function defined", path, hookRequest, hookResponse, hookChunk)
+	}
+	return p, nil
+}
+
+func (p *Plugin) applySpec(spec *starlark.Dict) error {
+	for _, item := range spec.Items() {
+		key, ok := starlark.AsString(item[0])
+		if !ok {
+			return fmt.Errorf("plugin keys must be strings")
+		}
+		var err error
+		switch key {
+		case "name":
+			name, isString := starlark.AsString(item[1])
+			if !isString {
+				return fmt.Errorf("plugin name must be a string")
+			}
+			if name = strings.TrimSpace(name); name != "" {
+				p.Name = name
+			}
+		case "source":
+			p.sources, err = stringList(key, item[1])
+		case "target":
+			p.targets, err = stringList(key, item[1])
+		case "models":
+			p.models, err = stringList(key, item[1])
+		default:
+			return fmt.Errorf("unknown plugin key %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringList accepts a string or a list/tuple of strings.
+func stringList(key string, v starlark.Value) ([]string, error) {
+	if s, ok := starlark.AsString(v); ok {
+		return normalizeList([]string{s}), nil
+	}
+	iterable, ok := v.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s must be a string or a list of strings", key)
+	}
+	var out []string
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		s, isString := starlark.AsString(item)
+		if !isString {
+			return nil, fmt.Errorf("plugin %s must only contain strings", key)
+		}
+		out = append(out, s)
+	}
+	return normalizeList(out), nil
+}
+
+func normalizeList(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if v == "*" {
+			return nil
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// Matches reports whether the plugin applies to a translation from the source (client)
+// format to the target (upstream) format for the given model.
+func (p *Plugin) Matches(source, target, model string) bool {
+	if !matchFormat(p.sources, source) || !matchFormat(p.targets, target) {
+		return false
+	}
+	if len(p.models) == 0 {
+		return true
+	}
+	for _, pattern := range p.models {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchFormat(formats []string, format string) bool {
+	if len(formats) == 0 {
+		return true
+	}
+	for _, f := range formats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModelPattern performs simple wildcard matching where '*' matches zero or more characters.
+func matchModelPattern(pattern, model string) bool {
+	if pattern == "*" {
+		return true
+	}
+	pi, si := 0, 0
+	starIdx, matchIdx := -1, 0
+	for si < len(model) {
+		switch {
+		case pi < len(pattern) && pattern[pi] == model[si]:
+			pi++
+			si++
+		case pi < len(pattern) && pattern[pi] == '*':
+			starIdx, matchIdx = pi, si
+			pi++
+		case starIdx != -1:
+			pi = starIdx + 1
+			matchIdx++
+			si = matchIdx
+		default:
+			return false
+		}
+	}
+	for pi < len(pattern) && pattern[pi] == '*' {
+		pi++
+	}
+	return pi == len(pattern)
+}
+
+func newThread(name string, maxSteps uint64) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load is not available to translator plugins")
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	return thread
+}
+
+// call invokes a hook with a dict argument built from fields. Payload fields are decoded
+// from JSON when possible so scripts can edit them as dicts and lists.
+func (p *Plugin) call(hook starlark.Callable, fields map[string]any) (starlark.Value, error) {
+	thread := newThread(p.Name, p.maxSteps)
+	arg := starlark.NewDict(len(fields))
+	for key, value := range fields {
+		var sv starlark.Value
+		switch v := value.(type) {
+		case string:
+			sv = starlark.String(v)
+		case bool:
+			sv = starlark.Bool(v)
+		case jsonPayload:
+			decoded, err := decodeJSON(thread, []byte(v))
+			if err != nil {
+				sv = starlark.String(v)
+			} else {
+				sv = decoded
+			}
+		case nil:
+			sv = starlark.None
+		default:
+			return nil, fmt.Errorf("unsupported hook argument %T", value)
+		}
+		if err := arg.SetKey(starlark.String(key), sv); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.Call(thread, hook, starlark.Tuple{arg}, nil)
+}
+
+// jsonPayload marks a hook argument that is passed to scripts as decoded JSON.
+type jsonPayload []byte
+
+func decodeJSON(thread *starlark.Thread, raw []byte) (starlark.Value, error) {
+	return starlark.Call(thread, json.Module.Members["decode"], starlark.Tuple{starlark.String(raw)}, nil)
+}
+
+// encodeResult converts a hook result back into payload bytes. changed is false when the
+// hook returned None.
+func (p *Plugin) encodeResult(result starlark.Value) (out []byte, changed bool, err error) {
+	switch v := result.(type) {
+	case starlark.NoneType:
+		return nil, false, nil
+	case starlark.String:
+		return []byte(v), true, nil
+	case starlark.Bytes:
+		return []byte(v), true, nil
+	}
+	thread := newThread(p.Name, p.maxSteps)
+	encoded, err := starlark.Call(thread, json.Module.Members["encode"], starlark.Tuple{result}, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	s, _ := starlark.AsString(encoded)
+	return []byte(s), true, nil
+}
+
+// TransformRequest runs the request hook on a translated upstream payload.
+func (p *Plugin) TransformRequest(source, target, model string, stream bool, body []byte) ([]byte, error) {
+	if p.request == nil {
+		return body, nil
+	}
+	result, err := p.call(p.request, map[string]any{
+		"source": source, "target": target, "model": model, "stream": stream, "body": jsonPayload(body),
+	})
+	if err != nil {
+		return body, err
+	}
+	out, changed, err := p.encodeResult(result)
+	if err != nil || !changed {
+		return body, err
+	}
+	return out, nil
+}
+
+// TransformResponse runs the response hook on a translated non-streaming client payload.
+func (p *Plugin) TransformResponse(source, target, model string, body []byte) ([]byte, error) {
+	if p.response == nil {
+		return body, nil
+	}
+	result, err := p.call(p.response, map[string]any{
+		"source": source, "target": target, "model": model, "stream": false, "body": jsonPayload(body),
+	})
+	if err != nil {
+		return body, err
+	}
+	out, changed, err := p.encodeResult(result)
+	if err != nil || !changed {
+		return body, err
+	}
+	return out, nil
+}
+
+// TransformChunk runs the chunk hook on one translated streaming chunk. The chunk's
+// "data:" line, when it carries JSON, is also exposed decoded as data; returning a dict or
+// list replaces that line, returning a string replaces the whole chunk and returning an
+// empty string drops it.
+func (p *Plugin) TransformChunk(source, target, model, chunk string) (string, bool, error) {
+	if p.chunk == nil {
+		return chunk, true, nil
+	}
+	dataIdx, data, hasData := chunkData(chunk)
+	fields := map[string]any{"source": source, "target": target, "model": model, "chunk": chunk, "data": nil}
+	if hasData {
+		fields["data"] = jsonPayload(data)
+	}
+	result, err := p.call(p.chunk, fields)
+	if err != nil {
+		return chunk, true, err
+	}
+	switch result.(type) {
+	case starlark.NoneType:
+		return chunk, true, nil
+	case starlark.String, starlark.Bytes:
+		out, _, _ := p.encodeResult(result)
+		return string(out), len(out) > 0, nil
+	}
+	out, _, err := p.encodeResult(result)
+	if err != nil {
+		return chunk, true, err
+	}
+	if !hasData || dataIdx < 0 {
+		return string(out), true, nil
+	}
+	lines := strings.Split(chunk, "\n")
+	lines[dataIdx] = "data: " + string(out)
+	return strings.Join(lines, "\n"), true, nil
+}
+
+// chunkData locates the JSON carried by a chunk: the first "data:" line of an SSE chunk, or
+// the whole chunk for streams that emit bare JSON objects. idx is the line index of the data
+// line, -1 for a bare JSON chunk, and ok is false when the chunk carries no JSON.
+func chunkData(chunk string) (idx int, payload string, ok bool) {
+	for i, line := range strings.Split(chunk, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "data:") {
+			continue
+		}
+		payload = strings.TrimSpace(strings.TrimPrefix(trimmed, "data:"))
+		if strings.HasPrefix(payload, "{") || strings.HasPrefix(payload, "[") {
+			return i, payload, true
+		}
+		return 0, "", false
+	}
+	if trimmed := strings.TrimSpace(chunk); strings.HasPrefix(trimmed, "{") {
+		return -1, trimmed, true
+	}
+	return 0, "", false
+}
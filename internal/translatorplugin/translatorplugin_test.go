@@ -0,0 +1,162 @@
+package translatorplugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const stripMetadataPlugin = `
+plugin = {"source": "openai", "target": "claude", "models": ["claude-*"]}
+
+def transform_request(req):
+    body = req["body"]
+    body.pop("metadata", None)
+    body["translated_for"] = req["target"]
+    return body
+
+def transform_response(resp):
+    resp["body"]["patched"] = True
+    return resp["body"]
+
+def transform_chunk(chunk):
+    data = chunk["data"]
+    if data == None:
+        return None
+    if data.get("drop"):
+        return ""
+    data["seen"] = chunk["model"]
+    return data
+`
+
+func newTestPipeline(t *testing.T, dir string) (*Manager, *sdktranslator.Pipeline) {
+	t.Helper()
+	registry := sdktranslator.NewRegistry()
+	registry.Register("openai", "claude",
+		func(_ string, raw []byte, _ bool) []byte { return raw },
+		sdktranslator.ResponseTransform{
+			Stream: func(_ context.Context, _ string, _, _, raw []byte, _ *any) []string {
+				return []string{"event: message\ndata: " + string(raw) + "\n\n"}
+			},
+			NonStream: func(_ context.Context, _ string, _, _, raw []byte, _ *any) string { return string(raw) },
+		})
+	pipeline := sdktranslator.NewPipeline(registry)
+	manager := NewManager()
+	manager.Register(pipeline)
+	manager.Configure(dir, 0)
+	t.Cleanup(manager.Stop)
+	return manager, pipeline
+}
+
+func writePlugin(t *testing.T, dir, name, src string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+}
+
+func TestManagerAppliesScopedTransforms(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "strip.star", stripMetadataPlugin)
+	_, pipeline := newTestPipeline(t, dir)
+	ctx := context.Background()
+
+	req := sdktranslator.RequestEnvelope{Format: "openai", Model: "claude-sonnet", Body: []byte(`{"metadata":{"a":1},"x":1}`)}
+	out, err := pipeline.TranslateRequest(ctx, "openai", "claude", req)
+	if err != nil {
+		t.Fatalf("TranslateRequest: %v", err)
+	}
+	if gjson.GetBytes(out.Body, "metadata").Exists() || gjson.GetBytes(out.Body, "translated_for").String() != "claude" {
+		t.Fatalf("unexpected request body: %s", out.Body)
+	}
+
+	req.Model = "gpt-5"
+	out, _ = pipeline.TranslateRequest(ctx, "openai", "claude", req)
+	if !gjson.GetBytes(out.Body, "metadata").Exists() {
+		t.Fatalf("plugin applied outside its model scope: %s", out.Body)
+	}
+
+	// Responses translate from the upstream format (claude) back to the client format (openai).
+	resp := sdktranslator.ResponseEnvelope{Format: "claude", Model: "claude-sonnet", Body: []byte(`{"id":"1"}`)}
+	outResp, _ := pipeline.TranslateResponse(ctx, "claude", "openai", resp, nil, nil, nil)
+	if !gjson.GetBytes(outResp.Body, "patched").Bool() {
+		t.Fatalf("unexpected response body: %s", outResp.Body)
+	}
+
+	resp.Stream = true
+	resp.Body = []byte(`{"n":1}`)
+	outResp, _ = pipeline.TranslateResponse(ctx, "claude", "openai", resp, nil, nil, nil)
+	if len(outResp.Chunks) != 1 || !strings.HasPrefix(outResp.Chunks[0], "event: message\ndata: ") ||
+		!strings.Contains(outResp.Chunks[0], `"seen":"claude-sonnet"`) {
+		t.Fatalf("unexpected chunks: %q", outResp.Chunks)
+	}
+	resp.Body = []byte(`{"drop":true}`)
+	outResp, _ = pipeline.TranslateResponse(ctx, "claude", "openai", resp, nil, nil, nil)
+	if len(outResp.Chunks) != 0 {
+		t.Fatalf("expected chunk to be dropped, got %q", outResp.Chunks)
+	}
+}
+
+func TestCompileRejectsInvalidPlugins(t *testing.T) {
+	cases := map[string]string{
+		"no hooks":    `plugin = {"source": "openai"}`,
+		"unknown key": "plugin = {\"sauce\": \"openai\"}\ndef transform_request(req):\n    return None",
+		"load":        "load(\"x.star\", \"y\")\ndef transform_request(req):\n    return None",
+		"runaway":     "x = 0\nwhile True:\n    x += 1",
+	}
+	for name, src := range cases {
+		if _, err := Compile(name+".star", []byte(src), 10_000); err == nil {
+			t.Fatalf("%s: expected compile error", name)
+		}
+	}
+}
+
+func TestTransformStepLimit(t *testing.T) {
+	src := "def transform_request(req):\n    while True:\n        pass"
+	plugin, err := Compile("loop.star", []byte(src), 10_000)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	body := []byte(`{"a":1}`)
+	out, err := plugin.TransformRequest("openai", "claude", "m", false, body)
+	if err == nil || string(out) != string(body) {
+		t.Fatalf("expected step limit error and unchanged body, got %s, %v", out, err)
+	}
+}
+
+func TestManagerHotReload(t *testing.T) {
+	dir := t.TempDir()
+	manager, pipeline := newTestPipeline(t, dir)
+	if len(manager.Plugins()) != 0 {
+		t.Fatalf("expected no plugins")
+	}
+
+	writePlugin(t, dir, "tag.star", "def transform_request(req):\n    req[\"body\"][\"tag\"] = 1\n    return req[\"body\"]")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(manager.Plugins()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin was not loaded after file creation")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	out, _ := pipeline.TranslateRequest(context.Background(), "openai", "claude", sdktranslator.RequestEnvelope{Format: "openai", Body: []byte(`{}`)})
+	if gjson.GetBytes(out.Body, "tag").Int() != 1 {
+		t.Fatalf("unexpected body: %s", out.Body)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "tag.star")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	for len(manager.Plugins()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin was not unloaded after file removal")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
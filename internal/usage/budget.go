@@ -0,0 +1,70 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// BudgetStatus reports the spend of an API key against its budget.
+type BudgetStatus struct {
+	Limit       float64   `json:"limit"`
+	Unit        string    `json:"unit"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start,omitempty"`
+	PeriodEnd   time.Time `json:"period_end,omitempty"`
+	Used        float64   `json:"used"`
+	Remaining   float64   `json:"remaining"`
+	Exceeded    bool      `json:"exceeded"`
+}
+
+// CheckBudget evaluates the budget of apiKey at now against the recorded statistics.
+// Spend is only tracked while usage statistics are enabled and is kept in memory.
+func (s *RequestStatistics) CheckBudget(apiKey string, budget config.APIKeyBudget, now time.Time) BudgetStatus {
+	start := budgetPeriodStart(budget.Period, now)
+	spend := s.SpendSince(apiKey, start)
+	used := spend.Cost
+	if budget.Unit == config.BudgetUnitTokens {
+		used = float64(spend.Tokens)
+	}
+	remaining := budget.Limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return BudgetStatus{
+		Limit:       budget.Limit,
+		Unit:        budget.Unit,
+		Period:      budget.Period,
+		PeriodStart: start,
+		PeriodEnd:   budgetPeriodEnd(budget.Period, start),
+		Used:        used,
+		Remaining:   remaining,
+		Exceeded:    used >= budget.Limit,
+	}
+}
+
+// budgetPeriodStart returns the local start of the day or month containing now, or the zero
+// time for budgets that never reset.
+func budgetPeriodStart(period string, now time.Time) time.Time {
+	switch period {
+	case config.BudgetPeriodDaily:
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case config.BudgetPeriodTotal:
+		return time.Time{}
+	default:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+}
+
+// budgetPeriodEnd returns the start of the period following the one beginning at start, or the
+// zero time for budgets that never reset.
+func budgetPeriodEnd(period string, start time.Time) time.Time {
+	switch period {
+	case config.BudgetPeriodDaily:
+		return start.AddDate(0, 0, 1)
+	case config.BudgetPeriodTotal:
+		return time.Time{}
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
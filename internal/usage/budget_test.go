@@ -0,0 +1,115 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRecordAggregatesCost(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	stats.Record(context.Background(), coreusage.Record{
+		Provider:    "codex",
+		Model:       "gpt-5",
+		APIKey:      "client-1",
+		AuthIndex:   "auth-a",
+		RequestedAt: now,
+		Detail:      coreusage.Detail{InputTokens: 1_000_000, OutputTokens: 100_000, TotalTokens: 1_100_000},
+	})
+	stats.Record(context.Background(), coreusage.Record{
+		Provider:    "codex",
+		Model:       "unpriced-model",
+		APIKey:      "client-1",
+		RequestedAt: now,
+		Detail:      coreusage.Detail{InputTokens: 10, OutputTokens: 10},
+	})
+
+	snapshot := stats.Snapshot()
+	want := 1.25 + 0.1*10
+	if math.Abs(snapshot.TotalCost-want) > 1e-9 {
+		t.Fatalf("total cost = %v, want %v", snapshot.TotalCost, want)
+	}
+	api := snapshot.APIs["client-1"]
+	if math.Abs(api.TotalCost-want) > 1e-9 || math.Abs(api.Models["gpt-5"].TotalCost-want) > 1e-9 {
+		t.Fatalf("per-key/model cost not aggregated: %+v", api)
+	}
+	if api.Models["unpriced-model"].TotalCost != 0 {
+		t.Fatalf("unpriced model should cost 0")
+	}
+	day := now.Format("2006-01-02")
+	if math.Abs(snapshot.CostByDay[day]-want) > 1e-9 || math.Abs(api.CostByDay[day]-want) > 1e-9 {
+		t.Fatalf("cost by day missing: %+v %+v", snapshot.CostByDay, api.CostByDay)
+	}
+	if math.Abs(snapshot.CostByAuth["auth-a"]-want) > 1e-9 || math.Abs(snapshot.CostByModel["gpt-5"]-want) > 1e-9 {
+		t.Fatalf("cost by auth/model missing: %+v %+v", snapshot.CostByAuth, snapshot.CostByModel)
+	}
+
+	imported := NewRequestStatistics()
+	imported.MergeSnapshot(snapshot)
+	if got := imported.Snapshot().TotalCost; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("imported cost = %v, want %v", got, want)
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	for _, at := range []time.Time{now.AddDate(0, -1, 0), now.AddDate(0, 0, -2), now} {
+		stats.Record(context.Background(), coreusage.Record{
+			Model:       "gpt-5",
+			APIKey:      "client-1",
+			RequestedAt: at,
+			Detail:      coreusage.Detail{InputTokens: 800_000, OutputTokens: 200_000, TotalTokens: 1_000_000},
+		})
+	}
+
+	daily := stats.CheckBudget("client-1", config.APIKeyBudget{Limit: 2_000_000, Unit: config.BudgetUnitTokens, Period: config.BudgetPeriodDaily}, now)
+	if daily.Used != 1_000_000 || daily.Exceeded {
+		t.Fatalf("daily budget = %+v", daily)
+	}
+	monthly := stats.CheckBudget("client-1", config.APIKeyBudget{Limit: 2_000_000, Unit: config.BudgetUnitTokens, Period: config.BudgetPeriodMonthly}, now)
+	if monthly.Used != 2_000_000 || !monthly.Exceeded {
+		t.Fatalf("monthly budget = %+v", monthly)
+	}
+	total := stats.CheckBudget("client-1", config.APIKeyBudget{Limit: 10, Unit: config.BudgetUnitCost, Period: config.BudgetPeriodTotal}, now)
+	wantCost := 3 * (0.8*1.25 + 0.2*10)
+	if math.Abs(total.Used-wantCost) > 1e-9 || total.Exceeded {
+		t.Fatalf("total budget = %+v, want used %v", total, wantCost)
+	}
+	if other := stats.CheckBudget("client-2", config.APIKeyBudget{Limit: 1, Unit: config.BudgetUnitCost, Period: config.BudgetPeriodTotal}, now); other.Used != 0 || other.Exceeded {
+		t.Fatalf("unknown key should have no spend: %+v", other)
+	}
+}
+
+func TestLinkedAPIKeysShareSpend(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	for _, key := range []string{"old", "new", "other"} {
+		stats.Record(context.Background(), coreusage.Record{
+			Provider:    "codex",
+			Model:       "gpt-5",
+			APIKey:      key,
+			RequestedAt: now,
+			Detail:      coreusage.Detail{InputTokens: 10, TotalTokens: 10},
+		})
+	}
+	stats.LinkAPIKeys("old", "new")
+
+	for _, key := range []string{"old", "new"} {
+		if spend := stats.SpendSince(key, time.Time{}); spend.Tokens != 20 {
+			t.Fatalf("spend of %s = %d tokens, want 20", key, spend.Tokens)
+		}
+	}
+	if spend := stats.SpendSince("other", time.Time{}); spend.Tokens != 10 {
+		t.Fatalf("unlinked key spend = %d tokens, want 10", spend.Tokens)
+	}
+	keyUsage := stats.KeyUsage("new", time.Time{})
+	if keyUsage.TotalRequests != 2 || keyUsage.ByModel["gpt-5"].Tokens != 20 || keyUsage.ByDay[now.Format("2006-01-02")].Requests != 2 {
+		t.Fatalf("key usage = %+v", keyUsage)
+	}
+}
@@ -0,0 +1,110 @@
+package usage
+
+import (
+	"slices"
+	"time"
+)
+
+// KeyUsage summarises the requests of a client API key and the keys it was rotated from.
+type KeyUsage struct {
+	TotalRequests int64                  `json:"total_requests"`
+	SuccessCount  int64                  `json:"success_count"`
+	FailureCount  int64                  `json:"failure_count"`
+	TotalTokens   int64                  `json:"total_tokens"`
+	TotalCost     float64                `json:"total_cost"`
+	ByDay         map[string]UsageBucket `json:"by_day"`
+	ByModel       map[string]UsageBucket `json:"by_model"`
+}
+
+// UsageBucket aggregates the requests of one day or model.
+type UsageBucket struct {
+	Requests int64   `json:"requests"`
+	Tokens   int64   `json:"tokens"`
+	Cost     float64 `json:"cost"`
+}
+
+// LinkAPIKeys records that newKey replaces oldKey, so budgets and client usage views count the
+// spend of both. Links are kept in memory and restored from api-key-rotations on startup.
+func (s *RequestStatistics) LinkAPIKeys(oldKey, newKey string) {
+	if s == nil || oldKey == "" || newKey == "" || oldKey == newKey {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	group := s.linkedKeysLocked(oldKey)
+	for _, key := range s.linkedKeysLocked(newKey) {
+		if !slices.Contains(group, key) {
+			group = append(group, key)
+		}
+	}
+	if s.linked == nil {
+		s.linked = make(map[string][]string)
+	}
+	for _, key := range group {
+		s.linked[key] = group
+	}
+}
+
+// LinkedAPIKeys returns apiKey together with the keys linked to it by rotation.
+func (s *RequestStatistics) LinkedAPIKeys(apiKey string) []string {
+	if s == nil {
+		return []string{apiKey}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.linkedKeysLocked(apiKey))
+}
+
+func (s *RequestStatistics) linkedKeysLocked(apiKey string) []string {
+	if group, ok := s.linked[apiKey]; ok {
+		return group
+	}
+	return []string{apiKey}
+}
+
+// KeyUsage aggregates the requests made with apiKey and its linked keys on or after since.
+// A zero since covers everything recorded.
+func (s *RequestStatistics) KeyUsage(apiKey string, since time.Time) KeyUsage {
+	out := KeyUsage{ByDay: make(map[string]UsageBucket), ByModel: make(map[string]UsageBucket)}
+	if s == nil {
+		return out
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.linkedKeysLocked(apiKey) {
+		stats, ok := s.apis[key]
+		if !ok || stats == nil {
+			continue
+		}
+		for modelName, model := range stats.Models {
+			for _, detail := range model.Details {
+				if !since.IsZero() && detail.Timestamp.Before(since) {
+					continue
+				}
+				tokens := detail.Tokens.TotalTokens
+				if tokens < 0 {
+					tokens = 0
+				}
+				out.TotalRequests++
+				if detail.Failed {
+					out.FailureCount++
+				} else {
+					out.SuccessCount++
+				}
+				out.TotalTokens += tokens
+				out.TotalCost += detail.Cost
+				dayKey := detail.Timestamp.Format("2006-01-02")
+				out.ByDay[dayKey] = out.ByDay[dayKey].add(tokens, detail.Cost)
+				out.ByModel[modelName] = out.ByModel[modelName].add(tokens, detail.Cost)
+			}
+		}
+	}
+	return out
+}
+
+func (b UsageBucket) add(tokens int64, cost float64) UsageBucket {
+	b.Requests++
+	b.Tokens += tokens
+	b.Cost += cost
+	return b
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
 	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 )
 
@@ -64,6 +65,7 @@ type RequestStatistics struct {
 	successCount  int64
 	failureCount  int64
 	totalTokens   int64
+	totalCost     float64
 
 	apis map[string]*apiStats
 
@@ -71,33 +73,55 @@ type RequestStatistics struct {
 	requestsByHour map[int]int64
 	tokensByDay    map[string]int64
 	tokensByHour   map[int]int64
+	costByDay      map[string]float64
+	costByModel    map[string]float64
+	costByAuth     map[string]float64
+
+	// linked groups API keys replaced by rotation; each member maps to the shared group.
+	linked map[string][]string
+
+	// tenants partitions the records of tenant requests; they are also counted here.
+	tenantsMu sync.Mutex
+	tenants   map[string]*RequestStatistics
 }
 
 // apiStats holds aggregated metrics for a single API key.
 type apiStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Models        map[string]*modelStats
+	Days          map[string]*Spend
 }
 
 // modelStats holds aggregated metrics for a specific model within an API.
 type modelStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Details       []RequestDetail
 }
 
+// Spend is the tokens and cost consumed by an API key.
+type Spend struct {
+	Tokens int64   `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
 // RequestDetail stores the timestamp and token usage for a single request.
 type RequestDetail struct {
-	Timestamp  time.Time  `json:"timestamp"`
-	Source     string     `json:"source"`
-	AuthIndex  string     `json:"auth_index"`
-	RequestID  string     `json:"request_id"`
-	SessionID  string     `json:"session_id"`
-	StatusCode int        `json:"status_code"`
-	DurationMs int64      `json:"duration_ms"`
-	Tokens     TokenStats `json:"tokens"`
-	Failed     bool       `json:"failed"`
+	Timestamp  time.Time `json:"timestamp"`
+	Source     string    `json:"source"`
+	AuthIndex  string    `json:"auth_index"`
+	RequestID  string    `json:"request_id"`
+	SessionID  string    `json:"session_id"`
+	StatusCode int       `json:"status_code"`
+	DurationMs int64     `json:"duration_ms"`
+	// FirstChunkMs is the time to the first streamed chunk; zero for non-streaming requests.
+	FirstChunkMs int64      `json:"first_chunk_ms,omitempty"`
+	Tokens       TokenStats `json:"tokens"`
+	Cost         float64    `json:"cost"`
+	Failed       bool       `json:"failed"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -111,23 +135,29 @@ type TokenStats struct {
 
 // StatisticsSnapshot represents an immutable view of the aggregated metrics.
 type StatisticsSnapshot struct {
-	TotalRequests int64 `json:"total_requests"`
-	SuccessCount  int64 `json:"success_count"`
-	FailureCount  int64 `json:"failure_count"`
-	TotalTokens   int64 `json:"total_tokens"`
+	TotalRequests int64   `json:"total_requests"`
+	SuccessCount  int64   `json:"success_count"`
+	FailureCount  int64   `json:"failure_count"`
+	TotalTokens   int64   `json:"total_tokens"`
+	TotalCost     float64 `json:"total_cost"`
 
 	APIs map[string]APISnapshot `json:"apis"`
 
-	RequestsByDay  map[string]int64 `json:"requests_by_day"`
-	RequestsByHour map[string]int64 `json:"requests_by_hour"`
-	TokensByDay    map[string]int64 `json:"tokens_by_day"`
-	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
+	RequestsByDay  map[string]int64   `json:"requests_by_day"`
+	RequestsByHour map[string]int64   `json:"requests_by_hour"`
+	TokensByDay    map[string]int64   `json:"tokens_by_day"`
+	TokensByHour   map[string]int64   `json:"tokens_by_hour"`
+	CostByDay      map[string]float64 `json:"cost_by_day"`
+	CostByModel    map[string]float64 `json:"cost_by_model"`
+	CostByAuth     map[string]float64 `json:"cost_by_auth"`
 }
 
 // APISnapshot summarises metrics for a single API key.
 type APISnapshot struct {
 	TotalRequests int64                    `json:"total_requests"`
 	TotalTokens   int64                    `json:"total_tokens"`
+	TotalCost     float64                  `json:"total_cost"`
+	CostByDay     map[string]float64       `json:"cost_by_day"`
 	Models        map[string]ModelSnapshot `json:"models"`
 }
 
@@ -135,6 +165,7 @@ type APISnapshot struct {
 type ModelSnapshot struct {
 	TotalRequests int64           `json:"total_requests"`
 	TotalTokens   int64           `json:"total_tokens"`
+	TotalCost     float64         `json:"total_cost"`
 	Details       []RequestDetail `json:"details"`
 }
 
@@ -151,6 +182,9 @@ func NewRequestStatistics() *RequestStatistics {
 		requestsByHour: make(map[int]int64),
 		tokensByDay:    make(map[string]int64),
 		tokensByHour:   make(map[int]int64),
+		costByDay:      make(map[string]float64),
+		costByModel:    make(map[string]float64),
+		costByAuth:     make(map[string]float64),
 	}
 }
 
@@ -167,7 +201,6 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		timestamp = time.Now()
 	}
 	detail := normaliseDetail(record.Detail)
-	totalTokens := detail.TotalTokens
 	statsKey := record.APIKey
 	if statsKey == "" {
 		statsKey = resolveAPIIdentifier(ctx, record)
@@ -181,8 +214,14 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	if modelName == "" {
 		modelName = "unknown"
 	}
-	dayKey := timestamp.Format("2006-01-02")
-	hourKey := timestamp.Hour()
+	cost := requestCost(record.Provider, modelName, detail)
+
+	if record.Tenant != "" {
+		tenantRecord := record
+		tenantRecord.Tenant = ""
+		tenantRecord.Failed = failed
+		s.Tenant(record.Tenant).Record(ctx, tenantRecord)
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -193,7 +232,6 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	} else {
 		s.failureCount++
 	}
-	s.totalTokens += totalTokens
 
 	stats, ok := s.apis[statsKey]
 	if !ok {
@@ -201,36 +239,128 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.apis[statsKey] = stats
 	}
 	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp:  timestamp,
-		Source:     record.Source,
-		AuthIndex:  record.AuthIndex,
-		RequestID:  record.RequestID,
-		SessionID:  record.SessionID,
-		StatusCode: record.StatusCode,
-		DurationMs: record.DurationMs,
-		Tokens:     detail,
-		Failed:     failed,
+		Timestamp:    timestamp,
+		Source:       record.Source,
+		AuthIndex:    record.AuthIndex,
+		RequestID:    record.RequestID,
+		SessionID:    record.SessionID,
+		StatusCode:   record.StatusCode,
+		DurationMs:   record.DurationMs,
+		FirstChunkMs: record.FirstChunkMs,
+		Tokens:       detail,
+		Cost:         cost,
+		Failed:       failed,
 	})
+}
+
+// Tenant returns the usage partition of the named tenant, creating it on first use.
+func (s *RequestStatistics) Tenant(name string) *RequestStatistics {
+	s.tenantsMu.Lock()
+	defer s.tenantsMu.Unlock()
+	if s.tenants == nil {
+		s.tenants = make(map[string]*RequestStatistics)
+	}
+	stats, ok := s.tenants[name]
+	if !ok {
+		stats = NewRequestStatistics()
+		s.tenants[name] = stats
+	}
+	return stats
+}
+
+// updateAPIStats adds detail to the per-key, per-model and time-bucketed aggregates.
+func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
+	totalTokens := detail.Tokens.TotalTokens
+	if totalTokens < 0 {
+		totalTokens = 0
+	}
+	dayKey := detail.Timestamp.Format("2006-01-02")
+	hourKey := detail.Timestamp.Hour()
 
+	s.totalTokens += totalTokens
+	s.totalCost += detail.Cost
 	s.requestsByDay[dayKey]++
 	s.requestsByHour[hourKey]++
 	s.tokensByDay[dayKey] += totalTokens
 	s.tokensByHour[hourKey] += totalTokens
-}
+	if detail.Cost > 0 {
+		s.costByDay[dayKey] += detail.Cost
+		s.costByModel[model] += detail.Cost
+		if detail.AuthIndex != "" {
+			s.costByAuth[detail.AuthIndex] += detail.Cost
+		}
+	}
 
-func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
 	stats.TotalRequests++
-	stats.TotalTokens += detail.Tokens.TotalTokens
+	stats.TotalTokens += totalTokens
+	stats.TotalCost += detail.Cost
+	if stats.Days == nil {
+		stats.Days = make(map[string]*Spend)
+	}
+	day, ok := stats.Days[dayKey]
+	if !ok {
+		day = &Spend{}
+		stats.Days[dayKey] = day
+	}
+	day.Tokens += totalTokens
+	day.Cost += detail.Cost
+
 	modelStatsValue, ok := stats.Models[model]
 	if !ok {
 		modelStatsValue = &modelStats{}
 		stats.Models[model] = modelStatsValue
 	}
 	modelStatsValue.TotalRequests++
-	modelStatsValue.TotalTokens += detail.Tokens.TotalTokens
+	modelStatsValue.TotalTokens += totalTokens
+	modelStatsValue.TotalCost += detail.Cost
 	modelStatsValue.Details = append(modelStatsValue.Details, detail)
 }
 
+// SpendSince returns the tokens and cost recorded for apiKey and its linked keys on or after
+// the day of since. A zero since covers everything recorded.
+func (s *RequestStatistics) SpendSince(apiKey string, since time.Time) Spend {
+	var out Spend
+	if s == nil {
+		return out
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sinceKey := ""
+	if !since.IsZero() {
+		sinceKey = since.Format("2006-01-02")
+	}
+	for _, key := range s.linkedKeysLocked(apiKey) {
+		stats, ok := s.apis[key]
+		if !ok || stats == nil {
+			continue
+		}
+		if sinceKey == "" {
+			out.Tokens += stats.TotalTokens
+			out.Cost += stats.TotalCost
+			continue
+		}
+		for dayKey, day := range stats.Days {
+			if dayKey >= sinceKey {
+				out.Tokens += day.Tokens
+				out.Cost += day.Cost
+			}
+		}
+	}
+	return out
+}
+
+// requestCost prices a request with the current price table. Models without a price cost 0.
+func requestCost(provider, model string, tokens TokenStats) float64 {
+	cost, _ := pricing.Current().Cost(provider, model, pricing.Usage{
+		InputTokens:     tokens.InputTokens,
+		OutputTokens:    tokens.OutputTokens,
+		ReasoningTokens: tokens.ReasoningTokens,
+		CachedTokens:    tokens.CachedTokens,
+		TotalTokens:     tokens.TotalTokens,
+	})
+	return cost
+}
+
 // Snapshot returns a copy of the aggregated metrics for external consumption.
 func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result := StatisticsSnapshot{}
@@ -245,20 +375,29 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
+	result.TotalCost = s.totalCost
 
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
 		apiSnapshot := APISnapshot{
 			TotalRequests: stats.TotalRequests,
 			TotalTokens:   stats.TotalTokens,
+			TotalCost:     stats.TotalCost,
+			CostByDay:     make(map[string]float64, len(stats.Days)),
 			Models:        make(map[string]ModelSnapshot, len(stats.Models)),
 		}
+		for dayKey, day := range stats.Days {
+			if day.Cost > 0 {
+				apiSnapshot.CostByDay[dayKey] = day.Cost
+			}
+		}
 		for modelName, modelStatsValue := range stats.Models {
 			requestDetails := make([]RequestDetail, len(modelStatsValue.Details))
 			copy(requestDetails, modelStatsValue.Details)
 			apiSnapshot.Models[modelName] = ModelSnapshot{
 				TotalRequests: modelStatsValue.TotalRequests,
 				TotalTokens:   modelStatsValue.TotalTokens,
+				TotalCost:     modelStatsValue.TotalCost,
 				Details:       requestDetails,
 			}
 		}
@@ -287,6 +426,10 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.TokensByHour[key] = v
 	}
 
+	result.CostByDay = copyCosts(s.costByDay)
+	result.CostByModel = copyCosts(s.costByModel)
+	result.CostByAuth = copyCosts(s.costByAuth)
+
 	return result
 }
 
@@ -358,29 +501,28 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 	return result
 }
 
+// recordImported adds an imported detail. Details exported before costs were tracked are
+// priced with the current table by model name.
 func (s *RequestStatistics) recordImported(apiName, modelName string, stats *apiStats, detail RequestDetail) {
-	totalTokens := detail.Tokens.TotalTokens
-	if totalTokens < 0 {
-		totalTokens = 0
-	}
-
 	s.totalRequests++
 	if detail.Failed {
 		s.failureCount++
 	} else {
 		s.successCount++
 	}
-	s.totalTokens += totalTokens
+	if detail.Cost <= 0 {
+		detail.Cost = requestCost("", modelName, detail.Tokens)
+	}
 
 	s.updateAPIStats(stats, modelName, detail)
+}
 
-	dayKey := detail.Timestamp.Format("2006-01-02")
-	hourKey := detail.Timestamp.Hour()
-
-	s.requestsByDay[dayKey]++
-	s.requestsByHour[hourKey]++
-	s.tokensByDay[dayKey] += totalTokens
-	s.tokensByHour[hourKey] += totalTokens
+func copyCosts(in map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
 }
 
 func dedupKey(apiName, modelName string, detail RequestDetail) string {
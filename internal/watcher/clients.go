@@ -32,6 +32,7 @@ func (w *Watcher) reloadClients(rescanAuth bool, affectedOAuthProviders []string
 		log.Error("config is nil, cannot reload clients")
 		return
 	}
+	w.watchTenantDirs(cfg)
 
 	if len(affectedOAuthProviders) > 0 {
 		w.clientsMutex.Lock()
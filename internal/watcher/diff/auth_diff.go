@@ -3,15 +3,16 @@ package diff
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 // BuildAuthChangeDetails computes a redacted, human-readable list of auth field changes.
-// Only prefix, proxy_url, and disabled fields are tracked; sensitive data is never printed.
+// Only prefix, proxy_url, disabled, tags and schedule fields are tracked; sensitive data is never printed.
 func BuildAuthChangeDetails(oldAuth, newAuth *coreauth.Auth) []string {
-	changes := make([]string, 0, 3)
+	changes := make([]string, 0, 5)
 
 	// Handle nil cases by using empty Auth as default
 	if oldAuth == nil {
@@ -40,5 +41,38 @@ func BuildAuthChangeDetails(oldAuth, newAuth *coreauth.Auth) []string {
 		changes = append(changes, fmt.Sprintf("disabled: %t -> %t", oldAuth.Disabled, newAuth.Disabled))
 	}
 
+	// Compare tags
+	if oldTags, newTags := formatTags(oldAuth.Tags), formatTags(newAuth.Tags); oldTags != newTags {
+		changes = append(changes, fmt.Sprintf("tags: %s -> %s", oldTags, newTags))
+	}
+
+	// Compare schedule
+	if oldSchedule, newSchedule := formatSchedule(oldAuth.Schedule), formatSchedule(newAuth.Schedule); oldSchedule != newSchedule {
+		changes = append(changes, fmt.Sprintf("schedule: %s -> %s", oldSchedule, newSchedule))
+	}
+
 	return changes
 }
+
+func formatTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return "none"
+	}
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func formatSchedule(schedule *coreauth.Schedule) string {
+	if schedule == nil || len(schedule.Windows) == 0 {
+		return "none"
+	}
+	out := strings.Join(schedule.Windows, "; ")
+	if schedule.Timezone != "" {
+		out += " (" + schedule.Timezone + ")"
+	}
+	return out
+}
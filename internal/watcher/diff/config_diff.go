@@ -33,6 +33,27 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if strings.TrimSpace(oldCfg.Pprof.Addr) != strings.TrimSpace(newCfg.Pprof.Addr) {
 		changes = append(changes, fmt.Sprintf("pprof.addr: %s -> %s", strings.TrimSpace(oldCfg.Pprof.Addr), strings.TrimSpace(newCfg.Pprof.Addr)))
 	}
+	if oldCfg.TranslatorPlugins.Enabled != newCfg.TranslatorPlugins.Enabled {
+		changes = append(changes, fmt.Sprintf("translator-plugins.enabled: %t -> %t", oldCfg.TranslatorPlugins.Enabled, newCfg.TranslatorPlugins.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.TranslatorPlugins.Dir) != strings.TrimSpace(newCfg.TranslatorPlugins.Dir) {
+		changes = append(changes, fmt.Sprintf("translator-plugins.dir: %s -> %s", strings.TrimSpace(oldCfg.TranslatorPlugins.Dir), strings.TrimSpace(newCfg.TranslatorPlugins.Dir)))
+	}
+	if oldCfg.Guardrails.Enabled != newCfg.Guardrails.Enabled {
+		changes = append(changes, fmt.Sprintf("guardrails.enabled: %t -> %t", oldCfg.Guardrails.Enabled, newCfg.Guardrails.Enabled))
+	}
+	if len(oldCfg.Guardrails.Rules) != len(newCfg.Guardrails.Rules) {
+		changes = append(changes, fmt.Sprintf("guardrails.rules: %d -> %d", len(oldCfg.Guardrails.Rules), len(newCfg.Guardrails.Rules)))
+	}
+	if !reflect.DeepEqual(oldCfg.PromptProfiles, newCfg.PromptProfiles) {
+		changes = append(changes, fmt.Sprintf("prompt-profiles: updated (%d -> %d profiles)", len(oldCfg.PromptProfiles), len(newCfg.PromptProfiles)))
+	}
+	if oldCfg.TokenCounting.DisableLocalFallback != newCfg.TokenCounting.DisableLocalFallback {
+		changes = append(changes, fmt.Sprintf("token-counting.disable-local-fallback: %t -> %t", oldCfg.TokenCounting.DisableLocalFallback, newCfg.TokenCounting.DisableLocalFallback))
+	}
+	if oldCfg.TokenCounting.ContextPreflight != newCfg.TokenCounting.ContextPreflight {
+		changes = append(changes, fmt.Sprintf("token-counting.context-preflight: %t -> %t", oldCfg.TokenCounting.ContextPreflight, newCfg.TokenCounting.ContextPreflight))
+	}
 	if oldCfg.LoggingToFile != newCfg.LoggingToFile {
 		changes = append(changes, fmt.Sprintf("logging-to-file: %t -> %t", oldCfg.LoggingToFile, newCfg.LoggingToFile))
 	}
@@ -84,6 +105,44 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if !reflect.DeepEqual(oldCfg.Routing.Weighted, newCfg.Routing.Weighted) {
+		changes = append(changes, "routing.weighted: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.PoolPriority, newCfg.Routing.PoolPriority) {
+		changes = append(changes, fmt.Sprintf("routing.pool-priority: %v -> %v", oldCfg.Routing.PoolPriority, newCfg.Routing.PoolPriority))
+	}
+	if !reflect.DeepEqual(oldCfg.AuthPools, newCfg.AuthPools) {
+		changes = append(changes, fmt.Sprintf("auth-pools: updated (%d -> %d pools)", len(oldCfg.AuthPools), len(newCfg.AuthPools)))
+	}
+	if oldCfg.Pricing.File != newCfg.Pricing.File {
+		changes = append(changes, fmt.Sprintf("pricing.file: %s -> %s", oldCfg.Pricing.File, newCfg.Pricing.File))
+	}
+	if oldCfg.Pricing.DisableDefaults != newCfg.Pricing.DisableDefaults {
+		changes = append(changes, fmt.Sprintf("pricing.disable-defaults: %t -> %t", oldCfg.Pricing.DisableDefaults, newCfg.Pricing.DisableDefaults))
+	}
+	if !reflect.DeepEqual(oldCfg.Pricing.Models, newCfg.Pricing.Models) {
+		changes = append(changes, fmt.Sprintf("pricing.models: updated (%d -> %d entries)", len(oldCfg.Pricing.Models), len(newCfg.Pricing.Models)))
+	}
+	if !reflect.DeepEqual(oldCfg.APIKeyBudgets, newCfg.APIKeyBudgets) {
+		changes = append(changes, fmt.Sprintf("api-key-budgets: updated (%d -> %d keys)", len(oldCfg.APIKeyBudgets), len(newCfg.APIKeyBudgets)))
+	}
+	if oldCfg.Drain.Timeout != newCfg.Drain.Timeout {
+		changes = append(changes, fmt.Sprintf("drain.timeout: %d -> %d", oldCfg.Drain.Timeout, newCfg.Drain.Timeout))
+	}
+	if oldCfg.Drain.RetryAfter != newCfg.Drain.RetryAfter {
+		changes = append(changes, fmt.Sprintf("drain.retry-after: %d -> %d", oldCfg.Drain.RetryAfter, newCfg.Drain.RetryAfter))
+	}
+	if oldCfg.ExternalExecutors.Enable != newCfg.ExternalExecutors.Enable {
+		changes = append(changes, fmt.Sprintf("external-executors.enable: %t -> %t", oldCfg.ExternalExecutors.Enable, newCfg.ExternalExecutors.Enable))
+	}
+	if !equalStringSet(oldCfg.ExternalExecutors.SecretKeys, newCfg.ExternalExecutors.SecretKeys) {
+		changes = append(changes, fmt.Sprintf("external-executors.secret-keys: updated (%d -> %d keys)", len(oldCfg.ExternalExecutors.SecretKeys), len(newCfg.ExternalExecutors.SecretKeys)))
+	}
+	if len(oldCfg.Tenants) != len(newCfg.Tenants) {
+		changes = append(changes, fmt.Sprintf("tenants count: %d -> %d", len(oldCfg.Tenants), len(newCfg.Tenants)))
+	} else if !reflect.DeepEqual(oldCfg.Tenants, newCfg.Tenants) {
+		changes = append(changes, "tenants: updated (count unchanged, keys redacted)")
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
@@ -157,6 +216,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if oldExcluded.hash != newExcluded.hash {
 				changes = append(changes, fmt.Sprintf("claude[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
 			}
+			if o.Batches != n.Batches {
+				changes = append(changes, fmt.Sprintf("claude[%d].batches: %t -> %t", i, o.Batches, n.Batches))
+			}
 			if o.Cloak != nil && n.Cloak != nil {
 				if strings.TrimSpace(o.Cloak.Mode) != strings.TrimSpace(n.Cloak.Mode) {
 					changes = append(changes, fmt.Sprintf("claude[%d].cloak.mode: %s -> %s", i, o.Cloak.Mode, n.Cloak.Mode))
@@ -171,6 +233,50 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Bedrock credentials (do not print key material)
+	if len(oldCfg.BedrockKey) != len(newCfg.BedrockKey) {
+		changes = append(changes, fmt.Sprintf("bedrock-api-key count: %d -> %d", len(oldCfg.BedrockKey), len(newCfg.BedrockKey)))
+	} else {
+		for i := range oldCfg.BedrockKey {
+			o := oldCfg.BedrockKey[i]
+			n := newCfg.BedrockKey[i]
+			if strings.TrimSpace(o.Region) != strings.TrimSpace(n.Region) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].region: %s -> %s", i, strings.TrimSpace(o.Region), strings.TrimSpace(n.Region)))
+			}
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if strings.TrimSpace(o.AccessKeyID) != strings.TrimSpace(n.AccessKeyID) || strings.TrimSpace(o.SecretAccessKey) != strings.TrimSpace(n.SecretAccessKey) || strings.TrimSpace(o.SessionToken) != strings.TrimSpace(n.SessionToken) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].credentials: updated", i))
+			}
+			if strings.TrimSpace(o.Profile) != strings.TrimSpace(n.Profile) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].profile: %s -> %s", i, strings.TrimSpace(o.Profile), strings.TrimSpace(n.Profile)))
+			}
+			if o.DisableInferenceProfile != n.DisableInferenceProfile {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].disable-inference-profile: %t -> %t", i, o.DisableInferenceProfile, n.DisableInferenceProfile))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].headers: updated", i))
+			}
+			oldModels := SummarizeBedrockModels(o.Models)
+			newModels := SummarizeBedrockModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
 	// Codex keys (do not print key material)
 	if len(oldCfg.CodexKey) != len(newCfg.CodexKey) {
 		changes = append(changes, fmt.Sprintf("codex-api-key count: %d -> %d", len(oldCfg.CodexKey), len(newCfg.CodexKey)))
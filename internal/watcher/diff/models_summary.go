@@ -29,6 +29,11 @@ type VertexModelsSummary struct {
 	count int
 }
 
+type BedrockModelsSummary struct {
+	hash  string
+	count int
+}
+
 // SummarizeGeminiModels hashes Gemini model aliases for change detection.
 func SummarizeGeminiModels(models []config.GeminiModel) GeminiModelsSummary {
 	if len(models) == 0 {
@@ -92,6 +97,27 @@ func SummarizeCodexModels(models []config.CodexModel) CodexModelsSummary {
 	}
 }
 
+// SummarizeBedrockModels hashes Bedrock model aliases for change detection.
+func SummarizeBedrockModels(models []config.BedrockModel) BedrockModelsSummary {
+	if len(models) == 0 {
+		return BedrockModelsSummary{}
+	}
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return BedrockModelsSummary{
+		hash:  hashJoined(keys),
+		count: len(keys),
+	}
+}
+
 // SummarizeVertexModels hashes Vertex-compatible model aliases for change detection.
 func SummarizeVertexModels(models []config.VertexCompatModel) VertexModelsSummary {
 	if len(models) == 0 {
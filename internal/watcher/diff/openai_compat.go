@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -75,6 +76,9 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if !reflect.DeepEqual(oldEntry.Azure, newEntry.Azure) {
+		details = append(details, "azure updated")
+	}
 	if len(details) == 0 {
 		return ""
 	}
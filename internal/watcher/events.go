@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -45,6 +46,26 @@ func (w *Watcher) start(ctx context.Context) error {
 	return nil
 }
 
+// watchTenantDirs creates and watches the auth directory of every configured tenant, since
+// fsnotify does not watch subdirectories. Adding an already watched directory is a no-op.
+func (w *Watcher) watchTenantDirs(cfg *config.Config) {
+	if w.watcher == nil || w.authDir == "" || cfg == nil {
+		return
+	}
+	for i := range cfg.Tenants {
+		dir := config.TenantAuthDir(w.authDir, cfg.Tenants[i].Name)
+		if errMkdir := os.MkdirAll(dir, 0o700); errMkdir != nil {
+			log.Errorf("failed to create tenant auth directory %s: %v", dir, errMkdir)
+			continue
+		}
+		if errAdd := w.watcher.Add(dir); errAdd != nil {
+			log.Errorf("failed to watch tenant auth directory %s: %v", dir, errAdd)
+			continue
+		}
+		log.Debugf("watching tenant auth directory: %s", dir)
+	}
+}
+
 func (w *Watcher) processEvents(ctx context.Context) {
 	for {
 		select {
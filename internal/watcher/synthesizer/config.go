@@ -10,7 +10,7 @@ import (
 )
 
 // ConfigSynthesizer generates Auth entries from configuration API keys.
-// It handles Gemini, Claude, Codex, OpenAI-compat, and Vertex-compat providers.
+// It handles Gemini, Claude, Bedrock, Codex, OpenAI-compat, and Vertex-compat providers.
 type ConfigSynthesizer struct{}
 
 // NewConfigSynthesizer creates a new ConfigSynthesizer instance.
@@ -29,6 +29,8 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeGeminiKeys(ctx)...)
 	// Claude API Keys
 	out = append(out, s.synthesizeClaudeKeys(ctx)...)
+	// Bedrock credentials
+	out = append(out, s.synthesizeBedrockKeys(ctx)...)
 	// Codex API Keys
 	out = append(out, s.synthesizeCodexKeys(ctx)...)
 	// OpenAI-compat
@@ -70,11 +72,14 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addConfigTenantToAttrs(entry.Tenant, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   "gemini",
 			Label:      "gemini-apikey",
 			Prefix:     prefix,
+			Tags:       coreauth.NormalizeTags(entry.Tags),
+			Schedule:   coreauth.NormalizeSchedule(entry.Schedule),
 			Status:     coreauth.StatusActive,
 			ProxyURL:   proxyURL,
 			Attributes: attrs,
@@ -116,13 +121,19 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if hash := diff.ComputeClaudeModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		if ck.Batches {
+			attrs["batches"] = "true"
+		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addConfigTenantToAttrs(ck.Tenant, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   "claude",
 			Label:      "claude-apikey",
 			Prefix:     prefix,
+			Tags:       coreauth.NormalizeTags(ck.Tags),
+			Schedule:   coreauth.NormalizeSchedule(ck.Schedule),
 			Status:     coreauth.StatusActive,
 			ProxyURL:   proxyURL,
 			Attributes: attrs,
@@ -135,6 +146,67 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 	return out
 }
 
+// synthesizeBedrockKeys creates Auth entries for AWS Bedrock credentials.
+func (s *ConfigSynthesizer) synthesizeBedrockKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		bk := cfg.BedrockKey[i]
+		identity := bk.GetAPIKey()
+		if identity == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(bk.Prefix)
+		base := bk.GetBaseURL()
+		id, token := idGen.Next("bedrock:apikey", identity, base)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:bedrock[%s]", token),
+			"api_key":  identity,
+			"base_url": base,
+			"region":   strings.TrimSpace(bk.Region),
+		}
+		if secret := strings.TrimSpace(bk.SecretAccessKey); secret != "" {
+			attrs["secret_access_key"] = secret
+		}
+		if sessionToken := strings.TrimSpace(bk.SessionToken); sessionToken != "" {
+			attrs["session_token"] = sessionToken
+		}
+		if profile := strings.TrimSpace(bk.Profile); profile != "" && strings.TrimSpace(bk.AccessKeyID) == "" {
+			attrs["profile"] = profile
+		}
+		if bk.DisableInferenceProfile {
+			attrs["disable_inference_profile"] = "true"
+		}
+		if bk.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(bk.Priority)
+		}
+		if hash := diff.ComputeBedrockModelsHash(bk.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(bk.Headers, attrs)
+		addConfigTenantToAttrs(bk.Tenant, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "bedrock",
+			Label:      "bedrock-apikey",
+			Prefix:     prefix,
+			Tags:       coreauth.NormalizeTags(bk.Tags),
+			Schedule:   coreauth.NormalizeSchedule(bk.Schedule),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(bk.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, bk.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
 // synthesizeCodexKeys creates Auth entries for Codex API keys.
 func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreauth.Auth {
 	cfg := ctx.Config
@@ -164,12 +236,15 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addConfigTenantToAttrs(ck.Tenant, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   "codex",
 			Label:      "codex-apikey",
 			Prefix:     prefix,
+			Tags:       coreauth.NormalizeTags(ck.Tags),
+			Schedule:   coreauth.NormalizeSchedule(ck.Schedule),
 			Status:     coreauth.StatusActive,
 			ProxyURL:   proxyURL,
 			Attributes: attrs,
@@ -222,11 +297,15 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addConfigTenantToAttrs(compat.Tenant, attrs)
+			addAzureOpenAIAttrs(compat.Azure, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
 				Label:      compat.Name,
 				Prefix:     prefix,
+				Tags:       coreauth.NormalizeTags(compat.Tags),
+				Schedule:   coreauth.NormalizeSchedule(compat.Schedule),
 				Status:     coreauth.StatusActive,
 				ProxyURL:   proxyURL,
 				Attributes: attrs,
@@ -253,11 +332,15 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addConfigTenantToAttrs(compat.Tenant, attrs)
+			addAzureOpenAIAttrs(compat.Azure, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
 				Label:      compat.Name,
 				Prefix:     prefix,
+				Tags:       coreauth.NormalizeTags(compat.Tags),
+				Schedule:   coreauth.NormalizeSchedule(compat.Schedule),
 				Status:     coreauth.StatusActive,
 				Attributes: attrs,
 				CreatedAt:  now,
@@ -301,11 +384,14 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(compat.Headers, attrs)
+		addConfigTenantToAttrs(compat.Tenant, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   providerName,
 			Label:      "vertex-apikey",
 			Prefix:     prefix,
+			Tags:       coreauth.NormalizeTags(compat.Tags),
+			Schedule:   coreauth.NormalizeSchedule(compat.Schedule),
 			Status:     coreauth.StatusActive,
 			ProxyURL:   proxyURL,
 			Attributes: attrs,
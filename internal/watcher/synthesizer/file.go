@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/geminicli"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
@@ -21,17 +22,29 @@ func NewFileSynthesizer() *FileSynthesizer {
 	return &FileSynthesizer{}
 }
 
-// Synthesize generates Auth entries from auth files in the auth directory.
+// Synthesize generates Auth entries from auth files in the auth directory and in the
+// directories of configured tenants.
 func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, error) {
 	out := make([]*coreauth.Auth, 0, 16)
 	if ctx == nil || ctx.AuthDir == "" {
 		return out, nil
 	}
+	out = s.synthesizeDir(ctx, ctx.AuthDir, "", out)
+	if ctx.Config != nil {
+		for i := range ctx.Config.Tenants {
+			tenant := ctx.Config.Tenants[i].Name
+			out = s.synthesizeDir(ctx, config.TenantAuthDir(ctx.AuthDir, tenant), tenant, out)
+		}
+	}
+	return out, nil
+}
 
-	entries, err := os.ReadDir(ctx.AuthDir)
+// synthesizeDir appends the auths of the JSON files directly inside dir, owned by tenant.
+func (s *FileSynthesizer) synthesizeDir(ctx *SynthesisContext, dir, tenant string, out []*coreauth.Auth) []*coreauth.Auth {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		// Not an error if directory doesn't exist
-		return out, nil
+		return out
 	}
 
 	now := ctx.Now
@@ -45,7 +58,7 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 		if !strings.HasSuffix(strings.ToLower(name), ".json") {
 			continue
 		}
-		full := filepath.Join(ctx.AuthDir, name)
+		full := filepath.Join(dir, name)
 		data, errRead := os.ReadFile(full)
 		if errRead != nil || len(data) == 0 {
 			continue
@@ -97,6 +110,8 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 			Provider: provider,
 			Label:    label,
 			Prefix:   prefix,
+			Tags:     coreauth.TagsFromMetadata(metadata),
+			Schedule: coreauth.ScheduleFromMetadata(metadata),
 			Status:   status,
 			Disabled: disabled,
 			Attributes: map[string]string{
@@ -108,6 +123,9 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 			CreatedAt: now,
 			UpdatedAt: now,
 		}
+		if tenant != "" {
+			a.Attributes[coreauth.AttributeTenant] = tenant
+		}
 		ApplyAuthExcludedModelsMeta(a, cfg, nil, "oauth")
 		if provider == "gemini-cli" {
 			if virtuals := SynthesizeGeminiVirtualAuths(a, metadata, now); len(virtuals) > 0 {
@@ -121,7 +139,7 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 		}
 		out = append(out, a)
 	}
-	return out, nil
+	return out
 }
 
 // SynthesizeGeminiVirtualAuths creates virtual Auth entries for multi-project Gemini credentials.
@@ -167,6 +185,9 @@ func SynthesizeGeminiVirtualAuths(primary *coreauth.Auth, metadata map[string]an
 		if authPath != "" {
 			attrs["path"] = authPath
 		}
+		if tenant := primary.Attributes[coreauth.AttributeTenant]; tenant != "" {
+			attrs[coreauth.AttributeTenant] = tenant
+		}
 		metadataCopy := map[string]any{
 			"email":             email,
 			"project_id":        projectID,
@@ -197,6 +218,8 @@ func SynthesizeGeminiVirtualAuths(primary *coreauth.Auth, metadata map[string]an
 			Metadata:   metadataCopy,
 			ProxyURL:   primary.ProxyURL,
 			Prefix:     primary.Prefix,
+			Tags:       coreauth.NormalizeTags(primary.Tags),
+			Schedule:   coreauth.NormalizeSchedule(primary.Schedule),
 			CreatedAt:  primary.CreatedAt,
 			UpdatedAt:  primary.UpdatedAt,
 			Runtime:    geminicli.NewVirtualCredential(projectID, shared),
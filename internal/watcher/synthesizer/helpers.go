@@ -108,3 +108,32 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addConfigTenantToAttrs assigns a config-backed auth to the tenant named by its entry.
+func addConfigTenantToAttrs(tenant string, attrs map[string]string) {
+	if tenant = strings.ToLower(strings.TrimSpace(tenant)); tenant != "" && attrs != nil {
+		attrs[coreauth.AttributeTenant] = tenant
+	}
+}
+
+// addAzureOpenAIAttrs records Azure OpenAI request settings for an OpenAI-compatibility auth.
+func addAzureOpenAIAttrs(azure *config.AzureOpenAIConfig, attrs map[string]string) {
+	if azure == nil || attrs == nil {
+		return
+	}
+	attrs["azure_api_version"] = azure.APIVersion
+	if azure.Responses {
+		attrs["azure_responses"] = "true"
+	}
+	if entra := azure.Entra; entra != nil {
+		attrs["azure_tenant_id"] = entra.TenantID
+		attrs["azure_client_id"] = entra.ClientID
+		attrs["azure_client_secret"] = entra.ClientSecret
+		if entra.Scope != "" {
+			attrs["azure_scope"] = entra.Scope
+		}
+		if entra.AuthorityHost != "" {
+			attrs["azure_authority_host"] = entra.AuthorityHost
+		}
+	}
+}
@@ -41,6 +41,12 @@ func (m *Manager) NonStream(ctx context.Context, provider string, req *HTTPReque
 		return nil, fmt.Errorf("wsrelay: request is nil")
 	}
 	msg := Message{ID: uuid.NewString(), Type: MessageTypeHTTPReq, Payload: encodeRequest(req)}
+	return m.nonStream(ctx, provider, msg, nil)
+}
+
+// nonStream sends msg and collects the reply into a single response. onCancel, when set, runs
+// if ctx ends before the reply is complete.
+func (m *Manager) nonStream(ctx context.Context, provider string, msg Message, onCancel func()) (*HTTPResponse, error) {
 	respCh, err := m.Send(ctx, provider, msg)
 	if err != nil {
 		return nil, err
@@ -53,6 +59,9 @@ func (m *Manager) NonStream(ctx context.Context, provider string, req *HTTPReque
 	for {
 		select {
 		case <-ctx.Done():
+			if onCancel != nil {
+				onCancel()
+			}
 			return nil, ctx.Err()
 		case msg, ok := <-respCh:
 			if !ok {
@@ -117,6 +126,12 @@ func (m *Manager) Stream(ctx context.Context, provider string, req *HTTPRequest)
 		return nil, fmt.Errorf("wsrelay: request is nil")
 	}
 	msg := Message{ID: uuid.NewString(), Type: MessageTypeHTTPReq, Payload: encodeRequest(req)}
+	return m.stream(ctx, provider, msg, nil)
+}
+
+// stream sends msg and relays the reply as stream events. onCancel, when set, runs if ctx ends
+// before the reply is complete.
+func (m *Manager) stream(ctx context.Context, provider string, msg Message, onCancel func()) (<-chan StreamEvent, error) {
 	respCh, err := m.Send(ctx, provider, msg)
 	if err != nil {
 		return nil, err
@@ -131,6 +146,9 @@ func (m *Manager) Stream(ctx context.Context, provider string, req *HTTPRequest)
 			}
 			select {
 			case <-ctx.Done():
+				if onCancel != nil {
+					onCancel()
+				}
 				return false
 			case out <- ev:
 				return true
@@ -139,6 +157,9 @@ func (m *Manager) Stream(ctx context.Context, provider string, req *HTTPRequest)
 		for {
 			select {
 			case <-ctx.Done():
+				if onCancel != nil {
+					onCancel()
+				}
 				return
 			case msg, ok := <-respCh:
 				if !ok {
@@ -232,6 +253,18 @@ func decodeChunk(payload map[string]any) []byte {
 	return nil
 }
 
+// StatusError is an error reported by a websocket client. Status is zero when the client did
+// not name one; RetryAfter is zero unless the client asked callers to back off.
+type StatusError struct {
+	Status     int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s (status=%d)", e.Message, e.Status)
+}
+
 func decodeError(payload map[string]any) error {
 	if payload == nil {
 		return errors.New("wsrelay: unknown error")
@@ -244,5 +277,9 @@ func decodeError(payload map[string]any) error {
 	if message == "" {
 		message = "wsrelay: upstream error"
 	}
-	return fmt.Errorf("%s (status=%d)", message, status)
+	statusErr := &StatusError{Status: status, Message: message}
+	if v, ok := payload["retry_after"].(float64); ok && v > 0 {
+		statusErr.RetryAfter = time.Duration(v * float64(time.Second))
+	}
+	return statusErr
 }
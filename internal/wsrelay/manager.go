@@ -22,8 +22,10 @@ type Manager struct {
 	sessMutex sync.RWMutex
 
 	providerFactory func(*http.Request) (string, error)
+	authorize       func(*http.Request) error
 	onConnected     func(string)
 	onDisconnected  func(string, error)
+	onMessage       func(string, Message)
 
 	logDebugf func(string, ...any)
 	logInfof  func(string, ...any)
@@ -39,6 +41,11 @@ type Options struct {
 	LogDebugf       func(string, ...any)
 	LogInfof        func(string, ...any)
 	LogWarnf        func(string, ...any)
+
+	// Authorize rejects an upgrade request with 401 when it returns an error.
+	Authorize func(*http.Request) error
+	// OnMessage receives client messages that do not answer a pending request.
+	OnMessage func(string, Message)
 }
 
 // NewManager builds a websocket relay manager with the supplied options.
@@ -61,8 +68,10 @@ func NewManager(opts Options) *Manager {
 			},
 		},
 		providerFactory: opts.ProviderFactory,
+		authorize:       opts.Authorize,
 		onConnected:     opts.OnConnected,
 		onDisconnected:  opts.OnDisconnected,
+		onMessage:       opts.OnMessage,
 		logDebugf:       opts.LogDebugf,
 		logInfof:        opts.LogInfof,
 		logWarnf:        opts.LogWarnf,
@@ -122,6 +131,12 @@ func (m *Manager) handleWebsocket(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if m.authorize != nil {
+		if err := m.authorize(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
 	conn, err := m.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		m.logWarnf("wsrelay: upgrade failed: %v", err)
@@ -169,6 +184,22 @@ func (m *Manager) Send(ctx context.Context, provider string, msg Message) (<-cha
 	return s.request(ctx, msg)
 }
 
+// Notify sends a message to the provider connection without waiting for a reply.
+func (m *Manager) Notify(ctx context.Context, provider string, msg Message) error {
+	s := m.session(provider)
+	if s == nil {
+		return fmt.Errorf("wsrelay: provider %s not connected", provider)
+	}
+	return s.send(ctx, msg)
+}
+
+// Close disconnects the provider connection, if any.
+func (m *Manager) Close(provider string, cause error) {
+	if s := m.session(provider); s != nil {
+		s.cleanup(cause)
+	}
+}
+
 func (m *Manager) session(provider string) *session {
 	key := strings.ToLower(strings.TrimSpace(provider))
 	m.sessMutex.RLock()
@@ -24,4 +24,12 @@ const (
 	MessageTypePing = "ping"
 	// MessageTypePong represents pong responses back to clients.
 	MessageTypePong = "pong"
+	// MessageTypeRegister announces an external executor worker, its models and its capacity.
+	MessageTypeRegister = "register"
+	// MessageTypeRegistered acknowledges a worker registration.
+	MessageTypeRegistered = "registered"
+	// MessageTypeExecute delivers a translated model request to a worker.
+	MessageTypeExecute = "execute"
+	// MessageTypeCancel tells a worker that the caller abandoned a request.
+	MessageTypeCancel = "cancel"
 )
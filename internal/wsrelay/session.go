@@ -116,6 +116,10 @@ func (s *session) dispatch(msg Message) {
 		}
 		return
 	}
+	if s.manager.onMessage != nil {
+		s.manager.onMessage(s.provider, msg)
+		return
+	}
 	if msg.Type == MessageTypeHTTPResp || msg.Type == MessageTypeError || msg.Type == MessageTypeStreamEnd {
 		s.manager.logDebugf("wsrelay: received terminal message for unknown id %s (provider=%s)", msg.ID, s.provider)
 	}
@@ -0,0 +1,151 @@
+package wsrelay
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// External executor workers speak the same envelope as AI Studio clients. After connecting a
+// worker sends a "register" message naming its provider, request format, capacity and models;
+// the relay answers "registered" (or "error") with the same id. Requests then arrive as
+// "execute" messages and are answered with "http_response", "stream_start"/"stream_chunk"/
+// "stream_end" or "error". A "cancel" message tells the worker the caller went away. See
+// docs/external-executors.md for the full protocol.
+
+// WorkerModel describes a model served by a worker.
+type WorkerModel struct {
+	ID                  string
+	DisplayName         string
+	ContextLength       int
+	MaxCompletionTokens int
+}
+
+// WorkerRegistration is the decoded payload of a "register" message.
+type WorkerRegistration struct {
+	// Provider is the provider name clients route to, e.g. "llama-local".
+	Provider string
+	// Format is the request format the worker accepts ("openai", "claude", "gemini", ...).
+	Format string
+	// Capacity caps concurrent requests sent to the worker. Zero means unlimited.
+	Capacity int
+	// Label is a display name for the worker.
+	Label  string
+	Models []WorkerModel
+}
+
+// ExecuteRequest is a translated model request delivered to a worker.
+type ExecuteRequest struct {
+	Model  string
+	Format string
+	Stream bool
+	Body   []byte
+	// Metadata carries request context such as the auth ID and the source format.
+	Metadata map[string]any
+}
+
+var workerProviderPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// DecodeRegistration validates a "register" payload.
+func DecodeRegistration(payload map[string]any) (*WorkerRegistration, error) {
+	if payload == nil {
+		return nil, fmt.Errorf("register payload is empty")
+	}
+	reg := &WorkerRegistration{}
+	reg.Provider = strings.ToLower(strings.TrimSpace(stringValue(payload["provider"])))
+	if !workerProviderPattern.MatchString(reg.Provider) {
+		return nil, fmt.Errorf("invalid provider name %q: use lowercase letters, digits, '.', '_' or '-'", reg.Provider)
+	}
+	reg.Format = strings.ToLower(strings.TrimSpace(stringValue(payload["format"])))
+	if reg.Format == "" {
+		reg.Format = "openai"
+	}
+	if v, ok := payload["capacity"].(float64); ok {
+		if v < 0 {
+			return nil, fmt.Errorf("capacity must not be negative")
+		}
+		reg.Capacity = int(v)
+	}
+	reg.Label = strings.TrimSpace(stringValue(payload["label"]))
+	rawModels, _ := payload["models"].([]any)
+	seen := make(map[string]struct{}, len(rawModels))
+	for _, raw := range rawModels {
+		var model WorkerModel
+		switch v := raw.(type) {
+		case string:
+			model.ID = strings.TrimSpace(v)
+		case map[string]any:
+			model.ID = strings.TrimSpace(stringValue(v["id"]))
+			model.DisplayName = strings.TrimSpace(stringValue(v["display_name"]))
+			if n, okNum := v["context_length"].(float64); okNum {
+				model.ContextLength = int(n)
+			}
+			if n, okNum := v["max_completion_tokens"].(float64); okNum {
+				model.MaxCompletionTokens = int(n)
+			}
+		}
+		if model.ID == "" {
+			continue
+		}
+		if _, dup := seen[model.ID]; dup {
+			continue
+		}
+		seen[model.ID] = struct{}{}
+		reg.Models = append(reg.Models, model)
+	}
+	if len(reg.Models) == 0 {
+		return nil, fmt.Errorf("worker must register at least one model")
+	}
+	return reg, nil
+}
+
+// Execute sends a model request to a worker and collects its reply.
+func (m *Manager) Execute(ctx context.Context, worker string, req *ExecuteRequest) (*HTTPResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("wsrelay: request is nil")
+	}
+	msg := Message{ID: uuid.NewString(), Type: MessageTypeExecute, Payload: encodeExecuteRequest(req)}
+	return m.nonStream(ctx, worker, msg, m.cancelFunc(worker, msg.ID))
+}
+
+// ExecuteStream sends a streaming model request to a worker and relays its reply.
+func (m *Manager) ExecuteStream(ctx context.Context, worker string, req *ExecuteRequest) (<-chan StreamEvent, error) {
+	if req == nil {
+		return nil, fmt.Errorf("wsrelay: request is nil")
+	}
+	msg := Message{ID: uuid.NewString(), Type: MessageTypeExecute, Payload: encodeExecuteRequest(req)}
+	return m.stream(ctx, worker, msg, m.cancelFunc(worker, msg.ID))
+}
+
+func (m *Manager) cancelFunc(worker, id string) func() {
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		defer cancel()
+		if err := m.Notify(ctx, worker, Message{ID: id, Type: MessageTypeCancel}); err != nil {
+			m.logDebugf("wsrelay: failed to cancel request %s on %s: %v", id, worker, err)
+		}
+	}
+}
+
+func encodeExecuteRequest(req *ExecuteRequest) map[string]any {
+	payload := map[string]any{
+		"model":   req.Model,
+		"format":  req.Format,
+		"stream":  req.Stream,
+		"body":    string(req.Body),
+		"sent_at": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if len(req.Metadata) > 0 {
+		payload["metadata"] = req.Metadata
+	}
+	return payload
+}
+
+func stringValue(v any) string {
+	s, _ := v.(string)
+	return s
+}
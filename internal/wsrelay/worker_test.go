@@ -0,0 +1,146 @@
+package wsrelay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDecodeRegistration(t *testing.T) {
+	reg, err := DecodeRegistration(map[string]any{
+		"provider": " Llama-Local ",
+		"capacity": float64(2),
+		"models": []any{
+			"small",
+			map[string]any{"id": "large", "context_length": float64(8192)},
+			"small",
+		},
+	})
+	if err != nil {
+		t.Fatalf("DecodeRegistration() error = %v", err)
+	}
+	if reg.Provider != "llama-local" || reg.Format != "openai" || reg.Capacity != 2 {
+		t.Fatalf("unexpected registration: %+v", reg)
+	}
+	if len(reg.Models) != 2 || reg.Models[1].ID != "large" || reg.Models[1].ContextLength != 8192 {
+		t.Fatalf("unexpected models: %+v", reg.Models)
+	}
+
+	invalid := []map[string]any{
+		{"provider": "bad name", "models": []any{"m"}},
+		{"provider": "ok"},
+		{"provider": "ok", "capacity": float64(-1), "models": []any{"m"}},
+	}
+	for _, payload := range invalid {
+		if _, err = DecodeRegistration(payload); err == nil {
+			t.Fatalf("DecodeRegistration(%v) succeeded, want error", payload)
+		}
+	}
+}
+
+func startWorker(t *testing.T, handle func(*websocket.Conn, Message)) *Manager {
+	t.Helper()
+	connected := make(chan string, 1)
+	manager := NewManager(Options{
+		Path:            "/ws",
+		ProviderFactory: func(*http.Request) (string, error) { return "worker-1", nil },
+		OnConnected:     func(id string) { connected <- id },
+		Authorize: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return errors.New("invalid worker key")
+			}
+			return nil
+		},
+	})
+	server := httptest.NewServer(manager.Handler())
+	t.Cleanup(server.Close)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated dial: err = %v, want 401", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer secret"}})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() {
+		for {
+			var msg Message
+			if errRead := conn.ReadJSON(&msg); errRead != nil {
+				return
+			}
+			handle(conn, msg)
+		}
+	}()
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("worker did not connect")
+	}
+	return manager
+}
+
+func TestExecuteRelaysWorkerReply(t *testing.T) {
+	manager := startWorker(t, func(conn *websocket.Conn, msg Message) {
+		if msg.Type != MessageTypeExecute || msg.Payload["model"] != "small" {
+			_ = conn.WriteJSON(Message{ID: msg.ID, Type: MessageTypeError, Payload: map[string]any{"status": 400, "error": "bad"}})
+			return
+		}
+		_ = conn.WriteJSON(Message{ID: msg.ID, Type: MessageTypeHTTPResp, Payload: map[string]any{"status": 200, "body": `{"ok":true}`}})
+	})
+	resp, err := manager.Execute(context.Background(), "worker-1", &ExecuteRequest{Model: "small", Format: "openai", Body: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.Status != http.StatusOK || string(resp.Body) != `{"ok":true}` {
+		t.Fatalf("unexpected response: %d %s", resp.Status, resp.Body)
+	}
+}
+
+func TestExecuteStreamErrorAndCancel(t *testing.T) {
+	cancelled := make(chan string, 1)
+	manager := startWorker(t, func(conn *websocket.Conn, msg Message) {
+		switch msg.Type {
+		case MessageTypeExecute:
+			if msg.Payload["model"] == "busy" {
+				_ = conn.WriteJSON(Message{ID: msg.ID, Type: MessageTypeError, Payload: map[string]any{"status": 503, "error": "overloaded", "retry_after": 7}})
+				return
+			}
+			_ = conn.WriteJSON(Message{ID: msg.ID, Type: MessageTypeStreamStart, Payload: map[string]any{"status": 200}})
+		case MessageTypeCancel:
+			cancelled <- msg.ID
+		}
+	})
+
+	events, err := manager.ExecuteStream(context.Background(), "worker-1", &ExecuteRequest{Model: "busy", Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	ev := <-events
+	var statusErr *StatusError
+	if !errors.As(ev.Err, &statusErr) || statusErr.Status != http.StatusServiceUnavailable || statusErr.RetryAfter != 7*time.Second {
+		t.Fatalf("unexpected error event: %+v", ev)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err = manager.ExecuteStream(ctx, "worker-1", &ExecuteRequest{Model: "small", Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if ev = <-events; ev.Type != MessageTypeStreamStart {
+		t.Fatalf("first event = %+v, want stream_start", ev)
+	}
+	cancel()
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("worker did not receive cancel")
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// StateStore returns the configured token store when it can persist auxiliary state, or an
+// in-memory store otherwise.
+//
+// Returns:
+//   - coreauth.StateStore: The store used for batch jobs and other runtime state
+func StateStore() coreauth.StateStore {
+	if store, ok := sdkAuth.GetTokenStore().(coreauth.StateStore); ok {
+		return store
+	}
+	log.Warn("token store does not support state persistence; runtime state is kept in memory")
+	return coreauth.NewMemoryStateStore()
+}
+
+// NewBatchManager creates a batch manager whose requests are replayed through ReplayMiddleware
+// and the given endpoint handlers on behalf of the client API key that created the batch, using
+// only credentials of its tenant.
+// Lines rejected for an exhausted budget are retried like rate-limited ones. Execution limits
+// follow the batch section of the current configuration and scale with the number of
+// credentials that can serve each model.
+//
+// Parameters:
+//   - namespace: The state store namespace prefix for the manager's files and jobs
+//   - endpoints: The endpoint handlers keyed by request path
+//
+// Returns:
+//   - *batch.Manager: A batch manager; call Start to resume persisted jobs
+func (h *BaseAPIHandler) NewBatchManager(namespace string, endpoints map[string]gin.HandlerFunc) *batch.Manager {
+	paths := make([]string, 0, len(endpoints))
+	for path := range endpoints {
+		paths = append(paths, path)
+	}
+	return batch.NewManager(batch.Options{
+		Store:     StateStore(),
+		Namespace: namespace,
+		Endpoints: paths,
+		OwnerKeys: h.clientAPIKeys,
+		Execute: func(ctx context.Context, apiKey, tenant string, req batch.Request) batch.Result {
+			status, header, body := ReplayRequest(ctx, h.ReplayMiddleware, endpoints[req.URL], apiKey, tenant, req.Method, req.URL, req.Body)
+			return batch.Result{StatusCode: status, Header: header, Body: body}
+		},
+		Available: func(tenant, model string) (int, time.Time) {
+			if h.AuthManager == nil {
+				return 1, time.Time{}
+			}
+			return h.AuthManager.AvailableAuthCount(tenant, model)
+		},
+		Settings: func() batch.Settings {
+			if h.Cfg == nil {
+				return batch.Settings{}
+			}
+			return batch.Settings{
+				MaxConcurrency:     h.Cfg.Batch.MaxConcurrency,
+				PerAuthConcurrency: h.Cfg.Batch.PerAuthConcurrency,
+				MaxFileBytes:       int64(h.Cfg.Batch.MaxFileSizeMB) << 20,
+			}
+		},
+	})
+}
+
+// clientAPIKeys returns the client API keys accepted by the server.
+func (h *BaseAPIHandler) clientAPIKeys() []string {
+	if h.ClientAPIKeys != nil {
+		return h.ClientAPIKeys()
+	}
+	if h.Cfg == nil {
+		return nil
+	}
+	return h.Cfg.APIKeys
+}
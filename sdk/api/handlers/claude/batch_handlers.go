@@ -0,0 +1,794 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// messageBatchNamespace prefixes the state of batches executed locally.
+	messageBatchNamespace = "claude-batch"
+	// remoteBatchNamespace stores which upstream credential owns a forwarded batch.
+	remoteBatchNamespace = "claude-batch-remote"
+
+	messageBatchIDPrefix    = "msgbatch_"
+	defaultAnthropicVersion = "2023-06-01"
+	defaultBatchListLimit   = 20
+	maxBatchListLimit       = 1000
+	maxBatchRequests        = 100000
+)
+
+var batchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ClaudeBatchAPIHandler serves the Anthropic Message Batches API (/v1/messages/batches).
+// Batches are forwarded to a claude-api-key upstream marked with "batches: true" when one
+// can serve every requested model; otherwise they run locally through the auth manager,
+// replaying each request through the /v1/messages handler, and results are served in the
+// Anthropic JSONL results format.
+type ClaudeBatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	manager *batch.Manager
+	store   coreauth.StateStore
+
+	countsMu sync.Mutex
+	// endedCounts caches request counts of finished local batches, which never change.
+	endedCounts map[string]messageBatchCounts
+}
+
+// remoteBatch records a batch created on an upstream credential.
+type remoteBatch struct {
+	ID string `json:"id"`
+	// Owner is batch.OwnerID of the client API key that created the batch.
+	Owner     string `json:"owner,omitempty"`
+	AuthID    string `json:"auth_id"`
+	CreatedAt int64  `json:"created_at"`
+	// Snapshot is the last message batch object returned by the upstream.
+	Snapshot json.RawMessage `json:"snapshot,omitempty"`
+}
+
+type messageBatchCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// messageBatch mirrors the Anthropic message batch object.
+type messageBatch struct {
+	ID                string             `json:"id"`
+	Type              string             `json:"type"`
+	ProcessingStatus  string             `json:"processing_status"`
+	RequestCounts     messageBatchCounts `json:"request_counts"`
+	EndedAt           *string            `json:"ended_at"`
+	CreatedAt         string             `json:"created_at"`
+	ExpiresAt         string             `json:"expires_at"`
+	ArchivedAt        *string            `json:"archived_at"`
+	CancelInitiatedAt *string            `json:"cancel_initiated_at"`
+	ResultsURL        *string            `json:"results_url"`
+}
+
+// NewClaudeBatchAPIHandler creates a new Claude Message Batches API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//   - messagesHandlers: The handlers used to execute batch requests locally
+//
+// Returns:
+//   - *ClaudeBatchAPIHandler: A new Claude Message Batches API handlers instance
+func NewClaudeBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, messagesHandlers *ClaudeCodeAPIHandler) *ClaudeBatchAPIHandler {
+	return &ClaudeBatchAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		manager: apiHandlers.NewBatchManager(messageBatchNamespace, map[string]gin.HandlerFunc{
+			"/v1/messages": messagesHandlers.ClaudeMessages,
+		}),
+		store:       handlers.StateStore(),
+		endedCounts: make(map[string]messageBatchCounts),
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *ClaudeBatchAPIHandler) HandlerType() string {
+	return Claude
+}
+
+// Models returns a list of models supported by this handler.
+func (h *ClaudeBatchAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return modelRegistry.GetAvailableModels("claude")
+}
+
+// Start resumes local batches persisted by a previous run.
+func (h *ClaudeBatchAPIHandler) Start(ctx context.Context) {
+	h.manager.Start(ctx)
+}
+
+// Stop checkpoints running local batches and stops executing them.
+func (h *ClaudeBatchAPIHandler) Stop(ctx context.Context) error {
+	return h.manager.Stop(ctx)
+}
+
+// CreateBatch handles POST /v1/messages/batches.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *ClaudeBatchAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", "Invalid request: body must be valid JSON")
+		return
+	}
+	requests := gjson.GetBytes(rawJSON, "requests")
+	if !requests.IsArray() || len(requests.Array()) == 0 {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", "requests: must be a non-empty array")
+		return
+	}
+	items := requests.Array()
+	if len(items) > maxBatchRequests {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests: at most %d requests are allowed per batch", maxBatchRequests))
+		return
+	}
+	seen := make(map[string]struct{}, len(items))
+	models := make(map[string]struct{})
+	for idx, item := range items {
+		customID := item.Get("custom_id").String()
+		if !batchCustomIDPattern.MatchString(customID) {
+			writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests.%d.custom_id: must be 1-64 characters of letters, digits, '-' or '_'", idx))
+			return
+		}
+		if _, duplicate := seen[customID]; duplicate {
+			writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests.%d.custom_id: %q is used more than once", idx, customID))
+			return
+		}
+		seen[customID] = struct{}{}
+		params := item.Get("params")
+		if !params.IsObject() {
+			writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests.%d.params: must be an object", idx))
+			return
+		}
+		model := strings.TrimSpace(params.Get("model").String())
+		if model == "" {
+			writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests.%d.params.model: field required", idx))
+			return
+		}
+		models[model] = struct{}{}
+	}
+
+	owner, tenant := c.GetString("apiKey"), c.GetString("tenant")
+	if auth := h.passthroughAuth(owner, tenant, models); auth != nil {
+		h.createRemote(c, auth, owner, rawJSON)
+		return
+	}
+
+	var input bytes.Buffer
+	for _, item := range items {
+		line := `{"method":"POST","url":"/v1/messages"}`
+		line, _ = sjson.Set(line, "custom_id", item.Get("custom_id").String())
+		line, _ = sjson.SetRaw(line, "body", item.Get("params").Raw)
+		input.WriteString(line)
+		input.WriteByte('\n')
+	}
+	ctx := c.Request.Context()
+	file, err := h.manager.CreateFile(ctx, owner, tenant, "message_batch.jsonl", batch.PurposeBatch, input.Bytes())
+	if err != nil {
+		writeMessageBatchError(c, err)
+		return
+	}
+	created, err := h.manager.CreateBatch(ctx, owner, tenant, file.ID, "/v1/messages", "", nil)
+	if err != nil {
+		_ = h.manager.DeleteFile(ctx, owner, file.ID)
+		writeMessageBatchError(c, err)
+		return
+	}
+	obj := h.localObject(c, created)
+	obj.RequestCounts.Processing = len(items)
+	c.JSON(http.StatusOK, obj)
+}
+
+// RetrieveBatch handles GET /v1/messages/batches/:batch_id.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *ClaudeBatchAPIHandler) RetrieveBatch(c *gin.Context) {
+	id := c.Param("batch_id")
+	owner := c.GetString("apiKey")
+	if remote := h.loadRemote(c.Request.Context(), owner, id); remote != nil {
+		h.forwardRemote(c, remote, http.MethodGet, "", true)
+		return
+	}
+	found, err := h.manager.GetBatch(c.Request.Context(), owner, localBatchID(id))
+	if err != nil {
+		writeMessageBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, h.localObject(c, found))
+}
+
+// ListBatches handles GET /v1/messages/batches.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *ClaudeBatchAPIHandler) ListBatches(c *gin.Context) {
+	limit := defaultBatchListLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxBatchListLimit {
+			writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("limit: must be between 1 and %d", maxBatchListLimit))
+			return
+		}
+		limit = parsed
+	}
+	ctx := c.Request.Context()
+	owner := c.GetString("apiKey")
+
+	type listed struct {
+		created int64
+		id      string
+		body    json.RawMessage
+	}
+	var entries []listed
+	local, err := h.manager.ListBatches(ctx, owner)
+	if err != nil {
+		writeMessageBatchError(c, err)
+		return
+	}
+	for idx := range local {
+		obj := h.localObject(c, &local[idx])
+		raw, _ := json.Marshal(obj)
+		entries = append(entries, listed{created: local[idx].CreatedAt, id: obj.ID, body: raw})
+	}
+	for _, remote := range h.listRemote(ctx, owner) {
+		snapshot := remote.Snapshot
+		if gjson.GetBytes(snapshot, "processing_status").String() != "ended" {
+			if refreshed := h.refreshRemote(c, remote); refreshed != nil {
+				snapshot = refreshed
+			}
+		}
+		if len(snapshot) == 0 {
+			continue
+		}
+		entries = append(entries, listed{created: remote.CreatedAt, id: remote.ID, body: h.rewriteResultsURL(c, snapshot)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].created != entries[j].created {
+			return entries[i].created > entries[j].created
+		}
+		return entries[i].id > entries[j].id
+	})
+
+	start, end := 0, len(entries)
+	if afterID := strings.TrimSpace(c.Query("after_id")); afterID != "" {
+		for idx, entry := range entries {
+			if entry.id == afterID {
+				start = idx + 1
+				break
+			}
+		}
+		end = min(start+limit, len(entries))
+	} else if beforeID := strings.TrimSpace(c.Query("before_id")); beforeID != "" {
+		for idx, entry := range entries {
+			if entry.id == beforeID {
+				end = idx
+				break
+			}
+		}
+		start = max(end-limit, 0)
+	} else {
+		end = min(limit, len(entries))
+	}
+	page := entries[start:end]
+	data := make([]json.RawMessage, 0, len(page))
+	for _, entry := range page {
+		data = append(data, entry.body)
+	}
+	resp := gin.H{"data": data, "has_more": end < len(entries), "first_id": nil, "last_id": nil}
+	if len(page) > 0 {
+		resp["first_id"] = page[0].id
+		resp["last_id"] = page[len(page)-1].id
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CancelBatch handles POST /v1/messages/batches/:batch_id/cancel.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *ClaudeBatchAPIHandler) CancelBatch(c *gin.Context) {
+	id := c.Param("batch_id")
+	owner := c.GetString("apiKey")
+	if remote := h.loadRemote(c.Request.Context(), owner, id); remote != nil {
+		h.forwardRemote(c, remote, http.MethodPost, "/cancel", true)
+		return
+	}
+	cancelled, err := h.manager.CancelBatch(c.Request.Context(), owner, localBatchID(id))
+	if err != nil {
+		writeMessageBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, h.localObject(c, cancelled))
+}
+
+// DeleteBatch handles DELETE /v1/messages/batches/:batch_id.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *ClaudeBatchAPIHandler) DeleteBatch(c *gin.Context) {
+	id := c.Param("batch_id")
+	ctx := c.Request.Context()
+	owner := c.GetString("apiKey")
+	if remote := h.loadRemote(ctx, owner, id); remote != nil {
+		if status := h.forwardRemote(c, remote, http.MethodDelete, "", false); status >= 200 && status < 300 {
+			if err := h.store.DeleteState(ctx, remoteBatchNamespace, remote.ID); err != nil {
+				log.Warnf("claude batches: unable to delete record of %s: %v", remote.ID, err)
+			}
+		}
+		return
+	}
+	found, err := h.manager.GetBatch(ctx, owner, localBatchID(id))
+	if err != nil {
+		writeMessageBatchError(c, err)
+		return
+	}
+	if err = h.manager.DeleteBatch(ctx, owner, found.ID); err != nil {
+		writeMessageBatchError(c, err)
+		return
+	}
+	if err = h.manager.DeleteFile(ctx, owner, found.InputFileID); err != nil && !errors.Is(err, batch.ErrNotFound) {
+		log.Warnf("claude batches: unable to delete input of %s: %v", id, err)
+	}
+	h.countsMu.Lock()
+	delete(h.endedCounts, found.ID)
+	h.countsMu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"id": id, "type": "message_batch_deleted"})
+}
+
+// BatchResults handles GET /v1/messages/batches/:batch_id/results.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *ClaudeBatchAPIHandler) BatchResults(c *gin.Context) {
+	id := c.Param("batch_id")
+	ctx := c.Request.Context()
+	owner := c.GetString("apiKey")
+	if remote := h.loadRemote(ctx, owner, id); remote != nil {
+		h.forwardRemote(c, remote, http.MethodGet, "/results", false)
+		return
+	}
+	found, err := h.manager.GetBatch(ctx, owner, localBatchID(id))
+	if err != nil {
+		writeMessageBatchError(c, err)
+		return
+	}
+	if !found.Terminal() {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Batch %s is still processing; results are available once it has ended", id))
+		return
+	}
+	var out bytes.Buffer
+	if found.OutputFileID != nil {
+		data, errContent := h.manager.FileContent(ctx, owner, *found.OutputFileID)
+		if errContent != nil {
+			writeMessageBatchError(c, errContent)
+			return
+		}
+		convertBatchLines(&out, data)
+	}
+	if found.ErrorFileID != nil {
+		data, errContent := h.manager.FileContent(ctx, owner, *found.ErrorFileID)
+		if errContent != nil {
+			writeMessageBatchError(c, errContent)
+			return
+		}
+		convertBatchLines(&out, data)
+	}
+	c.Data(http.StatusOK, "application/x-jsonl", out.Bytes())
+}
+
+// localObject converts a locally executed batch to an Anthropic message batch object.
+func (h *ClaudeBatchAPIHandler) localObject(c *gin.Context, b *batch.Batch) messageBatch {
+	id := messageBatchIDPrefix + strings.TrimPrefix(b.ID, "batch_")
+	obj := messageBatch{
+		ID:                id,
+		Type:              "message_batch",
+		ProcessingStatus:  "in_progress",
+		CreatedAt:         formatUnix(b.CreatedAt),
+		ExpiresAt:         formatUnix(b.ExpiresAt),
+		CancelInitiatedAt: formatUnixPtr(b.CancellingAt),
+	}
+	if b.Status == batch.StatusCancelling {
+		obj.ProcessingStatus = "canceling"
+	}
+	if !b.Terminal() {
+		counts := b.RequestCounts
+		obj.RequestCounts = messageBatchCounts{
+			Processing: max(counts.Total-counts.Completed-counts.Failed, 0),
+			Succeeded:  counts.Completed,
+			Errored:    counts.Failed,
+		}
+		return obj
+	}
+	obj.ProcessingStatus = "ended"
+	for _, endedAt := range []*int64{b.CompletedAt, b.CancelledAt, b.ExpiredAt, b.FailedAt} {
+		if endedAt != nil {
+			obj.EndedAt = formatUnixPtr(endedAt)
+			break
+		}
+	}
+	resultsURL := requestBaseURL(c) + "/v1/messages/batches/" + id + "/results"
+	obj.ResultsURL = &resultsURL
+	obj.RequestCounts = h.endedBatchCounts(c.Request.Context(), c.GetString("apiKey"), b)
+	return obj
+}
+
+// endedBatchCounts classifies the results of a finished local batch.
+func (h *ClaudeBatchAPIHandler) endedBatchCounts(ctx context.Context, owner string, b *batch.Batch) messageBatchCounts {
+	h.countsMu.Lock()
+	cached, ok := h.endedCounts[b.ID]
+	h.countsMu.Unlock()
+	if ok {
+		return cached
+	}
+	counts := messageBatchCounts{Succeeded: b.RequestCounts.Completed}
+	if b.Status == batch.StatusFailed {
+		// The input never validated; nothing ran.
+		return counts
+	}
+	counts.Errored = b.RequestCounts.Failed
+	if b.ErrorFileID != nil {
+		if data, err := h.manager.FileContent(ctx, owner, *b.ErrorFileID); err == nil {
+			counts.Errored = 0
+			for _, line := range bytes.Split(data, []byte("\n")) {
+				if len(bytes.TrimSpace(line)) == 0 {
+					continue
+				}
+				switch gjson.GetBytes(line, "error.code").String() {
+				case "batch_cancelled":
+					counts.Canceled++
+				case "batch_expired":
+					counts.Expired++
+				default:
+					counts.Errored++
+				}
+			}
+		}
+	}
+	h.countsMu.Lock()
+	h.endedCounts[b.ID] = counts
+	h.countsMu.Unlock()
+	return counts
+}
+
+// passthroughAuth returns the upstream credential that should receive a batch, if any.
+// Only claude-api-key entries of the client's tenant with batches enabled that the client may
+// use and that serve every requested model qualify; the highest priority wins.
+func (h *ClaudeBatchAPIHandler) passthroughAuth(owner, tenant string, models map[string]struct{}) *coreauth.Auth {
+	if h.AuthManager == nil {
+		return nil
+	}
+	allowed, restricted := h.AuthManager.AllowedAuthIDsForClientKey(owner)
+	modelRegistry := registry.GetGlobalRegistry()
+	var (
+		best         *coreauth.Auth
+		bestPriority int
+	)
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || !strings.EqualFold(auth.Provider, "claude") || auth.Attributes == nil || coreauth.AuthTenant(auth) != tenant {
+			continue
+		}
+		if auth.Attributes["batches"] != "true" || strings.TrimSpace(auth.Attributes["api_key"]) == "" {
+			continue
+		}
+		if restricted {
+			if _, ok := allowed[auth.ID]; !ok {
+				continue
+			}
+		}
+		supported := true
+		for model := range models {
+			if !modelRegistry.ClientSupportsModel(auth.ID, thinking.ParseSuffix(model).ModelName) {
+				supported = false
+				break
+			}
+		}
+		if !supported {
+			continue
+		}
+		priority, _ := strconv.Atoi(auth.Attributes["priority"])
+		if best == nil || priority > bestPriority || (priority == bestPriority && auth.ID < best.ID) {
+			best, bestPriority = auth, priority
+		}
+	}
+	return best
+}
+
+// createRemote forwards a batch to the upstream credential and records its owner.
+func (h *ClaudeBatchAPIHandler) createRemote(c *gin.Context, auth *coreauth.Auth, owner string, rawJSON []byte) {
+	body := rawJSON
+	for idx, item := range gjson.GetBytes(rawJSON, "requests").Array() {
+		model := item.Get("params.model").String()
+		upstream := thinking.ParseSuffix(h.AuthManager.UpstreamModelForAuth(auth, model)).ModelName
+		if upstream != "" && upstream != model {
+			body, _ = sjson.SetBytes(body, fmt.Sprintf("requests.%d.params.model", idx), upstream)
+		}
+	}
+	status, respBody, header, err := h.doUpstream(c, auth, http.MethodPost, "", body)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("upstream batch request failed: %v", err))
+		return
+	}
+	if status >= 200 && status < 300 {
+		id := gjson.GetBytes(respBody, "id").String()
+		if coreauth.ValidateStateKey(id) == nil {
+			rec := remoteBatch{ID: id, Owner: batch.OwnerID(owner), AuthID: auth.ID, CreatedAt: time.Now().Unix(), Snapshot: respBody}
+			if errSave := h.saveRemote(c.Request.Context(), &rec); errSave != nil {
+				log.Errorf("claude batches: unable to record upstream batch %s: %v", id, errSave)
+			}
+		}
+		respBody = h.rewriteResultsURL(c, respBody)
+	}
+	writeUpstream(c, status, header, respBody)
+}
+
+// forwardRemote proxies a request about an upstream batch and returns the upstream status.
+func (h *ClaudeBatchAPIHandler) forwardRemote(c *gin.Context, remote *remoteBatch, method, suffix string, batchObject bool) int {
+	auth, ok := h.AuthManager.GetByID(remote.AuthID)
+	if !ok {
+		writeClaudeError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("the credential that owns batch %s is no longer configured", remote.ID))
+		return http.StatusBadGateway
+	}
+	status, respBody, header, err := h.doUpstream(c, auth, method, "/"+remote.ID+suffix, nil)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("upstream batch request failed: %v", err))
+		return http.StatusBadGateway
+	}
+	if batchObject && status >= 200 && status < 300 && gjson.ValidBytes(respBody) {
+		remote.Snapshot = respBody
+		if errSave := h.saveRemote(c.Request.Context(), remote); errSave != nil {
+			log.Warnf("claude batches: unable to update record of %s: %v", remote.ID, errSave)
+		}
+		respBody = h.rewriteResultsURL(c, respBody)
+	}
+	writeUpstream(c, status, header, respBody)
+	return status
+}
+
+// refreshRemote fetches the current state of an upstream batch for listings.
+func (h *ClaudeBatchAPIHandler) refreshRemote(c *gin.Context, remote *remoteBatch) json.RawMessage {
+	auth, ok := h.AuthManager.GetByID(remote.AuthID)
+	if !ok {
+		return nil
+	}
+	status, respBody, _, err := h.doUpstream(c, auth, http.MethodGet, "/"+remote.ID, nil)
+	if err != nil || status < 200 || status >= 300 || !gjson.ValidBytes(respBody) {
+		return nil
+	}
+	remote.Snapshot = respBody
+	if errSave := h.saveRemote(c.Request.Context(), remote); errSave != nil {
+		log.Warnf("claude batches: unable to update record of %s: %v", remote.ID, errSave)
+	}
+	return respBody
+}
+
+func (h *ClaudeBatchAPIHandler) doUpstream(c *gin.Context, auth *coreauth.Auth, method, path string, body []byte) (int, []byte, http.Header, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/")
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(c.Request.Context(), method, baseURL+"/v1/messages/batches"+path, reader)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	version := strings.TrimSpace(c.GetHeader("anthropic-version"))
+	if version == "" {
+		version = defaultAnthropicVersion
+	}
+	req.Header.Set("anthropic-version", version)
+	if beta := strings.TrimSpace(c.GetHeader("anthropic-beta")); beta != "" {
+		req.Header.Set("anthropic-beta", beta)
+	}
+	resp, err := h.AuthManager.HttpRequest(c.Request.Context(), auth, req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Warnf("claude batches: close upstream response: %v", errClose)
+		}
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, respBody, resp.Header, nil
+}
+
+// rewriteResultsURL points results_url at this proxy so clients download results with their proxy key.
+func (h *ClaudeBatchAPIHandler) rewriteResultsURL(c *gin.Context, body []byte) []byte {
+	id := gjson.GetBytes(body, "id").String()
+	if id == "" || gjson.GetBytes(body, "results_url").Type != gjson.String {
+		return body
+	}
+	rewritten, err := sjson.SetBytes(body, "results_url", requestBaseURL(c)+"/v1/messages/batches/"+id+"/results")
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
+
+func (h *ClaudeBatchAPIHandler) loadRemote(ctx context.Context, owner, id string) *remoteBatch {
+	if coreauth.ValidateStateKey(id) != nil {
+		return nil
+	}
+	raw, err := h.store.LoadState(ctx, remoteBatchNamespace, id)
+	if err != nil {
+		return nil
+	}
+	var rec remoteBatch
+	if err = json.Unmarshal(raw, &rec); err != nil || rec.Owner != batch.OwnerID(owner) {
+		return nil
+	}
+	return &rec
+}
+
+func (h *ClaudeBatchAPIHandler) listRemote(ctx context.Context, owner string) []*remoteBatch {
+	keys, err := h.store.ListState(ctx, remoteBatchNamespace)
+	if err != nil {
+		log.Warnf("claude batches: unable to list upstream batches: %v", err)
+		return nil
+	}
+	out := make([]*remoteBatch, 0, len(keys))
+	for _, key := range keys {
+		if rec := h.loadRemote(ctx, owner, key); rec != nil {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+func (h *ClaudeBatchAPIHandler) saveRemote(ctx context.Context, rec *remoteBatch) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return h.store.SaveState(ctx, remoteBatchNamespace, rec.ID, raw)
+}
+
+// convertBatchLines rewrites batch engine output and error lines into Anthropic result lines.
+func convertBatchLines(out *bytes.Buffer, data []byte) {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		root := gjson.ParseBytes(line)
+		result := `{"custom_id":"","result":{}}`
+		result, _ = sjson.Set(result, "custom_id", root.Get("custom_id").String())
+		status := root.Get("response.status_code").Int()
+		body := root.Get("response.body")
+		switch {
+		case status >= 200 && status < 300:
+			result, _ = sjson.Set(result, "result.type", "succeeded")
+			result, _ = sjson.SetRaw(result, "result.message", body.Raw)
+		case root.Get("error.code").String() == "batch_cancelled":
+			result, _ = sjson.Set(result, "result.type", "canceled")
+		case root.Get("error.code").String() == "batch_expired":
+			result, _ = sjson.Set(result, "result.type", "expired")
+		default:
+			result, _ = sjson.Set(result, "result.type", "errored")
+			result, _ = sjson.SetRaw(result, "result.error", claudeErrorBody(status, body, root.Get("error.message").String()))
+		}
+		out.WriteString(result)
+		out.WriteByte('\n')
+	}
+}
+
+// claudeErrorBody normalises an error response into the Anthropic error shape.
+func claudeErrorBody(status int64, body gjson.Result, fallback string) string {
+	if body.Get("type").String() == "error" && body.Get("error").IsObject() {
+		return body.Raw
+	}
+	errType := body.Get("error.type").String()
+	message := body.Get("error.message").String()
+	if message == "" {
+		message = body.String()
+	}
+	if message == "" {
+		message = fallback
+	}
+	if errType == "" || errType == "server_error" {
+		switch {
+		case status == http.StatusBadRequest:
+			errType = "invalid_request_error"
+		case status == http.StatusNotFound:
+			errType = "not_found_error"
+		case status == http.StatusTooManyRequests:
+			errType = "rate_limit_error"
+		default:
+			errType = "api_error"
+		}
+	}
+	raw, _ := json.Marshal(claudeErrorResponse{Type: "error", Error: claudeErrorDetail{Type: errType, Message: message}})
+	return string(raw)
+}
+
+func writeUpstream(c *gin.Context, status int, header http.Header, body []byte) {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(status, contentType, body)
+}
+
+func writeClaudeError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, claudeErrorResponse{Type: "error", Error: claudeErrorDetail{Type: errType, Message: message}})
+}
+
+func writeMessageBatchError(c *gin.Context, err error) {
+	var reqErr *batch.RequestError
+	switch {
+	case errors.As(err, &reqErr):
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", reqErr.Message)
+	case errors.Is(err, batch.ErrNotFound):
+		writeClaudeError(c, http.StatusNotFound, "not_found_error", "Batch not found: "+c.Param("batch_id"))
+	default:
+		writeClaudeError(c, http.StatusInternalServerError, "api_error", err.Error())
+	}
+}
+
+// localBatchID maps a message batch ID to the batch engine ID.
+func localBatchID(id string) string {
+	if !strings.HasPrefix(id, messageBatchIDPrefix) {
+		return ""
+	}
+	return "batch_" + strings.TrimPrefix(id, messageBatchIDPrefix)
+}
+
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := strings.TrimSpace(c.GetHeader("X-Forwarded-Proto")); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func formatUnix(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
+
+func formatUnixPtr(ts *int64) *string {
+	if ts == nil {
+		return nil
+	}
+	formatted := formatUnix(*ts)
+	return &formatted
+}
@@ -0,0 +1,250 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type batchTestExecutor struct {
+	provider string
+
+	mu      sync.Mutex
+	authIDs []string
+}
+
+func (e *batchTestExecutor) Identifier() string { return e.provider }
+
+func (e *batchTestExecutor) Execute(_ context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.authIDs = append(e.authIDs, auth.ID)
+	e.mu.Unlock()
+	if strings.Contains(string(req.Payload), "boom") {
+		return coreexecutor.Response{}, errors.New("upstream exploded")
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}]}`)}, nil
+}
+
+func (e *batchTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *batchTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *batchTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *batchTestExecutor) HttpRequest(ctx context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	req.Header.Set("x-api-key", auth.Attributes["api_key"])
+	return http.DefaultClient.Do(req.WithContext(ctx))
+}
+
+func newBatchTestRouter(t *testing.T, provider, tenant string, auths ...*coreauth.Auth) (*gin.Engine, *batchTestExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := sdkAuth.NewFileTokenStore()
+	store.SetBaseDir(t.TempDir())
+	previous := sdkAuth.GetTokenStore()
+	sdkAuth.RegisterTokenStore(store)
+	t.Cleanup(func() { sdkAuth.RegisterTokenStore(previous) })
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	executor := &batchTestExecutor{provider: provider}
+	manager.RegisterExecutor(executor)
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register auth: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "claude-test"}})
+		id := auth.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	h := NewClaudeBatchAPIHandler(base, NewClaudeCodeAPIHandler(base))
+	t.Cleanup(func() { _ = h.Stop(context.Background()) })
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("apiKey", "client-key")
+		if tenant != "" {
+			c.Set("tenant", tenant)
+		}
+	})
+	router.POST("/v1/messages/batches", h.CreateBatch)
+	router.GET("/v1/messages/batches", h.ListBatches)
+	router.GET("/v1/messages/batches/:batch_id", h.RetrieveBatch)
+	router.GET("/v1/messages/batches/:batch_id/results", h.BatchResults)
+	return router, executor
+}
+
+func serveBatchRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestClaudeBatchRunsLocally(t *testing.T) {
+	auth := &coreauth.Auth{ID: "batch-local", Provider: "batch-test-provider", Status: coreauth.StatusActive}
+	router, _ := newBatchTestRouter(t, "batch-test-provider", "", auth)
+
+	resp := serveBatchRequest(router, http.MethodPost, "/v1/messages/batches", `{"requests":[
+		{"custom_id":"first","params":{"model":"claude-test","max_tokens":16,"messages":[{"role":"user","content":"hello"}]}},
+		{"custom_id":"second","params":{"model":"claude-test","max_tokens":16,"messages":[{"role":"user","content":"boom"}]}}
+	]}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d: %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+	if !strings.HasPrefix(id, "msgbatch_") || gjson.Get(resp.Body.String(), "request_counts.processing").Int() != 2 {
+		t.Fatalf("unexpected create response: %s", resp.Body.String())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var retrieved string
+	for time.Now().Before(deadline) {
+		retrieved = serveBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id, "").Body.String()
+		if gjson.Get(retrieved, "processing_status").String() == "ended" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if gjson.Get(retrieved, "processing_status").String() != "ended" {
+		t.Fatalf("batch did not end: %s", retrieved)
+	}
+	if gjson.Get(retrieved, "request_counts.succeeded").Int() != 1 || gjson.Get(retrieved, "request_counts.errored").Int() != 1 {
+		t.Fatalf("unexpected counts: %s", retrieved)
+	}
+	if !strings.HasSuffix(gjson.Get(retrieved, "results_url").String(), "/v1/messages/batches/"+id+"/results") {
+		t.Fatalf("unexpected results_url: %s", retrieved)
+	}
+
+	results := serveBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id+"/results", "").Body.String()
+	types := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(results), "\n") {
+		types[gjson.Get(line, "custom_id").String()] = gjson.Get(line, "result.type").String()
+		if gjson.Get(line, "result.type").String() == "errored" && gjson.Get(line, "result.error.type").String() != "error" {
+			t.Fatalf("errored result must carry an Anthropic error: %s", line)
+		}
+	}
+	if types["first"] != "succeeded" || types["second"] != "errored" {
+		t.Fatalf("unexpected results:\n%s", results)
+	}
+
+	list := serveBatchRequest(router, http.MethodGet, "/v1/messages/batches?limit=1", "").Body.String()
+	if gjson.Get(list, "data.0.id").String() != id || gjson.Get(list, "first_id").String() != id {
+		t.Fatalf("unexpected list: %s", list)
+	}
+}
+
+func TestClaudeBatchPassthroughToUpstream(t *testing.T) {
+	var created string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "sk-upstream" || r.Header.Get("anthropic-version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches":
+			body, _ := io.ReadAll(r.Body)
+			created = string(body)
+			_, _ = w.Write([]byte(`{"id":"msgbatch_upstream1","type":"message_batch","processing_status":"in_progress","results_url":null}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_upstream1":
+			_, _ = w.Write([]byte(`{"id":"msgbatch_upstream1","type":"message_batch","processing_status":"ended","results_url":"https://api.anthropic.com/v1/messages/batches/msgbatch_upstream1/results"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_upstream1/results":
+			_, _ = w.Write([]byte(`{"custom_id":"a","result":{"type":"succeeded"}}` + "\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	auth := &coreauth.Auth{
+		ID:         "batch-upstream",
+		Provider:   "claude",
+		Status:     coreauth.StatusActive,
+		Attributes: map[string]string{"api_key": "sk-upstream", "base_url": upstream.URL, "batches": "true"},
+	}
+	router, _ := newBatchTestRouter(t, "claude", "", auth)
+
+	resp := serveBatchRequest(router, http.MethodPost, "/v1/messages/batches", `{"requests":[{"custom_id":"a","params":{"model":"claude-test","max_tokens":8,"messages":[]}}]}`)
+	if resp.Code != http.StatusOK || gjson.Get(resp.Body.String(), "id").String() != "msgbatch_upstream1" {
+		t.Fatalf("create: %d %s", resp.Code, resp.Body.String())
+	}
+	if gjson.Get(created, "requests.0.custom_id").String() != "a" {
+		t.Fatalf("upstream did not receive the batch: %s", created)
+	}
+
+	retrieved := serveBatchRequest(router, http.MethodGet, "/v1/messages/batches/msgbatch_upstream1", "").Body.String()
+	if got := gjson.Get(retrieved, "results_url").String(); got != "http://example.com/v1/messages/batches/msgbatch_upstream1/results" {
+		t.Fatalf("results_url = %q", got)
+	}
+	results := serveBatchRequest(router, http.MethodGet, "/v1/messages/batches/msgbatch_upstream1/results", "").Body.String()
+	if gjson.Get(results, "result.type").String() != "succeeded" {
+		t.Fatalf("unexpected results: %s", results)
+	}
+}
+
+func TestClaudeBatchOfTenantUsesOnlyTenantAuths(t *testing.T) {
+	shared := &coreauth.Auth{ID: "batch-shared", Provider: "batch-test-provider", Status: coreauth.StatusActive}
+	owned := &coreauth.Auth{
+		ID:         "batch-tenant",
+		Provider:   "batch-test-provider",
+		Status:     coreauth.StatusActive,
+		Attributes: map[string]string{coreauth.AttributeTenant: "acme"},
+	}
+	router, executor := newBatchTestRouter(t, "batch-test-provider", "acme", shared, owned)
+
+	var requests []string
+	for _, id := range []string{"a", "b", "c", "d"} {
+		requests = append(requests, `{"custom_id":"`+id+`","params":{"model":"claude-test","max_tokens":8,"messages":[{"role":"user","content":"hi"}]}}`)
+	}
+	resp := serveBatchRequest(router, http.MethodPost, "/v1/messages/batches", `{"requests":[`+strings.Join(requests, ",")+`]}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d: %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+
+	deadline := time.Now().Add(5 * time.Second)
+	var retrieved string
+	for time.Now().Before(deadline) {
+		retrieved = serveBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id, "").Body.String()
+		if gjson.Get(retrieved, "processing_status").String() == "ended" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if gjson.Get(retrieved, "request_counts.succeeded").Int() != 4 {
+		t.Fatalf("unexpected counts: %s", retrieved)
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if len(executor.authIDs) != 4 {
+		t.Fatalf("executions = %v", executor.authIDs)
+	}
+	for _, authID := range executor.authIDs {
+		if authID != owned.ID {
+			t.Fatalf("tenant batch executed on %s", authID)
+		}
+	}
+}
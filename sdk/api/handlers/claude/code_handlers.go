@@ -312,7 +312,11 @@ type claudeErrorResponse struct {
 	Error claudeErrorDetail `json:"error"`
 }
 
-func (h *ClaudeCodeAPIHandler) toClaudeError(msg *interfaces.ErrorMessage) claudeErrorResponse {
+func (h *ClaudeCodeAPIHandler) toClaudeError(msg *interfaces.ErrorMessage) any {
+	// Errors that are already Claude-shaped (e.g. structured output validation) pass through.
+	if raw := []byte(msg.Error.Error()); json.Valid(raw) && gjson.GetBytes(raw, "type").String() == "error" {
+		return json.RawMessage(raw)
+	}
 	return claudeErrorResponse{
 		Type: "error",
 		Error: claudeErrorDetail{
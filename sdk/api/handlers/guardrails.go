@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// guardrailsLogKey holds the guardrail decisions of a request for the request log.
+const guardrailsLogKey = "API_GUARDRAILS"
+
+// withGuardrailLog exposes the guardrail decisions taken by the auth manager to the request log.
+func withGuardrailLog(ctx context.Context) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ctx
+	}
+	return coreauth.WithGuardrailRecorder(ctx, func(entry []byte) {
+		ginCtx.Set(guardrailsLogKey, entry)
+	})
+}
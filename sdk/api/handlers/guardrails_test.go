@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newGuardrailsHandler(t *testing.T, executor *scriptedExecutor, rules ...sdkconfig.GuardrailRule) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "guardrails-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	cfg := &sdkconfig.Config{SDKConfig: sdkconfig.SDKConfig{
+		Guardrails: sdkconfig.GuardrailsConfig{Enabled: true, Rules: rules},
+	}}
+	manager.SetConfig(cfg)
+	return NewBaseAPIHandlers(&cfg.SDKConfig, manager)
+}
+
+func TestExecuteWithAuthManager_GuardrailsTokenizeRoundTrip(t *testing.T) {
+	executor := &scriptedExecutor{contents: []string{"Sent to [[EMAIL_1]]."}}
+	handler := newGuardrailsHandler(t, executor, sdkconfig.GuardrailRule{
+		Name:      "pii",
+		Action:    "tokenize",
+		Detectors: []sdkconfig.GuardrailDetector{{Type: "email"}},
+	})
+
+	request := `{"model":"test-model","messages":[{"role":"user","content":"email jane@corp.io"}]}`
+	resp, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "test-model", []byte(request), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if len(executor.requests) != 1 || strings.Contains(string(executor.requests[0]), "jane@corp.io") {
+		t.Fatalf("original value reached the upstream: %s", executor.requests)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != "Sent to jane@corp.io." {
+		t.Fatalf("content = %q", got)
+	}
+}
+
+func TestExecuteWithAuthManager_GuardrailsBlock(t *testing.T) {
+	executor := &scriptedExecutor{contents: []string{"ok"}}
+	handler := newGuardrailsHandler(t, executor, sdkconfig.GuardrailRule{
+		Name:      "secrets",
+		Action:    "block",
+		Detectors: []sdkconfig.GuardrailDetector{{Type: "api-key"}},
+	})
+
+	request := `{"model":"test-model","messages":[{"role":"user","content":"use sk-abcdefghijklmnopqrstuvwxyz"}]}`
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "test-model", []byte(request), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected block, got %+v", errMsg)
+	}
+	if len(executor.requests) != 0 {
+		t.Fatalf("blocked request reached the upstream")
+	}
+}
+
+func TestExecuteStreamWithAuthManager_GuardrailsRedact(t *testing.T) {
+	executor := &scriptedExecutor{}
+	handler := newGuardrailsHandler(t, executor, sdkconfig.GuardrailRule{
+		Name:      "answers",
+		Action:    "redact",
+		Direction: "response",
+		Detectors: []sdkconfig.GuardrailDetector{{Type: "regex", Pattern: `42`}},
+	})
+
+	request := `{"model":"test-model","stream":true,"messages":[{"role":"user","content":"answer"}]}`
+	data, errs := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "test-model", []byte(request), "")
+	var streamed strings.Builder
+	for chunk := range data {
+		streamed.Write(chunk)
+	}
+	if errMsg := <-errs; errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if strings.Contains(streamed.String(), "42") {
+		t.Fatalf("redacted value reached the client: %s", streamed.String())
+	}
+}
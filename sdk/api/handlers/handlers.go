@@ -18,6 +18,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/structuredoutput"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -188,10 +189,12 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// It is forwarded as execution metadata; when absent we generate a UUID.
 	key := ""
 	clientKey := ""
+	tenant := ""
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
 			clientKey = clientAPIKeyFromGin(ginCtx)
+			tenant = ginCtx.GetString("tenant")
 		}
 	}
 	if key == "" {
@@ -201,6 +204,9 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	if clientKey != "" {
 		meta[coreexecutor.ClientAPIKeyMetadataKey] = clientKey
 	}
+	if tenant != "" {
+		meta[coreexecutor.TenantMetadataKey] = tenant
+	}
 	return meta
 }
 
@@ -411,6 +417,14 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	// ClientAPIKeys lists every client API key accepted by the server, including keys that
+	// are not part of Cfg such as tenant keys. When nil, Cfg.APIKeys is used.
+	ClientAPIKeys func() []string
+
+	// ReplayMiddleware runs ahead of the endpoint handler for replayed requests such as batch
+	// lines, so they are authenticated and held to budgets like live traffic.
+	ReplayMiddleware []gin.HandlerFunc
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -510,11 +524,12 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
+		done := newCtx.Done()
 		go func() {
 			select {
 			case <-requestCtx.Done():
 				cancel()
-			case <-newCtx.Done():
+			case <-done:
 			}
 		}()
 	}
@@ -638,6 +653,7 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	modelName, rawJSON = h.applyPromptProfiles(ctx, handlerType, modelName, rawJSON)
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
@@ -649,6 +665,7 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		reqMeta[coreexecutor.SessionIDMetadataKey] = sessionID
 		ctx = coreauth.WithSessionID(ctx, sessionID)
 	}
+	ctx = withGuardrailLog(ctx)
 	updateMonitorRequestContext(ctx, handlerType, normalizedModel, sessionID)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -661,6 +678,13 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
+	spec, errMsg := h.structuredOutputSpec(handlerType, normalizedRawJSON, alt)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if errPreflight := h.contextPreflight(handlerType, normalizedModel, req.Payload); errPreflight != nil {
+		return nil, errPreflight
+	}
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
@@ -677,12 +701,19 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	return cloneBytes(resp.Payload), nil
+	payload := cloneBytes(resp.Payload)
+	if spec != nil {
+		if payload, errMsg = h.enforceStructuredOutput(ctx, spec, handlerType, providers, req, opts, payload); errMsg != nil {
+			return nil, errMsg
+		}
+	}
+	return payload, nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	modelName, rawJSON = h.applyPromptProfiles(ctx, handlerType, modelName, rawJSON)
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
@@ -694,6 +725,7 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		reqMeta[coreexecutor.SessionIDMetadataKey] = sessionID
 		ctx = coreauth.WithSessionID(ctx, sessionID)
 	}
+	ctx = withGuardrailLog(ctx)
 	updateMonitorRequestContext(ctx, handlerType, normalizedModel, sessionID)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -708,6 +740,9 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	opts.Metadata = reqMeta
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
 	if err != nil {
+		if local, ok := h.localCountFallback(ctx, handlerType, normalizedModel, req.Payload, err); ok {
+			return local, nil
+		}
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -728,6 +763,7 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	modelName, rawJSON = h.applyPromptProfiles(ctx, handlerType, modelName, rawJSON)
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -742,6 +778,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		reqMeta[coreexecutor.SessionIDMetadataKey] = sessionID
 		ctx = coreauth.WithSessionID(ctx, sessionID)
 	}
+	ctx = withGuardrailLog(ctx)
 	updateMonitorRequestContext(ctx, handlerType, normalizedModel, sessionID)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -754,6 +791,19 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
+	spec, specErr := h.structuredOutputSpec(handlerType, normalizedRawJSON, alt)
+	if specErr != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- specErr
+		close(errChan)
+		return nil, errChan
+	}
+	if errPreflight := h.contextPreflight(handlerType, normalizedModel, req.Payload); errPreflight != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errPreflight
+		close(errChan)
+		return nil, errChan
+	}
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -780,6 +830,10 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		defer close(errChan)
 		sentPayload := false
 		bootstrapRetries := 0
+		var accumulator *structuredoutput.StreamAccumulator
+		if spec != nil {
+			accumulator = structuredoutput.NewStreamAccumulator(handlerType)
+		}
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
@@ -837,6 +891,14 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					// Streamed output cannot be re-asked; report a schema mismatch after the fact.
+					if accumulator != nil {
+						if output := accumulator.Output(); !output.ToolCall {
+							if errs := spec.Validate(output.Text); len(errs) > 0 {
+								_ = sendErr(structuredOutputError(handlerType, errs))
+							}
+						}
+					}
 					return
 				}
 				if chunk.Err != nil {
@@ -872,6 +934,9 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				}
 				if len(chunk.Payload) > 0 {
 					sentPayload = true
+					if accumulator != nil {
+						accumulator.Add(chunk.Payload)
+					}
 					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
 						return
 					}
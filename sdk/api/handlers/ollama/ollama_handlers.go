@@ -0,0 +1,400 @@
+// Package ollama provides HTTP handlers for the Ollama API.
+// It exposes /api/chat, /api/generate, /api/tags, /api/show and /api/version so IDE plugins
+// and agent frameworks that only speak the Ollama protocol can use every configured backend.
+// Requests are translated into the OpenAI Chat Completions format, executed through the auth
+// manager like any other request, and the responses are converted back into Ollama objects,
+// streamed as newline-delimited JSON.
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ollamaVersion is the Ollama API version reported to clients that gate features on it.
+const ollamaVersion = "0.12.6"
+
+// OllamaAPIHandler contains the handlers for Ollama API endpoints.
+type OllamaAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOllamaAPIHandler creates a new Ollama API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OllamaAPIHandler: A new Ollama API handlers instance
+func NewOllamaAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OllamaAPIHandler {
+	return &OllamaAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OllamaAPIHandler) HandlerType() string {
+	return Ollama
+}
+
+// Models returns the Ollama-compatible model metadata supported by this handler.
+func (h *OllamaAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return modelRegistry.GetAvailableModels(Ollama)
+}
+
+// Tags handles the /api/tags endpoint, listing the models available to the client.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OllamaAPIHandler) Tags(c *gin.Context) {
+	models := h.AvailableModelsForRequest(c, Ollama)
+	sort.Slice(models, func(i, j int) bool {
+		return fmt.Sprint(models[i]["name"]) < fmt.Sprint(models[j]["name"])
+	})
+	c.JSON(http.StatusOK, gin.H{"models": models})
+}
+
+// Version handles the /api/version endpoint.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OllamaAPIHandler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"version": ollamaVersion})
+}
+
+// Show handles the /api/show endpoint, describing a single model.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OllamaAPIHandler) Show(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeOllamaError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		modelName = strings.TrimSpace(gjson.GetBytes(rawJSON, "name").String())
+	}
+	if modelName == "" {
+		writeOllamaError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+
+	var model map[string]any
+	for _, candidate := range h.AvailableModelsForRequest(c, Ollama) {
+		if candidate["name"] == modelName {
+			model = candidate
+			break
+		}
+	}
+	if model == nil {
+		writeOllamaError(c, http.StatusNotFound, fmt.Sprintf("model '%s' not found", modelName))
+		return
+	}
+
+	details, _ := model["details"].(map[string]any)
+	family, _ := details["family"].(string)
+	modelInfo := map[string]any{"general.architecture": family}
+	if contextLength, ok := model["context_length"]; ok {
+		modelInfo[family+".context_length"] = contextLength
+	}
+	capabilities := []string{"completion", "tools"}
+	if info := registry.GetGlobalRegistry().GetModelInfo(modelName, ""); info != nil && info.Thinking != nil {
+		capabilities = append(capabilities, "thinking")
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"modelfile":    "",
+		"parameters":   "",
+		"template":     "{{ .Prompt }}",
+		"details":      details,
+		"model_info":   modelInfo,
+		"capabilities": capabilities,
+		"modified_at":  model["modified_at"],
+	})
+}
+
+// Chat handles the /api/chat endpoint. Responses stream by default, as in Ollama.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OllamaAPIHandler) Chat(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeOllamaError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String()) == "" {
+		writeOllamaError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	h.handleRequest(c, rawJSON, nil)
+}
+
+// Generate handles the /api/generate endpoint by running the prompt as a single-turn chat
+// and reporting the reply in the generate response shape.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OllamaAPIHandler) Generate(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeOllamaError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeOllamaError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	// An empty prompt only asks Ollama to load the model; there is nothing to load here.
+	if gjson.GetBytes(rawJSON, "prompt").String() == "" && !gjson.GetBytes(rawJSON, "images").IsArray() {
+		out := `{"model":"","created_at":"","response":"","done":true,"done_reason":"load"}`
+		out, _ = sjson.Set(out, "model", modelName)
+		out, _ = sjson.Set(out, "created_at", nowRFC3339())
+		c.Data(http.StatusOK, "application/json", []byte(out))
+		return
+	}
+	h.handleRequest(c, convertGenerateRequestToChat(rawJSON), convertChatResponseToGenerate)
+}
+
+// handleRequest executes an Ollama chat request. When convert is set, every Ollama chat
+// object is rewritten before it is written to the client.
+func (h *OllamaAPIHandler) handleRequest(c *gin.Context, rawJSON []byte, convert func(string) string) {
+	if convert == nil {
+		convert = func(s string) string { return s }
+	}
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	stream := gjson.GetBytes(rawJSON, "stream").Type != gjson.False
+	openAIJSON := sdktranslator.TranslateRequest(sdktranslator.FormatOllama, sdktranslator.FormatOpenAI, modelName, rawJSON, stream)
+
+	if stream {
+		h.handleStreamingResponse(c, modelName, rawJSON, openAIJSON, convert)
+	} else {
+		h.handleNonStreamingResponse(c, modelName, rawJSON, openAIJSON, convert)
+	}
+}
+
+func (h *OllamaAPIHandler) handleNonStreamingResponse(c *gin.Context, modelName string, rawJSON, openAIJSON []byte, convert func(string) string) {
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, OpenAI, modelName, openAIJSON, "")
+	stopKeepAlive()
+	if errMsg != nil {
+		h.writeErrorMessage(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(cliCtx, sdktranslator.FormatOpenAI, sdktranslator.FormatOllama, modelName, rawJSON, openAIJSON, resp, &param)
+	_, _ = c.Writer.Write([]byte(convert(out)))
+	cliCancel()
+}
+
+func (h *OllamaAPIHandler) handleStreamingResponse(c *gin.Context, modelName string, rawJSON, openAIJSON []byte, convert func(string) string) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		writeOllamaError(c, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, OpenAI, modelName, openAIJSON, "")
+
+	var param any
+	translate := func(chunk []byte) []byte {
+		var buf []byte
+		for _, line := range sdktranslator.TranslateStream(cliCtx, sdktranslator.FormatOpenAI, sdktranslator.FormatOllama, modelName, rawJSON, openAIJSON, chunk, &param) {
+			buf = append(buf, convert(line)...)
+			buf = append(buf, '\n')
+		}
+		return buf
+	}
+	setHeaders := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+	}
+
+	// Peek at the first chunk so upstream failures still produce a proper error status.
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cliCancel(c.Request.Context().Err())
+			return
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			h.writeErrorMessage(c, errMsg)
+			if errMsg != nil {
+				cliCancel(errMsg.Error)
+			} else {
+				cliCancel(nil)
+			}
+			return
+		case chunk, ok := <-dataChan:
+			if !ok {
+				setHeaders()
+				_, _ = c.Writer.Write(translate([]byte("[DONE]")))
+				flusher.Flush()
+				cliCancel(nil)
+				return
+			}
+
+			setHeaders()
+			if first := translate(chunk); len(first) > 0 {
+				_, _ = c.Writer.Write(first)
+				flusher.Flush()
+			}
+
+			done := make(chan struct{})
+			var doneOnce sync.Once
+			stop := func() { doneOnce.Do(func() { close(done) }) }
+
+			convertedChan := make(chan []byte)
+			go func() {
+				defer close(convertedChan)
+				for {
+					select {
+					case <-done:
+						return
+					case chunk, ok := <-dataChan:
+						if !ok {
+							return
+						}
+						converted := translate(chunk)
+						if len(converted) == 0 {
+							continue
+						}
+						select {
+						case <-done:
+							return
+						case convertedChan <- converted:
+						}
+					}
+				}
+			}()
+
+			// NDJSON has no comment syntax, so keep-alive heartbeats are disabled.
+			noKeepAlive := time.Duration(0)
+			h.ForwardStream(c, flusher, func(err error) {
+				stop()
+				cliCancel(err)
+			}, convertedChan, errChan, handlers.StreamForwardOptions{
+				KeepAliveInterval: &noKeepAlive,
+				WriteChunk: func(chunk []byte) {
+					_, _ = c.Writer.Write(chunk)
+				},
+				WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
+					_, message := ollamaErrorFromMessage(errMsg)
+					body, _ := sjson.Set(`{}`, "error", message)
+					_, _ = c.Writer.Write([]byte(body + "\n"))
+				},
+				WriteDone: func() {
+					_, _ = c.Writer.Write(translate([]byte("[DONE]")))
+				},
+			})
+			return
+		}
+	}
+}
+
+// writeErrorMessage writes an upstream error in the Ollama {"error": "..."} shape.
+func (h *OllamaAPIHandler) writeErrorMessage(c *gin.Context, msg *interfaces.ErrorMessage) {
+	if msg != nil {
+		for key, values := range msg.Addon {
+			c.Writer.Header().Del(key)
+			for _, value := range values {
+				c.Writer.Header().Add(key, value)
+			}
+		}
+	}
+	status, message := ollamaErrorFromMessage(msg)
+	writeOllamaError(c, status, message)
+}
+
+func ollamaErrorFromMessage(msg *interfaces.ErrorMessage) (int, string) {
+	status := http.StatusInternalServerError
+	if msg != nil && msg.StatusCode > 0 {
+		status = msg.StatusCode
+	}
+	message := http.StatusText(status)
+	if msg != nil && msg.Error != nil {
+		if text := strings.TrimSpace(msg.Error.Error()); text != "" {
+			message = text
+			// Upstream errors usually carry a JSON body; surface only its message.
+			if gjson.Valid(text) {
+				if inner := gjson.Get(text, "error.message").String(); inner != "" {
+					message = inner
+				} else if inner = gjson.Get(text, "error").String(); inner != "" && !gjson.Get(text, "error").IsObject() {
+					message = inner
+				}
+			}
+		}
+	}
+	return status, message
+}
+
+func writeOllamaError(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{"error": message})
+}
+
+// convertGenerateRequestToChat turns an /api/generate request into an /api/chat request with
+// an optional system message followed by the prompt.
+func convertGenerateRequestToChat(rawJSON []byte) []byte {
+	root := gjson.ParseBytes(rawJSON)
+	out := `{"model":"","messages":[]}`
+	out, _ = sjson.Set(out, "model", root.Get("model").String())
+	for _, key := range []string{"stream", "format", "options", "think", "keep_alive"} {
+		if value := root.Get(key); value.Exists() {
+			out, _ = sjson.SetRaw(out, key, value.Raw)
+		}
+	}
+	if system := root.Get("system").String(); system != "" {
+		msg, _ := sjson.Set(`{"role":"system"}`, "content", system)
+		out, _ = sjson.SetRaw(out, "messages.-1", msg)
+	}
+	msg, _ := sjson.Set(`{"role":"user"}`, "content", root.Get("prompt").String())
+	if images := root.Get("images"); images.IsArray() {
+		msg, _ = sjson.SetRaw(msg, "images", images.Raw)
+	}
+	out, _ = sjson.SetRaw(out, "messages.-1", msg)
+	return []byte(out)
+}
+
+// convertChatResponseToGenerate rewrites an Ollama chat object into the generate shape,
+// moving the assistant message into the response and thinking fields.
+func convertChatResponseToGenerate(chat string) string {
+	message := gjson.Get(chat, "message")
+	if !message.Exists() {
+		return chat
+	}
+	out, _ := sjson.Delete(chat, "message")
+	out, _ = sjson.Set(out, "response", message.Get("content").String())
+	if thinking := message.Get("thinking").String(); thinking != "" {
+		out, _ = sjson.Set(out, "thinking", thinking)
+	}
+	return out
+}
+
+func nowRFC3339() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
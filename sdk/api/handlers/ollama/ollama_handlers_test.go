@@ -0,0 +1,135 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type ollamaTestExecutor struct{}
+
+func (e *ollamaTestExecutor) Identifier() string { return "ollama-test-provider" }
+
+func (e *ollamaTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if gjson.GetBytes(req.Payload, "messages.0.role").String() != "system" {
+		return coreexecutor.Response{}, errors.New("expected system message first")
+	}
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}],"usage":{"prompt_tokens":4,"completion_tokens":1}}`)}, nil
+}
+
+func (e *ollamaTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk, 3)
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"choices":[{"index":0,"delta":{"content":"Hel"}}]}`)}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"length"}]}`)}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2}}`)}
+	close(ch)
+	return ch, nil
+}
+
+func (e *ollamaTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *ollamaTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *ollamaTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newOllamaTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&ollamaTestExecutor{})
+	auth := &coreauth.Auth{ID: "ollama-auth", Provider: "ollama-test-provider", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "ollama-test-model", OwnedBy: "tester"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOllamaAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.GET("/api/tags", h.Tags)
+	router.POST("/api/show", h.Show)
+	router.POST("/api/chat", h.Chat)
+	router.POST("/api/generate", h.Generate)
+	return router
+}
+
+func serveOllama(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestOllamaChatStreamsNDJSON(t *testing.T) {
+	router := newOllamaTestRouter(t)
+
+	resp := serveOllama(router, http.MethodPost, "/api/chat", `{"model":"ollama-test-model","messages":[{"role":"user","content":"hi"}]}`)
+	if resp.Code != http.StatusOK || resp.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("status = %d, content type = %q: %s", resp.Code, resp.Header().Get("Content-Type"), resp.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 NDJSON lines, got %d:\n%s", len(lines), resp.Body.String())
+	}
+	var text strings.Builder
+	for _, line := range lines[:2] {
+		text.WriteString(gjson.Get(line, "message.content").String())
+	}
+	if text.String() != "Hello" {
+		t.Fatalf("streamed content = %q", text.String())
+	}
+	last := gjson.Parse(lines[2])
+	if !last.Get("done").Bool() || last.Get("done_reason").String() != "length" || last.Get("eval_count").Int() != 2 || last.Get("model").String() != "ollama-test-model" {
+		t.Fatalf("unexpected final line: %s", lines[2])
+	}
+}
+
+func TestOllamaGenerateAndModelEndpoints(t *testing.T) {
+	router := newOllamaTestRouter(t)
+
+	resp := serveOllama(router, http.MethodPost, "/api/generate", `{"model":"ollama-test-model","system":"terse","prompt":"ping","stream":false}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("generate status = %d: %s", resp.Code, resp.Body.String())
+	}
+	body := gjson.Parse(resp.Body.String())
+	if body.Get("response").String() != "pong" || body.Get("message").Exists() || !body.Get("done").Bool() || body.Get("prompt_eval_count").Int() != 4 {
+		t.Fatalf("unexpected generate response: %s", resp.Body.String())
+	}
+
+	tags := serveOllama(router, http.MethodGet, "/api/tags", "").Body.String()
+	found := false
+	for _, model := range gjson.Get(tags, "models").Array() {
+		if model.Get("name").String() == "ollama-test-model" && model.Get("details.family").String() == "tester" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("model missing from tags: %s", tags)
+	}
+
+	if show := serveOllama(router, http.MethodPost, "/api/show", `{"model":"ollama-test-model"}`); show.Code != http.StatusOK || !gjson.Get(show.Body.String(), "capabilities").IsArray() {
+		t.Fatalf("show: %d %s", show.Code, show.Body.String())
+	}
+	missing := serveOllama(router, http.MethodPost, "/api/show", `{"model":"nope"}`)
+	if missing.Code != http.StatusNotFound || gjson.Get(missing.Body.String(), "error").String() != "model 'nope' not found" {
+		t.Fatalf("missing model: %d %s", missing.Code, missing.Body.String())
+	}
+}
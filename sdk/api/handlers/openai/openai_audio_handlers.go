@@ -0,0 +1,226 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	audioconverter "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/audio"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxAudioUploadBytes mirrors the OpenAI 25 MB upload limit; larger request bodies get 413.
+const maxAudioUploadBytes = 25 << 20
+
+// OpenAIAudioAPIHandler serves the OpenAI Audio API (/v1/audio/transcriptions,
+// /v1/audio/translations and /v1/audio/speech) on top of Gemini multimodal and TTS
+// models. Requests are translated to Gemini generateContent payloads and executed
+// through the auth manager like any other Gemini request.
+type OpenAIAudioAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOpenAIAudioAPIHandler creates a new OpenAI Audio API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OpenAIAudioAPIHandler: A new OpenAI Audio API handlers instance
+func NewOpenAIAudioAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIAudioAPIHandler {
+	return &OpenAIAudioAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIAudioAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIAudioAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return modelRegistry.GetAvailableModels("openai")
+}
+
+// Transcriptions handles the /v1/audio/transcriptions endpoint.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAudioAPIHandler) Transcriptions(c *gin.Context) {
+	h.handleTranscription(c, "transcribe")
+}
+
+// Translations handles the /v1/audio/translations endpoint (speech to English text).
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAudioAPIHandler) Translations(c *gin.Context) {
+	h.handleTranscription(c, "translate")
+}
+
+func (h *OpenAIAudioAPIHandler) handleTranscription(c *gin.Context, task string) {
+	if !parseLimitedMultipartForm(c, maxAudioUploadBytes) {
+		return
+	}
+	payload := `{}`
+	payload, _ = sjson.Set(payload, "task", task)
+	for _, field := range []string{"model", "prompt", "language", "response_format"} {
+		if value := strings.TrimSpace(c.Request.FormValue(field)); value != "" {
+			payload, _ = sjson.Set(payload, field, value)
+		}
+	}
+	if value := strings.TrimSpace(c.Request.FormValue("temperature")); value != "" {
+		temperature, errParse := strconv.ParseFloat(value, 64)
+		if errParse != nil {
+			writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: temperature must be a number")
+			return
+		}
+		payload, _ = sjson.Set(payload, "temperature", temperature)
+	}
+
+	modelName := gjson.Get(payload, "model").String()
+	if modelName == "" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: model is required")
+		return
+	}
+	responseFormat := gjson.Get(payload, "response_format").String()
+	switch responseFormat {
+	case "", "json", "text", "verbose_json", "srt", "vtt":
+	default:
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: response_format must be one of json, text, verbose_json, srt, vtt")
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: file is required")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: cannot open file: %v", err))
+		return
+	}
+	data, err := io.ReadAll(file)
+	_ = file.Close()
+	if err != nil {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: cannot read file: %v", err))
+		return
+	}
+	mimeType := audioMimeType(fileHeader.Filename, fileHeader.Header.Get("Content-Type"), data)
+	if !strings.HasPrefix(mimeType, "audio/") && !strings.HasPrefix(mimeType, "video/") {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: unsupported file type %s", mimeType))
+		return
+	}
+	payload, _ = sjson.Set(payload, "file.mime_type", mimeType)
+	payload, _ = sjson.Set(payload, "file.data", base64.StdEncoding.EncodeToString(data))
+
+	geminiRequest := audioconverter.ConvertOpenAITranscriptionRequestToGemini(modelName, []byte(payload))
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, Gemini, modelName, geminiRequest, "")
+	stopKeepAlive()
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+
+	out, contentType := audioconverter.ConvertGeminiResponseToOpenAITranscription(resp, responseFormat, task)
+	c.Header("Content-Type", contentType)
+	_, _ = c.Writer.Write(out)
+	cliCancel()
+}
+
+// Speech handles the /v1/audio/speech endpoint using Gemini TTS models.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAudioAPIHandler) Speech(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: body must be valid JSON")
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: model is required")
+		return
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "input").String()) == "" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: input is required")
+		return
+	}
+	if gjson.GetBytes(rawJSON, "stream_format").String() == "sse" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: stream_format sse is not supported")
+		return
+	}
+
+	geminiRequest := audioconverter.ConvertOpenAISpeechRequestToGemini(modelName, rawJSON)
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, Gemini, modelName, geminiRequest, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+
+	audio, contentType, errAudio := audioconverter.ConvertGeminiResponseToOpenAISpeech(resp, gjson.GetBytes(rawJSON, "response_format").String())
+	if errAudio != nil {
+		errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("model %s: %w", modelName, errAudio)}
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	c.Data(http.StatusOK, contentType, audio)
+	cliCancel()
+}
+
+// audioMimeType resolves the MIME type of an uploaded audio file, preferring the
+// part header, then the file extension, then content sniffing. Types are normalised
+// to the spellings accepted by Gemini (audio/wav, audio/mp3, audio/flac, ...).
+func audioMimeType(filename, declared string, data []byte) string {
+	mimeType := strings.ToLower(strings.TrimSpace(declared))
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+		if byExt, ok := misc.MimeTypes[ext]; ok {
+			mimeType = byExt
+		} else if ext == "mpga" || ext == "mpeg" {
+			mimeType = "audio/mpeg"
+		} else {
+			mimeType = http.DetectContentType(data)
+		}
+	}
+	if base, _, found := strings.Cut(mimeType, ";"); found {
+		mimeType = strings.TrimSpace(base)
+	}
+	switch mimeType {
+	case "audio/x-wav", "audio/wave", "audio/vnd.wave":
+		return "audio/wav"
+	case "audio/mpeg", "audio/mpeg3", "audio/x-mpeg-3":
+		return "audio/mp3"
+	case "audio/x-flac":
+		return "audio/flac"
+	case "audio/x-aiff":
+		return "audio/aiff"
+	case "audio/x-m4a", "audio/m4a":
+		return "audio/mp4"
+	}
+	return mimeType
+}
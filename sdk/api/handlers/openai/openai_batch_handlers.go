@@ -0,0 +1,280 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 10000
+)
+
+// OpenAIBatchAPIHandler serves the OpenAI Files API (/v1/files, purpose "batch") and
+// Batch API (/v1/batches). Batches run in the background: every input line is replayed
+// through the matching endpoint handler on behalf of the client API key that created
+// the batch, and results are collected into output and error files.
+type OpenAIBatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	manager *batch.Manager
+}
+
+// NewOpenAIBatchAPIHandler creates a new OpenAI Batch API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//   - chatHandlers: The handlers used for /v1/chat/completions and /v1/completions lines
+//   - responsesHandlers: The handlers used for /v1/responses lines
+//
+// Returns:
+//   - *OpenAIBatchAPIHandler: A new OpenAI Batch API handlers instance
+func NewOpenAIBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, chatHandlers *OpenAIAPIHandler, responsesHandlers *OpenAIResponsesAPIHandler) *OpenAIBatchAPIHandler {
+	h := &OpenAIBatchAPIHandler{BaseAPIHandler: apiHandlers}
+	h.manager = apiHandlers.NewBatchManager("batch", map[string]gin.HandlerFunc{
+		"/v1/chat/completions": chatHandlers.ChatCompletions,
+		"/v1/completions":      chatHandlers.Completions,
+		"/v1/responses":        responsesHandlers.Responses,
+	})
+	return h
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIBatchAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIBatchAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return modelRegistry.GetAvailableModels("openai")
+}
+
+// Start resumes batches persisted by a previous run.
+func (h *OpenAIBatchAPIHandler) Start(ctx context.Context) {
+	h.manager.Start(ctx)
+}
+
+// Stop checkpoints running batches and stops executing them.
+func (h *OpenAIBatchAPIHandler) Stop(ctx context.Context) error {
+	return h.manager.Stop(ctx)
+}
+
+// UploadFile handles POST /v1/files.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) UploadFile(c *gin.Context) {
+	maxBytes := h.manager.Settings().MaxFileBytes
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: file is required (%v)", err))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: cannot open file: %v", err))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	_ = file.Close()
+	if err != nil {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: cannot read file: %v", err))
+		return
+	}
+	created, err := h.manager.CreateFile(c.Request.Context(), c.GetString("apiKey"), c.GetString("tenant"), fileHeader.Filename, strings.TrimSpace(c.PostForm("purpose")), data)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// ListFiles handles GET /v1/files.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) ListFiles(c *gin.Context) {
+	files, err := h.manager.ListFiles(c.Request.Context(), c.GetString("apiKey"), strings.TrimSpace(c.Query("purpose")))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	if c.Query("order") == "asc" {
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+	writeList(c, files, func(f batch.File) string { return f.ID })
+}
+
+// RetrieveFile handles GET /v1/files/:file_id.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) RetrieveFile(c *gin.Context) {
+	file, err := h.manager.GetFile(c.Request.Context(), c.GetString("apiKey"), c.Param("file_id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// FileContent handles GET /v1/files/:file_id/content.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) FileContent(c *gin.Context) {
+	data, err := h.manager.FileContent(c.Request.Context(), c.GetString("apiKey"), c.Param("file_id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/jsonl", data)
+}
+
+// DeleteFile handles DELETE /v1/files/:file_id.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) DeleteFile(c *gin.Context) {
+	id := c.Param("file_id")
+	if err := h.manager.DeleteFile(c.Request.Context(), c.GetString("apiKey"), id); err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// CreateBatch handles POST /v1/batches.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: body must be valid JSON")
+		return
+	}
+	inputFileID := strings.TrimSpace(gjson.GetBytes(rawJSON, "input_file_id").String())
+	if inputFileID == "" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: input_file_id is required")
+		return
+	}
+	endpoint := strings.TrimSpace(gjson.GetBytes(rawJSON, "endpoint").String())
+	if endpoint == "" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: endpoint is required")
+		return
+	}
+	var metadata map[string]string
+	if meta := gjson.GetBytes(rawJSON, "metadata"); meta.IsObject() {
+		metadata = make(map[string]string)
+		meta.ForEach(func(key, value gjson.Result) bool {
+			metadata[key.String()] = value.String()
+			return true
+		})
+	}
+	created, err := h.manager.CreateBatch(c.Request.Context(), c.GetString("apiKey"), c.GetString("tenant"), inputFileID, endpoint, gjson.GetBytes(rawJSON, "completion_window").String(), metadata)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// ListBatches handles GET /v1/batches.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) ListBatches(c *gin.Context) {
+	batches, err := h.manager.ListBatches(c.Request.Context(), c.GetString("apiKey"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	writeList(c, batches, func(b batch.Batch) string { return b.ID })
+}
+
+// RetrieveBatch handles GET /v1/batches/:batch_id.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) RetrieveBatch(c *gin.Context) {
+	found, err := h.manager.GetBatch(c.Request.Context(), c.GetString("apiKey"), c.Param("batch_id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, found)
+}
+
+// CancelBatch handles POST /v1/batches/:batch_id/cancel.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIBatchAPIHandler) CancelBatch(c *gin.Context) {
+	cancelled, err := h.manager.CancelBatch(c.Request.Context(), c.GetString("apiKey"), c.Param("batch_id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// writeList renders a cursor-paginated OpenAI list honouring the limit and after query parameters.
+func writeList[T any](c *gin.Context, items []T, id func(T) string) {
+	limit := defaultListLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: limit must be a positive integer")
+			return
+		}
+		limit = min(parsed, maxListLimit)
+	}
+	if after := strings.TrimSpace(c.Query("after")); after != "" {
+		for idx, item := range items {
+			if id(item) == after {
+				items = items[idx+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	resp := gin.H{"object": "list", "data": items, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(items) > 0 {
+		resp["first_id"] = id(items[0])
+		resp["last_id"] = id(items[len(items)-1])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func writeBatchError(c *gin.Context, err error) {
+	var reqErr *batch.RequestError
+	switch {
+	case errors.As(err, &reqErr):
+		writeInvalidRequestError(c, http.StatusBadRequest, reqErr.Message)
+	case errors.Is(err, batch.ErrNotFound):
+		writeInvalidRequestError(c, http.StatusNotFound, "No such object: "+c.Param("file_id")+c.Param("batch_id"))
+	default:
+		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{Message: err.Error(), Type: "server_error"},
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	imagesconverter "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/images"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxImagesPerRequest mirrors the OpenAI limit on the "n" parameter.
+	maxImagesPerRequest = 10
+	// maxImageUploadBytes bounds the multipart request body of /v1/images/edits.
+	maxImageUploadBytes = 32 << 20
+)
+
+// OpenAIImagesAPIHandler serves the OpenAI Images API (/v1/images/generations and
+// /v1/images/edits) on top of Gemini image models. Requests are translated to
+// Gemini generateContent payloads and executed through the auth manager, so every
+// provider that accepts the Gemini schema (Gemini, Vertex, AI Studio, Antigravity)
+// can serve them.
+type OpenAIImagesAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOpenAIImagesAPIHandler creates a new OpenAI Images API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OpenAIImagesAPIHandler: A new OpenAI Images API handlers instance
+func NewOpenAIImagesAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIImagesAPIHandler {
+	return &OpenAIImagesAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIImagesAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIImagesAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return modelRegistry.GetAvailableModels("openai")
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIImagesAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: body must be valid JSON")
+		return
+	}
+	h.handleImageRequest(c, rawJSON)
+}
+
+// ImageEdits handles the /v1/images/edits endpoint.
+// It accepts the multipart form used by the OpenAI SDKs ("image" or "image[]" files,
+// optional "mask", "prompt", "model", "n", "size", "response_format") as well as a
+// JSON body whose "images" entries carry data URLs in "image_url".
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIImagesAPIHandler) ImageEdits(c *gin.Context) {
+	contentType := strings.ToLower(c.GetHeader("Content-Type"))
+	if strings.HasPrefix(contentType, "application/json") {
+		rawJSON, err := c.GetRawData()
+		if err != nil || !gjson.ValidBytes(rawJSON) {
+			writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: body must be valid JSON")
+			return
+		}
+		payload, errConvert := convertJSONImageEditRequest(rawJSON)
+		if errConvert != nil {
+			writeInvalidRequestError(c, http.StatusBadRequest, errConvert.Error())
+			return
+		}
+		h.handleImageRequest(c, payload)
+		return
+	}
+
+	if !parseLimitedMultipartForm(c, maxImageUploadBytes) {
+		return
+	}
+	form := c.Request.MultipartForm
+	payload := `{}`
+	for _, field := range []string{"model", "prompt", "size", "response_format", "quality", "background", "user"} {
+		if value := strings.TrimSpace(c.Request.FormValue(field)); value != "" {
+			payload, _ = sjson.Set(payload, field, value)
+		}
+	}
+	if nValue := strings.TrimSpace(c.Request.FormValue("n")); nValue != "" {
+		n, errAtoi := strconv.Atoi(nValue)
+		if errAtoi != nil {
+			writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: n must be an integer")
+			return
+		}
+		payload, _ = sjson.Set(payload, "n", n)
+	}
+
+	var files []*multipart.FileHeader
+	if form != nil {
+		files = append(files, form.File["image"]...)
+		files = append(files, form.File["image[]"]...)
+	}
+	if len(files) == 0 {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: at least one image file is required")
+		return
+	}
+	for _, fh := range files {
+		part, errRead := readImageUpload(fh)
+		if errRead != nil {
+			writeInvalidRequestError(c, http.StatusBadRequest, errRead.Error())
+			return
+		}
+		payload, _ = sjson.SetRaw(payload, "image.-1", part)
+	}
+	if form != nil && len(form.File["mask"]) > 0 {
+		part, errRead := readImageUpload(form.File["mask"][0])
+		if errRead != nil {
+			writeInvalidRequestError(c, http.StatusBadRequest, errRead.Error())
+			return
+		}
+		payload, _ = sjson.SetRaw(payload, "mask", part)
+	}
+
+	h.handleImageRequest(c, []byte(payload))
+}
+
+// handleImageRequest executes an OpenAI image request (generation or edit) against
+// the Gemini backend and writes the OpenAI-shaped result.
+func (h *OpenAIImagesAPIHandler) handleImageRequest(c *gin.Context, rawJSON []byte) {
+	c.Header("Content-Type", "application/json")
+
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: model is required")
+		return
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "prompt").String()) == "" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: prompt is required")
+		return
+	}
+	n := 1
+	if nResult := gjson.GetBytes(rawJSON, "n"); nResult.Exists() {
+		n = int(nResult.Int())
+	}
+	if n < 1 || n > maxImagesPerRequest {
+		writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: n must be between 1 and %d", maxImagesPerRequest))
+		return
+	}
+	responseFormat := strings.TrimSpace(gjson.GetBytes(rawJSON, "response_format").String())
+	if responseFormat != "" && responseFormat != "b64_json" && responseFormat != "url" {
+		writeInvalidRequestError(c, http.StatusBadRequest, "Invalid request: response_format must be b64_json or url")
+		return
+	}
+
+	geminiRequest := imagesconverter.ConvertOpenAIImageRequestToGemini(modelName, rawJSON)
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+
+	// Gemini image models return a single image per call, so n images are produced
+	// by independent executions; each one goes through auth rotation on its own.
+	// Calls that answer with text only are retried, up to 2n calls in total. A failed
+	// execution, or too few images, fails the request rather than returning fewer than n.
+	responses := make([][]byte, 0, n)
+	for produced, attempt := 0, 0; produced < n && attempt < 2*n; attempt++ {
+		resp, errMsg := h.ExecuteWithAuthManager(cliCtx, Gemini, modelName, geminiRequest, "")
+		if errMsg != nil {
+			stopKeepAlive()
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		responses = append(responses, resp)
+		_, images := imagesconverter.ConvertGeminiResponsesToOpenAIImages([][]byte{resp}, responseFormat, 0)
+		produced += images
+	}
+	stopKeepAlive()
+
+	out, count := imagesconverter.ConvertGeminiResponsesToOpenAIImages(responses, responseFormat, time.Now().Unix())
+	if count < n {
+		errMsg := &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("model %s returned %d of %d requested images", modelName, count, n)}
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	if count > n {
+		// A response may carry several image parts; return exactly what was asked for.
+		items := gjson.GetBytes(out, "data").Array()[:n]
+		raws := make([]string, 0, n)
+		for _, item := range items {
+			raws = append(raws, item.Raw)
+		}
+		out, _ = sjson.SetRawBytes(out, "data", []byte("["+strings.Join(raws, ",")+"]"))
+	}
+	_, _ = c.Writer.Write(out)
+	cliCancel()
+}
+
+// convertJSONImageEditRequest folds the JSON flavour of /v1/images/edits into the
+// internal payload shape understood by the images translator.
+func convertJSONImageEditRequest(rawJSON []byte) ([]byte, error) {
+	root := gjson.ParseBytes(rawJSON)
+	payload := rawJSON
+	payload, _ = sjson.DeleteBytes(payload, "images")
+	payload, _ = sjson.DeleteBytes(payload, "mask")
+	added := 0
+	var errParse error
+	root.Get("images").ForEach(func(_, image gjson.Result) bool {
+		part, err := dataURLToInlinePart(image.Get("image_url").String())
+		if err != nil {
+			errParse = err
+			return false
+		}
+		payload, _ = sjson.SetRawBytes(payload, "image.-1", []byte(part))
+		added++
+		return true
+	})
+	if errParse != nil {
+		return nil, errParse
+	}
+	if added == 0 {
+		return nil, fmt.Errorf("Invalid request: at least one image is required")
+	}
+	if maskURL := root.Get("mask.image_url").String(); maskURL != "" {
+		part, err := dataURLToInlinePart(maskURL)
+		if err != nil {
+			return nil, err
+		}
+		payload, _ = sjson.SetRawBytes(payload, "mask", []byte(part))
+	}
+	return payload, nil
+}
+
+func dataURLToInlinePart(dataURL string) (string, error) {
+	dataURL = strings.TrimSpace(dataURL)
+	if !strings.HasPrefix(dataURL, "data:") {
+		return "", fmt.Errorf("Invalid request: only base64 data URLs are supported for image_url")
+	}
+	header, data, ok := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return "", fmt.Errorf("Invalid request: image_url must be a base64 data URL")
+	}
+	part := `{"mime_type":"","data":""}`
+	part, _ = sjson.Set(part, "mime_type", strings.TrimSuffix(header, ";base64"))
+	part, _ = sjson.Set(part, "data", data)
+	return part, nil
+}
+
+func readImageUpload(fh *multipart.FileHeader) (string, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return "", fmt.Errorf("Invalid request: cannot open %s: %v", fh.Filename, err)
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("Invalid request: cannot read %s: %v", fh.Filename, err)
+	}
+	mimeType := strings.TrimSpace(fh.Header.Get("Content-Type"))
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("Invalid request: %s is not an image (%s)", fh.Filename, mimeType)
+	}
+	part := `{"mime_type":"","data":""}`
+	part, _ = sjson.Set(part, "mime_type", mimeType)
+	part, _ = sjson.Set(part, "data", base64.StdEncoding.EncodeToString(data))
+	return part, nil
+}
+
+// parseLimitedMultipartForm parses the multipart body, writing a 413 or 400 and returning false on failure.
+func parseLimitedMultipartForm(c *gin.Context, limit int64) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	err := c.Request.ParseMultipartForm(limit)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeInvalidRequestError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d MB", limit>>20))
+		return false
+	}
+	writeInvalidRequestError(c, http.StatusBadRequest, fmt.Sprintf("Invalid multipart form: %v", err))
+	return false
+}
+
+// writeInvalidRequestError writes an OpenAI-style invalid_request_error body.
+func writeInvalidRequestError(c *gin.Context, status int, message string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
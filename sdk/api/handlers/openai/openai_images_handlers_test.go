@@ -0,0 +1,152 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// imageExecutor answers with two inline images per call and fails from call failFrom on.
+// With textOnly set it answers with text and no image.
+type imageExecutor struct {
+	calls    int
+	failFrom int
+	textOnly bool
+}
+
+func (e *imageExecutor) Identifier() string { return "test-image-provider" }
+
+func (e *imageExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls++
+	if e.failFrom > 0 && e.calls >= e.failFrom {
+		return coreexecutor.Response{}, errors.New("upstream failed")
+	}
+	if e.textOnly {
+		return coreexecutor.Response{Payload: []byte(`{"candidates":[{"content":{"parts":[{"text":"I cannot draw that."}]}}]}`)}, nil
+	}
+	return coreexecutor.Response{Payload: []byte(`{"candidates":[{"content":{"parts":[` +
+		`{"inlineData":{"mimeType":"image/png","data":"AAAA"}},` +
+		`{"inlineData":{"mimeType":"image/png","data":"BBBB"}}]}}]}`)}, nil
+}
+
+func (e *imageExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *imageExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *imageExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *imageExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newImagesRouter(t *testing.T, executor *imageExecutor) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "images-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-image-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+	h := NewOpenAIImagesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/images/generations", h.ImageGenerations)
+	router.POST("/v1/images/edits", h.ImageEdits)
+	return router
+}
+
+func TestImageGenerationsReturnsExactlyN(t *testing.T) {
+	executor := &imageExecutor{}
+	router := newImagesRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"a cat","n":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	if got := len(gjson.GetBytes(resp.Body.Bytes(), "data").Array()); got != 3 {
+		t.Fatalf("images = %d, want 3", got)
+	}
+}
+
+func TestImageGenerationsFailsOnPartialFailure(t *testing.T) {
+	executor := &imageExecutor{failFrom: 2}
+	router := newImagesRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"a cat","n":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code == http.StatusOK {
+		t.Fatalf("partial failure returned 200: %s", resp.Body.String())
+	}
+}
+
+func TestImageGenerationsFailsWhenTooFewImagesAreProduced(t *testing.T) {
+	executor := &imageExecutor{textOnly: true}
+	router := newImagesRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"a cat","n":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code == http.StatusOK {
+		t.Fatalf("short result returned 200: %s", resp.Body.String())
+	}
+	if executor.calls != 4 {
+		t.Fatalf("executor calls = %d, want 4", executor.calls)
+	}
+}
+
+func TestImageEditsRejectsOversizedUpload(t *testing.T) {
+	executor := &imageExecutor{}
+	router := newImagesRouter(t, executor)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("model", "test-image-model")
+	_ = writer.WriteField("prompt", "edit")
+	part, _ := writer.CreateFormFile("image", "big.png")
+	_, _ = part.Write(make([]byte, maxImageUploadBytes+1))
+	_ = writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/edits", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413; body %s", resp.Code, resp.Body.String())
+	}
+	if executor.calls != 0 {
+		t.Fatalf("executor calls = %d, want 0", executor.calls)
+	}
+}
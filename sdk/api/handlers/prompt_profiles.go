@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/promptprofile"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyPromptProfiles injects the system prompts and the selected template of the prompt
+// profiles matching the client into the source-format request. A template model suffix is
+// removed from the returned model name and from the body's model field.
+func (h *BaseAPIHandler) applyPromptProfiles(ctx context.Context, handlerType, modelName string, rawJSON []byte) (string, []byte) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.PromptProfiles) == 0 {
+		return modelName, rawJSON
+	}
+	req := promptprofile.Request{
+		Model: modelName,
+		User:  promptprofile.UserFromRequest(handlerType, rawJSON),
+	}
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			req.APIKey = clientAPIKeyFromGin(ginCtx)
+			if ginCtx.Request != nil {
+				req.Template = strings.TrimSpace(ginCtx.GetHeader(promptprofile.TemplateHeader))
+			}
+		}
+	}
+	model, sel := promptprofile.Resolve(h.Cfg.PromptProfiles, req)
+	if model != modelName && gjson.GetBytes(rawJSON, "model").String() == modelName {
+		if updated, err := sjson.SetBytes(rawJSON, "model", model); err == nil {
+			rawJSON = updated
+		}
+	}
+	return model, promptprofile.Inject(handlerType, rawJSON, sel)
+}
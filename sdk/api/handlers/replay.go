@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplayRequest runs an endpoint handler for a request that did not arrive over HTTP,
+// such as a line of a batch input file. The request carries apiKey as a bearer token and
+// passes through middleware before reaching handler, so authentication, budgets and other
+// per-key restrictions apply as for live traffic. Middleware runs in order until one aborts;
+// work it would do after c.Next is not supported. Before the middleware runs, the Gin context
+// is authenticated as apiKey of tenant, the way AuthMiddleware marks live requests.
+//
+// Parameters:
+//   - ctx: The context bounding the request; cancelling it aborts upstream calls
+//   - middleware: The handlers run ahead of handler, e.g. BaseAPIHandler.ReplayMiddleware
+//   - handler: The Gin handler of the target endpoint
+//   - apiKey: The client API key the request is executed for
+//   - tenant: The tenant of apiKey, or "" for none
+//   - method: The HTTP method
+//   - url: The request path (e.g. /v1/chat/completions)
+//   - body: The JSON request body
+//
+// Returns:
+//   - int: The HTTP status written by the handler
+//   - http.Header: The response headers
+//   - []byte: The response body
+func ReplayRequest(ctx context.Context, middleware []gin.HandlerFunc, handler gin.HandlerFunc, apiKey, tenant, method, url string, body []byte) (int, http.Header, []byte) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return http.StatusBadRequest, nil, BuildErrorResponseBody(http.StatusBadRequest, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	c.Request = req
+	if apiKey != "" {
+		c.Set("apiKey", apiKey)
+	}
+	if tenant != "" {
+		c.Set("tenant", tenant)
+		c.Set("accessMetadata", map[string]string{"tenant": tenant})
+	}
+	// The test context has no handler chain, so c.Next is a no-op and each step runs in turn
+	// until one aborts.
+	for _, step := range append(append([]gin.HandlerFunc(nil), middleware...), handler) {
+		step(c)
+		if c.IsAborted() {
+			break
+		}
+	}
+	c.Writer.WriteHeaderNow()
+	return recorder.Code, recorder.Header(), recorder.Body.Bytes()
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/structuredoutput"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// structuredOutputSpec returns the JSON output contract of the request when structured-output
+// enforcement is enabled, or nil when the request needs no validation.
+func (h *BaseAPIHandler) structuredOutputSpec(handlerType string, rawJSON []byte, alt string) (*structuredoutput.Spec, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || !h.Cfg.StructuredOutput.Enabled || alt == "responses/compact" {
+		return nil, nil
+	}
+	spec, err := structuredoutput.SpecFromRequest(handlerType, rawJSON)
+	if err != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: err}
+	}
+	return spec, nil
+}
+
+// enforceStructuredOutput validates a non-streaming response against spec. Invalid output is
+// sent back to the model with the validation errors up to the configured retry budget; if it
+// still does not conform, a schema validation error shaped for the client format is returned.
+func (h *BaseAPIHandler) enforceStructuredOutput(ctx context.Context, spec *structuredoutput.Spec, handlerType string, providers []string, req coreexecutor.Request, opts coreexecutor.Options, payload []byte) ([]byte, *interfaces.ErrorMessage) {
+	retries := h.Cfg.StructuredOutput.MaxRetries
+	for attempt := 0; ; attempt++ {
+		output := structuredoutput.OutputFromResponse(handlerType, inspectablePayload(payload))
+		if output.ToolCall {
+			return payload, nil
+		}
+		errs := spec.Validate(output.Text)
+		if len(errs) == 0 {
+			return payload, nil
+		}
+		repaired, ok := []byte(nil), false
+		if attempt < retries {
+			repaired, ok = structuredoutput.RepairRequest(handlerType, req.Payload, output.Text, errs)
+		}
+		if !ok {
+			return nil, structuredOutputError(handlerType, errs)
+		}
+		log.Debugf("structured output: attempt %d failed validation (%s), re-asking model %s", attempt+1, errs[0].String(), req.Model)
+		req.Payload = repaired
+		opts.OriginalRequest = cloneBytes(repaired)
+		resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+		if err != nil {
+			return nil, errorMessageFromExecution(err)
+		}
+		payload = cloneBytes(resp.Payload)
+	}
+}
+
+// structuredOutputError reports output that failed schema validation.
+func structuredOutputError(handlerType string, errs []structuredoutput.ValidationError) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusUnprocessableEntity,
+		Error:      errors.New(string(structuredoutput.ErrorBody(handlerType, errs))),
+	}
+}
+
+// errorMessageFromExecution converts an executor error into an ErrorMessage, preserving the
+// upstream status code and headers when available.
+func errorMessageFromExecution(err error) *interfaces.ErrorMessage {
+	status := http.StatusInternalServerError
+	if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+		if code := se.StatusCode(); code > 0 {
+			status = code
+		}
+	}
+	var addon http.Header
+	if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
+		if hdr := he.Headers(); hdr != nil {
+			addon = hdr.Clone()
+		}
+	}
+	return &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+}
+
+// inspectablePayload undoes gzip compression that some upstreams apply without a
+// Content-Encoding header, so the response text can be validated.
+func inspectablePayload(payload []byte) []byte {
+	if len(payload) < 2 || payload[0] != 0x1f || payload[1] != 0x8b {
+		return payload
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return payload
+	}
+	defer func() { _ = reader.Close() }()
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return payload
+	}
+	return decompressed
+}
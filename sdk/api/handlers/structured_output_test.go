@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// scriptedExecutor answers successive Execute calls with the configured chat completion contents.
+type scriptedExecutor struct {
+	mu       sync.Mutex
+	contents []string
+	requests [][]byte
+}
+
+func (e *scriptedExecutor) Identifier() string { return "codex" }
+
+func (e *scriptedExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, req.Payload)
+	content := e.contents[min(len(e.requests), len(e.contents))-1]
+	payload := `{"choices":[{"index":0,"message":{"role":"assistant","content":` + jsonString(content) + `}}]}`
+	return coreexecutor.Response{Payload: []byte(payload)}, nil
+}
+
+func (e *scriptedExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk, 2)
+	ch <- coreexecutor.StreamChunk{Payload: []byte("data: {\"choices\":[{\"delta\":{\"content\":\"{\\\"answer\\\":\"}}]}\n\n")}
+	ch <- coreexecutor.StreamChunk{Payload: []byte("data: {\"choices\":[{\"delta\":{\"content\":\"42}\"}}]}\n\n")}
+	close(ch)
+	return ch, nil
+}
+
+func (e *scriptedExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *scriptedExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *scriptedExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func jsonString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+const structuredRequest = `{"model":"test-model","messages":[{"role":"user","content":"answer"}],` +
+	`"response_format":{"type":"json_schema","json_schema":{"name":"answer","schema":{"type":"object",` +
+	`"properties":{"answer":{"type":"string"}},"required":["answer"],"additionalProperties":false}}}}`
+
+func newStructuredOutputHandler(t *testing.T, executor *scriptedExecutor, maxRetries int) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "structured-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		StructuredOutput: sdkconfig.StructuredOutputConfig{Enabled: true, MaxRetries: maxRetries},
+	}, manager)
+}
+
+func TestExecuteWithAuthManager_StructuredOutputRetry(t *testing.T) {
+	executor := &scriptedExecutor{contents: []string{`{"answer":42}`, `{"answer":"42"}`}}
+	handler := newStructuredOutputHandler(t, executor, 1)
+
+	resp, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "test-model", []byte(structuredRequest), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "choices.0.message.content").String(); got != `{"answer":"42"}` {
+		t.Fatalf("content = %q", got)
+	}
+	if len(executor.requests) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(executor.requests))
+	}
+	messages := gjson.GetBytes(executor.requests[1], "messages").Array()
+	if len(messages) != 3 || messages[1].Get("role").String() != "assistant" || !strings.Contains(messages[2].Get("content").String(), "$.answer") {
+		t.Fatalf("unexpected repair request: %s", executor.requests[1])
+	}
+}
+
+func TestExecuteWithAuthManager_StructuredOutputFailure(t *testing.T) {
+	executor := &scriptedExecutor{contents: []string{`not json`}}
+	handler := newStructuredOutputHandler(t, executor, 0)
+
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "test-model", []byte(structuredRequest), "")
+	if errMsg == nil {
+		t.Fatalf("expected validation error")
+	}
+	if errMsg.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d", errMsg.StatusCode)
+	}
+	if code := gjson.Get(errMsg.Error.Error(), "error.code").String(); code != "json_schema_validation_failed" {
+		t.Fatalf("error body = %s", errMsg.Error.Error())
+	}
+}
+
+func TestExecuteStreamWithAuthManager_StructuredOutputValidatesAccumulatedText(t *testing.T) {
+	executor := &scriptedExecutor{}
+	handler := newStructuredOutputHandler(t, executor, 0)
+
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "test-model", []byte(structuredRequest), "")
+	for range dataChan {
+	}
+	var got *string
+	for msg := range errChan {
+		if msg != nil {
+			text := msg.Error.Error()
+			got = &text
+		}
+	}
+	if got == nil || !strings.Contains(*got, "expected string, got number") {
+		t.Fatalf("expected stream validation error, got %v", got)
+	}
+}
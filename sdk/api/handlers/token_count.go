@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tokencount"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// localCountFallback answers a count-tokens request with a local estimate when the upstream
+// could not count it. It reports false when the error is not one a local count can stand in
+// for, e.g. an invalid request or rejected credentials.
+func (h *BaseAPIHandler) localCountFallback(ctx context.Context, handlerType, model string, payload []byte, err error) ([]byte, bool) {
+	if h == nil || (h.Cfg != nil && h.Cfg.TokenCounting.DisableLocalFallback) || !countFallbackEligible(err) {
+		return nil, false
+	}
+	count, errCount := tokencount.CountRequest(handlerType, thinking.ParseSuffix(model).ModelName, payload)
+	if errCount != nil {
+		log.Debugf("token count fallback failed for model %s: %v", model, errCount)
+		return nil, false
+	}
+	log.Debugf("token count for model %s answered locally (%d tokens): %v", model, count, err)
+	usageJSON := []byte(fmt.Sprintf(`{"usage":{"prompt_tokens":%d,"completion_tokens":0,"total_tokens":%d}}`, count, count))
+	out := sdktranslator.TranslateTokenCount(ctx, sdktranslator.FromString("openai"), sdktranslator.FromString(handlerType), count, usageJSON)
+	return []byte(out), true
+}
+
+// countFallbackEligible reports whether err means the upstream could not count rather than
+// that the request itself is wrong.
+func countFallbackEligible(err error) bool {
+	if err == nil {
+		return false
+	}
+	var authErr *coreauth.Error
+	if errors.As(err, &authErr) {
+		switch authErr.Code {
+		case "auth_not_found", "auth_unavailable", "provider_not_found", "executor_not_found", "not_implemented":
+			return true
+		}
+	}
+	status := 0
+	if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+		status = se.StatusCode()
+	}
+	switch {
+	case status == 0,
+		status == http.StatusNotFound,
+		status == http.StatusMethodNotAllowed,
+		status == http.StatusTooManyRequests,
+		status == http.StatusNotImplemented,
+		status >= http.StatusInternalServerError:
+		return true
+	}
+	return false
+}
+
+// contextPreflight rejects a request whose estimated prompt exceeds the input limit of the
+// model, so oversized prompts fail fast with a 400 instead of an upstream error.
+func (h *BaseAPIHandler) contextPreflight(handlerType, model string, payload []byte) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil || !h.Cfg.TokenCounting.ContextPreflight {
+		return nil
+	}
+	baseModel := thinking.ParseSuffix(model).ModelName
+	limit := modelInputLimit(baseModel)
+	if limit <= 0 {
+		return nil
+	}
+	count, err := tokencount.CountRequest(handlerType, baseModel, payload)
+	if err != nil || count <= int64(limit) {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("prompt is too long: estimated %d tokens > %d maximum for model %s", count, limit, baseModel),
+	}
+}
+
+// modelInputLimit returns the input token limit of a registered model, falling back to its
+// context window. Zero means unknown.
+func modelInputLimit(model string) int {
+	info := registry.LookupModelInfo(model)
+	if info == nil {
+		return 0
+	}
+	if info.InputTokenLimit > 0 {
+		return info.InputTokenLimit
+	}
+	return info.ContextLength
+}
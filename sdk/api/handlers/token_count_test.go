@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newTokenCountHandler(t *testing.T, executor *scriptedExecutor, model *registry.ModelInfo, cfg sdkconfig.TokenCountingConfig) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "token-count-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{model})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{TokenCounting: cfg}, manager)
+}
+
+func TestExecuteCountWithAuthManager_LocalFallback(t *testing.T) {
+	handler := newTokenCountHandler(t, &scriptedExecutor{}, &registry.ModelInfo{ID: "claude-count-model"}, sdkconfig.TokenCountingConfig{})
+
+	request := `{"model":"claude-count-model","messages":[{"role":"user","content":"Count the tokens of this short prompt."}]}`
+	resp, errMsg := handler.ExecuteCountWithAuthManager(context.Background(), "claude", "claude-count-model", []byte(request), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(resp, "input_tokens").Int(); got <= 0 {
+		t.Fatalf("input_tokens = %d in %s", got, resp)
+	}
+}
+
+func TestExecuteCountWithAuthManager_FallbackDisabled(t *testing.T) {
+	handler := newTokenCountHandler(t, &scriptedExecutor{}, &registry.ModelInfo{ID: "claude-count-model"}, sdkconfig.TokenCountingConfig{DisableLocalFallback: true})
+
+	request := `{"model":"claude-count-model","messages":[{"role":"user","content":"hello"}]}`
+	if _, errMsg := handler.ExecuteCountWithAuthManager(context.Background(), "claude", "claude-count-model", []byte(request), ""); errMsg == nil {
+		t.Fatalf("expected the upstream error")
+	}
+}
+
+func TestExecuteWithAuthManager_ContextPreflight(t *testing.T) {
+	executor := &scriptedExecutor{contents: []string{"ok"}}
+	handler := newTokenCountHandler(t, executor, &registry.ModelInfo{ID: "small-context-model", ContextLength: 16}, sdkconfig.TokenCountingConfig{ContextPreflight: true})
+
+	request := `{"model":"small-context-model","messages":[{"role":"user","content":"` + strings.Repeat("lorem ipsum ", 40) + `"}]}`
+	_, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "small-context-model", []byte(request), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %+v", errMsg)
+	}
+	if !strings.Contains(errMsg.Error.Error(), "prompt is too long") {
+		t.Fatalf("unexpected message: %v", errMsg.Error)
+	}
+	if len(executor.requests) != 0 {
+		t.Fatalf("oversized request reached the upstream")
+	}
+
+	short := `{"model":"small-context-model","messages":[{"role":"user","content":"hi"}]}`
+	if _, errMsg = handler.ExecuteWithAuthManager(context.Background(), "openai", "small-context-model", []byte(short), ""); errMsg != nil {
+		t.Fatalf("short prompt rejected: %v", errMsg.Error)
+	}
+}
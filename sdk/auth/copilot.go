@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/browser"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// CopilotAuthenticator implements the GitHub device flow login for GitHub Copilot.
+type CopilotAuthenticator struct{}
+
+// NewCopilotAuthenticator constructs a GitHub Copilot authenticator.
+func NewCopilotAuthenticator() *CopilotAuthenticator {
+	return &CopilotAuthenticator{}
+}
+
+func (a *CopilotAuthenticator) Provider() string {
+	return "copilot"
+}
+
+// RefreshLead mints a new Copilot token well before the current one, valid for about thirty
+// minutes, expires.
+func (a *CopilotAuthenticator) RefreshLead() *time.Duration {
+	d := 5 * time.Minute
+	return &d
+}
+
+func (a *CopilotAuthenticator) Login(ctx context.Context, cfg *config.Config, opts *LoginOptions) (*coreauth.Auth, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("cliproxy auth: configuration is required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts == nil {
+		opts = &LoginOptions{}
+	}
+
+	authSvc := copilot.NewCopilotAuth(cfg)
+
+	deviceFlow, err := authSvc.InitiateDeviceFlow(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("copilot device flow initiation failed: %w", err)
+	}
+
+	authURL := deviceFlow.VerificationURI
+	fmt.Printf("Enter the code %s at %s\n", deviceFlow.UserCode, authURL)
+	if !opts.NoBrowser {
+		fmt.Println("Opening browser for GitHub Copilot authentication")
+		if !browser.IsAvailable() {
+			log.Warn("No browser available; please open the URL manually")
+		} else if err = browser.OpenURL(authURL); err != nil {
+			log.Warnf("Failed to open browser automatically: %v", err)
+		}
+	}
+
+	fmt.Println("Waiting for GitHub Copilot authentication...")
+
+	githubToken, err := authSvc.PollForToken(ctx, deviceFlow)
+	if err != nil {
+		return nil, fmt.Errorf("copilot authentication failed: %w", err)
+	}
+
+	tokenData, err := authSvc.ExchangeToken(ctx, githubToken)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenStorage := authSvc.CreateTokenStorage(githubToken, tokenData)
+	login, email, errUser := authSvc.FetchUser(ctx, githubToken)
+	if errUser != nil {
+		log.Warnf("copilot: failed to fetch GitHub user: %v", errUser)
+	}
+	tokenStorage.Login = strings.TrimSpace(login)
+	tokenStorage.Email = strings.TrimSpace(email)
+
+	account := tokenStorage.Login
+	if account == "" {
+		account = fmt.Sprintf("%d", time.Now().UnixMilli())
+	}
+	fileName := fmt.Sprintf("copilot-%s.json", account)
+	metadata := map[string]any{
+		"login": tokenStorage.Login,
+	}
+	if tokenStorage.Email != "" {
+		metadata["email"] = tokenStorage.Email
+	}
+
+	fmt.Println("GitHub Copilot authentication successful")
+
+	return &coreauth.Auth{
+		ID:       fileName,
+		Provider: a.Provider(),
+		FileName: fileName,
+		Label:    tokenStorage.Login,
+		Storage:  tokenStorage,
+		Metadata: metadata,
+	}, nil
+}
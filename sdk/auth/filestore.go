@@ -167,6 +167,34 @@ func (s *FileTokenStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// SaveState persists auxiliary runtime state under <auth-dir>/.state.
+func (s *FileTokenStore) SaveState(ctx context.Context, namespace, key string, data []byte) error {
+	return s.stateStore().SaveState(ctx, namespace, key, data)
+}
+
+// LoadState reads auxiliary runtime state stored under <auth-dir>/.state.
+func (s *FileTokenStore) LoadState(ctx context.Context, namespace, key string) ([]byte, error) {
+	return s.stateStore().LoadState(ctx, namespace, key)
+}
+
+// ListState lists the state keys stored in a namespace.
+func (s *FileTokenStore) ListState(ctx context.Context, namespace string) ([]string, error) {
+	return s.stateStore().ListState(ctx, namespace)
+}
+
+// DeleteState removes a state entry.
+func (s *FileTokenStore) DeleteState(ctx context.Context, namespace, key string) error {
+	return s.stateStore().DeleteState(ctx, namespace, key)
+}
+
+func (s *FileTokenStore) stateStore() *cliproxyauth.DirStateStore {
+	dir := s.baseDirSnapshot()
+	if dir == "" {
+		return cliproxyauth.NewDirStateStore("")
+	}
+	return cliproxyauth.NewDirStateStore(filepath.Join(dir, ".state"))
+}
+
 func (s *FileTokenStore) resolveDeletePath(id string) (string, error) {
 	if strings.ContainsRune(id, os.PathSeparator) || filepath.IsAbs(id) {
 		return id, nil
@@ -233,6 +261,8 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 		Provider:         provider,
 		FileName:         id,
 		Label:            s.labelFor(metadata),
+		Tags:             cliproxyauth.TagsFromMetadata(metadata),
+		Schedule:         cliproxyauth.ScheduleFromMetadata(metadata),
 		Status:           status,
 		Disabled:         disabled,
 		Attributes:       map[string]string{"path": path},
@@ -245,6 +275,7 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 	if email, ok := metadata["email"].(string); ok && email != "" {
 		auth.Attributes["email"] = email
 	}
+	cliproxyauth.SetTenantFromPath(auth, id)
 	return auth, nil
 }
 
@@ -28,31 +28,54 @@ func clientAPIKeyFromOptions(opts cliproxyexecutor.Options) string {
 	}
 }
 
-func (m *Manager) allowedAuthRefsForClientKey(clientKey string) (map[string]struct{}, bool) {
+// authRefs holds auth references by ID, index or file name together with the selectors of
+// referenced auth pools.
+type authRefs struct {
+	refs  map[string]struct{}
+	pools []TagSelector
+}
+
+func (r authRefs) empty() bool {
+	return len(r.refs) == 0 && len(r.pools) == 0
+}
+
+// newAuthRefs resolves raw references, expanding "pool:<name>" through cfg.AuthPools.
+// Unknown pools match nothing.
+func newAuthRefs(cfg *internalconfig.Config, raw []string) authRefs {
+	out := authRefs{refs: make(map[string]struct{}, len(raw))}
+	for _, ref := range raw {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		if name, ok := PoolNameFromRef(ref); ok {
+			if selector, okPool := PoolSelector(cfg, name); okPool {
+				out.pools = append(out.pools, selector)
+			}
+			continue
+		}
+		out.refs[ref] = struct{}{}
+	}
+	return out
+}
+
+func (m *Manager) allowedAuthRefsForClientKey(clientKey string) (authRefs, bool) {
 	if m == nil {
-		return nil, false
+		return authRefs{}, false
 	}
 	clientKey = strings.TrimSpace(clientKey)
 	if clientKey == "" {
-		return nil, false
+		return authRefs{}, false
 	}
 	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
 	if cfg == nil || len(cfg.APIKeyAuth) == 0 {
-		return nil, false
+		return authRefs{}, false
 	}
 	refs, ok := cfg.APIKeyAuth[clientKey]
 	if !ok {
-		return nil, false
-	}
-	allowed := make(map[string]struct{}, len(refs))
-	for _, ref := range refs {
-		ref = strings.TrimSpace(ref)
-		if ref == "" {
-			continue
-		}
-		allowed[ref] = struct{}{}
+		return authRefs{}, false
 	}
-	return allowed, true
+	return newAuthRefs(cfg, refs), true
 }
 
 // AllowedAuthIDsForClientKey resolves the auth IDs permitted for a client API key.
@@ -63,7 +86,7 @@ func (m *Manager) AllowedAuthIDsForClientKey(clientKey string) (allowed map[stri
 	if !restricted {
 		return nil, false
 	}
-	if len(allowedRefs) == 0 {
+	if allowedRefs.empty() {
 		return map[string]struct{}{}, true
 	}
 	m.mu.RLock()
@@ -77,22 +100,27 @@ func (m *Manager) AllowedAuthIDsForClientKey(clientKey string) (allowed map[stri
 	return out, true
 }
 
-func authMatchesAllowedRefs(auth *Auth, allowed map[string]struct{}) bool {
-	if auth == nil || len(allowed) == 0 {
+func authMatchesAllowedRefs(auth *Auth, allowed authRefs) bool {
+	if auth == nil || allowed.empty() {
 		return false
 	}
 	if id := strings.TrimSpace(auth.ID); id != "" {
-		if _, ok := allowed[id]; ok {
+		if _, ok := allowed.refs[id]; ok {
 			return true
 		}
 	}
 	if idx := authIndexForMatch(auth); idx != "" {
-		if _, ok := allowed[idx]; ok {
+		if _, ok := allowed.refs[idx]; ok {
 			return true
 		}
 	}
 	if name := strings.TrimSpace(auth.FileName); name != "" {
-		if _, ok := allowed[name]; ok {
+		if _, ok := allowed.refs[name]; ok {
+			return true
+		}
+	}
+	for _, selector := range allowed.pools {
+		if selector.Matches(auth.Tags) {
 			return true
 		}
 	}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AttributeMaxConcurrency caps how many requests may run on an auth at once. External executor
+// workers set it from the capacity they announce; zero or absent means unlimited.
+const AttributeMaxConcurrency = "max_concurrency"
+
+// MaxConcurrency returns the concurrency cap of the auth, or zero when it is unlimited.
+func MaxConcurrency(auth *Auth) int {
+	if auth == nil || auth.Attributes == nil {
+		return 0
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(auth.Attributes[AttributeMaxConcurrency]))
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// atCapacity reports whether the auth already runs as many requests as it allows. Callers hold
+// m.mu; the check is advisory, so concurrent selections may briefly exceed the cap.
+func (m *Manager) atCapacity(auth *Auth) bool {
+	limit := MaxConcurrency(auth)
+	return limit > 0 && m.inflight.count(auth.ID) >= limit
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestCapacitySkipsBusyAuths(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	exec := &blockingExecutor{provider: "claude", release: make(chan struct{})}
+	manager.RegisterExecutor(exec)
+	ctx := context.Background()
+	capped := map[string]string{AttributeMaxConcurrency: "1"}
+	_, _ = manager.Register(ctx, &Auth{ID: "a", Provider: "claude", Status: StatusActive, Attributes: capped})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+		done <- err
+	}()
+	waitInFlight(t, manager, "a", 1)
+
+	_, err := manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != "auth_busy" || authErr.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("Execute() error = %v, want auth_busy 429", err)
+	}
+
+	_, _ = manager.Register(ctx, &Auth{ID: "b", Provider: "claude", Status: StatusActive})
+	if _, err = manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	exec.mu.Lock()
+	last := exec.used[len(exec.used)-1]
+	exec.mu.Unlock()
+	if last != "b" {
+		t.Fatalf("auth at capacity was selected: used %q", last)
+	}
+
+	close(exec.release)
+	if err = <-done; err != nil {
+		t.Fatalf("in-flight Execute() error = %v", err)
+	}
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	cases := map[string]int{"": 0, "4": 4, "-1": 0, "x": 0}
+	for raw, want := range cases {
+		auth := &Auth{Attributes: map[string]string{AttributeMaxConcurrency: raw}}
+		if got := MaxConcurrency(auth); got != want {
+			t.Fatalf("MaxConcurrency(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
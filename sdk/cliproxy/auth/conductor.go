@@ -141,9 +141,15 @@ type Manager struct {
 	// It is initialized in NewManager; never Load() before first Store().
 	runtimeConfig atomic.Value
 
+	// guardrails caches the guardrails engine compiled from runtimeConfig (*guardrailsSnapshot).
+	guardrails atomic.Value
+
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// inflight counts executing requests per auth for draining.
+	inflight inflightTracker
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -229,6 +235,7 @@ func (m *Manager) SetConfig(cfg *internalconfig.Config) {
 		cfg = &internalconfig.Config{}
 	}
 	m.runtimeConfig.Store(cfg)
+	m.rebuildGuardrails(cfg)
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
 }
 
@@ -317,6 +324,10 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 			if entry := resolveClaudeAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
+		case "bedrock":
+			if entry := resolveBedrockAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
 		case "codex":
 			if entry := resolveCodexAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
@@ -454,9 +465,13 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		return nil, nil
 	}
 	m.mu.Lock()
-	if existing, ok := m.auths[auth.ID]; ok && existing != nil && !auth.indexAssigned && auth.Index == "" {
-		auth.Index = existing.Index
-		auth.indexAssigned = existing.indexAssigned
+	if existing, ok := m.auths[auth.ID]; ok && existing != nil {
+		if !auth.indexAssigned && auth.Index == "" {
+			auth.Index = existing.Index
+			auth.indexAssigned = existing.indexAssigned
+		}
+		// Draining is runtime state that reloads from the store must not reset.
+		auth.Draining = auth.Draining || existing.Draining
 	}
 	auth.EnsureIndex()
 	m.auths[auth.ID] = auth.Clone()
@@ -502,13 +517,19 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	session := m.guardrailSession(req.Model, opts)
+	req, opts, errGuard := guardRequest(ctx, session, req, opts)
+	if errGuard != nil {
+		return cliproxyexecutor.Response{}, errGuard
+	}
+
 	_, maxWait := m.retrySettings()
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts)
 		if errExec == nil {
-			return resp, nil
+			return guardResponse(ctx, session, resp)
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
@@ -533,6 +554,12 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	session := m.guardrailSession(req.Model, opts)
+	req, opts, errGuard := guardRequest(ctx, session, req, opts)
+	if errGuard != nil {
+		return cliproxyexecutor.Response{}, errGuard
+	}
+
 	_, maxWait := m.retrySettings()
 
 	var lastErr error
@@ -564,13 +591,19 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	session := m.guardrailSession(req.Model, opts)
+	req, opts, errGuard := guardRequest(ctx, session, req, opts)
+	if errGuard != nil {
+		return nil, errGuard
+	}
+
 	_, maxWait := m.retrySettings()
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		chunks, errStream := m.executeStreamMixedOnce(ctx, normalized, req, opts)
 		if errStream == nil {
-			return chunks, nil
+			return guardStream(ctx, session, opts.SourceFormat.String(), chunks), nil
 		}
 		lastErr = errStream
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, normalized, req.Model, maxWait)
@@ -587,6 +620,14 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 	return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 }
 
+// UpstreamModelForAuth resolves the model name sent upstream when requestedModel is served by
+// auth, applying the credential prefix and any OAuth or API-key model aliases.
+func (m *Manager) UpstreamModelForAuth(auth *Auth, requestedModel string) string {
+	model := rewriteModelForAuth(requestedModel, auth)
+	model = m.applyOAuthModelAlias(auth, model)
+	return m.applyAPIKeyModelAlias(auth, model)
+}
+
 func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	if len(providers) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -617,7 +658,10 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		resp, errExec := func() (cliproxyexecutor.Response, error) {
+			defer m.inflight.release(auth.ID)
+			return executor.Execute(execCtx, auth, execReq, opts)
+		}()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -674,7 +718,10 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		resp, errExec := func() (cliproxyexecutor.Response, error) {
+			defer m.inflight.release(auth.ID)
+			return executor.CountTokens(execCtx, auth, execReq, opts)
+		}()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -731,7 +778,16 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		execCtx, timing := withStreamTiming(execCtx)
+		chunks, errStream := func() (chunks <-chan cliproxyexecutor.StreamChunk, err error) {
+			// The stream goroutine below releases the slot once a stream is handed over.
+			defer func() {
+				if err != nil || chunks == nil {
+					m.inflight.release(auth.ID)
+				}
+			}()
+			return executor.ExecuteStream(execCtx, auth, execReq, opts)
+		}()
 		if errStream != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
@@ -754,9 +810,13 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
+			defer m.inflight.release(streamAuth.ID)
 			var failed bool
 			forward := true
 			for chunk := range streamChunks {
+				if len(chunk.Payload) > 0 {
+					timing.markFirstChunk()
+				}
 				if chunk.Err != nil && !failed {
 					failed = true
 					rerr := &Error{Message: chunk.Err.Error()}
@@ -878,6 +938,8 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 		upstreamModel = resolveUpstreamModelForGeminiAPIKey(cfg, auth, requestedModel)
 	case "claude":
 		upstreamModel = resolveUpstreamModelForClaudeAPIKey(cfg, auth, requestedModel)
+	case "bedrock":
+		upstreamModel = resolveUpstreamModelForBedrockAPIKey(cfg, auth, requestedModel)
 	case "codex":
 		upstreamModel = resolveUpstreamModelForCodexAPIKey(cfg, auth, requestedModel)
 	case "vertex":
@@ -952,6 +1014,13 @@ func resolveClaudeAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internal
 	return resolveAPIKeyConfig(cfg.ClaudeKey, auth)
 }
 
+func resolveBedrockAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.BedrockKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.BedrockKey, auth)
+}
+
 func resolveCodexAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.CodexKey {
 	if cfg == nil {
 		return nil
@@ -982,6 +1051,14 @@ func resolveUpstreamModelForClaudeAPIKey(cfg *internalconfig.Config, auth *Auth,
 	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
 }
 
+func resolveUpstreamModelForBedrockAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveBedrockAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
 func resolveUpstreamModelForCodexAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	entry := resolveCodexAPIKeyConfig(cfg, auth)
 	if entry == nil {
@@ -1627,6 +1704,43 @@ func (m *Manager) GetByID(id string) (*Auth, bool) {
 	return auth.Clone(), true
 }
 
+// AvailableAuthCount reports how many auths of tenant ("" for shared auths) can serve model
+// right now: enabled, backed by a registered executor, supporting the model and not cooling
+// down. When none are available the earliest time a cooling auth recovers is returned as well
+// (zero when unknown).
+func (m *Manager) AvailableAuthCount(tenant, model string) (int, time.Time) {
+	modelKey := strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(modelKey); parsed.ModelName != "" {
+		modelKey = strings.TrimSpace(parsed.ModelName)
+	}
+	now := time.Now()
+	registryRef := registry.GetGlobalRegistry()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	count := 0
+	var earliest time.Time
+	for _, candidate := range m.auths {
+		if candidate == nil || candidate.Disabled || candidate.Draining || AuthTenant(candidate) != tenant {
+			continue
+		}
+		if _, ok := m.executors[strings.ToLower(strings.TrimSpace(candidate.Provider))]; !ok {
+			continue
+		}
+		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
+			continue
+		}
+		blocked, reason, next := isAuthBlockedForModel(candidate, model, now)
+		if !blocked {
+			count++
+			continue
+		}
+		if reason == blockReasonCooldown && !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	return count, earliest
+}
+
 func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
 	m.mu.RLock()
 	executor, okExecutor := m.executors[provider]
@@ -1636,7 +1750,8 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 	}
 	clientKey := clientAPIKeyFromOptions(opts)
 	allowedRefs, restricted := m.allowedAuthRefsForClientKey(clientKey)
-	if restricted && len(allowedRefs) == 0 {
+	tenant := tenantFromOptions(opts)
+	if restricted && allowedRefs.empty() {
 		m.mu.RUnlock()
 		return nil, nil, &Error{Code: "access_denied", Message: "API key has no permitted accounts", HTTPStatus: http.StatusForbidden}
 	}
@@ -1655,6 +1770,9 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if candidate.Provider != provider {
 			continue
 		}
+		if AuthTenant(candidate) != tenant {
+			continue
+		}
 		if restricted {
 			if authMatchesAllowedRefs(candidate, allowedRefs) {
 				allowedMatch = true
@@ -1662,7 +1780,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 				continue
 			}
 		}
-		if candidate.Disabled {
+		if candidate.Disabled || candidate.Draining {
 			continue
 		}
 		if _, used := tried[candidate.ID]; used {
@@ -1681,7 +1799,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		m.mu.RUnlock()
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
+	selected, errPick := m.pickWithPoolPriority(ctx, provider, model, opts, candidates)
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, errPick
@@ -1719,12 +1837,14 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 	m.mu.RLock()
 	clientKey := clientAPIKeyFromOptions(opts)
 	allowedRefs, restricted := m.allowedAuthRefsForClientKey(clientKey)
-	if restricted && len(allowedRefs) == 0 {
+	tenant := tenantFromOptions(opts)
+	if restricted && allowedRefs.empty() {
 		m.mu.RUnlock()
 		return nil, nil, "", &Error{Code: "access_denied", Message: "API key has no permitted accounts", HTTPStatus: http.StatusForbidden}
 	}
 	candidates := make([]*Auth, 0, len(m.auths))
 	allowedMatch := false
+	busy := false
 	modelKey := strings.TrimSpace(model)
 	// Always use base model name (without thinking suffix) for auth matching.
 	if modelKey != "" {
@@ -1745,6 +1865,9 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if _, ok := providerSet[providerKey]; !ok {
 			continue
 		}
+		if AuthTenant(candidate) != tenant {
+			continue
+		}
 		if restricted {
 			if authMatchesAllowedRefs(candidate, allowedRefs) {
 				allowedMatch = true
@@ -1752,7 +1875,7 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 				continue
 			}
 		}
-		if candidate.Disabled {
+		if candidate.Disabled || candidate.Draining {
 			continue
 		}
 		if _, used := tried[candidate.ID]; used {
@@ -1764,6 +1887,10 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
+		if m.atCapacity(candidate) {
+			busy = true
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
@@ -1772,9 +1899,12 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 			return nil, nil, "", &Error{Code: "access_denied", Message: "API key is not authorized for this provider", HTTPStatus: http.StatusForbidden}
 		}
 		m.mu.RUnlock()
+		if busy {
+			return nil, nil, "", &Error{Code: "auth_busy", Message: "all matching accounts are at capacity", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+		}
 		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	selected, errPick := m.selector.Pick(ctx, "mixed", model, opts, candidates)
+	selected, errPick := m.pickWithPoolPriority(ctx, "mixed", model, opts, candidates)
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, "", errPick
@@ -1789,6 +1919,8 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		m.mu.RUnlock()
 		return nil, nil, "", &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
+	// The caller releases the slot once the execution finishes.
+	m.inflight.acquire(selected.ID)
 	authCopy := selected.Clone()
 	m.mu.RUnlock()
 	if !selected.indexAssigned {
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// inflightTracker counts requests executing on each auth and signals when an auth goes idle.
+// The zero value is ready to use.
+type inflightTracker struct {
+	mu      sync.Mutex
+	counts  map[string]int
+	waiters map[string][]chan struct{}
+}
+
+func (t *inflightTracker) acquire(authID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.counts == nil {
+		t.counts = make(map[string]int)
+	}
+	t.counts[authID]++
+}
+
+func (t *inflightTracker) release(authID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.counts[authID] > 1 {
+		t.counts[authID]--
+		return
+	}
+	delete(t.counts, authID)
+	for _, ch := range t.waiters[authID] {
+		close(ch)
+	}
+	delete(t.waiters, authID)
+}
+
+func (t *inflightTracker) count(authID string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts[authID]
+}
+
+// idle returns a channel closed once authID has no request in flight.
+func (t *inflightTracker) idle(authID string) <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := make(chan struct{})
+	if t.counts[authID] == 0 {
+		close(ch)
+		return ch
+	}
+	if t.waiters == nil {
+		t.waiters = make(map[string][]chan struct{})
+	}
+	t.waiters[authID] = append(t.waiters[authID], ch)
+	return ch
+}
+
+// InFlight reports how many requests are currently executing on the auth.
+func (m *Manager) InFlight(id string) int {
+	if m == nil {
+		return 0
+	}
+	return m.inflight.count(strings.TrimSpace(id))
+}
+
+// Drain stops new selections of the auth while letting in-flight requests finish. The returned
+// channel is closed once no request is executing on it. Draining is an in-memory state: it is
+// cleared by CancelDrain or a restart.
+func (m *Manager) Drain(id string) (<-chan struct{}, error) {
+	if m == nil {
+		return nil, &Error{Code: "auth_not_found", Message: "auth manager unavailable"}
+	}
+	id = strings.TrimSpace(id)
+	m.mu.Lock()
+	auth, ok := m.auths[id]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return nil, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	auth.Draining = true
+	// Selections acquire the in-flight slot under the read lock, so once the flag is set under the
+	// write lock no new request can start on the auth.
+	idle := m.inflight.idle(id)
+	m.mu.Unlock()
+	return idle, nil
+}
+
+// CancelDrain makes a draining auth selectable again. It reports whether the auth was draining.
+func (m *Manager) CancelDrain(id string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	auth, ok := m.auths[strings.TrimSpace(id)]
+	if !ok || auth == nil || !auth.Draining {
+		return false
+	}
+	auth.Draining = false
+	return true
+}
+
+// WaitDrained blocks until the auth has no request in flight or ctx is done.
+func (m *Manager) WaitDrained(ctx context.Context, id string) error {
+	if m == nil {
+		return nil
+	}
+	select {
+	case <-m.inflight.idle(strings.TrimSpace(id)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type blockingExecutor struct {
+	provider string
+	release  chan struct{}
+	mu       sync.Mutex
+	used     []string
+}
+
+func (e *blockingExecutor) Identifier() string { return e.provider }
+
+func (e *blockingExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.used = append(e.used, auth.ID)
+	e.mu.Unlock()
+	if auth.ID == "a" {
+		select {
+		case <-e.release:
+		case <-ctx.Done():
+		}
+	}
+	return cliproxyexecutor.Response{Payload: []byte("{}")}, nil
+}
+
+func (e *blockingExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		<-e.release
+	}()
+	return out, nil
+}
+
+func (e *blockingExecutor) Refresh(context.Context, *Auth) (*Auth, error) { return nil, nil }
+
+func (e *blockingExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *blockingExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func waitInFlight(t *testing.T, manager *Manager, id string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for manager.InFlight(id) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("InFlight(%q) = %d, want %d", id, manager.InFlight(id), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDrainWaitsForInFlightRequests(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	exec := &blockingExecutor{provider: "claude", release: make(chan struct{})}
+	manager.RegisterExecutor(exec)
+	ctx := context.Background()
+	_, _ = manager.Register(ctx, &Auth{ID: "a", Provider: "claude", Status: StatusActive})
+	_, _ = manager.Register(ctx, &Auth{ID: "b", Provider: "claude", Status: StatusActive})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+		done <- err
+	}()
+	waitInFlight(t, manager, "a", 1)
+
+	idle, err := manager.Drain("a")
+	if err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	select {
+	case <-idle:
+		t.Fatal("drain completed while a request was in flight")
+	default:
+	}
+
+	if _, err = manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	exec.mu.Lock()
+	last := exec.used[len(exec.used)-1]
+	exec.mu.Unlock()
+	if last != "b" {
+		t.Fatalf("draining auth was selected: used %q", last)
+	}
+	// Reloading the auth from the store must not reset draining.
+	_, _ = manager.Update(ctx, &Auth{ID: "a", Provider: "claude", Status: StatusActive})
+	if current, _ := manager.GetByID("a"); !current.Draining {
+		t.Fatal("Update() cleared the draining state")
+	}
+
+	close(exec.release)
+	if err = <-done; err != nil {
+		t.Fatalf("in-flight Execute() error = %v", err)
+	}
+	select {
+	case <-idle:
+	case <-time.After(2 * time.Second):
+		t.Fatal("drain did not complete after the request finished")
+	}
+
+	if !manager.CancelDrain("a") {
+		t.Fatal("CancelDrain() = false, want true")
+	}
+	if current, _ := manager.GetByID("a"); current.Draining {
+		t.Fatal("auth still draining after CancelDrain()")
+	}
+}
+
+func TestDrainCountsStreamsUntilClosed(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	exec := &blockingExecutor{provider: "claude", release: make(chan struct{})}
+	manager.RegisterExecutor(exec)
+	_, _ = manager.Register(context.Background(), &Auth{ID: "a", Provider: "claude", Status: StatusActive})
+
+	chunks, err := manager.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if got := manager.InFlight("a"); got != 1 {
+		t.Fatalf("InFlight() = %d, want 1", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err = manager.WaitDrained(ctx, "a"); err == nil {
+		t.Fatal("WaitDrained() returned before the stream closed")
+	}
+	close(exec.release)
+	for range chunks {
+	}
+	if err = manager.WaitDrained(context.Background(), "a"); err != nil {
+		t.Fatalf("WaitDrained() error = %v", err)
+	}
+}
+
+type panickingExecutor struct{ blockingExecutor }
+
+func (e *panickingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	panic("executor failure")
+}
+
+func TestExecutorPanicReleasesInFlightSlot(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	manager.RegisterExecutor(&panickingExecutor{blockingExecutor{provider: "claude"}})
+	_, _ = manager.Register(context.Background(), &Auth{ID: "a", Provider: "claude", Status: StatusActive})
+
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = manager.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	}()
+	if got := manager.InFlight("a"); got != 0 {
+		t.Fatalf("InFlight() = %d after a panic, want 0", got)
+	}
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/guardrails"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// guardrailsSnapshot pairs a config snapshot with the guardrails engine compiled from it.
+type guardrailsSnapshot struct {
+	cfg    *internalconfig.Config
+	engine *guardrails.Engine
+}
+
+type guardrailRecorderContextKey struct{}
+
+// WithGuardrailRecorder stores a callback that receives the guardrail decision log of the
+// request each time it changes.
+func WithGuardrailRecorder(ctx context.Context, record func(entry []byte)) context.Context {
+	if ctx == nil || record == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, guardrailRecorderContextKey{}, record)
+}
+
+// rebuildGuardrails compiles the guardrails engine for cfg unless it was already built for it.
+func (m *Manager) rebuildGuardrails(cfg *internalconfig.Config) {
+	if current, _ := m.guardrails.Load().(*guardrailsSnapshot); current != nil && current.cfg == cfg {
+		return
+	}
+	snapshot := &guardrailsSnapshot{cfg: cfg}
+	if cfg != nil {
+		snapshot.engine = guardrails.Compile(cfg.Guardrails)
+	}
+	m.guardrails.Store(snapshot)
+}
+
+// guardrailSession returns the guardrail session covering the client and model of a request,
+// or nil when no rule applies.
+func (m *Manager) guardrailSession(model string, opts cliproxyexecutor.Options) *guardrails.Session {
+	snapshot, _ := m.guardrails.Load().(*guardrailsSnapshot)
+	if snapshot == nil || snapshot.engine == nil {
+		return nil
+	}
+	apiKey, _ := opts.Metadata[cliproxyexecutor.ClientAPIKeyMetadataKey].(string)
+	return snapshot.engine.NewSession(apiKey, model, thinking.ParseSuffix(model).ModelName)
+}
+
+// guardRequest screens a client request body before it is sent upstream.
+func guardRequest(ctx context.Context, session *guardrails.Session, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Request, cliproxyexecutor.Options, error) {
+	if session == nil {
+		return req, opts, nil
+	}
+	guarded, err := session.GuardRequest(req.Payload)
+	recordGuardrailDecisions(ctx, session)
+	if err != nil {
+		return req, opts, guardrailError(err)
+	}
+	req.Payload = guarded
+	opts.OriginalRequest = append([]byte(nil), guarded...)
+	return req, opts, nil
+}
+
+// guardResponse restores placeholders in a non-streaming response and screens it.
+func guardResponse(ctx context.Context, session *guardrails.Session, resp cliproxyexecutor.Response) (cliproxyexecutor.Response, error) {
+	if session == nil {
+		return resp, nil
+	}
+	guarded, err := session.GuardResponse(resp.Payload)
+	recordGuardrailDecisions(ctx, session)
+	if err != nil {
+		return cliproxyexecutor.Response{}, guardrailError(err)
+	}
+	resp.Payload = guarded
+	return resp, nil
+}
+
+// guardStream screens a streamed response in format, ending the stream with an error chunk
+// when a rule blocks it.
+func guardStream(ctx context.Context, session *guardrails.Session, format string, in <-chan cliproxyexecutor.StreamChunk) <-chan cliproxyexecutor.StreamChunk {
+	filter := session.NewStreamFilter(format)
+	if filter == nil {
+		return in
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		send := func(chunk cliproxyexecutor.StreamChunk) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- chunk:
+				return true
+			}
+		}
+		fail := func(err error) {
+			_ = send(cliproxyexecutor.StreamChunk{Err: guardrailError(err)})
+			// Drain the upstream so its producer can finish.
+			for range in {
+			}
+		}
+		for chunk := range in {
+			if chunk.Err != nil || len(chunk.Payload) == 0 {
+				if !send(chunk) {
+					return
+				}
+				continue
+			}
+			ready, err := filter.Write(chunk.Payload)
+			recordGuardrailDecisions(ctx, session)
+			if err != nil {
+				fail(err)
+				return
+			}
+			for _, payload := range ready {
+				if !send(cliproxyexecutor.StreamChunk{Payload: payload}) {
+					return
+				}
+			}
+		}
+		held, err := filter.Flush()
+		recordGuardrailDecisions(ctx, session)
+		if err != nil {
+			_ = send(cliproxyexecutor.StreamChunk{Err: guardrailError(err)})
+			return
+		}
+		for _, payload := range held {
+			if !send(cliproxyexecutor.StreamChunk{Payload: payload}) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// recordGuardrailDecisions hands the decisions taken so far to the request's recorder.
+func recordGuardrailDecisions(ctx context.Context, session *guardrails.Session) {
+	if ctx == nil {
+		return
+	}
+	record, ok := ctx.Value(guardrailRecorderContextKey{}).(func(entry []byte))
+	if !ok {
+		return
+	}
+	if entry := session.Log(); len(entry) > 0 {
+		record(entry)
+	}
+}
+
+func guardrailError(err error) error {
+	var blocked *guardrails.BlockError
+	if errors.As(err, &blocked) {
+		log.Infof("guardrails: %v", blocked)
+		return &Error{Code: "guardrail_blocked", Message: blocked.Error(), HTTPStatus: http.StatusBadRequest}
+	}
+	return &Error{Code: "guardrail_failed", Message: err.Error(), HTTPStatus: http.StatusInternalServerError}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// defaultLatencySmoothing is the weight of a new sample in the latency averages.
+const defaultLatencySmoothing = 0.2
+
+var defaultLatencyTracker = NewLatencyTracker(defaultLatencySmoothing)
+
+func init() {
+	coreusage.RegisterPlugin(defaultLatencyTracker)
+}
+
+// Latency returns the process-wide tracker fed by usage records.
+func Latency() *LatencyTracker { return defaultLatencyTracker }
+
+// LatencyStats holds exponentially weighted moving averages of an auth's latency.
+type LatencyStats struct {
+	// FirstChunkMs averages the time to the first streamed chunk.
+	FirstChunkMs float64 `json:"first_chunk_ms"`
+	// DurationMs averages the total request duration.
+	DurationMs float64 `json:"duration_ms"`
+	// Samples counts the successful requests observed.
+	Samples int64 `json:"samples"`
+}
+
+// LatencyTracker keeps per-auth latency averages from usage records. It implements
+// coreusage.Plugin.
+type LatencyTracker struct {
+	mu        sync.RWMutex
+	smoothing float64
+	stats     map[string]*LatencyStats
+}
+
+// NewLatencyTracker constructs a tracker where smoothing (0-1] is the weight of new samples.
+func NewLatencyTracker(smoothing float64) *LatencyTracker {
+	t := &LatencyTracker{stats: make(map[string]*LatencyStats)}
+	t.SetSmoothing(smoothing)
+	return t
+}
+
+// SetSmoothing changes the weight of new samples. Values outside (0,1] use the default.
+func (t *LatencyTracker) SetSmoothing(smoothing float64) {
+	if t == nil {
+		return
+	}
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = defaultLatencySmoothing
+	}
+	t.mu.Lock()
+	t.smoothing = smoothing
+	t.mu.Unlock()
+}
+
+// HandleUsage implements coreusage.Plugin. Failed requests are ignored so errors that return
+// quickly do not make an auth look fast.
+func (t *LatencyTracker) HandleUsage(_ context.Context, record coreusage.Record) {
+	if t == nil || record.AuthID == "" || record.Failed || record.DurationMs <= 0 {
+		return
+	}
+	t.Observe(record.AuthID, time.Duration(record.FirstChunkMs)*time.Millisecond, time.Duration(record.DurationMs)*time.Millisecond)
+}
+
+// Observe adds a sample for authID. A zero firstChunk leaves the first-chunk average unchanged.
+func (t *LatencyTracker) Observe(authID string, firstChunk, duration time.Duration) {
+	if t == nil || authID == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.stats[authID]
+	if stats == nil {
+		stats = &LatencyStats{}
+		t.stats[authID] = stats
+	}
+	stats.Samples++
+	stats.DurationMs = ewma(stats.DurationMs, float64(duration.Milliseconds()), t.smoothing)
+	if firstChunk > 0 {
+		stats.FirstChunkMs = ewma(stats.FirstChunkMs, float64(firstChunk.Milliseconds()), t.smoothing)
+	}
+}
+
+// Stats returns the averages of authID.
+func (t *LatencyTracker) Stats(authID string) (LatencyStats, bool) {
+	if t == nil {
+		return LatencyStats{}, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	stats, ok := t.stats[authID]
+	if !ok || stats == nil {
+		return LatencyStats{}, false
+	}
+	return *stats, true
+}
+
+func ewma(current, sample, smoothing float64) float64 {
+	if current <= 0 {
+		return sample
+	}
+	return current + smoothing*(sample-current)
+}
+
+type streamTimingContextKey struct{}
+
+// streamTiming records when the first chunk of a stream was forwarded.
+type streamTiming struct {
+	start      time.Time
+	firstChunk atomic.Int64
+}
+
+func withStreamTiming(ctx context.Context) (context.Context, *streamTiming) {
+	timing := &streamTiming{start: time.Now()}
+	return context.WithValue(ctx, streamTimingContextKey{}, timing), timing
+}
+
+func (t *streamTiming) markFirstChunk() {
+	if t == nil {
+		return
+	}
+	t.firstChunk.CompareAndSwap(0, max(int64(time.Since(t.start)), 1))
+}
+
+// FirstChunkLatency returns the time from the start of a streamed execution to its first
+// chunk, or zero when the context is not streaming or no chunk arrived yet.
+func FirstChunkLatency(ctx context.Context) time.Duration {
+	if ctx == nil {
+		return 0
+	}
+	timing, ok := ctx.Value(streamTimingContextKey{}).(*streamTiming)
+	if !ok || timing == nil {
+		return 0
+	}
+	return time.Duration(timing.firstChunk.Load())
+}
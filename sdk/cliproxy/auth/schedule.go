@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// Schedule limits an auth to time windows. See internalconfig.Schedule for the window syntax.
+type Schedule = internalconfig.Schedule
+
+type compiledScheduleEntry struct {
+	schedule *internalconfig.CompiledSchedule
+	err      error
+}
+
+// compiledSchedules caches parsed schedules by their canonical text.
+var compiledSchedules sync.Map
+
+// NormalizeSchedule returns a trimmed copy of schedule, or nil when it has no windows.
+func NormalizeSchedule(schedule *Schedule) *Schedule {
+	return internalconfig.NormalizeSchedule(schedule)
+}
+
+// ScheduleFromMetadata reads the "schedule" field of auth-file metadata. It accepts an object
+// ({"timezone":"Europe/Berlin","windows":["mon-fri 18:00-08:00"]}) or a string of windows
+// separated by ";".
+func ScheduleFromMetadata(metadata map[string]any) *Schedule {
+	if metadata == nil {
+		return nil
+	}
+	raw, ok := metadata["schedule"]
+	if !ok || raw == nil {
+		return nil
+	}
+	schedule := &Schedule{}
+	switch v := raw.(type) {
+	case string:
+		schedule.Windows = strings.Split(v, ";")
+	case map[string]any:
+		if tz, okTZ := v["timezone"].(string); okTZ {
+			schedule.Timezone = tz
+		}
+		switch windows := v["windows"].(type) {
+		case string:
+			schedule.Windows = strings.Split(windows, ";")
+		case []any:
+			for _, item := range windows {
+				if s, okString := item.(string); okString {
+					schedule.Windows = append(schedule.Windows, s)
+				}
+			}
+		case []string:
+			schedule.Windows = append(schedule.Windows, windows...)
+		}
+	case *Schedule:
+		schedule = v
+	}
+	return NormalizeSchedule(schedule)
+}
+
+// ScheduleToMetadata converts a schedule into the auth-file representation.
+func ScheduleToMetadata(schedule *Schedule) map[string]any {
+	schedule = NormalizeSchedule(schedule)
+	if schedule == nil {
+		return nil
+	}
+	windows := make([]any, 0, len(schedule.Windows))
+	for _, window := range schedule.Windows {
+		windows = append(windows, window)
+	}
+	out := map[string]any{"windows": windows}
+	if schedule.Timezone != "" {
+		out["timezone"] = schedule.Timezone
+	}
+	return out
+}
+
+// ScheduleAvailability reports whether the auth's schedule allows it at now. When it does
+// not, next is the start of the following window (zero when unknown) and err describes an
+// invalid schedule, which keeps the auth unavailable.
+func ScheduleAvailability(auth *Auth, now time.Time) (available bool, next time.Time, err error) {
+	if auth == nil || auth.Schedule == nil || len(auth.Schedule.Windows) == 0 {
+		return true, time.Time{}, nil
+	}
+	key := auth.Schedule.Timezone + "|" + strings.Join(auth.Schedule.Windows, ";")
+	cached, ok := compiledSchedules.Load(key)
+	if !ok {
+		compiled, errCompile := internalconfig.CompileSchedule(auth.Schedule)
+		cached, _ = compiledSchedules.LoadOrStore(key, compiledScheduleEntry{schedule: compiled, err: errCompile})
+	}
+	entry := cached.(compiledScheduleEntry)
+	if entry.err != nil {
+		return false, time.Time{}, entry.err
+	}
+	available, next = entry.schedule.Available(now)
+	return available, next, nil
+}
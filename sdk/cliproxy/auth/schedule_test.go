@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetAvailableAuthsSkipsAuthsOutsideSchedule(t *testing.T) {
+	// Tuesday noon UTC.
+	now := time.Date(2026, 3, 17, 12, 0, 0, 0, time.UTC)
+	evenings := &Schedule{Timezone: "UTC", Windows: []string{"mon-fri 18:00-08:00"}}
+	personal := &Auth{ID: "personal", Provider: "claude", Schedule: evenings}
+	work := &Auth{ID: "work", Provider: "claude"}
+
+	available, err := getAvailableAuths([]*Auth{personal, work}, "claude", "m", now)
+	if err != nil || len(available) != 1 || available[0].ID != "work" {
+		t.Fatalf("getAvailableAuths() = %v, %v; want only work", available, err)
+	}
+
+	_, err = getAvailableAuths([]*Auth{personal}, "claude", "m", now)
+	var cooldown *modelCooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("error = %v, want model cooldown", err)
+	}
+	if cooldown.resetIn != 6*time.Hour {
+		t.Fatalf("resetIn = %v, want 6h", cooldown.resetIn)
+	}
+
+	if available, err = getAvailableAuths([]*Auth{personal}, "claude", "m", now.Add(7*time.Hour)); err != nil || len(available) != 1 {
+		t.Fatalf("getAvailableAuths() in window = %v, %v", available, err)
+	}
+}
+
+func TestScheduleFromMetadata(t *testing.T) {
+	schedule := ScheduleFromMetadata(map[string]any{
+		"schedule": map[string]any{"timezone": " UTC ", "windows": []any{"sat,sun", "  mon   09:00-10:00 "}},
+	})
+	if schedule == nil || schedule.Timezone != "UTC" || len(schedule.Windows) != 2 || schedule.Windows[1] != "mon 09:00-10:00" {
+		t.Fatalf("schedule = %+v", schedule)
+	}
+	if round := ScheduleFromMetadata(map[string]any{"schedule": ScheduleToMetadata(schedule)}); round == nil || round.Windows[0] != "sat,sun" {
+		t.Fatalf("round trip = %+v", round)
+	}
+	broken := &Auth{ID: "x", Schedule: &Schedule{Windows: []string{"someday"}}}
+	if ok, _, err := ScheduleAvailability(broken, time.Now()); ok || err == nil {
+		t.Fatal("an invalid schedule should keep the auth unavailable")
+	}
+}
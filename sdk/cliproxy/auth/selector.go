@@ -221,6 +221,10 @@ func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, block
 	if auth.Disabled || auth.Status == StatusDisabled {
 		return true, blockReasonDisabled, time.Time{}
 	}
+	// Outside its schedule an auth counts as cooling down until its next window opens.
+	if available, next, _ := ScheduleAvailability(auth, now); !available {
+		return true, blockReasonCooldown, next
+	}
 	if model != "" {
 		if len(auth.ModelStates) > 0 {
 			if state, ok := auth.ModelStates[model]; ok && state != nil {
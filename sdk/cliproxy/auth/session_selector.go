@@ -35,6 +35,8 @@ type sessionBinding struct {
 	lastUsed      time.Time
 	failCount     int
 	cooldownUntil time.Time
+	// provisional marks a binding created while the store is still being searched for one.
+	provisional bool
 }
 
 type resultSample struct {
@@ -56,6 +58,17 @@ type SessionSelector struct {
 	sessions map[string]*sessionBinding
 	stats    map[string]*authStats
 	clock    func() time.Time
+
+	// store persists bindings and statistics so stickiness survives restarts and is shared
+	// between replicas; nil keeps all state in memory.
+	store           StateStore
+	persistInterval time.Duration
+	lastFlush       time.Time
+	flushing        bool
+	dirtySessions   map[string]struct{}
+	deletedSessions map[string]struct{}
+	dirtyStats      map[string]struct{}
+	prefetching     map[string]struct{}
 }
 
 // NewSessionSelector constructs a session-aware selector.
@@ -66,6 +79,11 @@ func NewSessionSelector(cfg SessionSelectorConfig) *SessionSelector {
 		sessions: make(map[string]*sessionBinding),
 		stats:    make(map[string]*authStats),
 		clock:    time.Now,
+
+		dirtySessions:   make(map[string]struct{}),
+		deletedSessions: make(map[string]struct{}),
+		dirtyStats:      make(map[string]struct{}),
+		prefetching:     make(map[string]struct{}),
 	}
 }
 
@@ -90,6 +108,7 @@ func (s *SessionSelector) RecordResult(ctx context.Context, result Result) {
 		status = result.Error.HTTPStatus
 	}
 
+	defer s.maybeFlush()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -114,6 +133,7 @@ func (s *SessionSelector) RecordResult(ctx context.Context, result Result) {
 		stats.recentRequests = pruneOldTimestamps(stats.recentRequests, now.Add(-s.cfg.LoadWindow))
 		stats.pendingRequests = pruneOldTimestamps(stats.pendingRequests, now.Add(-s.cfg.LoadWindow))
 	}
+	s.markStatsDirtyLocked(result.AuthID)
 
 	sessionID := SessionIDFromContext(ctx)
 	if sessionID == "" || !s.isProviderEnabled(result.Provider) {
@@ -125,6 +145,7 @@ func (s *SessionSelector) RecordResult(ctx context.Context, result Result) {
 		return
 	}
 	binding.lastUsed = now
+	s.markSessionDirtyLocked(key)
 	if result.Success {
 		binding.failCount = 0
 		binding.cooldownUntil = time.Time{}
@@ -155,9 +176,19 @@ func (s *SessionSelector) Pick(ctx context.Context, provider, model string, opts
 	sessionID := extractSessionIDFromOptions(opts)
 	providerKey := strings.TrimSpace(strings.ToLower(provider))
 	isMixedProvider := providerKey == "mixed"
+	var missing []string
+	defer func() {
+		if len(missing) > 0 {
+			go s.prefetchSessions(context.Background(), missing)
+		}
+		s.maybeFlush()
+	}()
 
 	s.mu.Lock()
 	s.cleanupLocked(now)
+	if sessionID != "" {
+		missing = s.missingSessionKeysLocked(provider, sessionID, available)
+	}
 	var excludedAuthID string
 	if sessionID != "" {
 		if isMixedProvider {
@@ -176,12 +207,14 @@ func (s *SessionSelector) Pick(ctx context.Context, provider, model string, opts
 				} else if binding.lastUsed.Add(s.cfg.TTL).After(now) {
 					if auth := findAuthByID(available, binding.authID); auth != nil {
 						binding.lastUsed = now
+						s.markSessionDirtyLocked(key)
 						s.trackPendingRequestLocked(auth.ID, now)
 						s.mu.Unlock()
 						return auth, nil
 					}
 				} else {
 					delete(s.sessions, key)
+					s.markSessionDeletedLocked(key)
 				}
 			}
 		}
@@ -200,10 +233,13 @@ func (s *SessionSelector) Pick(ctx context.Context, provider, model string, opts
 		}
 		if s.isProviderEnabled(bindingProvider) {
 			key := s.sessionKey(bindingProvider, sessionID)
+			_, pending := s.prefetching[key]
 			s.sessions[key] = &sessionBinding{
-				authID:   selected.ID,
-				lastUsed: now,
+				authID:      selected.ID,
+				lastUsed:    now,
+				provisional: pending,
 			}
+			s.markSessionDirtyLocked(key)
 		}
 	}
 	s.trackPendingRequestLocked(selected.ID, now)
@@ -284,6 +320,7 @@ func (s *SessionSelector) cleanupLocked(now time.Time) {
 	for key, binding := range s.sessions {
 		if binding == nil || binding.lastUsed.Before(ttlCutoff) {
 			delete(s.sessions, key)
+			s.markSessionDeletedLocked(key)
 		}
 	}
 	if s.cfg.LoadWindow <= 0 {
@@ -317,6 +354,7 @@ func (s *SessionSelector) resolveMixedBindingLocked(sessionID string, available
 
 	var (
 		stickyAuth     *Auth
+		stickyKey      string
 		stickyBinding  *sessionBinding
 		stickyLastUsed time.Time
 		excludedAuthID string
@@ -334,6 +372,7 @@ func (s *SessionSelector) resolveMixedBindingLocked(sessionID string, available
 		}
 		if !binding.lastUsed.Add(s.cfg.TTL).After(now) {
 			delete(s.sessions, key)
+			s.markSessionDeletedLocked(key)
 			continue
 		}
 		auth := availableByID[binding.authID]
@@ -349,6 +388,7 @@ func (s *SessionSelector) resolveMixedBindingLocked(sessionID string, available
 		}
 		if stickyBinding == nil || binding.lastUsed.After(stickyLastUsed) {
 			stickyAuth = auth
+			stickyKey = key
 			stickyBinding = binding
 			stickyLastUsed = binding.lastUsed
 		}
@@ -356,6 +396,7 @@ func (s *SessionSelector) resolveMixedBindingLocked(sessionID string, available
 
 	if stickyAuth != nil && stickyBinding != nil {
 		stickyBinding.lastUsed = now
+		s.markSessionDirtyLocked(stickyKey)
 		return stickyAuth, ""
 	}
 	return nil, excludedAuthID
@@ -96,3 +96,84 @@ func TestSessionSelectorPick_MixedCooldownFailover(t *testing.T) {
 		t.Fatalf("second Pick() selected = %v, want auth-b", second)
 	}
 }
+
+func TestSessionSelectorPersistence_RestoresAndSharesBindings(t *testing.T) {
+	ctx := context.Background()
+	store := NewDirStateStore(t.TempDir())
+	cfg := SessionSelectorConfig{Enabled: true, TTL: 5 * time.Minute, LoadWindow: time.Minute}
+	opts := cliproxyexecutor.Options{
+		Metadata: map[string]any{
+			cliproxyexecutor.SessionIDMetadataKey: "session-1",
+		},
+	}
+	authA := &Auth{ID: "auth-a", Provider: "codex", Status: StatusActive}
+	authB := &Auth{ID: "auth-b", Provider: "codex", Status: StatusActive}
+
+	first := NewSessionSelector(cfg)
+	first.SetStateStore(ctx, store, time.Hour)
+	// A replica that is already running only sees the binding through a store lookup.
+	peer := NewSessionSelector(cfg)
+	peer.SetStateStore(ctx, store, time.Hour)
+
+	selected, err := first.Pick(ctx, "codex", "test-model", opts, []*Auth{authB})
+	if err != nil || selected.ID != "auth-b" {
+		t.Fatalf("first Pick() = %v, %v; want auth-b", selected, err)
+	}
+	first.RecordResult(WithSessionID(ctx, "session-1"), Result{AuthID: "auth-b", Provider: "codex", Success: true})
+	waitForSessionPrefetch(t, first)
+	first.Flush(ctx)
+
+	// Without the persisted binding the tie between both auths would resolve to auth-a.
+	restarted := NewSessionSelector(cfg)
+	restarted.SetStateStore(ctx, store, time.Hour)
+	selected, err = restarted.Pick(ctx, "codex", "test-model", opts, []*Auth{authA, authB})
+	if err != nil || selected.ID != "auth-b" {
+		t.Fatalf("restarted Pick() = %v, %v; want auth-b", selected, err)
+	}
+
+	// The peer does not block on the store: its first pick is provisional and the stored
+	// binding takes over once the background lookup completes.
+	if _, err = peer.Pick(ctx, "codex", "test-model", opts, []*Auth{authA, authB}); err != nil {
+		t.Fatalf("peer Pick() error = %v", err)
+	}
+	waitForSessionPrefetch(t, peer)
+	selected, err = peer.Pick(ctx, "codex", "test-model", opts, []*Auth{authA, authB})
+	if err != nil || selected.ID != "auth-b" {
+		t.Fatalf("peer Pick() after lookup = %v, %v; want auth-b", selected, err)
+	}
+	restarted.mu.Lock()
+	stats := restarted.stats["auth-b"]
+	restarted.mu.Unlock()
+	if stats == nil || len(stats.recentResults) != 1 {
+		t.Fatalf("expected restored health stats for auth-b, got %+v", stats)
+	}
+
+	sessions := peer.Sessions(ctx)
+	if len(sessions) != 1 || sessions[0].SessionID != "session-1" || sessions[0].AuthID != "auth-b" {
+		t.Fatalf("Sessions() = %+v", sessions)
+	}
+	if evicted := peer.EvictSessions(ctx, SessionFilter{AuthID: "auth-b"}); evicted != 1 {
+		t.Fatalf("EvictSessions() = %d, want 1", evicted)
+	}
+	if keys, _ := store.ListState(ctx, sessionBindingNamespace); len(keys) != 0 {
+		t.Fatalf("expected evicted binding to be removed from the store, got %v", keys)
+	}
+}
+
+// waitForSessionPrefetch waits until the background store lookups of s have completed.
+func waitForSessionPrefetch(t *testing.T, s *SessionSelector) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		pending := len(s.prefetching)
+		s.mu.Unlock()
+		if pending == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session store lookups did not complete")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,532 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// sessionBindingNamespace holds sticky session bindings shared across replicas.
+	sessionBindingNamespace = "routing-sessions"
+	// sessionStatsNamespace holds per-auth health statistics used for scoring.
+	sessionStatsNamespace = "routing-stats"
+	// defaultSessionPersistInterval bounds how often dirty state is written back.
+	defaultSessionPersistInterval = 10 * time.Second
+)
+
+// SessionBindingInfo describes a sticky session binding.
+type SessionBindingInfo struct {
+	Provider      string     `json:"provider"`
+	SessionID     string     `json:"session_id"`
+	AuthID        string     `json:"auth_id"`
+	LastUsed      time.Time  `json:"last_used"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	FailCount     int        `json:"fail_count"`
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+	// Local reports whether this instance holds the binding in memory; bindings created by
+	// other replicas are only present in the shared store.
+	Local bool `json:"local"`
+}
+
+// SessionFilter selects bindings for eviction. Empty fields match everything.
+type SessionFilter struct {
+	Provider  string
+	SessionID string
+	AuthID    string
+}
+
+func (f SessionFilter) matches(provider, sessionID, authID string) bool {
+	if f.Provider != "" && !strings.EqualFold(f.Provider, provider) {
+		return false
+	}
+	if f.SessionID != "" && f.SessionID != sessionID {
+		return false
+	}
+	if f.AuthID != "" && f.AuthID != authID {
+		return false
+	}
+	return true
+}
+
+type persistedBinding struct {
+	Key           string    `json:"key"`
+	AuthID        string    `json:"auth_id"`
+	LastUsed      time.Time `json:"last_used"`
+	FailCount     int       `json:"fail_count,omitempty"`
+	CooldownUntil time.Time `json:"cooldown_until,omitempty"`
+}
+
+type persistedSample struct {
+	At      time.Time `json:"at"`
+	Success bool      `json:"success"`
+	Status  int       `json:"status,omitempty"`
+}
+
+type persistedStats struct {
+	AuthID    string            `json:"auth_id"`
+	Results   []persistedSample `json:"results,omitempty"`
+	Requests  []time.Time       `json:"requests,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// SetStateStore enables persistence of session bindings and health statistics. State already
+// present in the store is restored immediately; afterwards changes are written back at most
+// once per interval, and bindings missing from memory are looked up in the store in the
+// background so sessions stay on the same auth across restarts and replicas. A nil store
+// disables persistence.
+func (s *SessionSelector) SetStateStore(ctx context.Context, store StateStore, interval time.Duration) {
+	if s == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultSessionPersistInterval
+	}
+	s.mu.Lock()
+	s.store = store
+	s.persistInterval = interval
+	s.lastFlush = s.now()
+	s.mu.Unlock()
+	if store != nil {
+		s.restore(ctx)
+	}
+}
+
+// Flush writes pending binding and statistics changes to the state store.
+func (s *SessionSelector) Flush(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	store := s.store
+	if store == nil || s.flushing {
+		s.mu.Unlock()
+		return
+	}
+	s.flushing = true
+	s.lastFlush = s.now()
+	deleted := make([]string, 0, len(s.deletedSessions))
+	for key := range s.deletedSessions {
+		deleted = append(deleted, key)
+	}
+	bindings := make([]persistedBinding, 0, len(s.dirtySessions))
+	// Provisional bindings wait for their store lookup so they never overwrite a shared one.
+	provisional := make(map[string]struct{})
+	for key := range s.dirtySessions {
+		binding := s.sessions[key]
+		switch {
+		case binding == nil:
+		case binding.provisional:
+			provisional[key] = struct{}{}
+		default:
+			bindings = append(bindings, persistBinding(key, binding))
+		}
+	}
+	stats := make([]persistedStats, 0, len(s.dirtyStats))
+	for authID := range s.dirtyStats {
+		if st := s.stats[authID]; st != nil {
+			stats = append(stats, persistStats(authID, st, s.lastFlush))
+		}
+	}
+	s.deletedSessions = make(map[string]struct{})
+	s.dirtySessions = provisional
+	s.dirtyStats = make(map[string]struct{})
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.flushing = false
+		s.mu.Unlock()
+	}()
+
+	for _, key := range deleted {
+		if err := store.DeleteState(ctx, sessionBindingNamespace, stateKeyFor(key)); err != nil {
+			log.Debugf("session selector: delete binding: %v", err)
+		}
+	}
+	for _, binding := range bindings {
+		// Another replica may have moved the session meanwhile; the most recent use wins.
+		if stored, err := loadBinding(ctx, store, binding.Key); err == nil && stored.LastUsed.After(binding.LastUsed) {
+			s.mu.Lock()
+			if local := s.sessions[binding.Key]; local != nil && !local.lastUsed.After(stored.LastUsed) {
+				s.sessions[binding.Key] = stored.binding()
+			}
+			s.mu.Unlock()
+			continue
+		}
+		if err := saveJSONState(ctx, store, sessionBindingNamespace, stateKeyFor(binding.Key), binding); err != nil {
+			log.Debugf("session selector: save binding: %v", err)
+		}
+	}
+	for _, st := range stats {
+		if err := saveJSONState(ctx, store, sessionStatsNamespace, stateKeyFor(st.AuthID), st); err != nil {
+			log.Debugf("session selector: save stats: %v", err)
+		}
+	}
+}
+
+// Sessions lists active bindings held in memory or in the shared store.
+func (s *SessionSelector) Sessions(ctx context.Context) []SessionBindingInfo {
+	if s == nil {
+		return nil
+	}
+	now := s.now()
+	s.mu.Lock()
+	ttl := s.cfg.TTL
+	store := s.store
+	byKey := make(map[string]SessionBindingInfo, len(s.sessions))
+	for key, binding := range s.sessions {
+		if binding == nil || !binding.lastUsed.Add(ttl).After(now) {
+			continue
+		}
+		byKey[key] = bindingInfo(key, binding, ttl, true)
+	}
+	s.mu.Unlock()
+
+	if store != nil {
+		s.forEachStoredBinding(ctx, store, now, ttl, func(stored persistedBinding) {
+			if local, ok := byKey[stored.Key]; ok && !stored.LastUsed.After(local.LastUsed) {
+				return
+			}
+			byKey[stored.Key] = bindingInfo(stored.Key, stored.binding(), ttl, false)
+		})
+	}
+
+	out := make([]SessionBindingInfo, 0, len(byKey))
+	for _, info := range byKey {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].LastUsed.Equal(out[j].LastUsed) {
+			return out[i].LastUsed.After(out[j].LastUsed)
+		}
+		return out[i].Provider+":"+out[i].SessionID < out[j].Provider+":"+out[j].SessionID
+	})
+	return out
+}
+
+// EvictSessions removes matching bindings from memory and from the shared store and returns
+// how many bindings were removed.
+func (s *SessionSelector) EvictSessions(ctx context.Context, filter SessionFilter) int {
+	if s == nil {
+		return 0
+	}
+	removed := make(map[string]struct{})
+	s.mu.Lock()
+	store := s.store
+	ttl := s.cfg.TTL
+	for key, binding := range s.sessions {
+		provider, sessionID := splitSessionKey(key)
+		authID := ""
+		if binding != nil {
+			authID = binding.authID
+		}
+		if filter.matches(provider, sessionID, authID) {
+			delete(s.sessions, key)
+			delete(s.dirtySessions, key)
+			removed[key] = struct{}{}
+		}
+	}
+	s.mu.Unlock()
+
+	if store != nil {
+		for key := range removed {
+			if err := store.DeleteState(ctx, sessionBindingNamespace, stateKeyFor(key)); err != nil {
+				log.Debugf("session selector: delete binding: %v", err)
+			}
+		}
+		s.forEachStoredBinding(ctx, store, s.now(), ttl, func(stored persistedBinding) {
+			provider, sessionID := splitSessionKey(stored.Key)
+			if !filter.matches(provider, sessionID, stored.AuthID) {
+				return
+			}
+			if err := store.DeleteState(ctx, sessionBindingNamespace, stateKeyFor(stored.Key)); err != nil {
+				log.Debugf("session selector: delete binding: %v", err)
+				return
+			}
+			removed[stored.Key] = struct{}{}
+		})
+	}
+	return len(removed)
+}
+
+// restore loads persisted bindings and statistics that are still fresh.
+func (s *SessionSelector) restore(ctx context.Context) {
+	s.mu.Lock()
+	store := s.store
+	ttl := s.cfg.TTL
+	loadWindow := s.cfg.LoadWindow
+	s.mu.Unlock()
+	now := s.now()
+
+	restored := 0
+	s.forEachStoredBinding(ctx, store, now, ttl, func(stored persistedBinding) {
+		s.mu.Lock()
+		if local := s.sessions[stored.Key]; local == nil || stored.LastUsed.After(local.lastUsed) {
+			s.sessions[stored.Key] = stored.binding()
+			restored++
+		}
+		s.mu.Unlock()
+	})
+
+	keys, err := store.ListState(ctx, sessionStatsNamespace)
+	if err != nil {
+		log.Warnf("session selector: unable to list persisted stats: %v", err)
+		return
+	}
+	for _, key := range keys {
+		var stored persistedStats
+		if errLoad := loadJSONState(ctx, store, sessionStatsNamespace, key, &stored); errLoad != nil || stored.AuthID == "" {
+			continue
+		}
+		st := stored.authStats()
+		if loadWindow > 0 {
+			st.recentRequests = pruneOldTimestamps(st.recentRequests, now.Add(-loadWindow))
+		}
+		s.mu.Lock()
+		if s.stats[stored.AuthID] == nil {
+			s.stats[stored.AuthID] = st
+		}
+		s.mu.Unlock()
+	}
+	if restored > 0 {
+		log.Infof("session selector: restored %d session bindings", restored)
+	}
+}
+
+// missingSessionKeysLocked returns the binding keys of sessionID that are neither in memory
+// nor already being looked up, and marks them as being looked up. Pick calls it while other
+// locks may be held, so it only reads memory; prefetchSessions does the store round trips.
+func (s *SessionSelector) missingSessionKeysLocked(provider, sessionID string, available []*Auth) []string {
+	if s.store == nil {
+		return nil
+	}
+	providers := []string{provider}
+	if strings.EqualFold(strings.TrimSpace(provider), "mixed") {
+		providers = providers[:0]
+		seen := make(map[string]struct{}, len(available))
+		for _, auth := range available {
+			if auth == nil {
+				continue
+			}
+			name := strings.TrimSpace(strings.ToLower(auth.Provider))
+			if _, ok := seen[name]; ok || name == "" {
+				continue
+			}
+			seen[name] = struct{}{}
+			providers = append(providers, name)
+		}
+	}
+	var missing []string
+	for _, name := range providers {
+		if !s.isProviderEnabled(name) {
+			continue
+		}
+		key := s.sessionKey(name, sessionID)
+		if s.sessions[key] != nil {
+			continue
+		}
+		if _, pending := s.prefetching[key]; pending {
+			continue
+		}
+		s.prefetching[key] = struct{}{}
+		missing = append(missing, key)
+	}
+	return missing
+}
+
+// prefetchSessions loads bindings created by another replica or a previous run in the
+// background. A stored binding replaces the provisional one the triggering request created,
+// so the session's following requests return to the auth it was bound to.
+func (s *SessionSelector) prefetchSessions(ctx context.Context, keys []string) {
+	s.mu.Lock()
+	store := s.store
+	ttl := s.cfg.TTL
+	s.mu.Unlock()
+	now := s.now()
+	for _, key := range keys {
+		var stored persistedBinding
+		found := false
+		if store != nil {
+			var err error
+			stored, err = loadBinding(ctx, store, key)
+			found = err == nil && stored.LastUsed.Add(ttl).After(now)
+		}
+		s.mu.Lock()
+		delete(s.prefetching, key)
+		local := s.sessions[key]
+		switch {
+		case found && (local == nil || local.provisional):
+			s.sessions[key] = stored.binding()
+			delete(s.dirtySessions, key)
+		case local != nil:
+			local.provisional = false
+		}
+		s.mu.Unlock()
+	}
+}
+
+// maybeFlush starts a background flush once the persistence interval has elapsed.
+func (s *SessionSelector) maybeFlush() {
+	s.mu.Lock()
+	due := s.flushDueLocked(s.now())
+	s.mu.Unlock()
+	if due {
+		go s.Flush(context.Background())
+	}
+}
+
+// markSessionDirtyLocked records a binding change for the next flush.
+func (s *SessionSelector) markSessionDirtyLocked(key string) {
+	if s.store == nil {
+		return
+	}
+	delete(s.deletedSessions, key)
+	s.dirtySessions[key] = struct{}{}
+}
+
+// markSessionDeletedLocked records an expired or evicted binding for the next flush.
+func (s *SessionSelector) markSessionDeletedLocked(key string) {
+	if s.store == nil {
+		return
+	}
+	delete(s.dirtySessions, key)
+	s.deletedSessions[key] = struct{}{}
+}
+
+func (s *SessionSelector) markStatsDirtyLocked(authID string) {
+	if s.store == nil {
+		return
+	}
+	s.dirtyStats[authID] = struct{}{}
+}
+
+// flushDueLocked reports whether a background flush should start now.
+func (s *SessionSelector) flushDueLocked(now time.Time) bool {
+	if s.store == nil || s.flushing {
+		return false
+	}
+	if len(s.dirtySessions) == 0 && len(s.deletedSessions) == 0 && len(s.dirtyStats) == 0 {
+		return false
+	}
+	return now.Sub(s.lastFlush) >= s.persistInterval
+}
+
+func (s *SessionSelector) forEachStoredBinding(ctx context.Context, store StateStore, now time.Time, ttl time.Duration, fn func(persistedBinding)) {
+	keys, err := store.ListState(ctx, sessionBindingNamespace)
+	if err != nil {
+		log.Warnf("session selector: unable to list persisted bindings: %v", err)
+		return
+	}
+	for _, key := range keys {
+		var stored persistedBinding
+		if errLoad := loadJSONState(ctx, store, sessionBindingNamespace, key, &stored); errLoad != nil || stored.Key == "" {
+			continue
+		}
+		if !stored.LastUsed.Add(ttl).After(now) {
+			// Expired bindings are garbage collected by whichever replica sees them first.
+			_ = store.DeleteState(ctx, sessionBindingNamespace, key)
+			continue
+		}
+		fn(stored)
+	}
+}
+
+func (p persistedBinding) binding() *sessionBinding {
+	return &sessionBinding{authID: p.AuthID, lastUsed: p.LastUsed, failCount: p.FailCount, cooldownUntil: p.CooldownUntil}
+}
+
+func persistBinding(key string, binding *sessionBinding) persistedBinding {
+	return persistedBinding{
+		Key:           key,
+		AuthID:        binding.authID,
+		LastUsed:      binding.lastUsed,
+		FailCount:     binding.failCount,
+		CooldownUntil: binding.cooldownUntil,
+	}
+}
+
+func (p persistedStats) authStats() *authStats {
+	st := &authStats{recentRequests: append([]time.Time(nil), p.Requests...)}
+	for _, sample := range p.Results {
+		st.recentResults = append(st.recentResults, resultSample{timestamp: sample.At, success: sample.Success, status: sample.Status})
+	}
+	return st
+}
+
+func persistStats(authID string, st *authStats, now time.Time) persistedStats {
+	out := persistedStats{AuthID: authID, Requests: append([]time.Time(nil), st.recentRequests...), UpdatedAt: now}
+	for _, sample := range st.recentResults {
+		out.Results = append(out.Results, persistedSample{At: sample.timestamp, Success: sample.success, Status: sample.status})
+	}
+	return out
+}
+
+func bindingInfo(key string, binding *sessionBinding, ttl time.Duration, local bool) SessionBindingInfo {
+	provider, sessionID := splitSessionKey(key)
+	info := SessionBindingInfo{
+		Provider:  provider,
+		SessionID: sessionID,
+		AuthID:    binding.authID,
+		LastUsed:  binding.lastUsed,
+		ExpiresAt: binding.lastUsed.Add(ttl),
+		FailCount: binding.failCount,
+		Local:     local,
+	}
+	if !binding.cooldownUntil.IsZero() {
+		cooldown := binding.cooldownUntil
+		info.CooldownUntil = &cooldown
+	}
+	return info
+}
+
+func splitSessionKey(key string) (string, string) {
+	provider, sessionID, found := strings.Cut(key, ":")
+	if !found {
+		return "", key
+	}
+	return provider, sessionID
+}
+
+func loadBinding(ctx context.Context, store StateStore, key string) (persistedBinding, error) {
+	var stored persistedBinding
+	if err := loadJSONState(ctx, store, sessionBindingNamespace, stateKeyFor(key), &stored); err != nil {
+		return stored, err
+	}
+	if stored.Key != key {
+		return stored, ErrStateNotFound
+	}
+	return stored, nil
+}
+
+// stateKeyFor maps arbitrary identifiers (session IDs, auth IDs) to valid state keys.
+func stateKeyFor(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func saveJSONState(ctx context.Context, store StateStore, namespace, key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return store.SaveState(ctx, namespace, key, raw)
+}
+
+func loadJSONState(ctx context.Context, store StateStore, namespace, key string, value any) error {
+	raw, err := store.LoadState(ctx, namespace, key)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(raw, value); err != nil {
+		return errors.Join(ErrStateNotFound, err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// stateFileSuffix keeps state files apart from the *.json auth files scanned by the watcher.
+const stateFileSuffix = ".state"
+
+var stateKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-]{0,199}$`)
+
+// ValidateStateKey reports whether value is usable as a StateStore namespace or key.
+func ValidateStateKey(value string) error {
+	if !stateKeyPattern.MatchString(value) {
+		return fmt.Errorf("invalid state key %q", value)
+	}
+	return nil
+}
+
+// DirStateStore is a StateStore backed by a local directory. Each value is kept in
+// <root>/<namespace>/<key>.state and written atomically.
+type DirStateStore struct {
+	mu   sync.RWMutex
+	root string
+}
+
+// NewDirStateStore creates a directory backed state store rooted at root.
+func NewDirStateStore(root string) *DirStateStore {
+	return &DirStateStore{root: strings.TrimSpace(root)}
+}
+
+// SetRoot changes the directory used for state persistence.
+func (s *DirStateStore) SetRoot(root string) {
+	s.mu.Lock()
+	s.root = strings.TrimSpace(root)
+	s.mu.Unlock()
+}
+
+// Root returns the directory used for state persistence.
+func (s *DirStateStore) Root() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.root
+}
+
+// Path returns the file path used for namespace/key.
+func (s *DirStateStore) Path(namespace, key string) (string, error) {
+	if err := ValidateStateKey(namespace); err != nil {
+		return "", err
+	}
+	if err := ValidateStateKey(key); err != nil {
+		return "", err
+	}
+	root := s.Root()
+	if root == "" {
+		return "", fmt.Errorf("state store: directory not configured")
+	}
+	return filepath.Join(root, namespace, key+stateFileSuffix), nil
+}
+
+// SaveState implements StateStore.
+func (s *DirStateStore) SaveState(_ context.Context, namespace, key string, data []byte) error {
+	path, err := s.Path(namespace, key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("state store: create directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("state store: write %s: %w", key, err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("state store: rename %s: %w", key, err)
+	}
+	return nil
+}
+
+// LoadState implements StateStore.
+func (s *DirStateStore) LoadState(_ context.Context, namespace, key string) ([]byte, error) {
+	path, err := s.Path(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrStateNotFound
+		}
+		return nil, fmt.Errorf("state store: read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// ListState implements StateStore.
+func (s *DirStateStore) ListState(_ context.Context, namespace string) ([]string, error) {
+	if err := ValidateStateKey(namespace); err != nil {
+		return nil, err
+	}
+	root := s.Root()
+	if root == "" {
+		return nil, fmt.Errorf("state store: directory not configured")
+	}
+	entries, err := os.ReadDir(filepath.Join(root, namespace))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("state store: list %s: %w", namespace, err)
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, stateFileSuffix) {
+			continue
+		}
+		keys = append(keys, strings.TrimSuffix(name, stateFileSuffix))
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DeleteState implements StateStore.
+func (s *DirStateStore) DeleteState(_ context.Context, namespace, key string) error {
+	path, err := s.Path(namespace, key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("state store: delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// MemoryStateStore is a process-local StateStore used when the configured token store
+// cannot persist state. Values are lost on restart.
+type MemoryStateStore struct {
+	mu     sync.RWMutex
+	values map[string]map[string][]byte
+}
+
+// NewMemoryStateStore creates an empty in-memory state store.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{values: make(map[string]map[string][]byte)}
+}
+
+// SaveState implements StateStore.
+func (s *MemoryStateStore) SaveState(_ context.Context, namespace, key string, data []byte) error {
+	if err := ValidateStateKey(namespace); err != nil {
+		return err
+	}
+	if err := ValidateStateKey(key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values[namespace] == nil {
+		s.values[namespace] = make(map[string][]byte)
+	}
+	s.values[namespace][key] = append([]byte(nil), data...)
+	return nil
+}
+
+// LoadState implements StateStore.
+func (s *MemoryStateStore) LoadState(_ context.Context, namespace, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.values[namespace][key]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// ListState implements StateStore.
+func (s *MemoryStateStore) ListState(_ context.Context, namespace string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.values[namespace]))
+	for key := range s.values[namespace] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DeleteState implements StateStore.
+func (s *MemoryStateStore) DeleteState(_ context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values[namespace], key)
+	return nil
+}
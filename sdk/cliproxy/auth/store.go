@@ -1,6 +1,9 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // Store abstracts persistence of Auth state across restarts.
 type Store interface {
@@ -11,3 +14,28 @@ type Store interface {
 	// Delete removes the auth record identified by id.
 	Delete(ctx context.Context, id string) error
 }
+
+// ErrStateNotFound is returned by StateStore.LoadState when the key does not exist.
+var ErrStateNotFound = errors.New("state not found")
+
+// StateStore is optionally implemented by a Store that can also persist auxiliary
+// runtime state (batch jobs, uploaded files, ...) next to the auth records.
+// Values are opaque blobs grouped by namespace; namespaces and keys must satisfy
+// ValidateStateKey.
+type StateStore interface {
+	// SaveState stores data under namespace/key, replacing any previous value.
+	SaveState(ctx context.Context, namespace, key string, data []byte) error
+	// LoadState returns the value stored under namespace/key or ErrStateNotFound.
+	LoadState(ctx context.Context, namespace, key string) ([]byte, error)
+	// ListState returns the keys stored in the namespace.
+	ListState(ctx context.Context, namespace string) ([]string, error)
+	// DeleteState removes namespace/key; deleting a missing key is not an error.
+	DeleteState(ctx context.Context, namespace, key string) error
+}
+
+// LocalStateStore is optionally implemented by a StateStore whose state stays on the local
+// machine even though its auth records are shared, so other replicas never see it.
+type LocalStateStore interface {
+	StateStore
+	StateIsLocal() bool
+}
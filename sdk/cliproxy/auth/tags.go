@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// PoolRefPrefix marks an auth reference naming a pool from auth-pools, e.g. "pool:research".
+const PoolRefPrefix = "pool:"
+
+// TagSelector matches auth tags. Terms are comma separated and must all hold:
+// "key=value" requires the tag value, "key!=value" excludes it and a bare "key"
+// requires the tag to be present.
+type TagSelector []tagTerm
+
+type tagTerm struct {
+	key    string
+	value  string
+	op     string // "=", "!=" or "" (presence)
+	negate bool
+}
+
+// ParseTagSelector parses a selector such as "team=research,tier=max".
+func ParseTagSelector(raw string) (TagSelector, error) {
+	var out TagSelector
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		term := tagTerm{}
+		switch {
+		case strings.Contains(part, "!="):
+			idx := strings.Index(part, "!=")
+			term.key, term.value, term.op = part[:idx], part[idx+2:], "!="
+		case strings.Contains(part, "="):
+			idx := strings.Index(part, "=")
+			term.key, term.value, term.op = part[:idx], part[idx+1:], "="
+		case strings.HasPrefix(part, "!"):
+			term.key, term.negate = part[1:], true
+		default:
+			term.key = part
+		}
+		term.key = normalizeTagKey(term.key)
+		term.value = strings.TrimSpace(term.value)
+		if term.key == "" {
+			return nil, fmt.Errorf("invalid tag selector term %q", part)
+		}
+		out = append(out, term)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty tag selector")
+	}
+	return out, nil
+}
+
+// Matches reports whether tags satisfy every term of the selector. An empty selector
+// matches nothing.
+func (s TagSelector) Matches(tags map[string]string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, term := range s {
+		value, ok := tags[term.key]
+		switch term.op {
+		case "=":
+			if !ok || !strings.EqualFold(value, term.value) {
+				return false
+			}
+		case "!=":
+			if ok && strings.EqualFold(value, term.value) {
+				return false
+			}
+		default:
+			if ok == term.negate {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NormalizeTags trims tag keys and values, lowercases keys and drops empty keys.
+// It returns nil when no tag remains.
+func NormalizeTags(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(tags))
+	for key, value := range tags {
+		key = normalizeTagKey(key)
+		if key == "" {
+			continue
+		}
+		out[key] = strings.TrimSpace(value)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// TagsFromMetadata reads the "tags" field of auth-file metadata. It accepts an object
+// ({"team":"research"}), a list of "key=value" strings or a comma separated string.
+func TagsFromMetadata(metadata map[string]any) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	raw, ok := metadata["tags"]
+	if !ok || raw == nil {
+		return nil
+	}
+	tags := make(map[string]string)
+	switch v := raw.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if value == nil {
+				tags[key] = ""
+				continue
+			}
+			tags[key] = fmt.Sprint(value)
+		}
+	case map[string]string:
+		for key, value := range v {
+			tags[key] = value
+		}
+	case []any:
+		for _, item := range v {
+			if s, okString := item.(string); okString {
+				addTagPair(tags, s)
+			}
+		}
+	case []string:
+		for _, item := range v {
+			addTagPair(tags, item)
+		}
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			addTagPair(tags, item)
+		}
+	}
+	return NormalizeTags(tags)
+}
+
+// TagsToMetadata converts tags to the object stored in auth-file metadata.
+func TagsToMetadata(tags map[string]string) map[string]any {
+	out := make(map[string]any, len(tags))
+	for key, value := range tags {
+		out[key] = value
+	}
+	return out
+}
+
+func addTagPair(tags map[string]string, pair string) {
+	pair = strings.TrimSpace(pair)
+	if pair == "" {
+		return
+	}
+	key, value, _ := strings.Cut(pair, "=")
+	tags[key] = value
+}
+
+func normalizeTagKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// PoolSelector returns the selector of a pool defined in auth-pools.
+func PoolSelector(cfg *internalconfig.Config, name string) (TagSelector, bool) {
+	if cfg == nil || len(cfg.AuthPools) == 0 {
+		return nil, false
+	}
+	raw, ok := cfg.AuthPools[strings.TrimSpace(name)]
+	if !ok {
+		return nil, false
+	}
+	selector, err := ParseTagSelector(raw)
+	if err != nil {
+		return nil, false
+	}
+	return selector, true
+}
+
+// PoolNameFromRef returns the pool name of a "pool:<name>" reference.
+func PoolNameFromRef(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if !strings.HasPrefix(ref, PoolRefPrefix) {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(ref, PoolRefPrefix))
+	return name, name != ""
+}
+
+// pickWithPoolPriority runs the selector over the candidates of each pool listed in
+// routing.pool-priority in turn, falling through to the next pool when a pool has no
+// available credential. Candidates outside every listed pool form the last tier.
+func (m *Manager) pickWithPoolPriority(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, candidates []*Auth) (*Auth, error) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.Routing.PoolPriority) == 0 {
+		return m.selector.Pick(ctx, provider, model, opts, candidates)
+	}
+	selectors := make([]TagSelector, 0, len(cfg.Routing.PoolPriority))
+	for _, ref := range cfg.Routing.PoolPriority {
+		name, ok := PoolNameFromRef(ref)
+		if !ok {
+			name = strings.TrimSpace(ref)
+		}
+		if selector, okPool := PoolSelector(cfg, name); okPool {
+			selectors = append(selectors, selector)
+		}
+	}
+	if len(selectors) == 0 {
+		return m.selector.Pick(ctx, provider, model, opts, candidates)
+	}
+	tiers := make([][]*Auth, len(selectors)+1)
+	for _, candidate := range candidates {
+		tier := len(selectors)
+		for i, selector := range selectors {
+			if selector.Matches(candidate.Tags) {
+				tier = i
+				break
+			}
+		}
+		tiers[tier] = append(tiers[tier], candidate)
+	}
+	var lastErr error
+	for _, tier := range tiers {
+		if len(tier) == 0 {
+			continue
+		}
+		selected, err := m.selector.Pick(ctx, provider, model, opts, tier)
+		if err == nil && selected != nil {
+			return selected, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestTagSelectorMatches(t *testing.T) {
+	tags := map[string]string{"team": "research", "tier": "max"}
+	cases := map[string]bool{
+		"team=research":          true,
+		"Team=Research,tier=max": true,
+		"team=research,tier=pro": false,
+		"tier!=pro":              true,
+		"team":                   true,
+		"!team":                  false,
+		"region":                 false,
+	}
+	for raw, want := range cases {
+		selector, err := ParseTagSelector(raw)
+		if err != nil {
+			t.Fatalf("ParseTagSelector(%q): %v", raw, err)
+		}
+		if got := selector.Matches(tags); got != want {
+			t.Errorf("%q.Matches = %t, want %t", raw, got, want)
+		}
+	}
+	if _, err := ParseTagSelector(" , "); err == nil {
+		t.Fatal("expected error for empty selector")
+	}
+}
+
+func TestTagsFromMetadata(t *testing.T) {
+	cases := []map[string]any{
+		{"tags": map[string]any{"Team": "research", "tier": "max"}},
+		{"tags": []any{"team=research", "tier=max"}},
+		{"tags": "team=research, tier=max"},
+	}
+	for _, metadata := range cases {
+		tags := TagsFromMetadata(metadata)
+		if tags["team"] != "research" || tags["tier"] != "max" || len(tags) != 2 {
+			t.Errorf("TagsFromMetadata(%v) = %v", metadata["tags"], tags)
+		}
+	}
+	if tags := TagsFromMetadata(map[string]any{"email": "x"}); tags != nil {
+		t.Fatalf("expected nil tags, got %v", tags)
+	}
+}
+
+func TestAPIKeyAuthPermissions_PoolReference(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	exec := &recordingExecutor{provider: "gemini"}
+	manager.RegisterExecutor(exec)
+	manager.SetConfig(&internalconfig.Config{
+		AuthPools:  map[string]string{"research": "team=research"},
+		APIKeyAuth: map[string][]string{"client-1": {"pool:research"}},
+	})
+
+	ctx := context.Background()
+	_, _ = manager.Register(ctx, &Auth{ID: "auth-a", Provider: "gemini", Status: StatusActive, Tags: map[string]string{"team": "ops"}})
+	_, _ = manager.Register(ctx, &Auth{ID: "auth-b", Provider: "gemini", Status: StatusActive, Tags: map[string]string{"team": "research"}})
+
+	allowed, restricted := manager.AllowedAuthIDsForClientKey("client-1")
+	if !restricted || len(allowed) != 1 {
+		t.Fatalf("AllowedAuthIDsForClientKey = %v, %t", allowed, restricted)
+	}
+	if _, ok := allowed["auth-b"]; !ok {
+		t.Fatalf("pool member missing from %v", allowed)
+	}
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.ClientAPIKeyMetadataKey: "client-1"}}
+	if _, err := manager.Execute(ctx, []string{"gemini"}, cliproxyexecutor.Request{}, opts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := exec.lastAuthID(); got != "auth-b" {
+		t.Fatalf("Execute() used auth %q, want auth-b", got)
+	}
+}
+
+func TestPoolPriority_PrefersFirstPool(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	exec := &recordingExecutor{provider: "gemini"}
+	manager.RegisterExecutor(exec)
+	cfg := &internalconfig.Config{AuthPools: map[string]string{"primary": "tier=max", "overflow": "tier=pro"}}
+	cfg.Routing.PoolPriority = []string{"primary", "pool:overflow"}
+	manager.SetConfig(cfg)
+
+	ctx := context.Background()
+	_, _ = manager.Register(ctx, &Auth{ID: "a-untagged", Provider: "gemini", Status: StatusActive})
+	_, _ = manager.Register(ctx, &Auth{ID: "b-pro", Provider: "gemini", Status: StatusActive, Tags: map[string]string{"tier": "pro"}})
+	_, _ = manager.Register(ctx, &Auth{ID: "c-max", Provider: "gemini", Status: StatusActive, Tags: map[string]string{"tier": "max"}})
+
+	if _, err := manager.Execute(ctx, []string{"gemini"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := exec.lastAuthID(); got != "c-max" {
+		t.Fatalf("Execute() used auth %q, want c-max", got)
+	}
+}
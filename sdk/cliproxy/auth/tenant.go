@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"path/filepath"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// AttributeTenant names the tenant owning an auth. Auths without it are shared by the
+// requests of clients that belong to no tenant.
+const AttributeTenant = "tenant"
+
+// AuthTenant returns the tenant owning the auth, or "" when it belongs to none.
+func AuthTenant(auth *Auth) string {
+	if auth == nil || auth.Attributes == nil {
+		return ""
+	}
+	return auth.Attributes[AttributeTenant]
+}
+
+// TenantFromAuthPath returns the tenant of an auth file from its path relative to the auth
+// directory: files below tenants/<name>/ belong to <name>.
+func TenantFromAuthPath(rel string) string {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(rel)), "/")
+	if len(parts) < 3 || parts[0] != internalconfig.TenantsDirName {
+		return ""
+	}
+	return parts[1]
+}
+
+// SetTenantFromPath records the tenant of a file-backed auth from its path relative to the
+// auth directory.
+func SetTenantFromPath(auth *Auth, rel string) {
+	if auth == nil {
+		return
+	}
+	tenant := TenantFromAuthPath(rel)
+	if tenant == "" {
+		if auth.Attributes != nil {
+			delete(auth.Attributes, AttributeTenant)
+		}
+		return
+	}
+	if auth.Attributes == nil {
+		auth.Attributes = make(map[string]string)
+	}
+	auth.Attributes[AttributeTenant] = tenant
+}
+
+func tenantFromOptions(opts cliproxyexecutor.Options) string {
+	if len(opts.Metadata) == 0 {
+		return ""
+	}
+	tenant, _ := opts.Metadata[cliproxyexecutor.TenantMetadataKey].(string)
+	return strings.TrimSpace(tenant)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestPickNextIsolatesTenants(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	exec := &recordingExecutor{provider: "gemini"}
+	manager.RegisterExecutor(exec)
+
+	ctx := context.Background()
+	_, _ = manager.Register(ctx, &Auth{ID: "a-shared", Provider: "gemini", Status: StatusActive})
+	_, _ = manager.Register(ctx, &Auth{ID: "b-team", Provider: "gemini", Status: StatusActive, Attributes: map[string]string{AttributeTenant: "team"}})
+
+	cases := []struct {
+		tenant string
+		want   string
+	}{
+		{"", "a-shared"},
+		{"team", "b-team"},
+	}
+	for _, tc := range cases {
+		opts := cliproxyexecutor.Options{Metadata: map[string]any{}}
+		if tc.tenant != "" {
+			opts.Metadata[cliproxyexecutor.TenantMetadataKey] = tc.tenant
+		}
+		if _, err := manager.Execute(ctx, []string{"gemini"}, cliproxyexecutor.Request{}, opts); err != nil {
+			t.Fatalf("tenant %q: Execute() error = %v", tc.tenant, err)
+		}
+		if got := exec.lastAuthID(); got != tc.want {
+			t.Fatalf("tenant %q: Execute() used auth %q, want %q", tc.tenant, got, tc.want)
+		}
+	}
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.TenantMetadataKey: "other"}}
+	if _, err := manager.Execute(ctx, []string{"gemini"}, cliproxyexecutor.Request{}, opts); err == nil {
+		t.Fatal("Execute() for a tenant without auths succeeded, want error")
+	}
+}
+
+func TestTenantFromAuthPath(t *testing.T) {
+	t.Parallel()
+
+	for rel, want := range map[string]string{
+		"claude.json":                  "",
+		"tenants/team-a/claude.json":   "team-a",
+		"tenants/claude.json":          "",
+		"other/team-a/claude.json":     "",
+		"tenants/team-a/x/claude.json": "team-a",
+	} {
+		if got := TenantFromAuthPath(rel); got != want {
+			t.Errorf("TenantFromAuthPath(%q) = %q, want %q", rel, got, want)
+		}
+	}
+}
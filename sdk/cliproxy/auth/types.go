@@ -28,12 +28,20 @@ type Auth struct {
 	Storage baseauth.TokenStorage `json:"-"`
 	// Label is an optional human readable label for logging.
 	Label string `json:"label,omitempty"`
+	// Tags label the credential for auth pools (e.g. team=research). They come from the
+	// auth file "tags" field or the config entry.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Schedule limits the auth to time windows. It comes from the auth file "schedule" field
+	// or the config entry.
+	Schedule *Schedule `json:"schedule,omitempty"`
 	// Status is the lifecycle status managed by the AuthManager.
 	Status Status `json:"status"`
 	// StatusMessage holds a short description for the current status.
 	StatusMessage string `json:"status_message,omitempty"`
 	// Disabled indicates the auth is intentionally disabled by operator.
 	Disabled bool `json:"disabled"`
+	// Draining stops new selections of the auth while in-flight requests finish.
+	Draining bool `json:"draining,omitempty"`
 	// Unavailable flags transient provider unavailability (e.g. quota exceeded).
 	Unavailable bool `json:"unavailable"`
 	// ProxyURL overrides the global proxy setting for this auth if provided.
@@ -107,6 +115,17 @@ func (a *Auth) Clone() *Auth {
 			copyAuth.Attributes[key] = value
 		}
 	}
+	if len(a.Tags) > 0 {
+		copyAuth.Tags = make(map[string]string, len(a.Tags))
+		for key, value := range a.Tags {
+			copyAuth.Tags[key] = value
+		}
+	}
+	if a.Schedule != nil {
+		schedule := *a.Schedule
+		schedule.Windows = append([]string(nil), a.Schedule.Windows...)
+		copyAuth.Schedule = &schedule
+	}
 	if len(a.Metadata) > 0 {
 		copyAuth.Metadata = make(map[string]any, len(a.Metadata))
 		for key, value := range a.Metadata {
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// weightedSessionTTL is how long a session is remembered for request counting.
+const weightedSessionTTL = 30 * time.Minute
+
+// WeightedSelectorConfig controls the weighted selection strategy.
+type WeightedSelectorConfig struct {
+	LatencyWeight       float64
+	PriceWeight         float64
+	QuotaWeight         float64
+	LatencySmoothing    float64
+	SmallRequestTokens  int
+	LongSessionRequests int
+	PreferenceBoost     float64
+	// Weights are operator weights keyed by auth ID, auth index, file name or "pool:<name>".
+	Weights map[string]float64
+	// AuthPools resolves "pool:<name>" weight keys.
+	AuthPools map[string]string
+}
+
+type requestClass int
+
+const (
+	requestClassDefault requestClass = iota
+	requestClassSmall
+	requestClassLongSession
+)
+
+type weightedSession struct {
+	requests int
+	lastSeen time.Time
+}
+
+type poolWeight struct {
+	selector TagSelector
+	weight   float64
+}
+
+// WeightedSelector scores every available auth by operator weight, observed latency, price
+// and quota health, and picks one at random in proportion to its score. Small requests favour
+// API-key credentials and long agent sessions favour subscription OAuth accounts.
+type WeightedSelector struct {
+	mu          sync.Mutex
+	cfg         WeightedSelectorConfig
+	weights     map[string]float64
+	poolWeights []poolWeight
+	sessions    map[string]*weightedSession
+	lastCleanup time.Time
+
+	latency *LatencyTracker
+	prices  func() *pricing.Table
+	clock   func() time.Time
+	rand    func() float64
+}
+
+// NewWeightedSelector constructs a weighted selector fed by the process-wide latency tracker.
+func NewWeightedSelector(cfg WeightedSelectorConfig) *WeightedSelector {
+	s := &WeightedSelector{
+		sessions: make(map[string]*weightedSession),
+		latency:  Latency(),
+		prices:   pricing.Current,
+		clock:    time.Now,
+		rand:     rand.Float64,
+	}
+	s.UpdateConfig(cfg)
+	return s
+}
+
+// UpdateConfig refreshes selector settings without clearing session counts.
+func (s *WeightedSelector) UpdateConfig(cfg WeightedSelectorConfig) {
+	if s == nil {
+		return
+	}
+	cfg = normaliseWeightedConfig(cfg)
+	weights := make(map[string]float64, len(cfg.Weights))
+	var pools []poolWeight
+	poolCfg := &internalconfig.Config{AuthPools: cfg.AuthPools}
+	keys := make([]string, 0, len(cfg.Weights))
+	for key := range cfg.Weights {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		weight := cfg.Weights[key]
+		if name, ok := PoolNameFromRef(key); ok {
+			if selector, okPool := PoolSelector(poolCfg, name); okPool {
+				pools = append(pools, poolWeight{selector: selector, weight: weight})
+			}
+			continue
+		}
+		weights[key] = weight
+	}
+	if s.latency != nil {
+		s.latency.SetSmoothing(cfg.LatencySmoothing)
+	}
+	s.mu.Lock()
+	s.cfg = cfg
+	s.weights = weights
+	s.poolWeights = pools
+	s.mu.Unlock()
+}
+
+// Pick implements Selector.
+func (s *WeightedSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	if s == nil {
+		selector := &RoundRobinSelector{}
+		return selector.Pick(ctx, provider, model, opts, auths)
+	}
+	now := s.clock()
+	available, err := getAvailableAuths(auths, provider, model, now)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	class := s.classifyLocked(opts, now)
+	if len(available) == 1 {
+		return available[0], nil
+	}
+	scores := s.scoresLocked(available, model, opts.Stream, class, now)
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	if total <= 0 {
+		return available[0], nil
+	}
+	target := s.rand() * total
+	for i, score := range scores {
+		target -= score
+		if target < 0 {
+			return available[i], nil
+		}
+	}
+	return available[len(available)-1], nil
+}
+
+// classifyLocked counts the request against its session and returns its class.
+func (s *WeightedSelector) classifyLocked(opts cliproxyexecutor.Options, now time.Time) requestClass {
+	if now.Sub(s.lastCleanup) > time.Minute {
+		for id, session := range s.sessions {
+			if now.Sub(session.lastSeen) > weightedSessionTTL {
+				delete(s.sessions, id)
+			}
+		}
+		s.lastCleanup = now
+	}
+	if sessionID := extractSessionIDFromOptions(opts); sessionID != "" {
+		session := s.sessions[sessionID]
+		if session == nil || now.Sub(session.lastSeen) > weightedSessionTTL {
+			session = &weightedSession{}
+			s.sessions[sessionID] = session
+		}
+		session.requests++
+		session.lastSeen = now
+		if session.requests > s.cfg.LongSessionRequests {
+			return requestClassLongSession
+		}
+	}
+	// Roughly four bytes per token is enough to tell small requests from large ones.
+	if len(opts.OriginalRequest)/4 <= s.cfg.SmallRequestTokens {
+		return requestClassSmall
+	}
+	return requestClassDefault
+}
+
+func (s *WeightedSelector) scoresLocked(auths []*Auth, model string, stream bool, class requestClass, now time.Time) []float64 {
+	latencies := make([]float64, len(auths))
+	prices := make([]float64, len(auths))
+	apiKey := make([]bool, len(auths))
+	bestLatency, cheapest := 0.0, 0.0
+	table := s.prices()
+	for i, auth := range auths {
+		if stats, ok := s.latency.Stats(auth.ID); ok {
+			latencies[i] = stats.DurationMs
+			if stream && stats.FirstChunkMs > 0 {
+				latencies[i] = stats.FirstChunkMs
+			}
+		}
+		if latencies[i] > 0 && (bestLatency == 0 || latencies[i] < bestLatency) {
+			bestLatency = latencies[i]
+		}
+		kind, _ := auth.AccountInfo()
+		apiKey[i] = kind == "api_key"
+		// Subscription accounts have no per-token price; only API keys are compared on price.
+		if apiKey[i] {
+			if price, ok := table.Lookup(auth.Provider, model); ok {
+				// Blend input and output 3:1, the usual shape of chat traffic.
+				prices[i] = (3*price.Input + price.Output) / 4
+			}
+		}
+		if prices[i] > 0 && (cheapest == 0 || prices[i] < cheapest) {
+			cheapest = prices[i]
+		}
+	}
+
+	scores := make([]float64, len(auths))
+	for i, auth := range auths {
+		score := s.operatorWeightLocked(auth)
+		if latencies[i] > 0 {
+			score *= math.Pow(bestLatency/latencies[i], s.cfg.LatencyWeight)
+		}
+		if prices[i] > 0 {
+			score *= math.Pow(cheapest/prices[i], s.cfg.PriceWeight)
+		}
+		score *= math.Pow(quotaHealth(auth, model, now), s.cfg.QuotaWeight)
+		switch {
+		case class == requestClassSmall && apiKey[i]:
+			score *= s.cfg.PreferenceBoost
+		case class == requestClassLongSession && !apiKey[i]:
+			score *= s.cfg.PreferenceBoost
+		}
+		scores[i] = score
+	}
+	return scores
+}
+
+// operatorWeightLocked resolves the static weight of auth. Direct references win over pools.
+func (s *WeightedSelector) operatorWeightLocked(auth *Auth) float64 {
+	for _, key := range []string{auth.ID, authIndexForMatch(auth), auth.FileName} {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if weight, ok := s.weights[key]; ok {
+			return weight
+		}
+	}
+	for _, pool := range s.poolWeights {
+		if pool.selector.Matches(auth.Tags) {
+			return pool.weight
+		}
+	}
+	return 1
+}
+
+func normaliseWeightedConfig(cfg WeightedSelectorConfig) WeightedSelectorConfig {
+	out := cfg
+	if out.LatencyWeight < 0 {
+		out.LatencyWeight = 0
+	}
+	if out.PriceWeight < 0 {
+		out.PriceWeight = 0
+	}
+	if out.QuotaWeight < 0 {
+		out.QuotaWeight = 0
+	}
+	if out.LatencyWeight == 0 && out.PriceWeight == 0 && out.QuotaWeight == 0 {
+		out.LatencyWeight, out.PriceWeight, out.QuotaWeight = 1, 1, 1
+	}
+	if out.LatencySmoothing <= 0 || out.LatencySmoothing > 1 {
+		out.LatencySmoothing = defaultLatencySmoothing
+	}
+	if out.SmallRequestTokens <= 0 {
+		out.SmallRequestTokens = 4000
+	}
+	if out.LongSessionRequests <= 0 {
+		out.LongSessionRequests = 5
+	}
+	if out.PreferenceBoost <= 0 {
+		out.PreferenceBoost = 4
+	}
+	weights := make(map[string]float64, len(out.Weights))
+	for key, weight := range out.Weights {
+		key = strings.TrimSpace(key)
+		if key == "" || weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			continue
+		}
+		weights[key] = weight
+	}
+	out.Weights = weights
+	return out
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newTestWeightedSelector(t *testing.T, cfg WeightedSelectorConfig, prices []internalconfig.ModelPrice) *WeightedSelector {
+	t.Helper()
+	table, err := pricing.Build(internalconfig.PricingConfig{DisableDefaults: true, Models: prices})
+	if err != nil {
+		t.Fatalf("build prices: %v", err)
+	}
+	selector := NewWeightedSelector(cfg)
+	selector.latency = NewLatencyTracker(1)
+	selector.prices = func() *pricing.Table { return table }
+	selector.rand = func() float64 { return 0.5 }
+	return selector
+}
+
+func apiKeyAuth(id, provider string) *Auth {
+	return &Auth{ID: id, Provider: provider, Attributes: map[string]string{"api_key": "sk-" + id}}
+}
+
+func oauthAuth(id, provider string) *Auth {
+	return &Auth{ID: id, Provider: provider, Metadata: map[string]any{"email": id + "@example.com"}}
+}
+
+func TestWeightedSelectorScores(t *testing.T) {
+	selector := newTestWeightedSelector(t, WeightedSelectorConfig{PreferenceBoost: 1}, []internalconfig.ModelPrice{
+		{Match: "model-x", Provider: "cheap", Input: 1, Output: 1},
+		{Match: "model-x", Provider: "pricey", Input: 4, Output: 4},
+	})
+	selector.latency.Observe("fast", 0, 100*time.Millisecond)
+	selector.latency.Observe("slow", 0, 400*time.Millisecond)
+
+	auths := []*Auth{apiKeyAuth("cheap", "cheap"), apiKeyAuth("pricey", "pricey"), oauthAuth("fast", "sub"), oauthAuth("slow", "sub")}
+	scores := selector.scoresLocked(auths, "model-x", false, requestClassDefault, time.Now())
+	want := []float64{1, 0.25, 1, 0.25}
+	for i := range want {
+		if diff := scores[i] - want[i]; diff > 1e-9 || diff < -1e-9 {
+			t.Fatalf("scores = %v, want %v", scores, want)
+		}
+	}
+
+	auths[0].Quota.Exceeded = true
+	if score := selector.scoresLocked(auths, "model-x", false, requestClassDefault, time.Now())[0]; score != 0 {
+		t.Fatalf("exceeded quota should zero the score, got %v", score)
+	}
+}
+
+func TestWeightedSelectorPrefersByRequestClass(t *testing.T) {
+	selector := newTestWeightedSelector(t, WeightedSelectorConfig{LongSessionRequests: 2, SmallRequestTokens: 10}, nil)
+	auths := []*Auth{apiKeyAuth("a-key", "claude"), oauthAuth("b-oauth", "claude")}
+	selector.rand = func() float64 { return 0.7 }
+
+	small := cliproxyexecutor.Options{OriginalRequest: []byte(`{"q":"hi"}`)}
+	got, err := selector.Pick(context.Background(), "claude", "m", small, auths)
+	if err != nil || got.ID != "a-key" {
+		t.Fatalf("small request picked %v (%v), want a-key", got, err)
+	}
+
+	large := []byte(`{"q":"` + strings.Repeat("x", 200) + `"}`)
+	session := cliproxyexecutor.Options{
+		OriginalRequest: large,
+		Metadata:        map[string]any{cliproxyexecutor.SessionIDMetadataKey: "s1"},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = selector.Pick(context.Background(), "claude", "m", session, auths); err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+	}
+	selector.rand = func() float64 { return 0.3 }
+	got, err = selector.Pick(context.Background(), "claude", "m", session, auths)
+	if err != nil || got.ID != "b-oauth" {
+		t.Fatalf("long session picked %v (%v), want b-oauth", got, err)
+	}
+}
+
+func TestWeightedSelectorOperatorWeights(t *testing.T) {
+	selector := newTestWeightedSelector(t, WeightedSelectorConfig{
+		Weights:   map[string]float64{"a": 0, "pool:research": 3},
+		AuthPools: map[string]string{"research": "team=research"},
+	}, nil)
+	research := oauthAuth("c", "codex")
+	research.Tags = map[string]string{"team": "research"}
+	auths := []*Auth{oauthAuth("a", "codex"), oauthAuth("b", "codex"), research}
+	scores := selector.scoresLocked(auths, "m", false, requestClassDefault, time.Now())
+	if scores[0] != 0 || scores[1] != 1 || scores[2] != 3 {
+		t.Fatalf("scores = %v, want [0 1 3]", scores)
+	}
+}
+
+func TestLatencyTrackerAveragesFirstChunk(t *testing.T) {
+	tracker := NewLatencyTracker(0.5)
+	tracker.Observe("a", 100*time.Millisecond, time.Second)
+	tracker.Observe("a", 300*time.Millisecond, 3*time.Second)
+	tracker.Observe("a", 0, time.Second)
+	stats, ok := tracker.Stats("a")
+	if !ok || stats.FirstChunkMs != 200 || stats.DurationMs != 1500 || stats.Samples != 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
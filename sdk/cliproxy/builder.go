@@ -4,6 +4,7 @@
 package cliproxy
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
 )
 
 // Builder constructs a Service instance with customizable providers.
@@ -200,6 +202,9 @@ func (b *Builder) Build() (*Service, error) {
 			dirSetter.SetBaseDir(b.cfg.AuthDir)
 		}
 
+		if err = checkSessionPersistence(b.cfg.Routing.Session); err != nil {
+			return nil, err
+		}
 		selector, hook := buildSelectorAndHook(b.cfg)
 		coreManager = coreauth.NewManager(tokenStore, selector, hook)
 	}
@@ -253,13 +258,71 @@ func buildSelectorAndHook(cfg *config.Config) (coreauth.Selector, coreauth.Hook)
 			PenaltyExponent:  sessionCfg.PenaltyExponent,
 			LoadBalanceMode:  sessionCfg.LoadBalanceMode,
 		})
+		applySessionPersistence(selector, sessionCfg)
 		return selector, coreauth.SessionSelectorHook{Selector: selector}
 	}
 
 	switch strategy {
 	case "fill-first", "fillfirst", "ff":
 		return &coreauth.FillFirstSelector{}, nil
+	case "weighted":
+		return coreauth.NewWeightedSelector(weightedSelectorConfig(cfg)), nil
 	default:
 		return &coreauth.RoundRobinSelector{}, nil
 	}
 }
+
+// weightedSelectorConfig maps routing.weighted onto the selector settings.
+func weightedSelectorConfig(cfg *config.Config) coreauth.WeightedSelectorConfig {
+	if cfg == nil {
+		return coreauth.WeightedSelectorConfig{}
+	}
+	weighted := cfg.Routing.Weighted
+	return coreauth.WeightedSelectorConfig{
+		LatencyWeight:       weighted.LatencyWeight,
+		PriceWeight:         weighted.PriceWeight,
+		QuotaWeight:         weighted.QuotaWeight,
+		LatencySmoothing:    weighted.LatencySmoothing,
+		SmallRequestTokens:  weighted.SmallRequestTokens,
+		LongSessionRequests: weighted.LongSessionRequests,
+		PreferenceBoost:     weighted.PreferenceBoost,
+		Weights:             weighted.Weights,
+		AuthPools:           cfg.AuthPools,
+	}
+}
+
+// checkSessionPersistence rejects session persistence on a token store that keeps runtime
+// state on the local machine, where bindings would silently stop being shared between replicas.
+func checkSessionPersistence(sessionCfg config.SessionRoutingConfig) error {
+	if !sessionCfg.Persist {
+		return nil
+	}
+	if store, ok := sdkAuth.GetTokenStore().(coreauth.LocalStateStore); ok && store.StateIsLocal() {
+		return fmt.Errorf("cliproxy: routing.session.persist is not supported by the git token store, which keeps runtime state in its local clone")
+	}
+	return nil
+}
+
+// applySessionPersistence attaches the token store to the session selector when persistence is
+// enabled so bindings survive restarts and are shared between replicas using the same store.
+func applySessionPersistence(selector *coreauth.SessionSelector, sessionCfg config.SessionRoutingConfig) {
+	if selector == nil {
+		return
+	}
+	if !sessionCfg.Persist {
+		selector.SetStateStore(context.Background(), nil, 0)
+		return
+	}
+	if err := checkSessionPersistence(sessionCfg); err != nil {
+		log.Errorf("%v; session bindings are kept in memory", err)
+		selector.SetStateStore(context.Background(), nil, 0)
+		return
+	}
+	store, ok := sdkAuth.GetTokenStore().(coreauth.StateStore)
+	if !ok {
+		log.Warn("session routing persistence requested but the token store cannot persist state")
+		return
+	}
+	interval := time.Duration(sessionCfg.PersistIntervalSeconds) * time.Second
+	selector.SetStateStore(context.Background(), store, interval)
+}
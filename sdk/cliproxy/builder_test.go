@@ -0,0 +1,36 @@
+package cliproxy
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// localStateTokenStore is a token store whose runtime state is not shared, like the git store.
+type localStateTokenStore struct {
+	*sdkAuth.FileTokenStore
+}
+
+func (localStateTokenStore) StateIsLocal() bool { return true }
+
+func TestBuildRejectsSessionPersistenceOnLocalStateStore(t *testing.T) {
+	previous := sdkAuth.GetTokenStore()
+	sdkAuth.RegisterTokenStore(localStateTokenStore{sdkAuth.NewFileTokenStore()})
+	t.Cleanup(func() { sdkAuth.RegisterTokenStore(previous) })
+
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.Routing.Strategy = "session"
+	cfg.Routing.Session.Persist = true
+	_, err := NewBuilder().WithConfig(cfg).WithConfigPath(filepath.Join(t.TempDir(), "config.yaml")).Build()
+	if err == nil || !strings.Contains(err.Error(), "routing.session.persist") {
+		t.Fatalf("Build error = %v, want session persistence rejected", err)
+	}
+
+	cfg.Routing.Session.Persist = false
+	if _, err = NewBuilder().WithConfig(cfg).WithConfigPath(filepath.Join(t.TempDir(), "config.yaml")).Build(); err != nil {
+		t.Fatalf("Build without persistence: %v", err)
+	}
+}
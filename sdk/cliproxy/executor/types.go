@@ -16,6 +16,9 @@ const SessionIDMetadataKey = "session_id"
 // ClientAPIKeyMetadataKey stores the authenticated client API key in Options.Metadata.
 const ClientAPIKeyMetadataKey = "client_api_key"
 
+// TenantMetadataKey stores the tenant of the authenticated client in Options.Metadata.
+const TenantMetadataKey = "tenant"
+
 // Request encapsulates the translated payload that will be sent to a provider executor.
 type Request struct {
 	// Model is the upstream model identifier after translation.
@@ -15,6 +15,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translatorplugin"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
@@ -60,6 +61,9 @@ type Service struct {
 	// pprofServer manages the optional pprof HTTP debug server.
 	pprofServer *pprofServer
 
+	// translatorPlugins applies runtime-loaded translator plugins.
+	translatorPlugins *translatorplugin.Manager
+
 	// serverErr channel for server startup/shutdown errors.
 	serverErr chan error
 
@@ -89,6 +93,12 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// workerGateway manages external executor workers.
+	workerGateway *wsrelay.Manager
+
+	// workers maps connected worker IDs to their *workerState.
+	workers sync.Map
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -108,6 +118,7 @@ func newDefaultAuthManager() *sdkAuth.Manager {
 		sdkAuth.NewCodexAuthenticator(),
 		sdkAuth.NewClaudeAuthenticator(),
 		sdkAuth.NewQwenAuthenticator(),
+		sdkAuth.NewCopilotAuthenticator(),
 	)
 }
 
@@ -366,6 +377,12 @@ func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 	if a.Disabled {
 		return
 	}
+	if isWorkerAuth(a) {
+		if s.workerGateway != nil {
+			s.coreManager.RegisterExecutor(executor.NewWorkerExecutor(s.cfg, a.Provider, s.workerGateway))
+		}
+		return
+	}
 	if compatProviderKey, _, isCompat := openAICompatInfoFromAuth(a); isCompat {
 		if compatProviderKey == "" {
 			compatProviderKey = strings.ToLower(strings.TrimSpace(a.Provider))
@@ -392,10 +409,14 @@ func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
 	case "claude":
 		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
 	case "codex":
 		s.coreManager.RegisterExecutor(executor.NewCodexExecutor(s.cfg))
 	case "qwen":
 		s.coreManager.RegisterExecutor(executor.NewQwenExecutor(s.cfg))
+	case "copilot":
+		s.coreManager.RegisterExecutor(executor.NewCopilotExecutor(s.cfg))
 	case "iflow":
 		s.coreManager.RegisterExecutor(executor.NewIFlowExecutor(s.cfg))
 	default:
@@ -437,9 +458,10 @@ func (s *Service) Run(ctx context.Context) error {
 
 	usage.StartDefault(ctx)
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
 	defer func() {
+		// The deadline starts when shutdown begins and leaves room for the drain.
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), s.drainTimeout()+30*time.Second)
+		defer shutdownCancel()
 		if err := s.Shutdown(shutdownCtx); err != nil {
 			log.Errorf("service shutdown returned error: %v", err)
 		}
@@ -503,6 +525,11 @@ func (s *Service) Run(ctx context.Context) error {
 		})
 	}
 
+	s.ensureWorkerGateway()
+	if s.server != nil && s.workerGateway != nil {
+		s.server.AttachExternalExecutorRoute(s.workerGateway.Path(), s.workerGateway.Handler())
+	}
+
 	if s.hooks.OnBeforeStart != nil {
 		s.hooks.OnBeforeStart(s.cfg)
 	}
@@ -520,6 +547,7 @@ func (s *Service) Run(ctx context.Context) error {
 	fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
 
 	s.applyPprofConfig(s.cfg)
+	s.applyTranslatorPlugins(s.cfg)
 
 	if s.hooks.OnAfterStart != nil {
 		s.hooks.OnAfterStart(s)
@@ -549,6 +577,10 @@ func (s *Service) Run(ctx context.Context) error {
 			switch strategy {
 			case "fill-first", "fillfirst", "ff":
 				return "fill-first"
+			case "session":
+				return "session"
+			case "weighted":
+				return "weighted"
 			default:
 				return "round-robin"
 			}
@@ -586,12 +618,18 @@ func (s *Service) Run(ctx context.Context) error {
 						Penalty403:       newCfg.Routing.Session.PenaltyStatus403,
 						Penalty5xx:       newCfg.Routing.Session.PenaltyStatus5xx,
 					})
+					applySessionPersistence(selector, newCfg.Routing.Session)
+				}
+			} else if nextMode == "weighted" {
+				if selector, ok := s.coreManager.GetSelector().(*coreauth.WeightedSelector); ok {
+					selector.UpdateConfig(weightedSelectorConfig(newCfg))
 				}
 			}
 		}
 
 		s.applyRetryConfig(newCfg)
 		s.applyPprofConfig(newCfg)
+		s.applyTranslatorPlugins(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -603,6 +641,7 @@ func (s *Service) Run(ctx context.Context) error {
 			s.coreManager.SetOAuthModelAlias(newCfg.OAuthModelAlias)
 		}
 		s.rebindExecutors()
+		s.revokeWorkers(newCfg)
 	}
 
 	watcherWrapper, err = s.watcherFactory(s.configPath, s.cfg.AuthDir, reloadCallback)
@@ -634,11 +673,24 @@ func (s *Service) Run(ctx context.Context) error {
 	case <-ctx.Done():
 		log.Debug("service context cancelled, shutting down...")
 		return ctx.Err()
+	case <-s.server.DrainRequested():
+		log.Info("drain requested via management API, shutting down...")
+		return nil
 	case err = <-s.serverErr:
 		return err
 	}
 }
 
+// drainTimeout returns how long shutdown waits for active requests to finish.
+func (s *Service) drainTimeout() time.Duration {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	if s.cfg == nil {
+		return config.DefaultDrainTimeout
+	}
+	return s.cfg.Drain.TimeoutDuration()
+}
+
 // Shutdown gracefully stops background workers and the HTTP server.
 // It ensures all resources are properly cleaned up and connections are closed.
 // The shutdown is idempotent and can be called multiple times safely.
@@ -658,6 +710,16 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			ctx = context.Background()
 		}
 
+		// Stop accepting requests and let active ones, including long streams, finish
+		// before anything they depend on is torn down.
+		if s.server != nil {
+			drainCtx, cancel := context.WithTimeout(ctx, s.drainTimeout())
+			if err := s.server.Drain(drainCtx); err != nil {
+				log.Warnf("drain deadline reached with %d active request(s); shutting down anyway", s.server.ActiveRequests())
+			}
+			cancel()
+		}
+
 		// legacy refresh loop removed; only stopping core auth manager below
 
 		if s.watcherCancel != nil {
@@ -665,6 +727,9 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			if selector, ok := s.coreManager.GetSelector().(*coreauth.SessionSelector); ok {
+				selector.Flush(ctx)
+			}
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -680,11 +745,23 @@ func (s *Service) Shutdown(ctx context.Context) error {
 				}
 			}
 		}
+		if s.workerGateway != nil {
+			if err := s.workerGateway.Stop(ctx); err != nil {
+				log.Errorf("failed to stop external executor gateway: %v", err)
+				if shutdownErr == nil {
+					shutdownErr = err
+				}
+			}
+		}
 		if s.authQueueStop != nil {
 			s.authQueueStop()
 			s.authQueueStop = nil
 		}
 
+		if s.translatorPlugins != nil {
+			s.translatorPlugins.Stop()
+		}
+
 		if errShutdownPprof := s.shutdownPprof(ctx); errShutdownPprof != nil {
 			log.Errorf("failed to stop pprof server: %v", errShutdownPprof)
 			if shutdownErr == nil {
@@ -737,6 +814,10 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		GlobalModelRegistry().UnregisterClient(a.ID)
 		return
 	}
+	if isWorkerAuth(a) {
+		s.registerWorkerModels(a)
+		return
+	}
 	authKind := strings.ToLower(strings.TrimSpace(a.Attributes["auth_kind"]))
 	if authKind == "" {
 		if kind, _ := a.AccountInfo(); strings.EqualFold(kind, "api_key") {
@@ -783,6 +864,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			if entry := s.resolveConfigVertexCompatKey(a); entry != nil && len(entry.Models) > 0 {
 				models = buildVertexCompatConfigModels(entry)
 			}
+		} else if vertexClaudeEnabled(a) {
+			// Service accounts opted into Anthropic models on Vertex also serve Claude.
+			models = append(models, registry.GetClaudeModels()...)
 		}
 		models = applyExcludedModels(models, excluded)
 	case "gemini-cli":
@@ -807,6 +891,17 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			}
 		}
 		models = applyExcludedModels(models, excluded)
+	case "bedrock":
+		models = registry.GetClaudeModels()
+		if entry := s.resolveConfigBedrockKey(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildBedrockConfigModels(entry)
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
 	case "codex":
 		models = registry.GetOpenAIModels()
 		if entry := s.resolveConfigCodexKey(a); entry != nil {
@@ -821,6 +916,11 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	case "qwen":
 		models = registry.GetQwenModels()
 		models = applyExcludedModels(models, excluded)
+	case "copilot":
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		models = executor.FetchCopilotModels(ctx, a, s.cfg)
+		cancel()
+		models = applyExcludedModels(models, excluded)
 	case "iflow":
 		models = registry.GetIFlowModels()
 		models = applyExcludedModels(models, excluded)
@@ -958,6 +1058,30 @@ func (s *Service) resolveConfigClaudeKey(auth *coreauth.Auth) *config.ClaudeKey
 	return nil
 }
 
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	if attrKey == "" {
+		return nil
+	}
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if !strings.EqualFold(entry.GetAPIKey(), attrKey) {
+			continue
+		}
+		if attrBase == "" || strings.EqualFold(entry.GetBaseURL(), attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
 func (s *Service) resolveConfigGeminiKey(auth *coreauth.Auth) *config.GeminiKey {
 	if auth == nil || s.cfg == nil {
 		return nil
@@ -1235,6 +1359,21 @@ func buildVertexCompatConfigModels(entry *config.VertexCompatKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "google", "vertex")
 }
 
+// vertexClaudeEnabled reports whether a Vertex service-account credential has opted into
+// serving Anthropic models via the claude_models flag in its auth file.
+func vertexClaudeEnabled(a *coreauth.Auth) bool {
+	if a == nil || a.Metadata == nil {
+		return false
+	}
+	switch v := a.Metadata["claude_models"].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(strings.TrimSpace(v), "true")
+	}
+	return false
+}
+
 func buildGeminiConfigModels(entry *config.GeminiKey) []*ModelInfo {
 	if entry == nil {
 		return nil
@@ -1249,6 +1388,13 @@ func buildClaudeConfigModels(entry *config.ClaudeKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "anthropic", "claude")
 }
 
+func buildBedrockConfigModels(entry *config.BedrockKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "anthropic", "claude")
+}
+
 func buildCodexConfigModels(entry *config.CodexKey) []*ModelInfo {
 	if entry == nil {
 		return nil
@@ -0,0 +1,39 @@
+package cliproxy
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translatorplugin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyTranslatorPlugins loads, reloads or unloads translator plugins to match cfg. The
+// plugin middleware is only installed on the default translator pipeline once plugins are
+// first enabled, so deployments without plugins keep the direct translation path.
+func (s *Service) applyTranslatorPlugins(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	dir := ""
+	if cfg.TranslatorPlugins.Enabled {
+		resolved, err := util.ResolveAuthDir(strings.TrimSpace(cfg.TranslatorPlugins.Dir))
+		if err != nil {
+			log.Warnf("translator plugins: %v", err)
+		}
+		if resolved == "" {
+			log.Warn("translator plugins: enabled without a dir, plugins are not loaded")
+		}
+		dir = resolved
+	}
+	if s.translatorPlugins == nil {
+		if dir == "" {
+			return
+		}
+		s.translatorPlugins = translatorplugin.NewManager()
+		s.translatorPlugins.Register(sdktranslator.DefaultPipeline())
+	}
+	s.translatorPlugins.Configure(dir, cfg.TranslatorPlugins.MaxSteps)
+}
@@ -10,9 +10,11 @@ import (
 
 // Record contains the usage statistics captured for a single provider request.
 type Record struct {
-	Provider    string
-	Model       string
-	APIKey      string
+	Provider string
+	Model    string
+	APIKey   string
+	// Tenant is the tenant owning the auth that served the request, if any.
+	Tenant      string
 	RequestID   string
 	AuthID      string
 	AuthIndex   string
@@ -22,7 +24,9 @@ type Record struct {
 	Failed      bool
 	StatusCode  int
 	DurationMs  int64
-	Detail      Detail
+	// FirstChunkMs is the time until the first streamed chunk; zero for non-streaming requests.
+	FirstChunkMs int64
+	Detail       Detail
 }
 
 // Detail holds the token usage breakdown.
@@ -0,0 +1,273 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// workerGatewayPath is the websocket endpoint external executor workers connect to.
+const workerGatewayPath = "/v1/executor/ws"
+
+// workerAttribute marks auths backed by an external executor worker.
+const workerAttribute = "external_worker"
+
+// builtinProviders cannot be claimed by workers because their executors are wired in.
+var builtinProviders = map[string]struct{}{
+	"gemini":               {},
+	"vertex":               {},
+	"gemini-cli":           {},
+	"aistudio":             {},
+	"antigravity":          {},
+	"claude":               {},
+	"bedrock":              {},
+	"codex":                {},
+	"qwen":                 {},
+	"iflow":                {},
+	"copilot":              {},
+	"openai-compatibility": {},
+}
+
+// workerState tracks a connected worker. reg is nil until the worker registers.
+type workerState struct {
+	key string
+	reg *wsrelay.WorkerRegistration
+}
+
+func isWorkerAuth(a *coreauth.Auth) bool {
+	return a != nil && a.Attributes != nil && a.Attributes[workerAttribute] == "true"
+}
+
+func (s *Service) ensureWorkerGateway() {
+	if s == nil || s.workerGateway != nil {
+		return
+	}
+	s.workerGateway = wsrelay.NewManager(wsrelay.Options{
+		Path:            workerGatewayPath,
+		ProviderFactory: s.workerOnConnect,
+		OnDisconnected:  s.workerOnDisconnected,
+		LogDebugf:       log.Debugf,
+		LogInfof:        log.Infof,
+		LogWarnf:        log.Warnf,
+		Authorize:       s.authorizeWorker,
+		OnMessage:       s.workerOnMessage,
+	})
+}
+
+func (s *Service) currentConfig() *config.Config {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.cfg
+}
+
+func workerKey(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+func (s *Service) authorizeWorker(r *http.Request) error {
+	cfg := s.currentConfig()
+	if cfg == nil || !cfg.ExternalExecutors.Enable {
+		return errors.New("external executors are disabled")
+	}
+	if !cfg.ExternalExecutors.AllowsWorkerKey(workerKey(r)) {
+		return errors.New("invalid worker key")
+	}
+	return nil
+}
+
+// workerOnConnect names the session of a newly connected worker.
+func (s *Service) workerOnConnect(r *http.Request) (string, error) {
+	id := "worker-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	s.workers.Store(id, &workerState{key: workerKey(r)})
+	log.Infof("external executor connected: %s", id)
+	return id, nil
+}
+
+func (s *Service) workerOnDisconnected(id string, reason error) {
+	value, ok := s.workers.LoadAndDelete(id)
+	if !ok {
+		return
+	}
+	if reason != nil {
+		log.Infof("external executor disconnected %s: %v", id, reason)
+	} else {
+		log.Infof("external executor disconnected %s", id)
+	}
+	if state, _ := value.(*workerState); state == nil || state.reg == nil {
+		return
+	}
+	s.emitAuthUpdate(context.Background(), watcher.AuthUpdate{
+		Action: watcher.AuthUpdateActionDelete,
+		ID:     id,
+	})
+}
+
+func (s *Service) workerOnMessage(id string, msg wsrelay.Message) {
+	switch msg.Type {
+	case wsrelay.MessageTypeRegister:
+		s.registerWorker(id, msg)
+	default:
+		log.Debugf("external executor %s: ignoring unsolicited %q message", id, msg.Type)
+	}
+}
+
+func (s *Service) registerWorker(id string, msg wsrelay.Message) {
+	value, ok := s.workers.Load(id)
+	if !ok {
+		return
+	}
+	prev, _ := value.(*workerState)
+	if prev == nil {
+		return
+	}
+	reg, err := wsrelay.DecodeRegistration(msg.Payload)
+	if err == nil {
+		err = s.checkWorkerProvider(id, reg.Provider)
+	}
+	if err == nil && !sdktranslator.HasTargetFormat(sdktranslator.FromString(reg.Format)) {
+		err = fmt.Errorf("format %q has no registered translator", reg.Format)
+	}
+	if err != nil {
+		log.Warnf("external executor %s: registration rejected: %v", id, err)
+		s.notifyWorker(id, wsrelay.Message{ID: msg.ID, Type: wsrelay.MessageTypeError, Payload: map[string]any{
+			"status": http.StatusBadRequest,
+			"error":  err.Error(),
+		}})
+		return
+	}
+	s.workers.Store(id, &workerState{key: prev.key, reg: reg})
+
+	label := reg.Label
+	if label == "" {
+		label = id
+	}
+	attrs := map[string]string{
+		"runtime_only":                 "true",
+		workerAttribute:                "true",
+		executor.WorkerFormatAttribute: reg.Format,
+	}
+	if reg.Capacity > 0 {
+		attrs[coreauth.AttributeMaxConcurrency] = strconv.Itoa(reg.Capacity)
+	}
+	now := time.Now().UTC()
+	auth := &coreauth.Auth{
+		ID:         id,
+		Provider:   reg.Provider,
+		Label:      label,
+		Status:     coreauth.StatusActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Attributes: attrs,
+		Metadata:   map[string]any{"email": label},
+	}
+	action := watcher.AuthUpdateActionAdd
+	if prev.reg != nil {
+		action = watcher.AuthUpdateActionModify
+	}
+	log.Infof("external executor %s registered provider %s with %d model(s)", id, reg.Provider, len(reg.Models))
+	s.emitAuthUpdate(context.Background(), watcher.AuthUpdate{Action: action, ID: id, Auth: auth})
+	s.notifyWorker(id, wsrelay.Message{ID: msg.ID, Type: wsrelay.MessageTypeRegistered, Payload: map[string]any{
+		"worker_id": id,
+		"provider":  reg.Provider,
+	}})
+}
+
+// checkWorkerProvider rejects provider names served by built-in executors, OpenAI
+// compatibility entries or non-worker auths.
+func (s *Service) checkWorkerProvider(id, provider string) error {
+	if _, reserved := builtinProviders[provider]; reserved {
+		return fmt.Errorf("provider %q is built in", provider)
+	}
+	if cfg := s.currentConfig(); cfg != nil {
+		for i := range cfg.OpenAICompatibility {
+			if strings.EqualFold(strings.TrimSpace(cfg.OpenAICompatibility[i].Name), provider) {
+				return fmt.Errorf("provider %q is configured under openai-compatibility", provider)
+			}
+		}
+	}
+	if s.coreManager != nil {
+		for _, a := range s.coreManager.List() {
+			if a == nil || a.ID == id || isWorkerAuth(a) {
+				continue
+			}
+			if strings.EqualFold(a.Provider, provider) {
+				return fmt.Errorf("provider %q is served by configured credentials", provider)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) notifyWorker(id string, msg wsrelay.Message) {
+	if s.workerGateway == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.workerGateway.Notify(ctx, id, msg); err != nil {
+		log.Debugf("external executor %s: failed to send %q: %v", id, msg.Type, err)
+	}
+}
+
+// revokeWorkers disconnects workers whose secret key is no longer accepted.
+func (s *Service) revokeWorkers(cfg *config.Config) {
+	if s == nil || s.workerGateway == nil || cfg == nil {
+		return
+	}
+	s.workers.Range(func(key, value any) bool {
+		id, _ := key.(string)
+		state, _ := value.(*workerState)
+		if state != nil && !cfg.ExternalExecutors.AllowsWorkerKey(state.key) {
+			s.workerGateway.Close(id, errors.New("worker key revoked"))
+		}
+		return true
+	})
+}
+
+func (s *Service) registerWorkerModels(a *coreauth.Auth) {
+	value, ok := s.workers.Load(a.ID)
+	state, _ := value.(*workerState)
+	if !ok || state == nil || state.reg == nil {
+		GlobalModelRegistry().UnregisterClient(a.ID)
+		return
+	}
+	reg := state.reg
+	now := time.Now().Unix()
+	models := make([]*ModelInfo, 0, len(reg.Models))
+	for _, m := range reg.Models {
+		display := m.DisplayName
+		if display == "" {
+			display = m.ID
+		}
+		models = append(models, &ModelInfo{
+			ID:                  m.ID,
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             reg.Provider,
+			Type:                reg.Provider,
+			DisplayName:         display,
+			ContextLength:       m.ContextLength,
+			MaxCompletionTokens: m.MaxCompletionTokens,
+			UserDefined:         true,
+		})
+	}
+	cfg := s.currentConfig()
+	GlobalModelRegistry().RegisterClient(a.ID, reg.Provider, applyModelPrefixes(models, a.Prefix, cfg != nil && cfg.ForceModelPrefix))
+}
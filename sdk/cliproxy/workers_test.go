@@ -0,0 +1,37 @@
+package cliproxy
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
+)
+
+func TestRegisterWorkerRejectsUnknownFormat(t *testing.T) {
+	s := &Service{authUpdates: make(chan watcher.AuthUpdate, 1)}
+	s.workers.Store("worker-1", &workerState{})
+	register := func(format string) {
+		s.registerWorker("worker-1", wsrelay.Message{ID: "r1", Type: wsrelay.MessageTypeRegister, Payload: map[string]any{
+			"provider": "llama-local",
+			"format":   format,
+			"models":   []any{"small"},
+		}})
+	}
+
+	register("klingon")
+	if value, _ := s.workers.Load("worker-1"); value.(*workerState).reg != nil {
+		t.Fatal("registration with unknown format was accepted")
+	}
+	if len(s.authUpdates) != 0 {
+		t.Fatal("auth emitted for rejected registration")
+	}
+
+	register("claude")
+	if value, _ := s.workers.Load("worker-1"); value.(*workerState).reg == nil {
+		t.Fatal("registration with claude format was rejected")
+	}
+	if update := <-s.authUpdates; update.Action != watcher.AuthUpdateActionAdd {
+		t.Fatalf("auth update action = %v, want add", update.Action)
+	}
+}
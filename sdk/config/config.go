@@ -13,8 +13,16 @@ type AccessProvider = internalconfig.AccessProvider
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type StructuredOutputConfig = internalconfig.StructuredOutputConfig
+type GuardrailsConfig = internalconfig.GuardrailsConfig
+type GuardrailRule = internalconfig.GuardrailRule
+type GuardrailDetector = internalconfig.GuardrailDetector
+type PromptProfile = internalconfig.PromptProfile
+type TokenCountingConfig = internalconfig.TokenCountingConfig
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
+type RoutingConfig = internalconfig.RoutingConfig
+type SessionRoutingConfig = internalconfig.SessionRoutingConfig
 type AmpCode = internalconfig.AmpCode
 type OAuthModelAlias = internalconfig.OAuthModelAlias
 type PayloadConfig = internalconfig.PayloadConfig
@@ -25,11 +33,19 @@ type PayloadModelRule = internalconfig.PayloadModelRule
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
+type BedrockKey = internalconfig.BedrockKey
+type BedrockModel = internalconfig.BedrockModel
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
+type AzureOpenAIConfig = internalconfig.AzureOpenAIConfig
+type AzureEntraConfig = internalconfig.AzureEntraConfig
+type DrainConfig = internalconfig.DrainConfig
+type Schedule = internalconfig.Schedule
+type ExternalExecutorConfig = internalconfig.ExternalExecutorConfig
+type TenantConfig = internalconfig.TenantConfig
 
 type TLS = internalconfig.TLSConfig
 
@@ -37,6 +53,7 @@ const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
 	DefaultPanelGitHubRepository   = internalconfig.DefaultPanelGitHubRepository
+	DefaultDrainTimeout            = internalconfig.DefaultDrainTimeout
 )
 
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
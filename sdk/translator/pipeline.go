@@ -1,6 +1,9 @@
 package translator
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 // RequestEnvelope represents a request in the translation pipeline.
 type RequestEnvelope struct {
@@ -34,6 +37,7 @@ type ResponseHandler func(ctx context.Context, resp ResponseEnvelope) (ResponseE
 // Pipeline orchestrates request/response transformation with middleware support.
 type Pipeline struct {
 	registry           *Registry
+	mu                 sync.RWMutex
 	requestMiddleware  []RequestMiddleware
 	responseMiddleware []ResponseMiddleware
 }
@@ -49,17 +53,35 @@ func NewPipeline(registry *Registry) *Pipeline {
 // UseRequest adds request middleware executed in registration order.
 func (p *Pipeline) UseRequest(mw RequestMiddleware) {
 	if mw != nil {
+		p.mu.Lock()
 		p.requestMiddleware = append(p.requestMiddleware, mw)
+		p.mu.Unlock()
 	}
 }
 
 // UseResponse adds response middleware executed in registration order.
 func (p *Pipeline) UseResponse(mw ResponseMiddleware) {
 	if mw != nil {
+		p.mu.Lock()
 		p.responseMiddleware = append(p.responseMiddleware, mw)
+		p.mu.Unlock()
 	}
 }
 
+// hasRequestMiddleware reports whether any request middleware is registered.
+func (p *Pipeline) hasRequestMiddleware() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.requestMiddleware) > 0
+}
+
+// hasResponseMiddleware reports whether any response middleware is registered.
+func (p *Pipeline) hasResponseMiddleware() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.responseMiddleware) > 0
+}
+
 // TranslateRequest applies middleware and registry transformations.
 func (p *Pipeline) TranslateRequest(ctx context.Context, from, to Format, req RequestEnvelope) (RequestEnvelope, error) {
 	terminal := func(ctx context.Context, input RequestEnvelope) (RequestEnvelope, error) {
@@ -69,9 +91,13 @@ func (p *Pipeline) TranslateRequest(ctx context.Context, from, to Format, req Re
 		return input, nil
 	}
 
+	p.mu.RLock()
+	middleware := p.requestMiddleware
+	p.mu.RUnlock()
+
 	handler := terminal
-	for i := len(p.requestMiddleware) - 1; i >= 0; i-- {
-		mw := p.requestMiddleware[i]
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw := middleware[i]
 		next := handler
 		handler = func(ctx context.Context, r RequestEnvelope) (RequestEnvelope, error) {
 			return mw(ctx, r, next)
@@ -93,9 +119,13 @@ func (p *Pipeline) TranslateResponse(ctx context.Context, from, to Format, resp
 		return input, nil
 	}
 
+	p.mu.RLock()
+	middleware := p.responseMiddleware
+	p.mu.RUnlock()
+
 	handler := terminal
-	for i := len(p.responseMiddleware) - 1; i >= 0; i-- {
-		mw := p.responseMiddleware[i]
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw := middleware[i]
 		next := handler
 		handler = func(ctx context.Context, r ResponseEnvelope) (ResponseEnvelope, error) {
 			return mw(ctx, r, next)
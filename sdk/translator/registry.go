@@ -65,6 +65,19 @@ func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	return false
 }
 
+// HasTargetFormat reports whether any request translator produces the given format.
+func (r *Registry) HasTargetFormat(format Format) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, byTarget := range r.requests {
+		if _, ok := byTarget[format]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // TranslateStream applies the registered streaming response translator.
 func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
 	r.mu.RLock()
@@ -104,7 +117,10 @@ func (r *Registry) TranslateTokenCount(ctx context.Context, from, to Format, cou
 	return string(rawJSON)
 }
 
-var defaultRegistry = NewRegistry()
+var (
+	defaultRegistry = NewRegistry()
+	defaultPipeline = NewPipeline(defaultRegistry)
+)
 
 // Default exposes the package-level registry for shared use.
 func Default() *Registry {
@@ -116,9 +132,24 @@ func Register(from, to Format, request RequestTransform, response ResponseTransf
 	defaultRegistry.Register(from, to, request, response)
 }
 
-// TranslateRequest is a helper on the default registry.
+// DefaultPipeline exposes the pipeline behind the package-level translate helpers.
+// Middleware registered on it applies to every executor translation.
+func DefaultPipeline() *Pipeline {
+	return defaultPipeline
+}
+
+// TranslateRequest is a helper on the default registry. Request middleware registered on
+// the default pipeline wraps the translation; if it fails, the plain translation is used.
 func TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
-	return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
+	if !defaultPipeline.hasRequestMiddleware() {
+		return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
+	}
+	req := RequestEnvelope{Format: from, Model: model, Stream: stream, Body: rawJSON}
+	out, err := defaultPipeline.TranslateRequest(context.Background(), from, to, req)
+	if err != nil {
+		return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
+	}
+	return out.Body
 }
 
 // HasResponseTransformer inspects the default registry.
@@ -126,14 +157,37 @@ func HasResponseTransformer(from, to Format) bool {
 	return defaultRegistry.HasResponseTransformer(from, to)
 }
 
-// TranslateStream is a helper on the default registry.
+// HasTargetFormat inspects the default registry.
+func HasTargetFormat(format Format) bool {
+	return defaultRegistry.HasTargetFormat(format)
+}
+
+// TranslateStream is a helper on the default registry, wrapped by the default pipeline's
+// response middleware when any is registered.
 func TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
-	return defaultRegistry.TranslateStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	if !defaultPipeline.hasResponseMiddleware() {
+		return defaultRegistry.TranslateStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	}
+	resp := ResponseEnvelope{Format: from, Model: model, Stream: true, Body: rawJSON}
+	out, err := defaultPipeline.TranslateResponse(ctx, from, to, resp, originalRequestRawJSON, requestRawJSON, param)
+	if err != nil {
+		return defaultRegistry.TranslateStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	}
+	return out.Chunks
 }
 
-// TranslateNonStream is a helper on the default registry.
+// TranslateNonStream is a helper on the default registry, wrapped by the default pipeline's
+// response middleware when any is registered.
 func TranslateNonStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) string {
-	return defaultRegistry.TranslateNonStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	if !defaultPipeline.hasResponseMiddleware() {
+		return defaultRegistry.TranslateNonStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	}
+	resp := ResponseEnvelope{Format: from, Model: model, Body: rawJSON}
+	out, err := defaultPipeline.TranslateResponse(ctx, from, to, resp, originalRequestRawJSON, requestRawJSON, param)
+	if err != nil {
+		return defaultRegistry.TranslateNonStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	}
+	return string(out.Body)
 }
 
 // TranslateTokenCount is a helper on the default registry.